@@ -0,0 +1,92 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const apikeyUsage = `Usage: meshmgr apikey <command>
+
+Commands:
+  revoke KEY_ID
+              Revoke one API key
+  revoke -user EMAIL
+              Revoke every API key of a user
+`
+
+// runAPIKey handles the apikey subcommands
+func runAPIKey(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "revoke" {
+		return fmt.Errorf("unknown apikey command\n\n%s", apikeyUsage)
+	}
+
+	flags := flag.NewFlagSet("apikey revoke", flag.ExitOnError)
+	email := flags.String("user", "", "revoke every API key of the user with this email")
+	_ = flags.Parse(args[1:])
+	if (*email == "") == (flags.NArg() == 0) || flags.NArg() > 1 {
+		return fmt.Errorf("give either a key ID or -user\n\n%s", apikeyUsage)
+	}
+
+	ctx := context.Background()
+	pool, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	q := meshdb.New(pool)
+
+	if *email != "" {
+		user, err := getUserByEmail(ctx, q, *email)
+		if err != nil {
+			return err
+		}
+		keys, err := q.ListAPIKeysByUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list API keys: %w", err)
+		}
+		for _, key := range keys {
+			if err := q.DeleteAPIKey(ctx, key.ID); err != nil {
+				return fmt.Errorf("failed to revoke API key %d: %w", key.ID, err)
+			}
+		}
+		fmt.Printf("Revoked %d API keys of %s\n", len(keys), user.Email)
+		return nil
+	}
+
+	keyID, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid API key ID %q", flags.Arg(0))
+	}
+	key, err := q.GetAPIKey(ctx, keyID)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("no API key with ID %d", keyID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get API key: %w", err)
+	}
+	if err := q.DeleteAPIKey(ctx, key.ID); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	fmt.Printf("Revoked API key %d (%s) of user %d\n", key.ID, key.KeyName, key.UserID)
+	return nil
+}
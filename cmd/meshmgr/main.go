@@ -15,20 +15,94 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/internal/secrets"
 	"github.com/skandragon/meshmgr/internal/server"
 )
 
+// shutdownTimeout is how long the server waits for requests and apply jobs
+// to finish when asked to stop
+const shutdownTimeout = 30 * time.Second
+
+const usage = `Usage: meshmgr [command]
+
+Commands:
+  serve [-migrate]  Run the server (the default), optionally applying
+                    pending migrations first
+  migrate           Apply, revert or show schema migrations
+  reencrypt         Re-encrypt stored secrets under the current master key
+  user              Create, list, disable and reset the passwords of users
+  mesh              List meshes, transfer their ownership and export them
+  apikey            Revoke API keys
+`
+
 func main() {
-	log.Println("Starting Meshtastic Node Manager")
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	// Load configuration from environment variables
-	cfg, err := config.Load()
+	switch command {
+	case "serve":
+		cfg := loadConfig(config.Load)
+		flags := flag.NewFlagSet("serve", flag.ExitOnError)
+		autoMigrate := flags.Bool("migrate", cfg.Database.AutoMigrate, "apply pending migrations on startup")
+		_ = flags.Parse(args)
+		cfg.Database.AutoMigrate = *autoMigrate
+		serve(cfg)
+	case "migrate":
+		if err := runMigrate(loadConfig(config.LoadDatabase), args); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "reencrypt":
+		if err := runReencrypt(loadConfig(config.LoadDatabase)); err != nil {
+			log.Fatalf("Re-encryption failed: %v", err)
+		}
+	case "user":
+		if err := runUser(loadConfig(config.LoadDatabase), args); err != nil {
+			log.Fatalf("User command failed: %v", err)
+		}
+	case "mesh":
+		if err := runMesh(loadConfig(config.LoadDatabase), args); err != nil {
+			log.Fatalf("Mesh command failed: %v", err)
+		}
+	case "apikey":
+		if err := runAPIKey(loadConfig(config.LoadDatabase), args); err != nil {
+			log.Fatalf("API key command failed: %v", err)
+		}
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+// loadConfig loads configuration from environment variables with load.
+// Only serve needs the full configuration; the commands that work on the
+// database directly load just what they use.
+func loadConfig(load func() (*config.Config, error)) *config.Config {
+	cfg, err := load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	return cfg
+}
+
+// serve runs the HTTP server until it fails or is interrupted
+func serve(cfg *config.Config) {
+	log.Println("Starting Meshtastic Node Manager")
 
 	// Create server instance
 	srv, err := server.New(cfg)
@@ -37,9 +111,51 @@ func main() {
 	}
 	defer srv.Close()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start HTTP server
 	log.Printf("Server starting on %s:%d", cfg.Server.Host, cfg.Server.Port)
-	if err := srv.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Start()
+	}()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+	case <-ctx.Done():
+		log.Println("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+		if err := <-errs; err != nil {
+			log.Printf("Server error: %v", err)
+		}
+	}
+}
+
+// connect opens a connection pool to the database, with the master keys
+// loaded so secrets can be read and written
+func connect(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	keyring, err := cfg.Secrets.Keyring()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load master keys: %w", err)
+	}
+	secrets.SetKeyring(keyring)
+	secrets.SetPlaintext(cfg.Secrets.AllowPlaintext)
+
+	pool, err := pgxpool.New(ctx, cfg.Database.ConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
+	return pool, nil
 }
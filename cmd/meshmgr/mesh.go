@@ -0,0 +1,225 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const meshUsage = `Usage: meshmgr mesh <command>
+
+Commands:
+  list
+              List every mesh with its owner
+  transfer-owner [-keep-previous=false] MESH_ID EMAIL
+              Make another user the owner of a mesh. The previous owner
+              keeps admin access unless -keep-previous=false.
+  export [-secrets] [-o FILE] MESH_ID
+              Write a mesh, its channels, admin keys, nodes and access
+              list as JSON. Private keys, PSKs and raw device configs are
+              left out unless -secrets is given.
+`
+
+// meshExport is everything stored about a mesh
+type meshExport struct {
+	Mesh      meshdb.Mesh                      `json:"mesh"`
+	Channels  []meshdb.MeshChannel             `json:"channels"`
+	AdminKeys []meshdb.AdminKey                `json:"admin_keys"`
+	Nodes     []meshdb.Node                    `json:"nodes"`
+	Access    []meshdb.ListMeshAccessByMeshRow `json:"access"`
+}
+
+// runMesh handles the mesh subcommands
+func runMesh(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing mesh command\n\n%s", meshUsage)
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("mesh "+command, flag.ExitOnError)
+	var keepPrevious, withSecrets *bool
+	var output *string
+	wantArgs := 0
+	switch command {
+	case "list":
+	case "transfer-owner":
+		keepPrevious = flags.Bool("keep-previous", true, "give the previous owner admin access")
+		wantArgs = 2
+	case "export":
+		withSecrets = flags.Bool("secrets", false, "include private keys, PSKs and raw device configs")
+		output = flags.String("o", "", "file to write (default: standard output)")
+		wantArgs = 1
+	default:
+		return fmt.Errorf("unknown mesh command %q\n\n%s", command, meshUsage)
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != wantArgs {
+		return fmt.Errorf("wrong number of arguments\n\n%s", meshUsage)
+	}
+
+	var meshID int64
+	if wantArgs > 0 {
+		var err error
+		meshID, err = strconv.ParseInt(flags.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid mesh ID %q", flags.Arg(0))
+		}
+	}
+
+	ctx := context.Background()
+	pool, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	q := meshdb.New(pool)
+
+	switch command {
+	case "list":
+		meshes, err := q.ListAllMeshes(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list meshes: %w", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tOWNER\tNODES\tCREATED")
+		for _, mesh := range meshes {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", mesh.ID, mesh.Name, mesh.OwnerEmail, mesh.NodeCount,
+				mesh.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return tw.Flush()
+
+	case "transfer-owner":
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+		qtx := q.WithTx(tx)
+
+		mesh, err := getMesh(ctx, qtx, meshID)
+		if err != nil {
+			return err
+		}
+		owner, err := getUserByEmail(ctx, qtx, flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		if owner.ID == mesh.OwnerID {
+			return fmt.Errorf("%s already owns mesh %d", owner.Email, mesh.ID)
+		}
+
+		if _, err := qtx.UpdateMeshOwner(ctx, meshdb.UpdateMeshOwnerParams{ID: mesh.ID, OwnerID: owner.ID}); err != nil {
+			return fmt.Errorf("failed to update owner: %w", err)
+		}
+		// The new owner's old access grant is superseded
+		err = qtx.RevokeMeshAccess(ctx, meshdb.RevokeMeshAccessParams{MeshID: mesh.ID, UserID: owner.ID})
+		if err != nil {
+			return fmt.Errorf("failed to revoke access: %w", err)
+		}
+		if *keepPrevious {
+			_, err := qtx.GrantMeshAccess(ctx, meshdb.GrantMeshAccessParams{
+				MeshID:      mesh.ID,
+				UserID:      mesh.OwnerID,
+				AccessLevel: "admin",
+			})
+			if err != nil {
+				return fmt.Errorf("failed to grant the previous owner access: %w", err)
+			}
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		fmt.Printf("Transferred mesh %d (%s) to %s\n", mesh.ID, mesh.Name, owner.Email)
+
+	case "export":
+		export, err := exportMesh(ctx, q, meshID, *withSecrets)
+		if err != nil {
+			return err
+		}
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	}
+	return nil
+}
+
+// getMesh looks up a mesh, with a readable error if there is none
+func getMesh(ctx context.Context, q *meshdb.Queries, meshID int64) (meshdb.Mesh, error) {
+	mesh, err := q.GetMeshByID(ctx, meshID)
+	if err == pgx.ErrNoRows {
+		return mesh, fmt.Errorf("no mesh with ID %d", meshID)
+	}
+	if err != nil {
+		return mesh, fmt.Errorf("failed to get mesh: %w", err)
+	}
+	return mesh, nil
+}
+
+// exportMesh collects everything stored about a mesh, leaving out secrets
+// unless asked for them
+func exportMesh(ctx context.Context, q *meshdb.Queries, meshID int64, withSecrets bool) (*meshExport, error) {
+	mesh, err := getMesh(ctx, q, meshID)
+	if err != nil {
+		return nil, err
+	}
+	export := &meshExport{Mesh: mesh}
+
+	if export.Channels, err = q.ListMeshChannels(ctx, meshID); err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+	if export.AdminKeys, err = q.ListAdminKeysByMesh(ctx, meshID); err != nil {
+		return nil, fmt.Errorf("failed to list admin keys: %w", err)
+	}
+	if export.Nodes, err = q.ListNodesByMesh(ctx, meshID); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if export.Access, err = q.ListMeshAccessByMesh(ctx, meshID); err != nil {
+		return nil, fmt.Errorf("failed to list access: %w", err)
+	}
+
+	if !withSecrets {
+		for i := range export.Channels {
+			export.Channels[i].Psk = nil
+		}
+		for i := range export.Nodes {
+			export.Nodes[i].PrivateKey = nil
+			export.Nodes[i].AppliedPrivateKey = nil
+			export.Nodes[i].RawDeviceConfig = nil
+		}
+	}
+	return export, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/internal/migrate"
+)
+
+const migrateUsage = `Usage: meshmgr migrate <command>
+
+Commands:
+  up          Apply every pending migration
+  down [N]    Revert the last N migrations (default 1)
+  status      Show the schema version and pending migrations
+  to VERSION  Migrate up or down to VERSION, 0 reverting everything
+`
+
+// runMigrate handles the migrate subcommands
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n\n%s", migrateUsage)
+	}
+
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator := migrate.New(pool, migrations)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version\n\n%s", migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		count, err := migrator.To(ctx, version)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Migrated to version %d (%d migrations)\n", version, count)
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d", status.Version)
+		if status.Dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		if len(status.Pending) == 0 {
+			fmt.Println("Up to date")
+		} else {
+			fmt.Printf("Pending: %d\n", len(status.Pending))
+			for _, m := range status.Pending {
+				fmt.Printf("  %d_%s\n", m.Version, m.Name)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/internal/secrets"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// reencryptCounts is how many rows of each kind were re-encrypted
+type reencryptCounts struct {
+	Nodes     int
+	Channels  int
+	AdminKeys int
+}
+
+// runReencrypt re-encrypts every stored secret under the current master
+// key. Values sealed under an old master key, or stored in plaintext
+// before a master key was configured, are read with OLD_MASTER_KEYS and
+// written back sealed under MASTER_KEY, after which the old keys can be
+// dropped.
+func runReencrypt(cfg *config.Config) error {
+	if cfg.Secrets.MasterKey == nil {
+		return fmt.Errorf("MASTER_KEY is required")
+	}
+
+	ctx := context.Background()
+	pool, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	keyring := secrets.Current()
+
+	counts, err := reencrypt(ctx, pool, keyring)
+	if err != nil {
+		return err
+	}
+	log.Printf("Re-encrypted %d nodes, %d channels and %d admin keys under master key %q",
+		counts.Nodes, counts.Channels, counts.AdminKeys, keyring.PrimaryID())
+	return nil
+}
+
+// reencrypt rewrites every secret in one transaction. The secret column
+// types open values with any key on the keyring as they are read and seal
+// them under the primary key as they are written.
+func reencrypt(ctx context.Context, pool *pgxpool.Pool, keyring *secrets.Keyring) (reencryptCounts, error) {
+	var counts reencryptCounts
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	q := meshdb.New(tx)
+
+	nodes, err := q.ListNodeSecrets(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("failed to list node secrets: %w", err)
+	}
+	for _, node := range nodes {
+		err := q.UpdateNodeSecrets(ctx, meshdb.UpdateNodeSecretsParams{
+			ID:                node.ID,
+			PrivateKey:        node.PrivateKey,
+			AppliedPrivateKey: node.AppliedPrivateKey,
+			RawDeviceConfig:   node.RawDeviceConfig,
+		})
+		if err != nil {
+			return counts, fmt.Errorf("failed to re-encrypt node %d: %w", node.ID, err)
+		}
+		counts.Nodes++
+	}
+
+	channels, err := q.ListMeshChannelSecrets(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("failed to list channel secrets: %w", err)
+	}
+	for _, ch := range channels {
+		err := q.UpdateMeshChannelSecret(ctx, meshdb.UpdateMeshChannelSecretParams{
+			ID:  ch.ID,
+			Psk: ch.Psk,
+		})
+		if err != nil {
+			return counts, fmt.Errorf("failed to re-encrypt channel %d: %w", ch.ID, err)
+		}
+		counts.Channels++
+	}
+
+	// Admin private keys are always sealed explicitly, never stored in
+	// plaintext
+	keys, err := q.ListAdminKeyPrivateKeys(ctx)
+	if err != nil {
+		return counts, fmt.Errorf("failed to list admin private keys: %w", err)
+	}
+	for _, key := range keys {
+		private, err := keyring.Open(key.PrivateKeyEncrypted)
+		if err != nil {
+			return counts, fmt.Errorf("failed to decrypt admin key %d: %w", key.ID, err)
+		}
+		sealed, err := keyring.Seal(private)
+		if err != nil {
+			return counts, fmt.Errorf("failed to encrypt admin key %d: %w", key.ID, err)
+		}
+		err = q.UpdateAdminKeyPrivateKey(ctx, meshdb.UpdateAdminKeyPrivateKeyParams{
+			ID:                  key.ID,
+			PrivateKeyEncrypted: sealed,
+		})
+		if err != nil {
+			return counts, fmt.Errorf("failed to re-encrypt admin key %d: %w", key.ID, err)
+		}
+		counts.AdminKeys++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return counts, fmt.Errorf("failed to commit: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,225 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/internal/auth"
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const userUsage = `Usage: meshmgr user <command>
+
+Commands:
+  create [-name NAME] [-password PASSWORD] EMAIL
+              Create a user. Without -password one is generated and printed.
+  reset-password [-password PASSWORD] EMAIL
+              Set a user's password and log them out everywhere
+  disable EMAIL
+              Stop a user logging in or using their tokens and API keys
+  enable EMAIL
+              Re-enable a disabled user
+  grant-admin EMAIL
+              Make a user a site admin
+  revoke-admin EMAIL
+              Remove a user's site admin access
+  list [-limit N] [-offset N]
+              List users, newest first
+`
+
+// runUser handles the user subcommands
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing user command\n\n%s", userUsage)
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("user "+command, flag.ExitOnError)
+	var name, password *string
+	var limit, offset *int
+	switch command {
+	case "create":
+		name = flags.String("name", "", "display name (default: the start of the email address)")
+		password = flags.String("password", "", "password (default: generated)")
+	case "reset-password":
+		password = flags.String("password", "", "new password (default: generated)")
+	case "list":
+		limit = flags.Int("limit", 100, "number of users to list")
+		offset = flags.Int("offset", 0, "number of users to skip")
+	case "disable", "enable", "grant-admin", "revoke-admin":
+	default:
+		return fmt.Errorf("unknown user command %q\n\n%s", command, userUsage)
+	}
+	_ = flags.Parse(args)
+
+	var email string
+	if command != "list" {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("expected one email address\n\n%s", userUsage)
+		}
+		email = flags.Arg(0)
+	}
+
+	ctx := context.Background()
+	pool, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	q := meshdb.New(pool)
+
+	switch command {
+	case "create":
+		displayName := *name
+		if displayName == "" {
+			displayName, _, _ = strings.Cut(email, "@")
+		}
+		pw, generated, err := passwordOrGenerated(*password)
+		if err != nil {
+			return err
+		}
+		hash, err := auth.HashPassword(pw, cfg.Auth.BCryptCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		user, err := q.CreateUser(ctx, meshdb.CreateUserParams{
+			Email:        email,
+			PasswordHash: hash,
+			DisplayName:  displayName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		fmt.Printf("Created user %d (%s)\n", user.ID, user.Email)
+		if generated {
+			fmt.Printf("Password: %s\n", pw)
+		}
+
+	case "reset-password":
+		user, err := getUserByEmail(ctx, q, email)
+		if err != nil {
+			return err
+		}
+		pw, generated, err := passwordOrGenerated(*password)
+		if err != nil {
+			return err
+		}
+		hash, err := auth.HashPassword(pw, cfg.Auth.BCryptCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		if _, err := q.UpdateUser(ctx, meshdb.UpdateUserParams{ID: user.ID, PasswordHash: &hash}); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		if err := q.DeleteUserSessions(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete sessions: %w", err)
+		}
+		fmt.Printf("Reset the password of user %d (%s)\n", user.ID, user.Email)
+		if generated {
+			fmt.Printf("Password: %s\n", pw)
+		}
+
+	case "disable", "enable":
+		user, err := getUserByEmail(ctx, q, email)
+		if err != nil {
+			return err
+		}
+		disabled := command == "disable"
+		if _, err := q.SetUserDisabled(ctx, meshdb.SetUserDisabledParams{ID: user.ID, Disabled: disabled}); err != nil {
+			return fmt.Errorf("failed to %s user: %w", command, err)
+		}
+		if !disabled {
+			fmt.Printf("Enabled user %d (%s)\n", user.ID, user.Email)
+			return nil
+		}
+		if err := q.DeleteUserSessions(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to delete sessions: %w", err)
+		}
+		fmt.Printf("Disabled user %d (%s)\n", user.ID, user.Email)
+
+	case "grant-admin", "revoke-admin":
+		user, err := getUserByEmail(ctx, q, email)
+		if err != nil {
+			return err
+		}
+		isAdmin := command == "grant-admin"
+		if _, err := q.SetUserAdmin(ctx, meshdb.SetUserAdminParams{ID: user.ID, IsAdmin: isAdmin}); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if isAdmin {
+			fmt.Printf("User %d (%s) is now a site admin\n", user.ID, user.Email)
+		} else {
+			fmt.Printf("User %d (%s) is no longer a site admin\n", user.ID, user.Email)
+		}
+
+	case "list":
+		users, err := q.ListUsers(ctx, meshdb.ListUsersParams{
+			LimitVal:  int32(*limit),
+			OffsetVal: int32(*offset),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tEMAIL\tNAME\tCREATED\tADMIN\tDISABLED")
+		for _, user := range users {
+			admin := ""
+			if user.IsAdmin {
+				admin = "yes"
+			}
+			disabled := ""
+			if user.DisabledAt != nil {
+				disabled = user.DisabledAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", user.ID, user.Email, user.DisplayName,
+				user.CreatedAt.Format("2006-01-02 15:04"), admin, disabled)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// getUserByEmail looks up a user, with a readable error if there is none
+func getUserByEmail(ctx context.Context, q *meshdb.Queries, email string) (meshdb.User, error) {
+	user, err := q.GetUserByEmail(ctx, email)
+	if err == pgx.ErrNoRows {
+		return user, fmt.Errorf("no user with email %s", email)
+	}
+	if err != nil {
+		return user, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// passwordOrGenerated returns password, or a random one if it is empty,
+// reporting whether it was generated
+func passwordOrGenerated(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	generated, err := auth.GenerateRandomToken()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate password: %w", err)
+	}
+	return generated, true, nil
+}
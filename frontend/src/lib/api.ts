@@ -24,6 +24,8 @@ export interface User {
 	display_name: string;
 	created_at: string;
 	updated_at: string;
+	disabled_at: string | null;
+	is_admin: boolean;
 }
 
 export interface AuthResponse {
@@ -231,6 +233,10 @@ class ApiClient {
 		return this.request(`/api/meshes/${meshId}/nodes/${nodeId}`);
 	}
 
+	async getNodeSecrets(meshId: number, nodeId: number) {
+		return this.request(`/api/meshes/${meshId}/nodes/${nodeId}/secrets`);
+	}
+
 	async createNode(meshId: number, data: {
 		hardware_id: string;
 		name: string;
@@ -296,6 +302,55 @@ class ApiClient {
 			method: 'DELETE',
 		});
 	}
+
+	// Site admin
+	async adminListUsers(limit?: number, offset?: number): Promise<User[]> {
+		const params = new URLSearchParams();
+		if (limit !== undefined) params.set('limit', String(limit));
+		if (offset !== undefined) params.set('offset', String(offset));
+		return this.request<User[]>(`/api/admin/users?${params}`);
+	}
+
+	async adminUpdateUser(userId: number, data: { disabled?: boolean; is_admin?: boolean }): Promise<User> {
+		return this.request<User>(`/api/admin/users/${userId}`, {
+			method: 'PUT',
+			body: JSON.stringify(data),
+		});
+	}
+
+	async adminDeleteUser(userId: number) {
+		return this.request(`/api/admin/users/${userId}`, {
+			method: 'DELETE',
+		});
+	}
+
+	async adminImpersonateUser(userId: number) {
+		return this.request<{ token: string; impersonation_id: number; user: User; expires_at: string }>(
+			`/api/admin/users/${userId}/impersonate`,
+			{ method: 'POST' }
+		);
+	}
+
+	async adminListImpersonations() {
+		return this.request('/api/admin/impersonations');
+	}
+
+	async adminEndImpersonation(impersonationId: number) {
+		return this.request(`/api/admin/impersonations/${impersonationId}`, {
+			method: 'DELETE',
+		});
+	}
+
+	async adminListAuditLog(limit?: number, offset?: number) {
+		const params = new URLSearchParams();
+		if (limit !== undefined) params.set('limit', String(limit));
+		if (offset !== undefined) params.set('offset', String(offset));
+		return this.request(`/api/admin/audit-log?${params}`);
+	}
+
+	async adminListMeshes() {
+		return this.request('/api/admin/meshes');
+	}
 }
 
 export const api = new ApiClient(API_BASE_URL);
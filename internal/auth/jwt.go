@@ -29,20 +29,48 @@ import (
 type Claims struct {
 	UserID int64  `json:"user_id"`
 	Email  string `json:"email"`
+
+	// ImpersonatedBy is the site admin acting as the user, and
+	// ImpersonationID the support session the token was issued for. Both
+	// are zero in the user's own tokens.
+	ImpersonatedBy  int64 `json:"impersonated_by,omitempty"`
+	ImpersonationID int64 `json:"impersonation_id,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
+// IsImpersonation reports whether the token was issued to a site admin
+// acting as the user
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonationID != 0
+}
+
 // GenerateToken generates a new JWT token for a user
 func GenerateToken(userID int64, email, secret string, expiration time.Duration) (string, error) {
-	now := time.Now()
-	claims := Claims{
+	return signToken(Claims{
 		UserID: userID,
 		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-		},
+	}, secret, expiration)
+}
+
+// GenerateImpersonationToken generates a JWT token for a site admin to act
+// as a user during a support session
+func GenerateImpersonationToken(userID int64, email string, adminID, impersonationID int64, secret string, expiration time.Duration) (string, error) {
+	return signToken(Claims{
+		UserID:          userID,
+		Email:           email,
+		ImpersonatedBy:  adminID,
+		ImpersonationID: impersonationID,
+	}, secret, expiration)
+}
+
+// signToken sets the registered claims and signs a token
+func signToken(claims Claims, secret string, expiration time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
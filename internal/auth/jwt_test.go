@@ -180,6 +180,28 @@ func TestTokenClaims(t *testing.T) {
 	assert.True(t, claims.ExpiresAt.After(now))
 }
 
+func TestImpersonationClaims(t *testing.T) {
+	secret := "test-secret"
+
+	token, err := GenerateImpersonationToken(42, "user@example.com", 7, 99, secret, time.Hour)
+	require.NoError(t, err)
+	claims, err := ValidateToken(token, secret)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), claims.UserID)
+	assert.Equal(t, int64(7), claims.ImpersonatedBy)
+	assert.Equal(t, int64(99), claims.ImpersonationID)
+	assert.True(t, claims.IsImpersonation())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, time.Minute)
+
+	// The user's own tokens carry no impersonator
+	token, err = GenerateToken(42, "user@example.com", secret, time.Hour)
+	require.NoError(t, err)
+	claims, err = ValidateToken(token, secret)
+	require.NoError(t, err)
+	assert.Zero(t, claims.ImpersonatedBy)
+	assert.False(t, claims.IsImpersonation())
+}
+
 func TestGenerateRandomToken(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -15,16 +15,24 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/skandragon/meshmgr/internal/secrets"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Auth      AuthConfig
+	Gateway   GatewayConfig
+	Retention RetentionConfig
+	Secrets   SecretsConfig
 }
 
 // ServerConfig holds server-specific configuration
@@ -41,40 +49,164 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// AutoMigrate applies pending schema migrations when the server starts
+	AutoMigrate bool
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	JWTSecret       string
-	JWTExpiration   time.Duration
-	BCryptCost      int
+	JWTSecret     string
+	JWTExpiration time.Duration
+	BCryptCost    int
+
+	// RegistrationEnabled lets anyone create an account. Without it, users
+	// are created with meshmgr user create.
+	RegistrationEnabled bool
+}
+
+// GatewayConfig holds the connection to a local gateway radio. The gateway
+// is disabled unless a serial port or host is set.
+type GatewayConfig struct {
+	SerialPort   string
+	SerialBaud   int
+	Host         string
+	ReconnectMin time.Duration
+	ReconnectMax time.Duration
+
+	// MeshID is the mesh the radio is on. Traffic is matched to nodes of
+	// this mesh only, or of every mesh if it is zero.
+	MeshID int64
+
+	// AdminKey is the X25519 private key admin messages are sent with. It
+	// must be the gateway radio's own private key, and its public key one
+	// of the mesh's admin keys. It isn't needed if the radio's key is one
+	// the server generated for the mesh.
+	AdminKey []byte
 }
 
-// Load loads configuration from environment variables
+// Enabled reports whether a gateway radio is configured
+func (c *GatewayConfig) Enabled() bool {
+	return c.SerialPort != "" || c.Host != ""
+}
+
+// RetentionConfig holds how long time-series data is kept, and how long a
+// topology edge lasts without being heard again. Zero keeps data forever.
+type RetentionConfig struct {
+	Telemetry time.Duration
+	Positions time.Duration
+	Topology  time.Duration
+}
+
+// SecretsConfig holds the keys secrets are encrypted under in the database
+type SecretsConfig struct {
+	// MasterKey is a 32 byte AES key. Without it, node private keys and
+	// channel PSKs can't be stored unless AllowPlaintext is set, and
+	// features that generate secrets are unavailable.
+	MasterKey []byte
+
+	// MasterKeyID tags values sealed under MasterKey, so the key can be
+	// rotated
+	MasterKeyID string
+
+	// OldMasterKeys are earlier master keys by ID, kept so values sealed
+	// under them can be read until they are re-encrypted
+	OldMasterKeys map[string][]byte
+
+	// AllowPlaintext lets secrets be stored in plaintext when there is no
+	// master key. Without it, writing a secret fails instead.
+	AllowPlaintext bool
+}
+
+// Load loads the server configuration from environment variables
 func Load() (*Config, error) {
+	cfg, err := LoadDatabase()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Server = ServerConfig{
+		Host: getEnv("SERVER_HOST", "0.0.0.0"),
+		Port: getEnvInt("SERVER_PORT", 8080),
+	}
+	cfg.Auth.JWTSecret = getEnv("JWT_SECRET", "")
+	cfg.Auth.JWTExpiration = getEnvDuration("JWT_EXPIRATION", 7*24*time.Hour)
+	cfg.Auth.RegistrationEnabled = getEnvBool("REGISTRATION_ENABLED", true)
+	cfg.Gateway = GatewayConfig{
+		SerialPort:   getEnv("GATEWAY_SERIAL_PORT", ""),
+		SerialBaud:   getEnvInt("GATEWAY_SERIAL_BAUD", 115200),
+		Host:         getEnv("GATEWAY_HOST", ""),
+		ReconnectMin: getEnvDuration("GATEWAY_RECONNECT_MIN", time.Second),
+		ReconnectMax: getEnvDuration("GATEWAY_RECONNECT_MAX", time.Minute),
+		MeshID:       int64(getEnvInt("GATEWAY_MESH_ID", 0)),
+	}
+	cfg.Retention = RetentionConfig{
+		Telemetry: getEnvDuration("TELEMETRY_RETENTION", 30*24*time.Hour),
+		Positions: getEnvDuration("POSITION_RETENTION", 30*24*time.Hour),
+		Topology:  getEnvDuration("TOPOLOGY_EDGE_TTL", 24*time.Hour),
+	}
+
+	// Validate required fields
+	if cfg.Auth.JWTSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
+	}
+	if cfg.Gateway.SerialPort != "" && cfg.Gateway.Host != "" {
+		return nil, fmt.Errorf("GATEWAY_SERIAL_PORT and GATEWAY_HOST cannot both be set")
+	}
+	if key := getEnv("GATEWAY_ADMIN_KEY", ""); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("GATEWAY_ADMIN_KEY must be a base64 encoded 32 byte key")
+		}
+		cfg.Gateway.AdminKey = decoded
+	}
+
+	return cfg, nil
+}
+
+// LoadDatabase loads only what commands working on the database directly
+// need: the connection, the master keys and the bcrypt cost. Unlike Load,
+// it doesn't require JWT_SECRET.
+func LoadDatabase() (*Config, error) {
 	cfg := &Config{
-		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnvInt("SERVER_PORT", 8080),
-		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "meshmgr"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "meshmgr"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnvInt("DB_PORT", 5432),
+			User:        getEnv("DB_USER", "meshmgr"),
+			Password:    getEnv("DB_PASSWORD", ""),
+			DBName:      getEnv("DB_NAME", "meshmgr"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
 		},
 		Auth: AuthConfig{
-			JWTSecret:     getEnv("JWT_SECRET", ""),
-			JWTExpiration: getEnvDuration("JWT_EXPIRATION", 7*24*time.Hour),
-			BCryptCost:    getEnvInt("BCRYPT_COST", 12),
+			BCryptCost: getEnvInt("BCRYPT_COST", 12),
 		},
 	}
 
-	// Validate required fields
-	if cfg.Auth.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
+	if key := getEnv("MASTER_KEY", ""); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("MASTER_KEY must be a base64 encoded 32 byte key")
+		}
+		cfg.Secrets.MasterKey = decoded
+	}
+	cfg.Secrets.MasterKeyID = getEnv("MASTER_KEY_ID", "1")
+	cfg.Secrets.AllowPlaintext = getEnvBool("SECRETS_ALLOW_PLAINTEXT", false)
+	if keys := getEnv("OLD_MASTER_KEYS", ""); keys != "" {
+		if cfg.Secrets.MasterKey == nil {
+			return nil, fmt.Errorf("OLD_MASTER_KEYS requires MASTER_KEY")
+		}
+		cfg.Secrets.OldMasterKeys = make(map[string][]byte)
+		for _, entry := range strings.Split(keys, ",") {
+			id, key, _ := strings.Cut(strings.TrimSpace(entry), ":")
+			decoded, err := base64.StdEncoding.DecodeString(key)
+			if err != nil || len(decoded) != 32 || id == "" {
+				return nil, fmt.Errorf("OLD_MASTER_KEYS must be a comma separated list of id:base64-key entries")
+			}
+			if id == cfg.Secrets.MasterKeyID {
+				return nil, fmt.Errorf("OLD_MASTER_KEYS cannot reuse MASTER_KEY_ID %q", id)
+			}
+			cfg.Secrets.OldMasterKeys[id] = decoded
+		}
 	}
 
 	return cfg, nil
@@ -95,6 +227,19 @@ func (c *DatabaseConfig) ConnectionString() string {
 	return connStr
 }
 
+// Keyring returns a keyring holding the master key and old master keys,
+// or nil if no master key is configured
+func (c *SecretsConfig) Keyring() (*secrets.Keyring, error) {
+	if c.MasterKey == nil {
+		return nil, nil
+	}
+	keys := map[string][]byte{c.MasterKeyID: c.MasterKey}
+	for id, key := range c.OldMasterKeys {
+		keys[id] = key
+	}
+	return secrets.NewKeyring(c.MasterKeyID, keys)
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -114,6 +259,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvBool gets an environment variable as bool or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvDuration gets an environment variable as duration or returns a default value
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
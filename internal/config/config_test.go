@@ -26,17 +26,28 @@ import (
 func TestLoad(t *testing.T) {
 	// Save original env vars and restore after test
 	originalEnv := map[string]string{
-		"JWT_SECRET":    os.Getenv("JWT_SECRET"),
-		"SERVER_HOST":   os.Getenv("SERVER_HOST"),
-		"SERVER_PORT":   os.Getenv("SERVER_PORT"),
-		"DB_HOST":       os.Getenv("DB_HOST"),
-		"DB_PORT":       os.Getenv("DB_PORT"),
-		"DB_USER":       os.Getenv("DB_USER"),
-		"DB_PASSWORD":   os.Getenv("DB_PASSWORD"),
-		"DB_NAME":       os.Getenv("DB_NAME"),
-		"DB_SSLMODE":    os.Getenv("DB_SSLMODE"),
-		"JWT_EXPIRATION": os.Getenv("JWT_EXPIRATION"),
-		"BCRYPT_COST":   os.Getenv("BCRYPT_COST"),
+		"JWT_SECRET":              os.Getenv("JWT_SECRET"),
+		"SERVER_HOST":             os.Getenv("SERVER_HOST"),
+		"SERVER_PORT":             os.Getenv("SERVER_PORT"),
+		"DB_HOST":                 os.Getenv("DB_HOST"),
+		"DB_PORT":                 os.Getenv("DB_PORT"),
+		"DB_USER":                 os.Getenv("DB_USER"),
+		"DB_PASSWORD":             os.Getenv("DB_PASSWORD"),
+		"DB_NAME":                 os.Getenv("DB_NAME"),
+		"DB_SSLMODE":              os.Getenv("DB_SSLMODE"),
+		"DB_AUTO_MIGRATE":         os.Getenv("DB_AUTO_MIGRATE"),
+		"JWT_EXPIRATION":          os.Getenv("JWT_EXPIRATION"),
+		"BCRYPT_COST":             os.Getenv("BCRYPT_COST"),
+		"REGISTRATION_ENABLED":    os.Getenv("REGISTRATION_ENABLED"),
+		"GATEWAY_SERIAL_PORT":     os.Getenv("GATEWAY_SERIAL_PORT"),
+		"GATEWAY_SERIAL_BAUD":     os.Getenv("GATEWAY_SERIAL_BAUD"),
+		"GATEWAY_HOST":            os.Getenv("GATEWAY_HOST"),
+		"GATEWAY_MESH_ID":         os.Getenv("GATEWAY_MESH_ID"),
+		"TELEMETRY_RETENTION":     os.Getenv("TELEMETRY_RETENTION"),
+		"POSITION_RETENTION":      os.Getenv("POSITION_RETENTION"),
+		"TOPOLOGY_EDGE_TTL":       os.Getenv("TOPOLOGY_EDGE_TTL"),
+		"MASTER_KEY":              os.Getenv("MASTER_KEY"),
+		"SECRETS_ALLOW_PLAINTEXT": os.Getenv("SECRETS_ALLOW_PLAINTEXT"),
 	}
 	defer func() {
 		for k, v := range originalEnv {
@@ -70,9 +81,18 @@ func TestLoad(t *testing.T) {
 				assert.Equal(t, "", cfg.Database.Password)
 				assert.Equal(t, "meshmgr", cfg.Database.DBName)
 				assert.Equal(t, "disable", cfg.Database.SSLMode)
+				assert.False(t, cfg.Database.AutoMigrate)
 				assert.Equal(t, "test-secret", cfg.Auth.JWTSecret)
 				assert.Equal(t, 7*24*time.Hour, cfg.Auth.JWTExpiration)
 				assert.Equal(t, 12, cfg.Auth.BCryptCost)
+				assert.True(t, cfg.Auth.RegistrationEnabled)
+				assert.False(t, cfg.Gateway.Enabled())
+				assert.Equal(t, 115200, cfg.Gateway.SerialBaud)
+				assert.Equal(t, time.Second, cfg.Gateway.ReconnectMin)
+				assert.Equal(t, time.Minute, cfg.Gateway.ReconnectMax)
+				assert.Equal(t, 30*24*time.Hour, cfg.Retention.Telemetry)
+				assert.Equal(t, 30*24*time.Hour, cfg.Retention.Positions)
+				assert.Equal(t, 24*time.Hour, cfg.Retention.Topology)
 			},
 		},
 		{
@@ -88,8 +108,12 @@ func TestLoad(t *testing.T) {
 				require.NoError(t, os.Setenv("DB_PASSWORD", "custompass"))
 				require.NoError(t, os.Setenv("DB_NAME", "customdb"))
 				require.NoError(t, os.Setenv("DB_SSLMODE", "require"))
+				require.NoError(t, os.Setenv("DB_AUTO_MIGRATE", "true"))
 				require.NoError(t, os.Setenv("JWT_EXPIRATION", "24h"))
 				require.NoError(t, os.Setenv("BCRYPT_COST", "10"))
+				require.NoError(t, os.Setenv("REGISTRATION_ENABLED", "false"))
+				require.NoError(t, os.Setenv("TELEMETRY_RETENTION", "168h"))
+				require.NoError(t, os.Setenv("POSITION_RETENTION", "0"))
 			},
 			wantErr: false,
 			checkConfig: func(t *testing.T, cfg *Config) {
@@ -101,11 +125,137 @@ func TestLoad(t *testing.T) {
 				assert.Equal(t, "custompass", cfg.Database.Password)
 				assert.Equal(t, "customdb", cfg.Database.DBName)
 				assert.Equal(t, "require", cfg.Database.SSLMode)
+				assert.True(t, cfg.Database.AutoMigrate)
 				assert.Equal(t, "custom-secret", cfg.Auth.JWTSecret)
 				assert.Equal(t, 24*time.Hour, cfg.Auth.JWTExpiration)
 				assert.Equal(t, 10, cfg.Auth.BCryptCost)
+				assert.False(t, cfg.Auth.RegistrationEnabled)
+				assert.Equal(t, 7*24*time.Hour, cfg.Retention.Telemetry)
+				assert.Equal(t, time.Duration(0), cfg.Retention.Positions)
 			},
 		},
+		{
+			name: "gateway over TCP",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("GATEWAY_HOST", "192.168.1.20"))
+				require.NoError(t, os.Setenv("GATEWAY_MESH_ID", "42"))
+			},
+			wantErr: false,
+			checkConfig: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Gateway.Enabled())
+				assert.Equal(t, "192.168.1.20", cfg.Gateway.Host)
+				assert.Equal(t, int64(42), cfg.Gateway.MeshID)
+			},
+		},
+		{
+			name: "gateway admin key",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("GATEWAY_ADMIN_KEY", "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo="))
+			},
+			wantErr: false,
+			checkConfig: func(t *testing.T, cfg *Config) {
+				assert.Len(t, cfg.Gateway.AdminKey, 32)
+				assert.Equal(t, byte(0x77), cfg.Gateway.AdminKey[0])
+			},
+		},
+		{
+			name: "gateway admin key of the wrong size",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("GATEWAY_ADMIN_KEY", "AQID"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "master key",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("MASTER_KEY", "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo="))
+			},
+			wantErr: false,
+			checkConfig: func(t *testing.T, cfg *Config) {
+				assert.Len(t, cfg.Secrets.MasterKey, 32)
+				assert.Equal(t, "1", cfg.Secrets.MasterKeyID)
+				assert.Empty(t, cfg.Secrets.OldMasterKeys)
+				assert.False(t, cfg.Secrets.AllowPlaintext)
+			},
+		},
+		{
+			name: "plaintext secrets",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("SECRETS_ALLOW_PLAINTEXT", "true"))
+			},
+			wantErr: false,
+			checkConfig: func(t *testing.T, cfg *Config) {
+				assert.Nil(t, cfg.Secrets.MasterKey)
+				assert.True(t, cfg.Secrets.AllowPlaintext)
+			},
+		},
+		{
+			name: "rotated master key",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("MASTER_KEY", "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo="))
+				require.NoError(t, os.Setenv("MASTER_KEY_ID", "2025-10"))
+				require.NoError(t, os.Setenv("OLD_MASTER_KEYS", "1:WlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlo=, 0:B3cHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc="))
+			},
+			wantErr: false,
+			checkConfig: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "2025-10", cfg.Secrets.MasterKeyID)
+				assert.Len(t, cfg.Secrets.OldMasterKeys, 2)
+				assert.Len(t, cfg.Secrets.OldMasterKeys["1"], 32)
+				keyring, err := cfg.Secrets.Keyring()
+				require.NoError(t, err)
+				assert.Equal(t, "2025-10", keyring.PrimaryID())
+			},
+		},
+		{
+			name: "old master key reusing the current ID",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("MASTER_KEY", "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo="))
+				require.NoError(t, os.Setenv("OLD_MASTER_KEYS", "1:WlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlo="))
+			},
+			wantErr: true,
+		},
+		{
+			name: "old master keys without a master key",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("OLD_MASTER_KEYS", "1:WlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlo="))
+			},
+			wantErr: true,
+		},
+		{
+			name: "master key that isn't base64",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("MASTER_KEY", "not a key"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "gateway with both serial port and host",
+			setupEnv: func() {
+				os.Clearenv()
+				require.NoError(t, os.Setenv("JWT_SECRET", "test-secret"))
+				require.NoError(t, os.Setenv("GATEWAY_SERIAL_PORT", "/dev/ttyUSB0"))
+				require.NoError(t, os.Setenv("GATEWAY_HOST", "192.168.1.20"))
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing JWT secret",
 			setupEnv: func() {
@@ -136,6 +286,31 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadDatabase(t *testing.T) {
+	// Commands that work on the database directly don't need a JWT secret
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DB_HOST", "db.example.com")
+	t.Setenv("BCRYPT_COST", "10")
+	t.Setenv("MASTER_KEY", "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo=")
+	t.Setenv("SECRETS_ALLOW_PLAINTEXT", "")
+	t.Setenv("OLD_MASTER_KEYS", "")
+
+	cfg, err := LoadDatabase()
+	require.NoError(t, err)
+	assert.Equal(t, "db.example.com", cfg.Database.Host)
+	assert.Equal(t, 10, cfg.Auth.BCryptCost)
+	assert.Len(t, cfg.Secrets.MasterKey, 32)
+	assert.Empty(t, cfg.Auth.JWTSecret)
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	// The master keys are still checked
+	t.Setenv("MASTER_KEY", "not a key")
+	_, err = LoadDatabase()
+	assert.Error(t, err)
+}
+
 func TestConnectionString(t *testing.T) {
 	tests := []struct {
 		name string
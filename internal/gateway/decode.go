@@ -0,0 +1,64 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package gateway
+
+import (
+	"fmt"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/proto"
+)
+
+// DecodePayload decodes a packet payload according to its port. Text ports
+// decode to a string and protobuf ports to their message type; ports with
+// no known payload format return nil.
+func DecodePayload(data *pb.Data) (any, error) {
+	var msg proto.Message
+	switch data.GetPortnum() {
+	case pb.PortNum_TEXT_MESSAGE_APP, pb.PortNum_DETECTION_SENSOR_APP, pb.PortNum_ALERT_APP, pb.PortNum_RANGE_TEST_APP:
+		return string(data.GetPayload()), nil
+	case pb.PortNum_POSITION_APP:
+		msg = &pb.Position{}
+	case pb.PortNum_NODEINFO_APP:
+		msg = &pb.User{}
+	case pb.PortNum_ROUTING_APP:
+		msg = &pb.Routing{}
+	case pb.PortNum_ADMIN_APP:
+		msg = &pb.AdminMessage{}
+	case pb.PortNum_WAYPOINT_APP:
+		msg = &pb.Waypoint{}
+	case pb.PortNum_KEY_VERIFICATION_APP:
+		msg = &pb.KeyVerification{}
+	case pb.PortNum_PAXCOUNTER_APP:
+		msg = &pb.Paxcount{}
+	case pb.PortNum_STORE_FORWARD_APP:
+		msg = &pb.StoreAndForward{}
+	case pb.PortNum_TELEMETRY_APP:
+		msg = &pb.Telemetry{}
+	case pb.PortNum_TRACEROUTE_APP:
+		msg = &pb.RouteDiscovery{}
+	case pb.PortNum_NEIGHBORINFO_APP:
+		msg = &pb.NeighborInfo{}
+	case pb.PortNum_MAP_REPORT_APP:
+		msg = &pb.MapReport{}
+	default:
+		return nil, nil
+	}
+
+	if err := proto.Unmarshal(data.GetPayload(), msg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", data.GetPortnum(), err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// EventType identifies what an Event reports
+type EventType string
+
+const (
+	// EventConnected is published when a connection to the radio is opened
+	EventConnected EventType = "connected"
+	// EventDisconnected is published when the connection ends; Err says why
+	EventDisconnected EventType = "disconnected"
+	// EventConfigComplete is published when the radio has finished sending
+	// its config and node database
+	EventConfigComplete EventType = "config_complete"
+	// EventNodeInfo is published for each entry in the radio's node database
+	EventNodeInfo EventType = "node_info"
+	// EventPacket is published for each mesh packet the radio receives
+	EventPacket EventType = "packet"
+	// EventFromRadio is published for any other message from the radio
+	EventFromRadio EventType = "from_radio"
+)
+
+// Event is something the gateway saw on its radio connection
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// LocalNode is the gateway radio's node number, once it is known
+	LocalNode uint32
+
+	// FromRadio is the message received, for all but connection events
+	FromRadio *pb.FromRadio
+
+	// Packet is the mesh packet of an EventPacket
+	Packet *pb.MeshPacket
+
+	// Payload is the decoded payload of Packet, or nil if the packet is
+	// encrypted or its port is not one DecodePayload knows
+	Payload any
+
+	// Err is why the connection ended, for EventDisconnected
+	Err error
+}
+
+// bus fans events out to subscribers. Publishing never blocks: events for
+// a subscriber whose buffer is full are dropped.
+type bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// subscribe registers a subscriber with a buffer of size events
+func (b *bus) subscribe(size int) (<-chan Event, func()) {
+	ch := make(chan Event, size)
+
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan Event]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish sends an event to every subscriber
+func (b *bus) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
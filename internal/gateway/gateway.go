@@ -0,0 +1,344 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gateway keeps a long-lived connection to a local Meshtastic radio
+// and publishes everything it hears to the rest of the server.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/skandragon/meshmgr/internal/config"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
+	"github.com/tarm/serial"
+)
+
+const (
+	// DefaultConfigTimeout is how long the radio has to finish its config
+	// download before the connection is considered dead
+	DefaultConfigTimeout = 30 * time.Second
+
+	defaultReconnectMin = time.Second
+	defaultReconnectMax = time.Minute
+)
+
+var (
+	// ErrNotConnected is returned when sending while the radio is not connected
+	ErrNotConnected = errors.New("gateway radio not connected")
+
+	// ErrConfigTimeout is returned when the radio does not finish its config
+	// download in time
+	ErrConfigTimeout = errors.New("timeout waiting for radio configuration")
+)
+
+// Dialer opens a connection to the gateway radio
+type Dialer func(ctx context.Context) (*transport.Conn, error)
+
+// TCPDialer connects to a network-attached radio. host may include a port;
+// otherwise the default API port is used.
+func TCPDialer(host string) Dialer {
+	return func(ctx context.Context) (*transport.Conn, error) {
+		return transport.DialTCP(ctx, host)
+	}
+}
+
+// SerialDialer connects to a radio on a serial port
+func SerialDialer(path string, baud int) Dialer {
+	return func(ctx context.Context) (*transport.Conn, error) {
+		s, err := serial.OpenPort(&serial.Config{
+			Name: path,
+			Baud: baud,
+			// Reads must return periodically so the connection can be closed
+			ReadTimeout: 100 * time.Millisecond,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open serial port %s: %w", path, err)
+		}
+		return transport.New(ctx, s), nil
+	}
+}
+
+// Option configures a Gateway
+type Option func(*Gateway)
+
+// WithBackoff sets the delay before reconnecting. The delay starts at
+// minDelay and doubles after each failed attempt up to maxDelay.
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(g *Gateway) {
+		g.reconnectMin = minDelay
+		g.reconnectMax = maxDelay
+	}
+}
+
+// WithConfigTimeout sets how long the radio has to finish its config download
+func WithConfigTimeout(timeout time.Duration) Option {
+	return func(g *Gateway) {
+		g.configTimeout = timeout
+	}
+}
+
+// Status describes the gateway's connection to its radio. PublicKey is the
+// radio's own X25519 public key, from its security config.
+type Status struct {
+	Address        string     `json:"address"`
+	Connected      bool       `json:"connected"`
+	ConfigComplete bool       `json:"config_complete"`
+	LocalNode      uint32     `json:"local_node,omitempty"`
+	PublicKey      []byte     `json:"public_key,omitempty"`
+	ConnectedAt    *time.Time `json:"connected_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Reconnects     int        `json:"reconnects"`
+}
+
+// Gateway holds a connection to a radio, reconnecting whenever it is lost
+type Gateway struct {
+	address       string
+	dial          Dialer
+	reconnectMin  time.Duration
+	reconnectMax  time.Duration
+	configTimeout time.Duration
+
+	bus bus
+
+	mu     sync.Mutex
+	conn   *transport.Conn
+	status Status
+}
+
+// New creates a gateway that connects with dial. address describes the
+// radio in status and logs.
+func New(address string, dial Dialer, opts ...Option) *Gateway {
+	g := &Gateway{
+		address:       address,
+		dial:          dial,
+		reconnectMin:  defaultReconnectMin,
+		reconnectMax:  defaultReconnectMax,
+		configTimeout: DefaultConfigTimeout,
+		status:        Status{Address: address},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// FromConfig creates a gateway for the configured radio
+func FromConfig(cfg *config.GatewayConfig) *Gateway {
+	opts := []Option{WithBackoff(cfg.ReconnectMin, cfg.ReconnectMax)}
+	if cfg.Host != "" {
+		return New(transport.TCPAddress(cfg.Host), TCPDialer(cfg.Host), opts...)
+	}
+	return New(cfg.SerialPort, SerialDialer(cfg.SerialPort, cfg.SerialBaud), opts...)
+}
+
+// Subscribe returns a channel of events buffered to size, and a function
+// that unsubscribes and closes it. Events are dropped for subscribers that
+// fall behind.
+func (g *Gateway) Subscribe(size int) (<-chan Event, func()) {
+	return g.bus.subscribe(size)
+}
+
+// Status returns the current connection status
+func (g *Gateway) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.status
+}
+
+// SendToRadio sends a message to the radio
+func (g *Gateway) SendToRadio(msg *pb.ToRadio) error {
+	g.mu.Lock()
+	conn := g.conn
+	g.mu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return conn.SendToRadio(msg)
+}
+
+// Run connects to the radio and keeps reconnecting until ctx is cancelled
+func (g *Gateway) Run(ctx context.Context) error {
+	delay := g.reconnectMin
+	for {
+		configured, err := g.session(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if configured {
+			// The link was healthy, so this is a fresh failure
+			delay = g.reconnectMin
+		}
+		log.Printf("Gateway connection to %s failed: %v; reconnecting in %s", g.address, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+		delay = min(delay*2, g.reconnectMax)
+
+		g.mu.Lock()
+		g.status.Reconnects++
+		g.mu.Unlock()
+	}
+}
+
+// session runs one connection to the radio until it fails. It reports
+// whether the radio finished its config download before then.
+func (g *Gateway) session(ctx context.Context) (configured bool, err error) {
+	conn, err := g.dial(ctx)
+	if err != nil {
+		g.setError(err)
+		return false, err
+	}
+	defer func() {
+		_ = conn.Close()
+		g.disconnected(err)
+	}()
+
+	now := time.Now()
+	g.mu.Lock()
+	g.conn = conn
+	g.status.Connected = true
+	g.status.ConfigComplete = false
+	g.status.LocalNode = 0
+	g.status.PublicKey = nil
+	g.status.ConnectedAt = &now
+	g.mu.Unlock()
+	g.bus.publish(Event{Type: EventConnected, Time: now})
+	log.Printf("Gateway connected to %s", g.address)
+
+	if err := conn.Wake(); err != nil {
+		return false, err
+	}
+	configID, err := requestConfig(conn)
+	if err != nil {
+		return false, err
+	}
+
+	configTimer := time.NewTimer(g.configTimeout)
+	defer configTimer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-conn.Packets():
+			if !ok {
+				return configured, conn.Err()
+			}
+			switch {
+			case msg.GetConfigCompleteId() != 0 && msg.GetConfigCompleteId() == configID:
+				configured = true
+				configTimer.Stop()
+				g.mu.Lock()
+				g.status.ConfigComplete = true
+				g.mu.Unlock()
+			case msg.GetRebooted():
+				// A rebooted radio forgets its API client, so ask again
+				if configID, err = requestConfig(conn); err != nil {
+					return configured, err
+				}
+				configured = false
+				configTimer.Reset(g.configTimeout)
+				g.mu.Lock()
+				g.status.ConfigComplete = false
+				g.mu.Unlock()
+			}
+			g.bus.publish(g.event(msg, configID))
+
+		case <-conn.Logs():
+			// Discard debug output
+
+		case <-configTimer.C:
+			return false, ErrConfigTimeout
+		}
+	}
+}
+
+// requestConfig asks the radio for its config and node database, returning
+// the id that marks the end of the download
+func requestConfig(conn *transport.Conn) (uint32, error) {
+	id := rand.Uint32N(1<<31) + 1
+	if err := conn.RequestConfig(id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// event builds the event for a message from the radio
+func (g *Gateway) event(msg *pb.FromRadio, configID uint32) Event {
+	if info := msg.GetMyInfo(); info != nil {
+		g.mu.Lock()
+		g.status.LocalNode = info.GetMyNodeNum()
+		g.mu.Unlock()
+	}
+	if key := msg.GetConfig().GetSecurity().GetPublicKey(); len(key) > 0 {
+		g.mu.Lock()
+		g.status.PublicKey = key
+		g.mu.Unlock()
+	}
+
+	event := Event{
+		Type:      EventFromRadio,
+		Time:      time.Now(),
+		LocalNode: g.Status().LocalNode,
+		FromRadio: msg,
+	}
+	switch v := msg.PayloadVariant.(type) {
+	case *pb.FromRadio_ConfigCompleteId:
+		if v.ConfigCompleteId == configID {
+			event.Type = EventConfigComplete
+		}
+	case *pb.FromRadio_NodeInfo:
+		event.Type = EventNodeInfo
+	case *pb.FromRadio_Packet:
+		event.Type = EventPacket
+		event.Packet = v.Packet
+		if decoded := v.Packet.GetDecoded(); decoded != nil {
+			payload, err := DecodePayload(decoded)
+			if err != nil {
+				log.Printf("Gateway: packet %d from !%08x: %v", v.Packet.GetId(), v.Packet.GetFrom(), err)
+			}
+			event.Payload = payload
+		}
+	}
+	return event
+}
+
+// setError records a failed connection attempt
+func (g *Gateway) setError(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status.LastError = err.Error()
+}
+
+// disconnected records the end of a connection and announces it
+func (g *Gateway) disconnected(err error) {
+	g.mu.Lock()
+	g.conn = nil
+	g.status.Connected = false
+	g.status.ConfigComplete = false
+	g.status.ConnectedAt = nil
+	if err != nil {
+		g.status.LastError = err.Error()
+	}
+	g.mu.Unlock()
+	g.bus.publish(Event{Type: EventDisconnected, Time: time.Now(), Err: err})
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const testNodeNum = 0x0a1b2c3d
+
+var testPublicKey = bytes.Repeat([]byte{0x42}, 32)
+
+// newTestDevice starts a fake radio with a small node database
+func newTestDevice(t *testing.T) *transporttest.Device {
+	t.Helper()
+	device, err := transporttest.NewDevice([]*pb.FromRadio{
+		{PayloadVariant: &pb.FromRadio_MyInfo{MyInfo: &pb.MyNodeInfo{MyNodeNum: testNodeNum}}},
+		{PayloadVariant: &pb.FromRadio_Config{Config: &pb.Config{
+			PayloadVariant: &pb.Config_Security{Security: &pb.Config_SecurityConfig{PublicKey: testPublicKey}},
+		}}},
+		{PayloadVariant: &pb.FromRadio_NodeInfo{NodeInfo: &pb.NodeInfo{
+			Num:  testNodeNum,
+			User: &pb.User{Id: "!0a1b2c3d", LongName: "Gateway"},
+		}}},
+		{PayloadVariant: &pb.FromRadio_NodeInfo{NodeInfo: &pb.NodeInfo{
+			Num:  0x11111111,
+			User: &pb.User{Id: "!11111111", LongName: "Hilltop"},
+		}}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = device.Close() })
+	return device
+}
+
+// startGateway runs a gateway until the test ends
+func startGateway(t *testing.T, dial Dialer) (*Gateway, <-chan Event) {
+	t.Helper()
+	gw := New("test", dial, WithBackoff(10*time.Millisecond, 40*time.Millisecond))
+	events, unsubscribe := gw.Subscribe(100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, gw.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		unsubscribe()
+	})
+	return gw, events
+}
+
+// waitFor returns the next event of type want, skipping others
+func waitFor(t *testing.T, events <-chan Event, want EventType) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == want {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+func TestGatewayEvents(t *testing.T) {
+	device := newTestDevice(t)
+	gw, events := startGateway(t, TCPDialer(device.Addr()))
+
+	waitFor(t, events, EventConnected)
+	first := waitFor(t, events, EventNodeInfo)
+	assert.Equal(t, uint32(testNodeNum), first.LocalNode)
+	assert.Equal(t, "Gateway", first.FromRadio.GetNodeInfo().GetUser().GetLongName())
+	second := waitFor(t, events, EventNodeInfo)
+	assert.Equal(t, "Hilltop", second.FromRadio.GetNodeInfo().GetUser().GetLongName())
+	waitFor(t, events, EventConfigComplete)
+
+	status := gw.Status()
+	assert.True(t, status.Connected)
+	assert.True(t, status.ConfigComplete)
+	assert.Equal(t, uint32(testNodeNum), status.LocalNode)
+	assert.Equal(t, testPublicKey, status.PublicKey)
+	assert.NotNil(t, status.ConnectedAt)
+
+	// Packets heard by the radio are published with their payload decoded
+	telemetry, err := proto.Marshal(&pb.Telemetry{
+		Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: &pb.DeviceMetrics{BatteryLevel: proto.Uint32(87)}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, device.Send(&pb.FromRadio{PayloadVariant: &pb.FromRadio_Packet{Packet: &pb.MeshPacket{
+		From:           0x11111111,
+		To:             0xffffffff,
+		Id:             42,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: &pb.Data{Portnum: pb.PortNum_TELEMETRY_APP, Payload: telemetry}},
+	}}}))
+
+	event := waitFor(t, events, EventPacket)
+	assert.Equal(t, uint32(0x11111111), event.Packet.GetFrom())
+	require.IsType(t, &pb.Telemetry{}, event.Payload)
+	assert.Equal(t, uint32(87), event.Payload.(*pb.Telemetry).GetDeviceMetrics().GetBatteryLevel())
+
+	// Messages can be sent to the radio while connected
+	require.NoError(t, gw.SendToRadio(&pb.ToRadio{PayloadVariant: &pb.ToRadio_Heartbeat{Heartbeat: &pb.Heartbeat{}}}))
+	require.Eventually(t, func() bool {
+		for _, msg := range device.Received() {
+			if msg.GetHeartbeat() != nil {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGatewayReconnects(t *testing.T) {
+	device := newTestDevice(t)
+	gw, events := startGateway(t, TCPDialer(device.Addr()))
+	waitFor(t, events, EventConfigComplete)
+
+	device.Disconnect()
+	event := waitFor(t, events, EventDisconnected)
+	assert.Error(t, event.Err)
+
+	waitFor(t, events, EventConnected)
+	waitFor(t, events, EventConfigComplete)
+	status := gw.Status()
+	assert.True(t, status.Connected)
+	assert.Equal(t, 1, status.Reconnects)
+}
+
+func TestGatewayRebootedRadio(t *testing.T) {
+	device := newTestDevice(t)
+	_, events := startGateway(t, TCPDialer(device.Addr()))
+	waitFor(t, events, EventConfigComplete)
+
+	// A rebooted radio is asked for its config again
+	require.NoError(t, device.Send(&pb.FromRadio{PayloadVariant: &pb.FromRadio_Rebooted{Rebooted: true}}))
+	waitFor(t, events, EventConfigComplete)
+
+	var requests int
+	for _, msg := range device.Received() {
+		if msg.GetWantConfigId() != 0 {
+			requests++
+		}
+	}
+	assert.Equal(t, 2, requests)
+}
+
+func TestGatewayDialBackoff(t *testing.T) {
+	device := newTestDevice(t)
+
+	// The radio is unreachable for the first attempts
+	var attempts atomic.Int32
+	dial := func(ctx context.Context) (*transport.Conn, error) {
+		if attempts.Add(1) <= 3 {
+			return nil, errors.New("connection refused")
+		}
+		return transport.DialTCP(ctx, device.Addr())
+	}
+	gw, events := startGateway(t, dial)
+	waitFor(t, events, EventConfigComplete)
+
+	status := gw.Status()
+	assert.Equal(t, int32(4), attempts.Load())
+	assert.Equal(t, 3, status.Reconnects)
+	assert.Equal(t, "connection refused", status.LastError)
+}
+
+func TestGatewayNotConnected(t *testing.T) {
+	gw := New("test", TCPDialer("127.0.0.1:1"))
+	err := gw.SendToRadio(&pb.ToRadio{})
+	assert.ErrorIs(t, err, ErrNotConnected)
+	assert.False(t, gw.Status().Connected)
+}
+
+func TestDecodePayload(t *testing.T) {
+	position, err := proto.Marshal(&pb.Position{LatitudeI: proto.Int32(377749000)})
+	require.NoError(t, err)
+
+	payload, err := DecodePayload(&pb.Data{Portnum: pb.PortNum_POSITION_APP, Payload: position})
+	require.NoError(t, err)
+	assert.Equal(t, int32(377749000), payload.(*pb.Position).GetLatitudeI())
+
+	payload, err = DecodePayload(&pb.Data{Portnum: pb.PortNum_TEXT_MESSAGE_APP, Payload: []byte("hello mesh")})
+	require.NoError(t, err)
+	assert.Equal(t, "hello mesh", payload)
+
+	payload, err = DecodePayload(&pb.Data{Portnum: pb.PortNum_PRIVATE_APP, Payload: []byte{1, 2, 3}})
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+
+	_, err = DecodePayload(&pb.Data{Portnum: pb.PortNum_TELEMETRY_APP, Payload: []byte{0xff}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,198 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/skandragon/meshmgr/meshtastic-cli/meshcrypto"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+const (
+	// defaultPresetName is the modem preset of a mesh that hasn't set one
+	defaultPresetName = "LongFast"
+
+	// customChannelName is the name of an unnamed channel on a mesh with
+	// custom modem settings
+	customChannelName = "Custom"
+)
+
+// ChannelName is the name a mesh channel goes by for its channel hash. An
+// unnamed channel takes the modem preset name, as the firmware does.
+func ChannelName(mesh meshdb.Mesh, ch meshdb.MeshChannel) string {
+	if ch.ChannelName != nil && *ch.ChannelName != "" {
+		return *ch.ChannelName
+	}
+	if !mesh.UsePreset {
+		return customChannelName
+	}
+	if mesh.ModemPreset != nil && *mesh.ModemPreset != "" {
+		return *mesh.ModemPreset
+	}
+	return defaultPresetName
+}
+
+// MeshChannel is a mesh channel ready to decrypt packets
+type MeshChannel struct {
+	MeshID int64
+	Index  int32
+	meshcrypto.Channel
+}
+
+// MeshChannels returns the channels of a mesh for decryption
+func MeshChannels(ctx context.Context, q *meshdb.Queries, meshID int64) ([]MeshChannel, error) {
+	mesh, err := q.GetMeshByID(ctx, meshID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mesh %d: %w", meshID, err)
+	}
+	rows, err := q.ListMeshChannels(ctx, meshID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels of mesh %d: %w", meshID, err)
+	}
+
+	channels := make([]MeshChannel, 0, len(rows))
+	for _, row := range rows {
+		ch, err := meshcrypto.NewChannel(ChannelName(mesh, row), row.Psk)
+		if err != nil {
+			// A bad PSK can't decrypt anything; the other channels still can
+			continue
+		}
+		channels = append(channels, MeshChannel{MeshID: meshID, Index: row.ChannelIndex, Channel: ch})
+	}
+	return channels, nil
+}
+
+// ChannelCache keeps the decryption channels of each mesh, so a packet
+// doesn't cost two queries. Whatever changes a mesh's channels or modem
+// preset must call Invalidate.
+type ChannelCache struct {
+	load func(ctx context.Context, meshID int64) ([]MeshChannel, error)
+
+	mu     sync.Mutex
+	meshes map[int64][]MeshChannel
+	// generation counts invalidations, so a load that raced one isn't kept
+	generation uint64
+}
+
+// NewChannelCache creates a cache loading channels from pool
+func NewChannelCache(pool *pgxpool.Pool) *ChannelCache {
+	return &ChannelCache{
+		load: func(ctx context.Context, meshID int64) ([]MeshChannel, error) {
+			return MeshChannels(ctx, meshdb.New(pool), meshID)
+		},
+		meshes: make(map[int64][]MeshChannel),
+	}
+}
+
+// Channels returns the channels of a mesh, loading them if they aren't cached
+func (c *ChannelCache) Channels(ctx context.Context, meshID int64) ([]MeshChannel, error) {
+	c.mu.Lock()
+	channels, ok := c.meshes[meshID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok {
+		return channels, nil
+	}
+
+	channels, err := c.load(ctx, meshID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.meshes[meshID] = channels
+	}
+	c.mu.Unlock()
+	return channels, nil
+}
+
+// Invalidate drops the cached channels of a mesh
+func (c *ChannelCache) Invalidate(meshID int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.meshes, meshID)
+	c.generation++
+}
+
+// meshChannels returns the channels of a mesh, from the cache if the
+// ingester has one
+func (i *Ingester) meshChannels(ctx context.Context, q *meshdb.Queries, meshID int64) ([]MeshChannel, error) {
+	if i.channels == nil {
+		return MeshChannels(ctx, q, meshID)
+	}
+	return i.channels.Channels(ctx, meshID)
+}
+
+// Decrypt decrypts an encrypted packet with the channel keys of the meshes
+// its sender belongs to, returning the channel it was sent on. It returns
+// meshcrypto.ErrNoChannel if no mesh channel matches.
+func (i *Ingester) Decrypt(ctx context.Context, packet *pb.MeshPacket) (MeshChannel, error) {
+	q := meshdb.New(i.pool)
+
+	var meshIDs []int64
+	if i.meshID != nil {
+		meshIDs = []int64{*i.meshID}
+	} else {
+		num := int64(packet.GetFrom())
+		var err error
+		meshIDs, err = q.ListMeshIDsByNodeNum(ctx, meshdb.ListMeshIDsByNodeNumParams{NodeNum: &num})
+		if err != nil {
+			return MeshChannel{}, fmt.Errorf("failed to look up node !%08x: %w", packet.GetFrom(), err)
+		}
+	}
+
+	for _, meshID := range meshIDs {
+		channels, err := i.meshChannels(ctx, q, meshID)
+		if err != nil {
+			return MeshChannel{}, err
+		}
+		for _, ch := range channels {
+			if ch.Hash != packet.GetChannel() {
+				continue
+			}
+			if err := ch.DecryptPacket(packet); err == nil {
+				return ch, nil
+			}
+		}
+	}
+	return MeshChannel{}, meshcrypto.ErrNoChannel
+}
+
+// decryptEvent decrypts an encrypted packet event in place and decodes its
+// payload. Packets on channels no mesh has a key for are left as they are.
+func (i *Ingester) decryptEvent(ctx context.Context, event *gateway.Event) error {
+	if event.Packet.GetEncrypted() == nil {
+		return nil
+	}
+	if _, err := i.Decrypt(ctx, event.Packet); err != nil {
+		if errors.Is(err, meshcrypto.ErrNoChannel) {
+			return nil
+		}
+		return err
+	}
+	// A payload that doesn't decode still shows the sender is alive
+	event.Payload, _ = gateway.DecodePayload(event.Packet.GetDecoded())
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelName(t *testing.T) {
+	preset := "MediumFast"
+	name := "admin"
+	empty := ""
+
+	assert.Equal(t, "admin", ChannelName(meshdb.Mesh{UsePreset: true}, meshdb.MeshChannel{ChannelName: &name}))
+	assert.Equal(t, "MediumFast", ChannelName(meshdb.Mesh{UsePreset: true, ModemPreset: &preset}, meshdb.MeshChannel{ChannelName: &empty}))
+	assert.Equal(t, "LongFast", ChannelName(meshdb.Mesh{UsePreset: true}, meshdb.MeshChannel{}))
+	assert.Equal(t, "Custom", ChannelName(meshdb.Mesh{ModemPreset: &preset}, meshdb.MeshChannel{}))
+}
+
+func TestChannelCache(t *testing.T) {
+	ctx := context.Background()
+	loads := map[int64]int{}
+	cache := &ChannelCache{meshes: make(map[int64][]MeshChannel)}
+	cache.load = func(ctx context.Context, meshID int64) ([]MeshChannel, error) {
+		loads[meshID]++
+		return []MeshChannel{{MeshID: meshID, Index: int32(loads[meshID])}}, nil
+	}
+
+	// Loaded once per mesh, then served from the cache
+	channels, err := cache.Channels(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), channels[0].Index)
+	channels, err = cache.Channels(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), channels[0].Index)
+	_, err = cache.Channels(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int{1: 1, 2: 1}, loads)
+
+	// Invalidating a mesh reloads only that mesh
+	cache.Invalidate(1)
+	channels, err = cache.Channels(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), channels[0].Index)
+	_, err = cache.Channels(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int{1: 2, 2: 1}, loads)
+
+	// A load that raced an invalidation is used but not kept
+	cache.Invalidate(3)
+	load := cache.load
+	cache.load = func(ctx context.Context, meshID int64) ([]MeshChannel, error) {
+		cache.Invalidate(meshID)
+		return load(ctx, meshID)
+	}
+	_, err = cache.Channels(ctx, 3)
+	require.NoError(t, err)
+	cache.load = load
+	channels, err = cache.Channels(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), channels[0].Index)
+
+	// A nil cache has nothing to invalidate
+	var none *ChannelCache
+	none.Invalidate(1)
+}
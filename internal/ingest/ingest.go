@@ -0,0 +1,197 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ingest records what the server hears from the mesh in the
+// database: which nodes are alive, when they were last heard, and the
+// telemetry, positions and neighbors they report.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// Status history sources
+const (
+	StatusSourceTraffic = "traffic"
+	StatusSourceSweeper = "sweeper"
+	StatusSourceImport  = "import"
+	StatusSourceManual  = "manual"
+)
+
+// Option configures an Ingester
+type Option func(*Ingester)
+
+// WithMesh limits the ingester to nodes of one mesh. Without it, traffic
+// from a node_num updates that node in every mesh it belongs to.
+func WithMesh(meshID int64) Option {
+	return func(i *Ingester) {
+		i.meshID = &meshID
+	}
+}
+
+// WithChannelCache has the ingester take mesh channel keys from cache
+// instead of querying them for every encrypted packet
+func WithChannelCache(cache *ChannelCache) Option {
+	return func(i *Ingester) {
+		i.channels = cache
+	}
+}
+
+// Ingester applies traffic heard from the mesh to the database
+type Ingester struct {
+	pool     *pgxpool.Pool
+	meshID   *int64
+	channels *ChannelCache
+}
+
+// New creates an ingester writing to pool
+func New(pool *pgxpool.Pool, opts ...Option) *Ingester {
+	i := &Ingester{pool: pool}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Run handles gateway events until ctx is cancelled or events is closed
+func (i *Ingester) Run(ctx context.Context, events <-chan gateway.Event) {
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := i.HandleEvent(ctx, event); err != nil {
+				log.Printf("Ingest: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// HandleEvent applies one gateway event
+func (i *Ingester) HandleEvent(ctx context.Context, event gateway.Event) error {
+	switch event.Type {
+	case gateway.EventPacket:
+		from := event.Packet.GetFrom()
+		if err := i.NodeSeen(ctx, from, event.Time); err != nil {
+			return err
+		}
+		if err := i.decryptEvent(ctx, &event); err != nil {
+			return err
+		}
+		switch payload := event.Payload.(type) {
+		case *pb.Telemetry:
+			return i.Telemetry(ctx, from, event.Time, payload)
+		case *pb.Position:
+			return i.Position(ctx, from, event.Time, payload)
+		case *pb.NeighborInfo:
+			return i.Edges(ctx, from, event.Time, EdgeSourceNeighborInfo, NeighborEdges(from, payload))
+		case *pb.RouteDiscovery:
+			to := event.Packet.GetTo()
+			reply := event.Packet.GetDecoded().GetRequestId() != 0
+			return i.Edges(ctx, from, event.Time, EdgeSourceTraceroute, TracerouteEdges(from, to, reply, payload))
+		}
+		return nil
+
+	case gateway.EventNodeInfo:
+		// The radio's node database says when it last heard each node, and
+		// the device metrics and position it last reported
+		info := event.FromRadio.GetNodeInfo()
+		if info.GetLastHeard() == 0 {
+			return nil
+		}
+		heard := time.Unix(int64(info.GetLastHeard()), 0)
+		if heard.After(event.Time) {
+			heard = event.Time
+		}
+		if err := i.NodeSeen(ctx, info.GetNum(), heard); err != nil {
+			return err
+		}
+		if metrics := info.GetDeviceMetrics(); metrics != nil {
+			err := i.Telemetry(ctx, info.GetNum(), heard, &pb.Telemetry{
+				Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: metrics},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return i.Position(ctx, info.GetNum(), heard, info.GetPosition())
+
+	case gateway.EventConfigComplete:
+		// The gateway radio itself is alive while it is connected
+		return i.NodeSeen(ctx, event.LocalNode, event.Time)
+	}
+	return nil
+}
+
+// NodeSeen records that nodeNum was heard at the given time
+func (i *Ingester) NodeSeen(ctx context.Context, nodeNum uint32, at time.Time) error {
+	if nodeNum == 0 {
+		return nil
+	}
+
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := meshdb.New(tx)
+
+	num := int64(nodeNum)
+	rows, err := qtx.MarkNodeSeen(ctx, meshdb.MarkNodeSeenParams{
+		SeenAt:  at,
+		NodeNum: &num,
+		MeshID:  i.meshID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark node !%08x seen: %w", nodeNum, err)
+	}
+	for _, row := range rows {
+		if err := RecordStatusChange(ctx, qtx, row.ID, row.OldStatus, row.NewStatus, StatusSourceTraffic); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RecordStatusChange adds a node status transition to its history. Nothing
+// is recorded if the status did not change.
+func RecordStatusChange(ctx context.Context, q *meshdb.Queries, nodeID int64, oldStatus, newStatus *string, source string) error {
+	if newStatus == nil || (oldStatus != nil && *oldStatus == *newStatus) {
+		return nil
+	}
+	_, err := q.CreateNodeStatusHistory(ctx, meshdb.CreateNodeStatusHistoryParams{
+		NodeID:    nodeID,
+		OldStatus: oldStatus,
+		NewStatus: *newStatus,
+		Source:    source,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record status change for node %d: %w", nodeID, err)
+	}
+	return nil
+}
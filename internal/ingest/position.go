@@ -0,0 +1,114 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// degreesScale converts Position's fixed-point coordinates to degrees
+const degreesScale = 1e-7
+
+// HasLocation reports whether a position report carries coordinates. Nodes
+// without a fix send reports with none, or with both set to zero.
+func HasLocation(p *pb.Position) bool {
+	if p == nil || p.LatitudeI == nil || p.LongitudeI == nil {
+		return false
+	}
+	return p.GetLatitudeI() != 0 || p.GetLongitudeI() != 0
+}
+
+// StorePosition records a position report for a node and makes it the
+// node's latest position unless a newer one is already known
+func StorePosition(ctx context.Context, q *meshdb.Queries, nodeID int64, at time.Time, p *pb.Position) error {
+	if !HasLocation(p) {
+		return nil
+	}
+
+	latitude := float64(p.GetLatitudeI()) * degreesScale
+	longitude := float64(p.GetLongitudeI()) * degreesScale
+	var altitude, precision pgtype.Int4
+	if p.Altitude != nil {
+		altitude = pgtype.Int4{Int32: p.GetAltitude(), Valid: true}
+	}
+	if p.GetPrecisionBits() != 0 {
+		precision = pgtype.Int4{Int32: int32(p.GetPrecisionBits()), Valid: true}
+	}
+
+	err := q.InsertNodePosition(ctx, meshdb.InsertNodePositionParams{
+		NodeID:        nodeID,
+		RecordedAt:    at,
+		Latitude:      latitude,
+		Longitude:     longitude,
+		Altitude:      altitude,
+		PrecisionBits: precision,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store position for node %d: %w", nodeID, err)
+	}
+	err = q.UpdateNodeLatestPosition(ctx, meshdb.UpdateNodeLatestPositionParams{
+		Latitude:      latitude,
+		Longitude:     longitude,
+		Altitude:      altitude,
+		PrecisionBits: precision,
+		RecordedAt:    at,
+		NodeID:        nodeID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update position of node %d: %w", nodeID, err)
+	}
+	return nil
+}
+
+// Position records a position report from nodeNum, received at the given
+// time, for every matching node
+func (i *Ingester) Position(ctx context.Context, nodeNum uint32, received time.Time, p *pb.Position) error {
+	if nodeNum == 0 || !HasLocation(p) {
+		return nil
+	}
+
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := meshdb.New(tx)
+
+	num := int64(nodeNum)
+	nodeIDs, err := qtx.ListNodeIDsByNodeNum(ctx, meshdb.ListNodeIDsByNodeNumParams{
+		NodeNum: &num,
+		MeshID:  i.meshID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up node !%08x: %w", nodeNum, err)
+	}
+
+	at := reportTime(p.GetTime(), received)
+	for _, nodeID := range nodeIDs {
+		if err := StorePosition(ctx, qtx, nodeID, at, p); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,30 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"testing"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasLocation(t *testing.T) {
+	lat, lon, zero := int32(377749000), int32(-1224194000), int32(0)
+	assert.True(t, HasLocation(&pb.Position{LatitudeI: &lat, LongitudeI: &lon}))
+	assert.False(t, HasLocation(&pb.Position{LatitudeI: &zero, LongitudeI: &zero}))
+	assert.False(t, HasLocation(&pb.Position{LatitudeI: &lat}))
+	assert.False(t, HasLocation(nil))
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// DefaultPruneInterval is how often expired time-series data is deleted
+const DefaultPruneInterval = time.Hour
+
+// Prune deletes time-series data older than the retention periods,
+// returning how many rows were deleted
+func Prune(ctx context.Context, pool *pgxpool.Pool, retention config.RetentionConfig) (int64, error) {
+	q := meshdb.New(pool)
+	now := time.Now()
+
+	var total int64
+	if retention.Telemetry > 0 {
+		n, err := q.DeleteTelemetryBefore(ctx, now.Add(-retention.Telemetry))
+		if err != nil {
+			return total, fmt.Errorf("failed to prune telemetry: %w", err)
+		}
+		total += n
+	}
+	if retention.Positions > 0 {
+		n, err := q.DeletePositionsBefore(ctx, now.Add(-retention.Positions))
+		if err != nil {
+			return total, fmt.Errorf("failed to prune positions: %w", err)
+		}
+		total += n
+	}
+	if retention.Topology > 0 {
+		n, err := q.DeleteTopologyEdgesBefore(ctx, now.Add(-retention.Topology))
+		if err != nil {
+			return total, fmt.Errorf("failed to age out topology edges: %w", err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// RunPruner prunes every interval until ctx is cancelled
+func RunPruner(ctx context.Context, pool *pgxpool.Pool, retention config.RetentionConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := Prune(ctx, pool, retention)
+			if err != nil {
+				log.Printf("Pruner: %v", err)
+			} else if n > 0 {
+				log.Printf("Pruner: deleted %d expired rows", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
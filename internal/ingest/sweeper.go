@@ -0,0 +1,77 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// DefaultSweepInterval is how often silent nodes are looked for
+const DefaultSweepInterval = time.Minute
+
+// Sweep marks online nodes offline once they have been silent for longer
+// than their mesh's threshold, returning how many were marked
+func Sweep(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := meshdb.New(tx)
+
+	rows, err := qtx.MarkSilentNodesOffline(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark silent nodes offline: %w", err)
+	}
+	online, offline := "online", "offline"
+	for _, row := range rows {
+		if err := RecordStatusChange(ctx, qtx, row.ID, &online, &offline, StatusSourceSweeper); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(rows), nil
+}
+
+// RunSweeper sweeps every interval until ctx is cancelled
+func RunSweeper(ctx context.Context, pool *pgxpool.Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := Sweep(ctx, pool)
+			if err != nil {
+				log.Printf("Sweeper: %v", err)
+			} else if n > 0 {
+				log.Printf("Sweeper: marked %d silent nodes offline", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
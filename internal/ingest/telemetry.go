@@ -0,0 +1,154 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxClockSkew is how far in the future a node's clock may be before its
+// timestamps are ignored
+const maxClockSkew = 5 * time.Minute
+
+// TelemetryMetrics flattens a telemetry report into named values. Device
+// metrics use their field names (battery_level, voltage, ...); other kinds
+// are prefixed (environment.temperature, power.ch1_voltage, ...).
+func TelemetryMetrics(t *pb.Telemetry) map[string]float64 {
+	var msg protoreflect.ProtoMessage
+	var prefix string
+	switch v := t.GetVariant().(type) {
+	case *pb.Telemetry_DeviceMetrics:
+		msg = v.DeviceMetrics
+	case *pb.Telemetry_EnvironmentMetrics:
+		msg, prefix = v.EnvironmentMetrics, "environment."
+	case *pb.Telemetry_AirQualityMetrics:
+		msg, prefix = v.AirQualityMetrics, "air_quality."
+	case *pb.Telemetry_PowerMetrics:
+		msg, prefix = v.PowerMetrics, "power."
+	case *pb.Telemetry_LocalStats:
+		msg, prefix = v.LocalStats, "local_stats."
+	case *pb.Telemetry_HealthMetrics:
+		msg, prefix = v.HealthMetrics, "health."
+	case *pb.Telemetry_HostMetrics:
+		msg, prefix = v.HostMetrics, "host."
+	default:
+		return nil
+	}
+	return numericFields(msg.ProtoReflect(), prefix)
+}
+
+// numericFields collects the numeric fields of a message. Optional fields
+// that are not set are left out; plain fields are always included.
+func numericFields(m protoreflect.Message, prefix string) map[string]float64 {
+	if !m.IsValid() {
+		return nil
+	}
+	values := make(map[string]float64)
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsList() || fd.IsMap() || (fd.HasPresence() && !m.Has(fd)) {
+			continue
+		}
+		v := m.Get(fd)
+		name := prefix + string(fd.Name())
+		switch fd.Kind() {
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			values[name] = v.Float()
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			values[name] = float64(v.Int())
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+			protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			values[name] = float64(v.Uint())
+		}
+	}
+	return values
+}
+
+// reportTime is when a report was made: the node's own timestamp if it
+// has a plausible one, otherwise when it was received
+func reportTime(timestamp uint32, received time.Time) time.Time {
+	if timestamp == 0 {
+		return received
+	}
+	t := time.Unix(int64(timestamp), 0)
+	if t.After(received.Add(maxClockSkew)) {
+		return received
+	}
+	return t
+}
+
+// StoreTelemetry records a telemetry report for a node
+func StoreTelemetry(ctx context.Context, q *meshdb.Queries, nodeID int64, at time.Time, t *pb.Telemetry) error {
+	values := TelemetryMetrics(t)
+	if len(values) == 0 {
+		return nil
+	}
+
+	metrics := make([]string, 0, len(values))
+	for name := range values {
+		metrics = append(metrics, name)
+	}
+	sort.Strings(metrics)
+	metricValues := make([]float64, len(metrics))
+	for i, name := range metrics {
+		metricValues[i] = values[name]
+	}
+
+	err := q.InsertNodeTelemetry(ctx, meshdb.InsertNodeTelemetryParams{
+		NodeID:       nodeID,
+		Metrics:      metrics,
+		RecordedAt:   at,
+		MetricValues: metricValues,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store telemetry for node %d: %w", nodeID, err)
+	}
+	return nil
+}
+
+// Telemetry records a telemetry report from nodeNum, received at the given
+// time, for every matching node
+func (i *Ingester) Telemetry(ctx context.Context, nodeNum uint32, received time.Time, t *pb.Telemetry) error {
+	if nodeNum == 0 {
+		return nil
+	}
+
+	q := meshdb.New(i.pool)
+	num := int64(nodeNum)
+	nodeIDs, err := q.ListNodeIDsByNodeNum(ctx, meshdb.ListNodeIDsByNodeNumParams{
+		NodeNum: &num,
+		MeshID:  i.meshID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up node !%08x: %w", nodeNum, err)
+	}
+
+	at := reportTime(t.GetTime(), received)
+	for _, nodeID := range nodeIDs {
+		if err := StoreTelemetry(ctx, q, nodeID, at, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelemetryMetrics(t *testing.T) {
+	battery := uint32(87)
+	voltage := float32(4.1)
+	metrics := TelemetryMetrics(&pb.Telemetry{
+		Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: &pb.DeviceMetrics{
+			BatteryLevel: &battery,
+			Voltage:      &voltage,
+		}},
+	})
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, 87.0, metrics["battery_level"])
+	assert.InDelta(t, 4.1, metrics["voltage"], 0.0001)
+
+	temperature := float32(-3.5)
+	metrics = TelemetryMetrics(&pb.Telemetry{
+		Variant: &pb.Telemetry_EnvironmentMetrics{EnvironmentMetrics: &pb.EnvironmentMetrics{
+			Temperature: &temperature,
+		}},
+	})
+	assert.Equal(t, map[string]float64{"environment.temperature": -3.5}, metrics)
+
+	assert.Empty(t, TelemetryMetrics(&pb.Telemetry{}))
+}
+
+func TestReportTime(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	assert.Equal(t, received, reportTime(0, received))
+	assert.Equal(t, received.Add(-time.Minute), reportTime(1700000000-60, received))
+	assert.Equal(t, received, reportTime(1700000000+3600, received))
+}
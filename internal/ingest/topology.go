@@ -0,0 +1,160 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// Topology edge sources
+const (
+	EdgeSourceNeighborInfo = "neighborinfo"
+	EdgeSourceTraceroute   = "traceroute"
+)
+
+const (
+	// broadcastNum stands in for hops a traceroute could not identify
+	broadcastNum = math.MaxUint32
+
+	// tracerouteSNRUnknown marks a hop whose SNR was not recorded
+	tracerouteSNRUnknown = math.MinInt8
+)
+
+// Edge is a radio link: To has heard From
+type Edge struct {
+	From uint32
+	To   uint32
+	// SNR is the signal-to-noise ratio in dB at To, nil if unknown
+	SNR *float32
+}
+
+// NeighborEdges returns the links a NeighborInfo report describes: the
+// reporting node has heard each of its neighbors
+func NeighborEdges(from uint32, info *pb.NeighborInfo) []Edge {
+	reporter := info.GetNodeId()
+	if reporter == 0 {
+		reporter = from
+	}
+	var edges []Edge
+	for _, n := range info.GetNeighbors() {
+		snr := n.GetSnr()
+		edges = append(edges, Edge{From: n.GetNodeId(), To: reporter, SNR: &snr})
+	}
+	return validEdges(edges)
+}
+
+// TracerouteEdges returns the links along a traceroute packet's path. A
+// request carries the hops from its origin so far; a reply (from the
+// destination back to the origin) carries the whole path there and the
+// hops back so far. SNRs are in quarter dB, one per hop received.
+func TracerouteEdges(from, to uint32, reply bool, route *pb.RouteDiscovery) []Edge {
+	var edges []Edge
+	if reply {
+		towards := append(append([]uint32{to}, route.GetRoute()...), from)
+		edges = append(edges, pathEdges(towards, route.GetSnrTowards())...)
+
+		back := append([]uint32{from}, route.GetRouteBack()...)
+		if len(route.GetSnrBack()) > len(route.GetRouteBack()) {
+			back = append(back, to)
+		}
+		edges = append(edges, pathEdges(back, route.GetSnrBack())...)
+	} else {
+		towards := append([]uint32{from}, route.GetRoute()...)
+		edges = append(edges, pathEdges(towards, route.GetSnrTowards())...)
+	}
+	return validEdges(edges)
+}
+
+// pathEdges links consecutive nodes of a path, with the SNR each hop was
+// received at if known
+func pathEdges(path []uint32, snrs []int32) []Edge {
+	var edges []Edge
+	for i := 0; i+1 < len(path); i++ {
+		edge := Edge{From: path[i], To: path[i+1]}
+		if i < len(snrs) && snrs[i] != tracerouteSNRUnknown {
+			snr := float32(snrs[i]) / 4
+			edge.SNR = &snr
+		}
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+// validEdges drops edges to or from unknown nodes, and loops
+func validEdges(edges []Edge) []Edge {
+	valid := edges[:0]
+	for _, e := range edges {
+		if e.From == 0 || e.To == 0 || e.From == broadcastNum || e.To == broadcastNum || e.From == e.To {
+			continue
+		}
+		valid = append(valid, e)
+	}
+	return valid
+}
+
+// Edges records links reported by a node, heard at the given time, in
+// every mesh the reporter belongs to
+func (i *Ingester) Edges(ctx context.Context, reporter uint32, heard time.Time, source string, edges []Edge) error {
+	if reporter == 0 || len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := meshdb.New(tx)
+
+	num := int64(reporter)
+	meshIDs, err := qtx.ListMeshIDsByNodeNum(ctx, meshdb.ListMeshIDsByNodeNumParams{
+		NodeNum: &num,
+		MeshID:  i.meshID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up node !%08x: %w", reporter, err)
+	}
+
+	for _, meshID := range meshIDs {
+		for _, e := range edges {
+			var snr pgtype.Float4
+			if e.SNR != nil {
+				snr = pgtype.Float4{Float32: *e.SNR, Valid: true}
+			}
+			err := qtx.UpsertTopologyEdge(ctx, meshdb.UpsertTopologyEdgeParams{
+				MeshID:    meshID,
+				FromNum:   int64(e.From),
+				ToNum:     int64(e.To),
+				Snr:       snr,
+				Source:    source,
+				LastHeard: heard,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record edge !%08x -> !%08x: %w", e.From, e.To, err)
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+package ingest
+
+import (
+	"testing"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeighborEdges(t *testing.T) {
+	edges := NeighborEdges(0x10, &pb.NeighborInfo{
+		Neighbors: []*pb.Neighbor{
+			{NodeId: 0x20, Snr: 6.5},
+			{NodeId: 0x10, Snr: 1},
+			{NodeId: 0x30, Snr: -3},
+		},
+	})
+	require.Len(t, edges, 2)
+	assert.Equal(t, uint32(0x20), edges[0].From)
+	assert.Equal(t, uint32(0x10), edges[0].To)
+	assert.Equal(t, float32(6.5), *edges[0].SNR)
+	assert.Equal(t, uint32(0x30), edges[1].From)
+}
+
+func TestTracerouteEdges(t *testing.T) {
+	// A request part way along: origin 0x1 via 0x2 so far
+	edges := TracerouteEdges(0x1, 0x9, false, &pb.RouteDiscovery{
+		Route:      []uint32{0x2},
+		SnrTowards: []int32{24},
+	})
+	require.Len(t, edges, 1)
+	assert.Equal(t, Edge{From: 0x1, To: 0x2, SNR: edges[0].SNR}, edges[0])
+	assert.Equal(t, float32(6), *edges[0].SNR)
+
+	// The reply from 0x9 back to 0x1: there via 0x2 and an unknown hop,
+	// back via 0x3
+	edges = TracerouteEdges(0x9, 0x1, true, &pb.RouteDiscovery{
+		Route:      []uint32{0x2, broadcastNum},
+		SnrTowards: []int32{24, tracerouteSNRUnknown, -8},
+		RouteBack:  []uint32{0x3},
+		SnrBack:    []int32{10},
+	})
+	require.Len(t, edges, 2)
+	assert.Equal(t, uint32(0x1), edges[0].From)
+	assert.Equal(t, uint32(0x2), edges[0].To)
+	assert.Equal(t, uint32(0x9), edges[1].From)
+	assert.Equal(t, uint32(0x3), edges[1].To)
+	assert.Equal(t, float32(2.5), *edges[1].SNR)
+}
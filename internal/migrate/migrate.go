@@ -0,0 +1,349 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package migrate applies the schema migrations in meshdb/migrations. It
+// keeps track of the schema version in a schema_migrations table laid out
+// like golang-migrate's, so databases migrated with either tool agree.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// lockID is the advisory lock held while migrating, so two servers
+// starting at once don't both apply the same migration
+const lockID = 0x6d6573686d6772 // "meshmgr"
+
+// ErrDirty is returned when a migration failed part way through outside a
+// transaction and the schema has to be repaired by hand
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migration is one schema change and how to undo it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads migrations named <version>_<name>.up.sql and
+// <version>_<name>.down.sql from a directory of fsys, in version order
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.up.sql or .down.sql", name)
+		}
+		versionStr, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s has no name", name)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s has an invalid version", name)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Status is the schema version of a database and the migrations not yet
+// applied to it
+type Status struct {
+	// Version is the last migration applied, 0 for none
+	Version int64
+	Dirty   bool
+	Pending []Migration
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New creates a migrator for a database
+func New(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// Latest returns the version of the newest migration, 0 if there are none
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status reports the schema version and pending migrations
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if err := ensureTable(ctx, conn.Conn()); err != nil {
+		return Status{}, err
+	}
+	version, dirty, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Up applies every pending migration, returning how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *pgx.Conn, version int64) error {
+		if version >= m.Latest() {
+			return nil
+		}
+		var err error
+		count, err = m.migrate(ctx, conn, version, m.Latest())
+		return err
+	})
+	return count, err
+}
+
+// Down reverts the last steps migrations, returning how many were
+// reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *pgx.Conn, version int64) error {
+		index := m.find(version)
+		if version != 0 && index < 0 {
+			return fmt.Errorf("database is at version %d, which has no migration", version)
+		}
+		var target int64
+		if index-steps >= 0 {
+			target = m.migrations[index-steps].Version
+		}
+		var err error
+		count, err = m.migrate(ctx, conn, version, target)
+		return err
+	})
+	return count, err
+}
+
+// To migrates up or down to a version, 0 reverting every migration. It
+// returns how many migrations were applied or reverted.
+func (m *Migrator) To(ctx context.Context, target int64) (int, error) {
+	if target != 0 && m.find(target) < 0 {
+		return 0, fmt.Errorf("no migration with version %d", target)
+	}
+
+	count := 0
+	err := m.locked(ctx, func(conn *pgx.Conn, version int64) error {
+		var err error
+		count, err = m.migrate(ctx, conn, version, target)
+		return err
+	})
+	return count, err
+}
+
+// migrate applies or reverts migrations one at a time from version to
+// target
+func (m *Migrator) migrate(ctx context.Context, conn *pgx.Conn, version, target int64) (int, error) {
+	count := 0
+	for version < target {
+		next := m.next(version)
+		if err := apply(ctx, conn, next.Up, next.Version); err != nil {
+			return count, fmt.Errorf("migration %d_%s failed: %w", next.Version, next.Name, err)
+		}
+		version = next.Version
+		count++
+	}
+	for version > target {
+		index := m.find(version)
+		if index < 0 {
+			return count, fmt.Errorf("database is at version %d, which has no migration", version)
+		}
+		current := m.migrations[index]
+		if current.Down == "" {
+			return count, fmt.Errorf("migration %d_%s can't be reverted", current.Version, current.Name)
+		}
+		var previous int64
+		if index > 0 {
+			previous = m.migrations[index-1].Version
+		}
+		if err := apply(ctx, conn, current.Down, previous); err != nil {
+			return count, fmt.Errorf("reverting migration %d_%s failed: %w", current.Version, current.Name, err)
+		}
+		version = previous
+		count++
+	}
+	return count, nil
+}
+
+// locked runs fn on one connection while holding the migration lock,
+// passing the current schema version
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgx.Conn, version int64) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+
+	if err := ensureTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	version, dirty, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+	return fn(conn.Conn(), version)
+}
+
+// next returns the first migration after version
+func (m *Migrator) next(version int64) Migration {
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			return migration
+		}
+	}
+	return Migration{}
+}
+
+// find returns the index of the migration with a version, or -1
+func (m *Migrator) find(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureTable creates the schema_migrations table if it doesn't exist
+func ensureTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// currentVersion returns the schema version, 0 if no migrations have been
+// applied
+func currentVersion(ctx context.Context, conn *pgx.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == pgx.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// apply runs one migration and records the new version in the same
+// transaction
+func apply(ctx context.Context, conn *pgx.Conn, sql string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	if version > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", version); err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Embedded returns the migrations built into the binary
+func Embedded() ([]Migration, error) {
+	return Load(meshdb.Migrations, "migrations")
+}
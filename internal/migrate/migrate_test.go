@@ -0,0 +1,84 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/2_add_nodes.up.sql":        {Data: []byte("CREATE TABLE nodes ();")},
+		"migrations/2_add_nodes.down.sql":      {Data: []byte("DROP TABLE nodes;")},
+		"migrations/10_add_keys.up.sql":        {Data: []byte("CREATE TABLE keys ();")},
+		"migrations/1_initial_schema.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"migrations/1_initial_schema.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/README.md":                 {Data: []byte("not a migration")},
+	}
+
+	migrations, err := Load(fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, Migration{Version: 1, Name: "initial_schema", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"}, migrations[0])
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, int64(10), migrations[2].Version)
+	assert.Empty(t, migrations[2].Down)
+
+	assert.Equal(t, int64(10), New(nil, migrations).Latest())
+	assert.Equal(t, int64(0), New(nil, nil).Latest())
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"no direction", "1_initial.sql"},
+		{"unknown direction", "1_initial.sideways.sql"},
+		{"no name", "1.up.sql"},
+		{"bad version", "first_initial.up.sql"},
+		{"down only", "3_orphan.down.sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"migrations/" + tt.file: {Data: []byte("SELECT 1;")}}
+			_, err := Load(fsys, "migrations")
+			assert.Error(t, err)
+		})
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/1_one.up.sql": {Data: []byte("SELECT 1;")},
+		"migrations/1_two.up.sql": {Data: []byte("SELECT 2;")},
+	}
+	_, err := Load(fsys, "migrations")
+	assert.Error(t, err)
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.NotEmpty(t, m.Down, "migration %d_%s has no down migration", m.Version, m.Name)
+		if i > 0 {
+			assert.Greater(t, m.Version, migrations[i-1].Version)
+		}
+	}
+}
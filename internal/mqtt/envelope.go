@@ -0,0 +1,69 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/internal/ingest"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/proto"
+)
+
+// Handler decodes the ServiceEnvelopes published for a mesh and ingests
+// the packets inside them
+type Handler struct {
+	pool     *pgxpool.Pool
+	meshID   int64
+	ingester *ingest.Ingester
+}
+
+// NewHandler creates a handler ingesting into meshID, taking channel keys
+// from channels if it isn't nil
+func NewHandler(pool *pgxpool.Pool, meshID int64, channels *ingest.ChannelCache) *Handler {
+	return &Handler{
+		pool:     pool,
+		meshID:   meshID,
+		ingester: ingest.New(pool, ingest.WithMesh(meshID), ingest.WithChannelCache(channels)),
+	}
+}
+
+// HandleMessage ingests one MQTT message received at the given time
+func (h *Handler) HandleMessage(ctx context.Context, payload []byte, received time.Time) error {
+	envelope := &pb.ServiceEnvelope{}
+	if err := proto.Unmarshal(payload, envelope); err != nil {
+		return fmt.Errorf("failed to decode service envelope: %w", err)
+	}
+	packet := envelope.GetPacket()
+	if packet == nil {
+		return nil
+	}
+
+	event := gateway.Event{
+		Type:   gateway.EventPacket,
+		Time:   received,
+		Packet: packet,
+	}
+	if decoded := packet.GetDecoded(); decoded != nil {
+		// A payload that doesn't decode still shows the sender is alive
+		event.Payload, _ = gateway.DecodePayload(decoded)
+	}
+	// Encrypted packets are decrypted with the mesh's channel keys by the
+	// ingester, which also skips those on channels the mesh doesn't have
+	return h.ingester.HandleEvent(ctx, event)
+}
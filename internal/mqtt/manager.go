@@ -0,0 +1,114 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/skandragon/meshmgr/internal/ingest"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// Manager keeps a subscriber running for every mesh with MQTT enabled
+type Manager struct {
+	pool     *pgxpool.Pool
+	channels *ingest.ChannelCache
+
+	mu sync.Mutex
+	// ctx is the context subscribers ingest under, nil until Run
+	ctx  context.Context
+	subs map[int64]*Subscriber
+}
+
+// NewManager creates a manager for the meshes in pool. Its subscribers
+// take channel keys from channels, if it isn't nil.
+func NewManager(pool *pgxpool.Pool, channels *ingest.ChannelCache) *Manager {
+	return &Manager{
+		pool:     pool,
+		channels: channels,
+		subs:     make(map[int64]*Subscriber),
+	}
+}
+
+// Run subscribes for every enabled mesh and keeps the subscriptions until
+// ctx is cancelled
+func (m *Manager) Run(ctx context.Context) error {
+	configs, err := meshdb.New(m.pool).ListEnabledMeshMQTT(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.ctx = ctx
+	for _, cfg := range configs {
+		m.startLocked(cfg)
+	}
+	m.mu.Unlock()
+
+	<-ctx.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for meshID, sub := range m.subs {
+		sub.Close()
+		delete(m.subs, meshID)
+	}
+	m.ctx = nil
+	return nil
+}
+
+// Reload picks up a changed MQTT configuration for a mesh, restarting or
+// stopping its subscriber. It does nothing until Run is called.
+func (m *Manager) Reload(ctx context.Context, meshID int64) error {
+	cfg, err := meshdb.New(m.pool).GetMeshMQTT(ctx, meshID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sub, ok := m.subs[meshID]; ok {
+		sub.Close()
+		delete(m.subs, meshID)
+	}
+	if m.ctx == nil || err != nil || !cfg.Enabled {
+		return nil
+	}
+	m.startLocked(cfg)
+	return nil
+}
+
+// Subscriber returns the running subscriber for a mesh, or nil
+func (m *Manager) Subscriber(meshID int64) *Subscriber {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.subs[meshID]
+}
+
+// startLocked starts a subscriber for cfg. m.mu must be held.
+func (m *Manager) startLocked(cfg meshdb.MeshMqtt) {
+	ctx := m.ctx
+	handler := NewHandler(m.pool, cfg.MeshID, m.channels)
+	m.subs[cfg.MeshID] = Subscribe(cfg, func(topic string, payload []byte) {
+		if err := handler.HandleMessage(ctx, payload, time.Now()); err != nil {
+			log.Printf("MQTT mesh %d: %s: %v", cfg.MeshID, topic, err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+package mqtt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/skandragon/meshmgr/internal/mqtt/mqtttest"
+	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionTopic(t *testing.T) {
+	assert.Equal(t, "msh/US/2/e/#", SubscriptionTopic("msh/US/2/e/"))
+	assert.Equal(t, "msh/US/2/e/#", SubscriptionTopic("msh/US/2/e"))
+}
+
+func TestSubscriber(t *testing.T) {
+	broker := mqtttest.NewBroker(t, mqtttest.WithCredentials("meshdev", "large4cats"))
+
+	type message struct {
+		topic   string
+		payload string
+	}
+	received := make(chan message, 10)
+	username, password := "meshdev", "large4cats"
+	sub := Subscribe(meshdb.MeshMqtt{
+		MeshID:    1,
+		BrokerUrl: broker.URL,
+		TopicRoot: "msh/US/2/e/",
+		Username:  &username,
+		Password:  &password,
+	}, func(topic string, payload []byte) {
+		received <- message{topic, string(payload)}
+	})
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, sub.WaitConnected(ctx))
+
+	broker.Publish(t, "msh/US/2/e/LongFast/!abcd1234", []byte("hello"))
+	broker.Publish(t, "msh/EU_868/2/e/LongFast/!abcd1234", []byte("elsewhere"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "msh/US/2/e/LongFast/!abcd1234", msg.topic)
+		assert.Equal(t, "hello", msg.payload)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for message")
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("unexpected message on %s", msg.topic)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscriberBadCredentials(t *testing.T) {
+	broker := mqtttest.NewBroker(t, mqtttest.WithCredentials("meshdev", "large4cats"))
+
+	sub := Subscribe(meshdb.MeshMqtt{
+		MeshID:    1,
+		BrokerUrl: broker.URL,
+		TopicRoot: "msh/US/2/e/",
+	}, func(string, []byte) {})
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	assert.Error(t, sub.WaitConnected(ctx))
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+// Package mqtttest provides an in-process MQTT broker for testing code that
+// subscribes to Meshtastic uplinks.
+package mqtttest
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// Broker is an MQTT broker listening on a local TCP port
+type Broker struct {
+	// URL is the broker's address, for a client to connect to
+	URL string
+
+	server *mochi.Server
+}
+
+// Option configures a Broker
+type Option func(*auth.Ledger)
+
+// WithCredentials makes the broker only accept clients logging in with
+// this username and password
+func WithCredentials(username, password string) Option {
+	return func(l *auth.Ledger) {
+		l.Auth = auth.AuthRules{{
+			Username: auth.RString(username),
+			Password: auth.RString(password),
+			Allow:    true,
+		}}
+	}
+}
+
+// NewBroker starts a broker that is shut down when the test ends
+func NewBroker(t testing.TB, opts ...Option) *Broker {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	ledger := &auth.Ledger{Auth: auth.AuthRules{{Allow: true}}}
+	for _, opt := range opts {
+		opt(ledger)
+	}
+	if err := server.AddHook(new(auth.Hook), &auth.Options{Ledger: ledger}); err != nil {
+		t.Fatalf("failed to add auth hook: %v", err)
+	}
+
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("failed to start broker: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return &Broker{
+		URL:    "tcp://" + tcp.Address(),
+		server: server,
+	}
+}
+
+// Publish publishes a message to every subscriber of topic
+func (b *Broker) Publish(t testing.TB, topic string, payload []byte) {
+	t.Helper()
+	if err := b.server.Publish(topic, payload, false, 0); err != nil {
+		t.Fatalf("failed to publish to %s: %v", topic, err)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+// Package mqtt subscribes to the MQTT uplink of a mesh's gateways and feeds
+// what they hear into the database, the same way the local gateway radio
+// does.
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const (
+	connectTimeout       = 10 * time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// MessageFunc handles one message received on a subscription
+type MessageFunc func(topic string, payload []byte)
+
+// Subscriber holds a connection to one mesh's MQTT broker, subscribed to
+// everything under its topic root. It reconnects and resubscribes on its
+// own until closed.
+type Subscriber struct {
+	meshID int64
+	topic  string
+	client paho.Client
+
+	// subscribed is set while connected with the subscription in place
+	subscribed atomic.Bool
+}
+
+// SubscriptionTopic is the topic filter covering a topic root
+func SubscriptionTopic(topicRoot string) string {
+	return strings.TrimSuffix(topicRoot, "/") + "/#"
+}
+
+// clientID makes a unique client ID, so two servers watching the same mesh
+// don't knock each other off the broker
+func clientID(meshID int64) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("meshmgr-%d-%s", meshID, hex.EncodeToString(b))
+}
+
+// Subscribe connects to the broker in cfg and calls handle for each message
+// under its topic root. It returns once the first connection attempt is
+// under way; a broker that is down is retried in the background.
+func Subscribe(cfg meshdb.MeshMqtt, handle MessageFunc) *Subscriber {
+	s := &Subscriber{
+		meshID: cfg.MeshID,
+		topic:  SubscriptionTopic(cfg.TopicRoot),
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.BrokerUrl).
+		SetClientID(clientID(cfg.MeshID)).
+		SetConnectTimeout(connectTimeout).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetMaxReconnectInterval(maxReconnectInterval).
+		SetOnConnectHandler(func(c paho.Client) {
+			// Subscriptions don't survive a reconnect with a clean session
+			token := c.Subscribe(s.topic, 0, func(_ paho.Client, msg paho.Message) {
+				handle(msg.Topic(), msg.Payload())
+			})
+			if !token.WaitTimeout(connectTimeout) {
+				log.Printf("MQTT mesh %d: timed out subscribing to %s", s.meshID, s.topic)
+				return
+			}
+			if err := token.Error(); err != nil {
+				log.Printf("MQTT mesh %d: failed to subscribe to %s: %v", s.meshID, s.topic, err)
+				return
+			}
+			s.subscribed.Store(true)
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			s.subscribed.Store(false)
+			log.Printf("MQTT mesh %d: connection lost: %v", s.meshID, err)
+		})
+	if cfg.Username != nil {
+		opts.SetUsername(*cfg.Username)
+	}
+	if cfg.Password != nil {
+		opts.SetPassword(*cfg.Password)
+	}
+
+	s.client = paho.NewClient(opts)
+	s.client.Connect()
+	return s
+}
+
+// Connected reports whether the subscriber is connected to its broker and
+// subscribed
+func (s *Subscriber) Connected() bool {
+	return s.subscribed.Load() && s.client.IsConnectionOpen()
+}
+
+// WaitConnected waits until the subscriber is connected and subscribed, or
+// ctx is done
+func (s *Subscriber) WaitConnected(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !s.Connected() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close disconnects from the broker
+func (s *Subscriber) Close() {
+	s.client.Disconnect(250)
+}
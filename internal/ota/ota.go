@@ -0,0 +1,480 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ota pushes a node's configuration to it over the mesh with admin
+// messages, retrying each message that isn't acknowledged.
+package ota
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skandragon/meshmgr/meshtastic-cli/admin"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// DefaultAttempts is how many times each admin message is sent before
+	// the push gives up
+	DefaultAttempts = 3
+
+	// DefaultBackoff is the wait before the first retry. It doubles with
+	// each retry after that.
+	DefaultBackoff = 5 * time.Second
+
+	// maxChannels is the number of channel slots on a node
+	maxChannels = 8
+)
+
+// Step outcomes
+const (
+	OutcomeAck     = "ack"
+	OutcomeNAK     = "nak"
+	OutcomeTimeout = "timeout"
+	OutcomeError   = "error"
+)
+
+// configTypes maps LocalConfig fields to the config type that reads them
+var configTypes = map[string]pb.AdminMessage_ConfigType{
+	"device":    pb.AdminMessage_DEVICE_CONFIG,
+	"position":  pb.AdminMessage_POSITION_CONFIG,
+	"power":     pb.AdminMessage_POWER_CONFIG,
+	"network":   pb.AdminMessage_NETWORK_CONFIG,
+	"display":   pb.AdminMessage_DISPLAY_CONFIG,
+	"lora":      pb.AdminMessage_LORA_CONFIG,
+	"bluetooth": pb.AdminMessage_BLUETOOTH_CONFIG,
+	"security":  pb.AdminMessage_SECURITY_CONFIG,
+}
+
+// moduleConfigTypes maps LocalModuleConfig fields to the module config type
+// that reads them
+var moduleConfigTypes = map[string]pb.AdminMessage_ModuleConfigType{
+	"mqtt":                  pb.AdminMessage_MQTT_CONFIG,
+	"serial":                pb.AdminMessage_SERIAL_CONFIG,
+	"external_notification": pb.AdminMessage_EXTNOTIF_CONFIG,
+	"store_forward":         pb.AdminMessage_STOREFORWARD_CONFIG,
+	"range_test":            pb.AdminMessage_RANGETEST_CONFIG,
+	"telemetry":             pb.AdminMessage_TELEMETRY_CONFIG,
+	"canned_message":        pb.AdminMessage_CANNEDMSG_CONFIG,
+	"audio":                 pb.AdminMessage_AUDIO_CONFIG,
+	"remote_hardware":       pb.AdminMessage_REMOTEHARDWARE_CONFIG,
+	"neighbor_info":         pb.AdminMessage_NEIGHBORINFO_CONFIG,
+	"ambient_lighting":      pb.AdminMessage_AMBIENTLIGHTING_CONFIG,
+	"detection_sensor":      pb.AdminMessage_DETECTIONSENSOR_CONFIG,
+	"paxcounter":            pb.AdminMessage_PAXCOUNTER_CONFIG,
+}
+
+// Desired is the configuration to write to a node. The config documents
+// use protobuf JSON names, as the effective config does, and only hold the
+// fields the mesh manages; everything else is left as it is on the node.
+type Desired struct {
+	ShortName     string
+	LongName      string
+	Unmessageable bool
+	Config        map[string]json.RawMessage
+	ModuleConfig  map[string]json.RawMessage
+	Channels      []json.RawMessage
+}
+
+// Applied is what the node holds after a push, for the values the server
+// tracks in its applied_* columns. Values the push didn't manage are nil.
+type Applied struct {
+	ShortName     string
+	LongName      string
+	Unmessageable bool
+	Role          *string
+	PublicKey     *string
+	PrivateKey    *string
+	// AdminKeys are the base64 admin public keys the node holds, nil if
+	// the push didn't manage them
+	AdminKeys []string
+}
+
+// Step is one attempt at sending one admin message
+type Step struct {
+	Message string
+	Attempt int
+	Outcome string
+	Detail  string
+}
+
+// Option configures a Pusher
+type Option func(*Pusher)
+
+// WithRetries sets how many times each message is sent and the wait before
+// the first retry
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(p *Pusher) {
+		p.attempts = attempts
+		p.backoff = backoff
+	}
+}
+
+// WithLog calls fn for every attempt at every message
+func WithLog(fn func(Step)) Option {
+	return func(p *Pusher) {
+		p.log = fn
+	}
+}
+
+// Pusher writes configuration to nodes through an admin client
+type Pusher struct {
+	client   *admin.Client
+	attempts int
+	backoff  time.Duration
+	log      func(Step)
+}
+
+// New creates a Pusher sending through client
+func New(client *admin.Client, opts ...Option) *Pusher {
+	p := &Pusher{
+		client:   client,
+		attempts: DefaultAttempts,
+		backoff:  DefaultBackoff,
+		log:      func(Step) {},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// retryable reports whether a failed message may succeed if sent again
+func retryable(err error) bool {
+	if errors.Is(err, admin.ErrTimeout) {
+		return true
+	}
+	var nak *admin.NAKError
+	if !errors.As(err, &nak) {
+		return false
+	}
+	switch nak.Reason {
+	case pb.Routing_NO_ROUTE, pb.Routing_GOT_NAK, pb.Routing_TIMEOUT,
+		pb.Routing_MAX_RETRANSMIT, pb.Routing_NO_RESPONSE,
+		pb.Routing_DUTY_CYCLE_LIMIT, pb.Routing_RATE_LIMIT_EXCEEDED,
+		pb.Routing_ADMIN_BAD_SESSION_KEY:
+		return true
+	}
+	return false
+}
+
+// outcome describes how an attempt ended for the log
+func outcome(err error) (string, string) {
+	var nak *admin.NAKError
+	switch {
+	case err == nil:
+		return OutcomeAck, ""
+	case errors.As(err, &nak):
+		return OutcomeNAK, nak.Reason.String()
+	case errors.Is(err, admin.ErrTimeout):
+		return OutcomeTimeout, ""
+	}
+	return OutcomeError, err.Error()
+}
+
+// try calls fn until it succeeds, fails for good, or runs out of attempts,
+// logging each attempt
+func (p *Pusher) try(ctx context.Context, name string, fn func() error) error {
+	delay := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		result, detail := outcome(err)
+		p.log(Step{Message: name, Attempt: attempt, Outcome: result, Detail: detail})
+		if err == nil || attempt >= p.attempts || !retryable(err) {
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// send sends an admin message and waits for its ACK
+func (p *Pusher) send(ctx context.Context, node uint32, msg *pb.AdminMessage) error {
+	return p.try(ctx, admin.MessageName(msg), func() error {
+		return p.client.Send(ctx, node, msg)
+	})
+}
+
+// Push brings a node to the desired configuration. Each config section,
+// the owner and the channels are read from the node first and only written
+// if they differ. All changes are made in one settings transaction.
+func (p *Pusher) Push(ctx context.Context, node uint32, desired Desired) (Applied, error) {
+	err := p.send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_BeginEditSettings{BeginEditSettings: true},
+	})
+	if err != nil {
+		return Applied{}, err
+	}
+
+	if err := p.pushOwner(ctx, node, desired); err != nil {
+		return Applied{}, err
+	}
+	config, err := p.pushConfig(ctx, node, desired.Config)
+	if err != nil {
+		return Applied{}, err
+	}
+	if err := p.pushModuleConfig(ctx, node, desired.ModuleConfig); err != nil {
+		return Applied{}, err
+	}
+	// Channels go last: a new PSK cuts off the channel the push is using
+	if err := p.pushChannels(ctx, node, desired.Channels); err != nil {
+		return Applied{}, err
+	}
+
+	err = p.send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_CommitEditSettings{CommitEditSettings: true},
+	})
+	if err != nil {
+		return Applied{}, err
+	}
+	return appliedState(desired, config), nil
+}
+
+// pushOwner sets the node's names
+func (p *Pusher) pushOwner(ctx context.Context, node uint32, desired Desired) error {
+	var current *pb.User
+	err := p.try(ctx, "get_owner_request", func() (err error) {
+		current, err = p.client.GetOwner(ctx, node)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	owner := protobuf.Clone(current).(*pb.User)
+	owner.ShortName = desired.ShortName
+	owner.LongName = desired.LongName
+	owner.IsUnmessagable = protobuf.Bool(desired.Unmessageable)
+	if protobuf.Equal(owner, current) {
+		return nil
+	}
+	return p.send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetOwner{SetOwner: owner},
+	})
+}
+
+// pushConfig writes the config sections that differ from the node's, and
+// returns the config as the node now has it
+func (p *Pusher) pushConfig(ctx context.Context, node uint32, desired map[string]json.RawMessage) (*pb.LocalConfig, error) {
+	local := &pb.LocalConfig{}
+	fields := local.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		data, ok := desired[string(fd.Name())]
+		if !ok {
+			continue
+		}
+		configType, ok := configTypes[string(fd.Name())]
+		if !ok {
+			return nil, fmt.Errorf("config section %s can't be set remotely", fd.Name())
+		}
+
+		var current *pb.Config
+		err := p.try(ctx, "get_config_request "+string(fd.Name()), func() (err error) {
+			current, err = p.client.GetConfig(ctx, node, configType)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		section, changed, err := mergeSection(current.ProtoReflect(), fd, data)
+		if err != nil {
+			return nil, err
+		}
+		local.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(section))
+		if !changed {
+			continue
+		}
+
+		config := &pb.Config{}
+		if err := admin.SetVariant(config.ProtoReflect(), section); err != nil {
+			return nil, err
+		}
+		err = p.send(ctx, node, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetConfig{SetConfig: config},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return local, nil
+}
+
+// pushModuleConfig writes the module config sections that differ from the
+// node's
+func (p *Pusher) pushModuleConfig(ctx context.Context, node uint32, desired map[string]json.RawMessage) error {
+	fields := (&pb.LocalModuleConfig{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		data, ok := desired[string(fd.Name())]
+		if !ok {
+			continue
+		}
+		configType, ok := moduleConfigTypes[string(fd.Name())]
+		if !ok {
+			return fmt.Errorf("module config section %s can't be set remotely", fd.Name())
+		}
+
+		var current *pb.ModuleConfig
+		err := p.try(ctx, "get_module_config_request "+string(fd.Name()), func() (err error) {
+			current, err = p.client.GetModuleConfig(ctx, node, configType)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		section, changed, err := mergeSection(current.ProtoReflect(), fd, data)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		moduleConfig := &pb.ModuleConfig{}
+		if err := admin.SetVariant(moduleConfig.ProtoReflect(), section); err != nil {
+			return err
+		}
+		err = p.send(ctx, node, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetModuleConfig{SetModuleConfig: moduleConfig},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeSection applies the desired fields to the section of a Config or
+// ModuleConfig reply that holds the LocalConfig or LocalModuleConfig field
+// fd. It returns the merged section and whether it differs from the node's.
+func mergeSection(reply protoreflect.Message, fd protoreflect.FieldDescriptor, data json.RawMessage) (protoreflect.Message, bool, error) {
+	var current protoreflect.Message
+	fields := reply.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if f.Message() != nil && f.Message().FullName() == fd.Message().FullName() {
+			current = reply.Get(f).Message()
+			break
+		}
+	}
+	if current == nil || !current.IsValid() {
+		return nil, false, fmt.Errorf("node did not return its %s config", fd.Name())
+	}
+
+	section := current.New()
+	protobuf.Merge(section.Interface(), current.Interface())
+	if err := admin.MergeJSONFields(section, data); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", fd.Name(), err)
+	}
+	return section, !protobuf.Equal(section.Interface(), current.Interface()), nil
+}
+
+// pushChannels writes the channels that differ from the mesh channel set.
+// Channels the mesh doesn't define are disabled. A mesh without channels
+// doesn't manage them.
+func (p *Pusher) pushChannels(ctx context.Context, node uint32, desired []json.RawMessage) error {
+	if len(desired) == 0 {
+		return nil
+	}
+
+	wanted := make(map[int32]*pb.Channel, len(desired))
+	for _, data := range desired {
+		ch := &pb.Channel{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, ch); err != nil {
+			return fmt.Errorf("invalid channel: %w", err)
+		}
+		wanted[ch.Index] = ch
+	}
+
+	for index := int32(0); index < maxChannels; index++ {
+		var current *pb.Channel
+		err := p.try(ctx, fmt.Sprintf("get_channel_request %d", index), func() (err error) {
+			current, err = p.client.GetChannel(ctx, node, uint32(index))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		want, ok := wanted[index]
+		if !ok {
+			if current.Role == pb.Channel_DISABLED {
+				continue
+			}
+			want = &pb.Channel{Index: index, Role: pb.Channel_DISABLED}
+		}
+		if protobuf.Equal(want, current) {
+			continue
+		}
+		err = p.send(ctx, node, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetChannel{SetChannel: want},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedState describes what the node now has for the values the server
+// tracks, leaving out config the mesh doesn't manage
+func appliedState(desired Desired, config *pb.LocalConfig) Applied {
+	applied := Applied{
+		ShortName:     desired.ShortName,
+		LongName:      desired.LongName,
+		Unmessageable: desired.Unmessageable,
+	}
+
+	var device map[string]json.RawMessage
+	if data, ok := desired.Config["device"]; ok && json.Unmarshal(data, &device) == nil {
+		if _, ok := device["role"]; ok {
+			applied.Role = protobuf.String(config.GetDevice().GetRole().String())
+		}
+	}
+
+	var security map[string]json.RawMessage
+	if data, ok := desired.Config["security"]; ok && json.Unmarshal(data, &security) == nil {
+		if _, ok := security["public_key"]; ok {
+			applied.PublicKey = protobuf.String(base64.StdEncoding.EncodeToString(config.GetSecurity().GetPublicKey()))
+		}
+		if _, ok := security["private_key"]; ok {
+			applied.PrivateKey = protobuf.String(base64.StdEncoding.EncodeToString(config.GetSecurity().GetPrivateKey()))
+		}
+		if _, ok := security["admin_key"]; ok {
+			applied.AdminKeys = []string{}
+			for _, key := range config.GetSecurity().GetAdminKey() {
+				applied.AdminKeys = append(applied.AdminKeys, base64.StdEncoding.EncodeToString(key))
+			}
+		}
+	}
+	return applied
+}
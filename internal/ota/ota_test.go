@@ -0,0 +1,280 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skandragon/meshmgr/meshtastic-cli/admin"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	testLocalNode  = 0x11111111
+	testRemoteNode = 0x22222222
+)
+
+// fakeNode answers admin requests from its own config the way the
+// firmware does
+type fakeNode struct {
+	t      *testing.T
+	client *admin.Client
+
+	mu       sync.Mutex
+	owner    *pb.User
+	config   *pb.LocalConfig
+	module   *pb.LocalModuleConfig
+	channels [maxChannels]*pb.Channel
+	sets     []string
+
+	// drop is how many times to ignore each message, by name
+	drop map[string]int
+
+	// nak rejects every change with this reason
+	nak pb.Routing_Error
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	f := &fakeNode{
+		t:      t,
+		owner:  &pb.User{Id: "!22222222", ShortName: "OLD", LongName: "Old name", IsLicensed: true},
+		config: &pb.LocalConfig{},
+		module: &pb.LocalModuleConfig{},
+		drop:   map[string]int{},
+	}
+	f.config.Device = &pb.Config_DeviceConfig{Role: pb.Config_DeviceConfig_CLIENT, NodeInfoBroadcastSecs: 900}
+	f.config.Lora = &pb.Config_LoRaConfig{HopLimit: 3, UsePreset: true}
+	f.config.Security = &pb.Config_SecurityConfig{PublicKey: []byte{1, 2, 3}}
+	f.module.NeighborInfo = &pb.ModuleConfig_NeighborInfoConfig{}
+	for i := range f.channels {
+		f.channels[i] = &pb.Channel{Index: int32(i)}
+	}
+	f.channels[0] = &pb.Channel{Index: 0, Role: pb.Channel_PRIMARY, Settings: &pb.ChannelSettings{Psk: []byte{1}}}
+	f.channels[3] = &pb.Channel{Index: 3, Role: pb.Channel_SECONDARY, Settings: &pb.ChannelSettings{Name: "old"}}
+	f.client = admin.NewClient(f, admin.WithLocalNode(testLocalNode), admin.WithTimeout(50*time.Millisecond))
+	return f
+}
+
+// section finds the field of local holding messages like section
+func section(local protoreflect.Message, name protoreflect.FullName) protoreflect.FieldDescriptor {
+	fields := local.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); fd.Message() != nil && fd.Message().FullName() == name {
+			return fd
+		}
+	}
+	return nil
+}
+
+// variant returns the section set in a Config or ModuleConfig
+func variant(msg protoreflect.Message) protoreflect.Message {
+	fd := msg.WhichOneof(msg.Descriptor().Oneofs().Get(0))
+	return msg.Get(fd).Message()
+}
+
+func (f *fakeNode) SendToRadio(msg *pb.ToRadio) error {
+	packet := msg.GetPacket()
+	request := &pb.AdminMessage{}
+	require.NoError(f.t, protobuf.Unmarshal(packet.GetDecoded().GetPayload(), request))
+	name := admin.MessageName(request)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.drop[name] > 0 {
+		f.drop[name]--
+		return nil
+	}
+
+	response := &pb.AdminMessage{SessionPasskey: []byte("passkey")}
+	var routing pb.Routing_Error
+	switch v := request.PayloadVariant.(type) {
+	case *pb.AdminMessage_GetDeviceMetadataRequest:
+		response.PayloadVariant = &pb.AdminMessage_GetDeviceMetadataResponse{GetDeviceMetadataResponse: &pb.DeviceMetadata{}}
+	case *pb.AdminMessage_GetOwnerRequest:
+		response.PayloadVariant = &pb.AdminMessage_GetOwnerResponse{GetOwnerResponse: protobuf.Clone(f.owner).(*pb.User)}
+	case *pb.AdminMessage_GetConfigRequest:
+		config := &pb.Config{}
+		for name, configType := range configTypes {
+			if configType == v.GetConfigRequest {
+				fd := f.config.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(name))
+				require.NoError(f.t, admin.SetVariant(config.ProtoReflect(), f.config.ProtoReflect().Get(fd).Message()))
+			}
+		}
+		response.PayloadVariant = &pb.AdminMessage_GetConfigResponse{GetConfigResponse: config}
+	case *pb.AdminMessage_GetModuleConfigRequest:
+		config := &pb.ModuleConfig{}
+		for name, configType := range moduleConfigTypes {
+			if configType == v.GetModuleConfigRequest {
+				fd := f.module.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(name))
+				require.NoError(f.t, admin.SetVariant(config.ProtoReflect(), f.module.ProtoReflect().Get(fd).Message()))
+			}
+		}
+		response.PayloadVariant = &pb.AdminMessage_GetModuleConfigResponse{GetModuleConfigResponse: config}
+	case *pb.AdminMessage_GetChannelRequest:
+		response.PayloadVariant = &pb.AdminMessage_GetChannelResponse{GetChannelResponse: f.channels[v.GetChannelRequest-1]}
+	default:
+		response = nil
+		routing = f.nak
+		if routing == pb.Routing_NONE {
+			f.sets = append(f.sets, name)
+			switch v := request.PayloadVariant.(type) {
+			case *pb.AdminMessage_SetOwner:
+				f.owner = v.SetOwner
+			case *pb.AdminMessage_SetConfig:
+				s := variant(v.SetConfig.ProtoReflect())
+				f.config.ProtoReflect().Set(section(f.config.ProtoReflect(), s.Descriptor().FullName()), protoreflect.ValueOfMessage(s))
+			case *pb.AdminMessage_SetModuleConfig:
+				s := variant(v.SetModuleConfig.ProtoReflect())
+				f.module.ProtoReflect().Set(section(f.module.ProtoReflect(), s.Descriptor().FullName()), protoreflect.ValueOfMessage(s))
+			case *pb.AdminMessage_SetChannel:
+				f.channels[v.SetChannel.Index] = v.SetChannel
+			}
+		}
+	}
+
+	reply := &pb.Data{RequestId: packet.Id}
+	if response != nil {
+		reply.Portnum = pb.PortNum_ADMIN_APP
+		reply.Payload, _ = protobuf.Marshal(response)
+	} else {
+		reply.Portnum = pb.PortNum_ROUTING_APP
+		reply.Payload, _ = protobuf.Marshal(&pb.Routing{
+			Variant: &pb.Routing_ErrorReason{ErrorReason: routing},
+		})
+	}
+	go f.client.HandlePacket(&pb.MeshPacket{
+		From:           packet.To,
+		To:             packet.From,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: reply},
+	})
+	return nil
+}
+
+func testDesired() Desired {
+	return Desired{
+		ShortName: "NEW",
+		LongName:  "New name",
+		Config: map[string]json.RawMessage{
+			"device":   json.RawMessage(`{"role":"ROUTER"}`),
+			"lora":     json.RawMessage(`{"hop_limit":3}`),
+			"security": json.RawMessage(`{"admin_key":["AQID"]}`),
+		},
+		ModuleConfig: map[string]json.RawMessage{
+			"neighbor_info": json.RawMessage(`{"enabled":true,"update_interval":3600}`),
+		},
+		Channels: []json.RawMessage{
+			json.RawMessage(`{"index":0,"role":"PRIMARY","settings":{"psk":"AQ=="}}`),
+			json.RawMessage(`{"index":1,"role":"SECONDARY","settings":{"name":"admin","psk":"Ag=="}}`),
+		},
+	}
+}
+
+func TestPush(t *testing.T) {
+	node := newFakeNode(t)
+	var steps []Step
+	pusher := New(node.client, WithLog(func(s Step) { steps = append(steps, s) }))
+
+	applied, err := pusher.Push(context.Background(), testRemoteNode, testDesired())
+	require.NoError(t, err)
+
+	// Only what differs is written, inside one settings transaction
+	assert.Equal(t, []string{
+		"begin_edit_settings",
+		"set_owner",
+		"set_config device",
+		"set_config security",
+		"set_module_config neighbor_info",
+		"set_channel 1",
+		"set_channel 3",
+		"commit_edit_settings",
+	}, node.sets)
+
+	// Fields the mesh doesn't manage are kept
+	assert.Equal(t, pb.Config_DeviceConfig_ROUTER, node.config.Device.Role)
+	assert.Equal(t, uint32(900), node.config.Device.NodeInfoBroadcastSecs)
+	assert.Equal(t, []byte{1, 2, 3}, node.config.Security.PublicKey)
+	assert.Equal(t, [][]byte{{1, 2, 3}}, node.config.Security.AdminKey)
+	assert.True(t, node.owner.IsLicensed)
+	assert.Equal(t, "NEW", node.owner.ShortName)
+	assert.Equal(t, "admin", node.channels[1].Settings.Name)
+	assert.Equal(t, pb.Channel_DISABLED, node.channels[3].Role)
+
+	assert.Equal(t, "NEW", applied.ShortName)
+	require.NotNil(t, applied.Role)
+	assert.Equal(t, "ROUTER", *applied.Role)
+	assert.Nil(t, applied.PublicKey)
+
+	for _, step := range steps {
+		assert.Equal(t, OutcomeAck, step.Outcome)
+		assert.Equal(t, 1, step.Attempt)
+	}
+
+	// A second push finds nothing to change
+	node.sets = nil
+	_, err = pusher.Push(context.Background(), testRemoteNode, testDesired())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"begin_edit_settings", "commit_edit_settings"}, node.sets)
+}
+
+func TestPushRetries(t *testing.T) {
+	node := newFakeNode(t)
+	node.drop["set_owner"] = 2
+	var steps []Step
+	pusher := New(node.client, WithRetries(3, time.Millisecond), WithLog(func(s Step) {
+		if s.Message == "set_owner" {
+			steps = append(steps, s)
+		}
+	}))
+
+	_, err := pusher.Push(context.Background(), testRemoteNode, testDesired())
+	require.NoError(t, err)
+	assert.Equal(t, []Step{
+		{Message: "set_owner", Attempt: 1, Outcome: OutcomeTimeout},
+		{Message: "set_owner", Attempt: 2, Outcome: OutcomeTimeout},
+		{Message: "set_owner", Attempt: 3, Outcome: OutcomeAck},
+	}, steps)
+
+	// Giving up after the last attempt fails the push
+	node = newFakeNode(t)
+	node.drop["begin_edit_settings"] = 2
+	_, err = New(node.client, WithRetries(2, time.Millisecond)).Push(context.Background(), testRemoteNode, testDesired())
+	assert.ErrorIs(t, err, admin.ErrTimeout)
+}
+
+func TestPushNAK(t *testing.T) {
+	node := newFakeNode(t)
+	node.nak = pb.Routing_ADMIN_PUBLIC_KEY_UNAUTHORIZED
+	var steps []Step
+	pusher := New(node.client, WithRetries(3, time.Millisecond), WithLog(func(s Step) { steps = append(steps, s) }))
+
+	// A node that rejects the admin key isn't retried
+	_, err := pusher.Push(context.Background(), testRemoteNode, testDesired())
+	require.Error(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, Step{
+		Message: "begin_edit_settings",
+		Attempt: 1,
+		Outcome: OutcomeNAK,
+		Detail:  "ADMIN_PUBLIC_KEY_UNAUTHORIZED",
+	}, steps[0])
+}
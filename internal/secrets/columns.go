@@ -0,0 +1,254 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package secrets
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DeviceConfigSecretPaths are the dotted paths of the secrets in a device
+// config document, besides the channel PSKs
+var DeviceConfigSecretPaths = []string{
+	"config.security.private_key",
+	"config.network.wifi_psk",
+	"module_config.mqtt.password",
+}
+
+// ChannelSecretField names the PSK in a channel's settings, and
+// ChannelSecretPath is its dotted path within each entry of a device config
+// document's channels
+const (
+	ChannelSecretField = "psk"
+	ChannelSecretPath  = "settings." + ChannelSecretField
+)
+
+// Text is a text column sealed by the current keyring when written and
+// opened when read. Values written before a keyring was set are read as
+// they are.
+type Text string
+
+// Value implements driver.Valuer
+func (t Text) Value() (driver.Value, error) {
+	if t == "" {
+		return string(t), nil
+	}
+	k, err := sealer()
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return string(t), nil
+	}
+	sealed, err := k.Seal([]byte(t))
+	if err != nil {
+		return nil, err
+	}
+	return string(sealed), nil
+}
+
+// Scan implements sql.Scanner
+func (t *Text) Scan(src any) error {
+	value, err := scanSecret(src)
+	*t = Text(value)
+	return err
+}
+
+// Bytes is a bytea column sealed by the current keyring when written and
+// opened when read
+type Bytes []byte
+
+// Value implements driver.Valuer
+func (b Bytes) Value() (driver.Value, error) {
+	if b == nil {
+		return []byte(b), nil
+	}
+	k, err := sealer()
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return []byte(b), nil
+	}
+	return k.Seal(b)
+}
+
+// Scan implements sql.Scanner
+func (b *Bytes) Scan(src any) error {
+	value, err := scanSecret(src)
+	*b = value
+	return err
+}
+
+// scanSecret copies a scanned value, opening it if it is sealed
+func scanSecret(src any) ([]byte, error) {
+	var value []byte
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		value = []byte(v)
+	case []byte:
+		value = bytes.Clone(v)
+	default:
+		return nil, fmt.Errorf("cannot scan %T into a secret", src)
+	}
+	if !IsSealed(value) {
+		return value, nil
+	}
+	k := Current()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	return k.Open(value)
+}
+
+// DeviceConfig is a JSON device config column whose secrets, at
+// DeviceConfigSecretPaths and the channel PSKs, are sealed in place by the
+// current keyring. The rest of the document stays readable.
+type DeviceConfig []byte
+
+// Value implements driver.Valuer
+func (d DeviceConfig) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return []byte(d), nil
+	}
+	k, err := sealer()
+	if err == nil && k == nil {
+		return []byte(d), nil
+	}
+	// Without a keyring, configs are only written if they hold no secrets
+	return rewriteDeviceConfig(d, func(v any) (any, error) {
+		if s, ok := v.(string); ok && IsSealed([]byte(s)) {
+			return s, nil
+		}
+		if k == nil {
+			if v == nil || v == "" {
+				return v, nil
+			}
+			return nil, err
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := k.Seal(encoded)
+		if err != nil {
+			return nil, err
+		}
+		return string(sealed), nil
+	})
+}
+
+// Scan implements sql.Scanner
+func (d *DeviceConfig) Scan(src any) error {
+	var value []byte
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case string:
+		value = []byte(v)
+	case []byte:
+		value = bytes.Clone(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a device config", src)
+	}
+	if !bytes.Contains(value, []byte(envelopePrefix)) {
+		*d = value
+		return nil
+	}
+
+	k := Current()
+	if k == nil {
+		return ErrNoKey
+	}
+	opened, err := rewriteDeviceConfig(value, func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok || !IsSealed([]byte(s)) {
+			return v, nil
+		}
+		encoded, err := k.Open([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		var opened any
+		if err := json.Unmarshal(encoded, &opened); err != nil {
+			return nil, err
+		}
+		return opened, nil
+	})
+	if err != nil {
+		return err
+	}
+	*d = opened
+	return nil
+}
+
+// rewriteDeviceConfig replaces each secret in a device config document
+// with the result of fn
+func rewriteDeviceConfig(raw []byte, fn func(any) (any, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid device config: %w", err)
+	}
+
+	for _, path := range DeviceConfigSecretPaths {
+		if err := rewritePath(doc, path, fn); err != nil {
+			return nil, err
+		}
+	}
+	if channels, ok := doc["channels"].([]any); ok {
+		for _, ch := range channels {
+			if channel, ok := ch.(map[string]any); ok {
+				if err := rewritePath(channel, ChannelSecretPath, fn); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// rewritePath replaces the value at a dotted path in a nested document,
+// if there is one
+func rewritePath(doc map[string]any, path string, fn func(any) (any, error)) error {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = child
+	}
+	last := parts[len(parts)-1]
+	value, ok := current[last]
+	if !ok {
+		return nil
+	}
+	replaced, err := fn(value)
+	if err != nil {
+		return err
+	}
+	current[last] = replaced
+	return nil
+}
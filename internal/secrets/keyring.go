@@ -0,0 +1,203 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// envelopePrefix starts every value sealed by a Keyring. The rest is the
+// master key ID, the wrapped data key and the ciphertext, separated by
+// colons.
+const envelopePrefix = "enc:v1:"
+
+// ErrUnknownKey is returned when a value was sealed under a master key the
+// keyring doesn't hold
+var ErrUnknownKey = errors.New("sealed under an unknown master key")
+
+// Keyring seals values with envelope encryption. Each value gets its own
+// random data key, which is wrapped by the primary master key and stored
+// alongside the ciphertext with that key's ID. Older master keys stay on
+// the keyring so values sealed under them can still be opened until they
+// are re-encrypted.
+type Keyring struct {
+	primary string
+	boxes   map[string]*Box
+}
+
+// NewKeyring creates a keyring from master keys by ID. New values are
+// sealed under primaryID, which must be one of keys.
+func NewKeyring(primaryID string, keys map[string][]byte) (*Keyring, error) {
+	k := &Keyring{primary: primaryID, boxes: make(map[string]*Box, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid master key ID %q", id)
+		}
+		box, err := New(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		k.boxes[id] = box
+	}
+	if _, ok := k.boxes[primaryID]; !ok {
+		return nil, fmt.Errorf("primary master key %q: %w", primaryID, ErrNoKey)
+	}
+	return k, nil
+}
+
+// PrimaryID returns the ID of the master key new values are sealed under
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by the primary
+// master key
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	data, err := New(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := data.Seal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.boxes[k.primary].Seal(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := envelopePrefix + k.primary + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext)
+	return []byte(sealed), nil
+}
+
+// Open decrypts a value made by Seal under any master key on the keyring.
+// Values sealed directly by a Box, before envelopes were used, are opened
+// with whichever master key fits.
+func (k *Keyring) Open(sealed []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return k.openBox(sealed)
+	}
+
+	parts := strings.Split(string(sealed[len(envelopePrefix):]), ":")
+	if len(parts) != 3 {
+		return nil, ErrDecrypt
+	}
+	box, ok := k.boxes[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	dataKey, err := box.Open(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	data, err := New(dataKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return data.Open(ciphertext)
+}
+
+// openBox opens a value sealed directly by a Box, trying the primary key
+// first
+func (k *Keyring) openBox(sealed []byte) ([]byte, error) {
+	if plaintext, err := k.boxes[k.primary].Open(sealed); err == nil {
+		return plaintext, nil
+	}
+	for id, box := range k.boxes {
+		if id == k.primary {
+			continue
+		}
+		if plaintext, err := box.Open(sealed); err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, ErrDecrypt
+}
+
+// IsSealed reports whether a value was sealed by a Keyring
+func IsSealed(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(envelopePrefix))
+}
+
+// SealedKeyID returns the ID of the master key a value was sealed under,
+// or false if it isn't sealed
+func SealedKeyID(value []byte) (string, bool) {
+	if !IsSealed(value) {
+		return "", false
+	}
+	id, _, ok := strings.Cut(string(value[len(envelopePrefix):]), ":")
+	return id, ok
+}
+
+// current is the keyring database columns are sealed and opened with. It
+// and plaintext are process-wide: sqlc scans into and encodes the column
+// types with no way to hand them a keyring, so every connection pool in the
+// process shares them. Only one server may run per process, and tests that
+// set them must not run in parallel and must reset them when done.
+var current atomic.Pointer[Keyring]
+
+// plaintext allows secrets to be written unsealed when no keyring is set
+var plaintext atomic.Bool
+
+// SetKeyring sets the keyring used by the column types in this package.
+// With none set, secrets can't be written unless SetPlaintext allows it,
+// and sealed values can't be read.
+func SetKeyring(k *Keyring) {
+	current.Store(k)
+}
+
+// Current returns the keyring set by SetKeyring, or nil
+func Current() *Keyring {
+	return current.Load()
+}
+
+// SetPlaintext sets whether the column types in this package write secrets
+// in plaintext when no keyring is set, rather than refusing with ErrNoKey
+func SetPlaintext(allow bool) {
+	plaintext.Store(allow)
+}
+
+// sealer returns the keyring to seal a secret with, or nil if the secret
+// is to be written in plaintext
+func sealer() (*Keyring, error) {
+	if k := Current(); k != nil {
+		return k, nil
+	}
+	if plaintext.Load() {
+		return nil, nil
+	}
+	return nil, ErrNoKey
+}
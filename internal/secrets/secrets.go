@@ -0,0 +1,85 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package secrets encrypts values the server stores in the database under
+// its master key, so a copy of the database alone doesn't give them away.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of a master key
+const KeySize = 32
+
+var (
+	// ErrNoKey is returned when secrets are needed and no master key is
+	// configured
+	ErrNoKey = errors.New("no master key configured")
+
+	// ErrDecrypt is returned when a sealed value is damaged or was sealed
+	// under another key
+	ErrDecrypt = errors.New("failed to decrypt secret")
+)
+
+// Box seals and opens secrets with AES-256-GCM. A sealed value is the
+// nonce followed by the ciphertext and tag.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a box using a master key. A nil key gives ErrNoKey.
+func New(key []byte) (*Box, error) {
+	if len(key) == 0 {
+		return nil, ErrNoKey
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext under a fresh random nonce
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a value made by Seal
+func (b *Box) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize()+b.aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
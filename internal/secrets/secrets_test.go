@@ -0,0 +1,240 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package secrets
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{7}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("private key"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "private key")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("private key"), opened)
+
+	// Each seal uses its own nonce
+	again, err := box.Seal([]byte("private key"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// Tampering and the wrong key are both detected
+	sealed[len(sealed)-1] ^= 1
+	_, err = box.Open(sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	other, err := New(bytes.Repeat([]byte{8}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(again)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = box.Open([]byte{1, 2, 3})
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNewKeys(t *testing.T) {
+	_, err := New(nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, err = New([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestKeyring(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, KeySize)
+	newKey := bytes.Repeat([]byte{2}, KeySize)
+
+	old, err := NewKeyring("old", map[string][]byte{"old": oldKey})
+	require.NoError(t, err)
+	sealed, err := old.Seal([]byte("channel psk"))
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.NotContains(t, string(sealed), "channel psk")
+	id, ok := SealedKeyID(sealed)
+	assert.True(t, ok)
+	assert.Equal(t, "old", id)
+
+	// After rotation, values sealed under the old key still open and new
+	// values are sealed under the new one
+	rotated, err := NewKeyring("new", map[string][]byte{"old": oldKey, "new": newKey})
+	require.NoError(t, err)
+	opened, err := rotated.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("channel psk"), opened)
+
+	resealed, err := rotated.Seal(opened)
+	require.NoError(t, err)
+	id, _ = SealedKeyID(resealed)
+	assert.Equal(t, "new", id)
+
+	// Once the old key is dropped, only resealed values open
+	current, err := NewKeyring("new", map[string][]byte{"new": newKey})
+	require.NoError(t, err)
+	_, err = current.Open(sealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	opened, err = current.Open(resealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("channel psk"), opened)
+
+	// Values sealed by a bare box before envelopes are still readable
+	box, err := New(oldKey)
+	require.NoError(t, err)
+	legacy, err := box.Seal([]byte("admin key"))
+	require.NoError(t, err)
+	opened, err = rotated.Open(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("admin key"), opened)
+
+	// Damaged envelopes are rejected
+	_, err = rotated.Open(append(bytes.Clone(resealed[:len(resealed)-2]), "AA"...))
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = rotated.Open([]byte("enc:v1:new:nope"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNewKeyringKeys(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+
+	_, err := NewKeyring("missing", map[string][]byte{"1": key})
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, err = NewKeyring("a:b", map[string][]byte{"a:b": key})
+	assert.Error(t, err)
+
+	_, err = NewKeyring("1", map[string][]byte{"1": {1, 2, 3}})
+	assert.Error(t, err)
+}
+
+func TestColumns(t *testing.T) {
+	keyring, err := NewKeyring("1", map[string][]byte{"1": bytes.Repeat([]byte{1}, KeySize)})
+	require.NoError(t, err)
+
+	// Without a keyring secrets are refused, unless plaintext is allowed
+	SetKeyring(nil)
+	_, err = Text("private").Value()
+	assert.ErrorIs(t, err, ErrNoKey)
+	_, err = Bytes{1, 2, 3}.Value()
+	assert.ErrorIs(t, err, ErrNoKey)
+	value, err := Text("").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "", value)
+
+	SetPlaintext(true)
+	t.Cleanup(func() {
+		SetPlaintext(false)
+	})
+	value, err = Text("private").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "private", value)
+	SetPlaintext(false)
+
+	var text Text
+	require.NoError(t, text.Scan("plaintext"))
+	assert.Equal(t, Text("plaintext"), text)
+
+	SetKeyring(keyring)
+	t.Cleanup(func() {
+		SetKeyring(nil)
+	})
+
+	value, err = Text("private").Value()
+	require.NoError(t, err)
+	assert.True(t, IsSealed([]byte(value.(string))))
+	require.NoError(t, text.Scan(value))
+	assert.Equal(t, Text("private"), text)
+
+	// Values written before the keyring was set still read
+	require.NoError(t, text.Scan("plaintext"))
+	assert.Equal(t, Text("plaintext"), text)
+
+	value, err = Bytes{1, 2, 3}.Value()
+	require.NoError(t, err)
+	assert.True(t, IsSealed(value.([]byte)))
+	var psk Bytes
+	require.NoError(t, psk.Scan(value))
+	assert.Equal(t, Bytes{1, 2, 3}, psk)
+
+	value, err = Bytes(nil).Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	require.NoError(t, psk.Scan(nil))
+	assert.Nil(t, psk)
+
+	// Sealed values can't be read without the keyring
+	sealed, err := Text("private").Value()
+	require.NoError(t, err)
+	SetKeyring(nil)
+	assert.ErrorIs(t, text.Scan(sealed), ErrNoKey)
+}
+
+func TestDeviceConfig(t *testing.T) {
+	keyring, err := NewKeyring("1", map[string][]byte{"1": bytes.Repeat([]byte{1}, KeySize)})
+	require.NoError(t, err)
+	SetKeyring(keyring)
+	t.Cleanup(func() {
+		SetKeyring(nil)
+	})
+
+	raw := DeviceConfig(`{
+		"config": {
+			"security": {"private_key": "cHJpdmF0ZQ=="},
+			"network": {"wifi_ssid": "ranch", "wifi_psk": "wifi-secret"}
+		},
+		"module_config": {"mqtt": {"username": "node", "password": "mqtt-secret"}},
+		"channels": [{"settings": {"name": "ranch", "psk": "AQ=="}}, {"role": 0}],
+		"my_node_num": 12345678901234567
+	}`)
+	value, err := raw.Value()
+	require.NoError(t, err)
+	stored := string(value.([]byte))
+	for _, secret := range []string{"cHJpdmF0ZQ==", "wifi-secret", "mqtt-secret", "AQ=="} {
+		assert.NotContains(t, stored, secret)
+	}
+	assert.Contains(t, stored, "ranch")
+	assert.Contains(t, stored, "12345678901234567")
+
+	// Sealing again leaves sealed secrets alone
+	again, err := DeviceConfig(stored).Value()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Count(stored, envelopePrefix), strings.Count(string(again.([]byte)), envelopePrefix))
+
+	var config DeviceConfig
+	require.NoError(t, config.Scan(stored))
+	assert.JSONEq(t, string(raw), string(config))
+
+	// Configs without secrets are read as they are
+	require.NoError(t, config.Scan([]byte(`{"config": {"lora": {"region": 1}}}`)))
+	assert.JSONEq(t, `{"config": {"lora": {"region": 1}}}`, string(config))
+	require.NoError(t, config.Scan(nil))
+	assert.Nil(t, config)
+
+	// Without a keyring only configs without secrets are written
+	SetKeyring(nil)
+	_, err = raw.Value()
+	assert.ErrorIs(t, err, ErrNoKey)
+	value, err = DeviceConfig(`{"config": {"network": {"wifi_ssid": "ranch", "wifi_psk": ""}}}`).Value()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"config": {"network": {"wifi_ssid": "ranch", "wifi_psk": ""}}}`, string(value.([]byte)))
+}
@@ -0,0 +1,611 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/skandragon/meshmgr/meshtastic-cli/meshcrypto"
+)
+
+// Admin key rotation statuses
+const (
+	RotationInProgress = "in_progress"
+	RotationCompleted  = "completed"
+	RotationCancelled  = "cancelled"
+)
+
+// maxMeshAdminKeys is how many admin keys a mesh may have, the number a
+// node's firmware can hold
+const maxMeshAdminKeys = 3
+
+// CreateAdminKeyRotationRequest starts replacing one admin key with
+// another. The new key is either an existing admin key of the mesh or a
+// public key to add.
+type CreateAdminKeyRotationRequest struct {
+	OldKeyID     int64   `json:"old_key_id"`
+	NewKeyID     *int64  `json:"new_key_id,omitempty"`
+	NewPublicKey string  `json:"new_public_key,omitempty"`
+	NewKeyName   *string `json:"new_key_name,omitempty"`
+}
+
+// AdminKeyRotationResponse is a rotation with the progress of every node
+// in the mesh. A node is confirmed once it reports holding the new key.
+type AdminKeyRotationResponse struct {
+	meshdb.AdminKeyRotation
+	Nodes          []meshdb.ListAdminKeyRotationNodesRow `json:"nodes"`
+	NodeCount      int                                   `json:"node_count"`
+	ConfirmedCount int                                   `json:"confirmed_count"`
+}
+
+// RotationPushResponse lists the apply jobs started to push a new admin key,
+// and the nodes that couldn't be pushed to
+type RotationPushResponse struct {
+	Jobs    []meshdb.NodeApplyJob `json:"jobs"`
+	Skipped []RotationSkippedNode `json:"skipped"`
+}
+
+// RotationSkippedNode is a node a push wasn't started for, and why
+type RotationSkippedNode struct {
+	NodeID int64  `json:"node_id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// adminKeyPublicKey decodes an admin key's base64 public key
+func adminKeyPublicKey(key meshdb.AdminKey) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(string(key.PublicKey))
+}
+
+// setEffectiveAdminKeys adds the mesh admin keys to an effective config,
+// oldest first. Keys that aren't usable X25519 keys are left out, and a
+// mesh without admin keys leaves the node's own alone.
+func setEffectiveAdminKeys(resp *EffectiveConfigResponse, keys []meshdb.AdminKey) {
+	encoded := []string{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		public, err := adminKeyPublicKey(keys[i])
+		if err != nil || len(public) != meshcrypto.KeySize {
+			continue
+		}
+		encoded = append(encoded, base64.StdEncoding.EncodeToString(public))
+	}
+	if len(encoded) == 0 {
+		return
+	}
+	setConfigPath(resp.Config, "security.admin_key", encoded)
+}
+
+// syncNodeAdminKeys records which of the mesh admin keys a node holds,
+// given the base64 admin keys it reported
+func syncNodeAdminKeys(ctx context.Context, q *meshdb.Queries, meshID, nodeID int64, present []string) error {
+	held := make([][]byte, 0, len(present))
+	for _, encoded := range present {
+		if key, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			held = append(held, key)
+		}
+	}
+
+	keys, err := q.ListAdminKeysByMesh(ctx, meshID)
+	if err != nil {
+		return fmt.Errorf("failed to list admin keys: %w", err)
+	}
+	for _, key := range keys {
+		found := false
+		if public, err := adminKeyPublicKey(key); err == nil {
+			for _, h := range held {
+				if bytes.Equal(h, public) {
+					found = true
+					break
+				}
+			}
+		}
+		var err error
+		if found {
+			_, err = q.AssignAdminKeyToNode(ctx, meshdb.AssignAdminKeyToNodeParams{
+				NodeID:     nodeID,
+				AdminKeyID: key.ID,
+			})
+		} else {
+			err = q.MarkAdminKeyNotCurrent(ctx, meshdb.MarkAdminKeyNotCurrentParams{
+				NodeID:     nodeID,
+				AdminKeyID: key.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record admin key %d for node %d: %w", key.ID, nodeID, err)
+		}
+	}
+	return nil
+}
+
+// rotationStatus adds per-node progress to a rotation
+func rotationStatus(ctx context.Context, q *meshdb.Queries, rotation meshdb.AdminKeyRotation) (AdminKeyRotationResponse, error) {
+	nodes, err := q.ListAdminKeyRotationNodes(ctx, meshdb.ListAdminKeyRotationNodesParams{
+		MeshID:   rotation.MeshID,
+		NewKeyID: rotation.NewKeyID,
+		OldKeyID: rotation.OldKeyID,
+	})
+	if err != nil {
+		return AdminKeyRotationResponse{}, err
+	}
+	if nodes == nil {
+		nodes = []meshdb.ListAdminKeyRotationNodesRow{}
+	}
+
+	resp := AdminKeyRotationResponse{
+		AdminKeyRotation: rotation,
+		Nodes:            nodes,
+		NodeCount:        len(nodes),
+	}
+	for _, node := range nodes {
+		if node.HasNewKey {
+			resp.ConfirmedCount++
+		}
+	}
+	return resp, nil
+}
+
+// getMeshRotation looks up a rotation by its path ID, writing an error
+// response and returning false if it isn't a rotation of the mesh
+func (s *Server) getMeshRotation(w http.ResponseWriter, r *http.Request, meshID int64) (meshdb.AdminKeyRotation, bool) {
+	rotationIDStr := r.PathValue("rotationID")
+	rotationID, err := strconv.ParseInt(rotationIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid rotation ID")
+		return meshdb.AdminKeyRotation{}, false
+	}
+
+	rotation, err := s.DB().GetAdminKeyRotation(r.Context(), rotationID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Key rotation not found")
+			return meshdb.AdminKeyRotation{}, false
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get key rotation")
+		return meshdb.AdminKeyRotation{}, false
+	}
+
+	if rotation.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Key rotation not found")
+		return meshdb.AdminKeyRotation{}, false
+	}
+	return rotation, true
+}
+
+// handleListAdminKeyRotations handles listing a mesh's key rotations,
+// newest first
+func (s *Server) handleListAdminKeyRotations(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rotations, err := s.DB().ListAdminKeyRotations(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list key rotations")
+		return
+	}
+	if rotations == nil {
+		rotations = []meshdb.AdminKeyRotation{}
+	}
+
+	writeJSON(w, http.StatusOK, rotations)
+}
+
+// handleCreateAdminKeyRotation handles starting to replace an admin key.
+// Nodes pick up the new key when their config is next applied, over serial
+// or through the push endpoint.
+func (s *Server) handleCreateAdminKeyRotation(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	var req CreateAdminKeyRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.NewKeyID == nil && req.NewPublicKey == "" {
+		writeError(w, http.StatusBadRequest, "New key is required")
+		return
+	}
+	if req.NewKeyID != nil && req.NewPublicKey != "" {
+		writeError(w, http.StatusBadRequest, "Give either a new key ID or a new public key")
+		return
+	}
+
+	oldKey, err := s.DB().GetAdminKey(r.Context(), req.OldKeyID)
+	if err != nil || oldKey.MeshID != meshID {
+		if err == nil || err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Admin key not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get admin key")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	var newKey meshdb.AdminKey
+	if req.NewKeyID != nil {
+		newKey, err = qtx.GetAdminKey(r.Context(), *req.NewKeyID)
+		if err != nil || newKey.MeshID != meshID {
+			if err == nil || err == pgx.ErrNoRows {
+				writeError(w, http.StatusNotFound, "Admin key not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "Failed to get admin key")
+			return
+		}
+		if newKey.ID == oldKey.ID {
+			writeError(w, http.StatusBadRequest, "New key must differ from the old key")
+			return
+		}
+	} else {
+		public, err := base64.StdEncoding.DecodeString(req.NewPublicKey)
+		if err != nil || len(public) != meshcrypto.KeySize {
+			writeError(w, http.StatusBadRequest, "New public key must be a base64 encoded 32 byte key")
+			return
+		}
+
+		count, err := qtx.CountAdminKeysByMesh(r.Context(), meshID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to check admin key count")
+			return
+		}
+		if count >= maxMeshAdminKeys {
+			writeError(w, http.StatusBadRequest, "Mesh already has maximum of 3 admin keys")
+			return
+		}
+
+		newKey, err = qtx.CreateAdminKey(r.Context(), meshdb.CreateAdminKeyParams{
+			MeshID:    meshID,
+			PublicKey: []byte(req.NewPublicKey),
+			KeyName:   req.NewKeyName,
+			AddedBy:   user.ID,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to create admin key")
+			return
+		}
+	}
+
+	rotation, err := qtx.CreateAdminKeyRotation(r.Context(), meshdb.CreateAdminKeyRotationParams{
+		MeshID:    meshID,
+		OldKeyID:  &oldKey.ID,
+		NewKeyID:  newKey.ID,
+		StartedBy: user.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusConflict, "A key rotation is already in progress for this mesh")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to create key rotation")
+		return
+	}
+
+	resp, err := rotationStatus(r.Context(), qtx, rotation)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get key rotation progress")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit key rotation")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleGetAdminKeyRotation handles getting a rotation with the progress of
+// every node
+func (s *Server) handleGetAdminKeyRotation(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rotation, ok := s.getMeshRotation(w, r, meshID)
+	if !ok {
+		return
+	}
+
+	resp, err := rotationStatus(r.Context(), s.DB(), rotation)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get key rotation progress")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handlePushAdminKeyRotation handles starting apply jobs for every node that
+// hasn't confirmed the new key yet
+func (s *Server) handlePushAdminKeyRotation(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rotation, ok := s.getMeshRotation(w, r, meshID)
+	if !ok {
+		return
+	}
+	if rotation.Status != RotationInProgress {
+		writeError(w, http.StatusConflict, "Key rotation is not in progress")
+		return
+	}
+
+	progress, err := rotationStatus(r.Context(), s.DB(), rotation)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get key rotation progress")
+		return
+	}
+
+	target, err := s.prepareApply(r.Context(), meshID)
+	if err != nil {
+		writeApplyError(w, err)
+		return
+	}
+
+	resp := RotationPushResponse{
+		Jobs:    []meshdb.NodeApplyJob{},
+		Skipped: []RotationSkippedNode{},
+	}
+	for _, row := range progress.Nodes {
+		if row.HasNewKey {
+			continue
+		}
+		node, err := s.DB().GetNode(r.Context(), row.NodeID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to get node")
+			return
+		}
+		job, err := s.startApplyJob(r.Context(), target, node, user.ID)
+		if err != nil {
+			resp.Skipped = append(resp.Skipped, RotationSkippedNode{
+				NodeID: node.ID,
+				Name:   node.Name,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		resp.Jobs = append(resp.Jobs, job)
+	}
+
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// handleCompleteAdminKeyRotation handles retiring the old key of a rotation.
+// It is refused until every node has confirmed the new key.
+func (s *Server) handleCompleteAdminKeyRotation(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rotation, ok := s.getMeshRotation(w, r, meshID)
+	if !ok {
+		return
+	}
+	if rotation.Status != RotationInProgress {
+		writeError(w, http.StatusConflict, "Key rotation is not in progress")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	progress, err := rotationStatus(r.Context(), qtx, rotation)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get key rotation progress")
+		return
+	}
+	if waiting := progress.NodeCount - progress.ConfirmedCount; waiting > 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%d nodes have not confirmed the new key", waiting))
+		return
+	}
+
+	if rotation.OldKeyID != nil {
+		if err := qtx.DeleteAdminKey(r.Context(), *rotation.OldKeyID); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to delete old admin key")
+			return
+		}
+	}
+
+	rotation, err = qtx.FinishAdminKeyRotation(r.Context(), meshdb.FinishAdminKeyRotationParams{
+		ID:     rotation.ID,
+		Status: RotationCompleted,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusConflict, "Key rotation is not in progress")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to complete key rotation")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit key rotation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotation)
+}
+
+// handleCancelAdminKeyRotation handles abandoning a rotation. Both keys stay
+// admin keys of the mesh.
+func (s *Server) handleCancelAdminKeyRotation(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rotation, ok := s.getMeshRotation(w, r, meshID)
+	if !ok {
+		return
+	}
+
+	rotation, err = s.DB().FinishAdminKeyRotation(r.Context(), meshdb.FinishAdminKeyRotationParams{
+		ID:     rotation.ID,
+		Status: RotationCancelled,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusConflict, "Key rotation is not in progress")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to cancel key rotation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotation)
+}
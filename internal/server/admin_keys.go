@@ -15,18 +15,33 @@
 package server
 
 import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/internal/secrets"
 	"github.com/skandragon/meshmgr/meshdb"
 )
 
-// CreateAdminKeyRequest represents a request to add an admin key
+// CreateAdminKeyRequest represents a request to add an admin key. With
+// Generate set, the server creates the keypair and keeps the private key,
+// encrypted under its master key, for owners to download.
 type CreateAdminKeyRequest struct {
 	PublicKey string  `json:"public_key"`
 	KeyName   *string `json:"key_name,omitempty"`
+	Generate  bool    `json:"generate,omitempty"`
+}
+
+// AdminKeyPrivateKeyResponse is a generated admin keypair, base64 encoded
+type AdminKeyPrivateKeyResponse struct {
+	ID         int64  `json:"id"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
 }
 
 // handleListAdminKeys handles listing admin keys for a mesh
@@ -145,7 +160,11 @@ func (s *Server) handleCreateAdminKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.PublicKey == "" {
+	if req.Generate && req.PublicKey != "" {
+		writeError(w, http.StatusBadRequest, "Give either a public key or generate one, not both")
+		return
+	}
+	if !req.Generate && req.PublicKey == "" {
 		writeError(w, http.StatusBadRequest, "Public key is required")
 		return
 	}
@@ -162,6 +181,11 @@ func (s *Server) handleCreateAdminKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Generate {
+		s.createGeneratedAdminKey(w, r, meshID, user.ID, req.KeyName)
+		return
+	}
+
 	// Decode base64 public key to bytes
 	publicKeyBytes := []byte(req.PublicKey)
 
@@ -228,6 +252,17 @@ func (s *Server) handleDeleteAdminKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse to cut nodes off from remote administration
+	stranded, err := s.DB().ListNodesOnlyReachableByAdminKey(r.Context(), keyID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to check admin key usage")
+		return
+	}
+	if len(stranded) > 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Admin key is still needed to reach %d nodes", len(stranded)))
+		return
+	}
+
 	// Delete the key
 	if err := s.DB().DeleteAdminKey(r.Context(), keyID); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to delete admin key")
@@ -238,3 +273,173 @@ func (s *Server) handleDeleteAdminKey(w http.ResponseWriter, r *http.Request) {
 		"message": "Admin key deleted successfully",
 	})
 }
+
+// createGeneratedAdminKey generates an X25519 keypair and adds it as an
+// admin key, storing the private key encrypted under the master key
+func (s *Server) createGeneratedAdminKey(w http.ResponseWriter, r *http.Request, meshID, userID int64, keyName *string) {
+	keyring := secrets.Current()
+	if keyring == nil {
+		writeError(w, http.StatusServiceUnavailable, "Server master key not configured")
+		return
+	}
+
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to generate admin key")
+		return
+	}
+	sealed, err := keyring.Seal(private.Bytes())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encrypt admin key")
+		return
+	}
+
+	key, err := s.DB().CreateGeneratedAdminKey(r.Context(), meshdb.CreateGeneratedAdminKeyParams{
+		MeshID:              meshID,
+		PublicKey:           []byte(base64.StdEncoding.EncodeToString(private.PublicKey().Bytes())),
+		KeyName:             keyName,
+		AddedBy:             userID,
+		PrivateKeyEncrypted: sealed,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to create admin key")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, key)
+}
+
+// handleDownloadAdminPrivateKey handles downloading the private key of an
+// admin key the server generated. Only owners may download, only while the
+// mesh allows it, and every download is recorded.
+func (s *Server) handleDownloadAdminPrivateKey(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	keyIDStr := r.PathValue("keyID")
+	keyID, err := strconv.ParseInt(keyIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+
+	// Check if user is the mesh owner
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelOwner); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+	if !mesh.AdminKeyDownloadEnabled {
+		writeError(w, http.StatusForbidden, "Admin key download is disabled for this mesh")
+		return
+	}
+
+	key, err := s.DB().GetAdminKey(r.Context(), keyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Admin key not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get admin key")
+		return
+	}
+
+	if key.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Admin key not found")
+		return
+	}
+	if !key.HasPrivateKey {
+		writeError(w, http.StatusNotFound, "Admin key has no stored private key")
+		return
+	}
+
+	keyring := secrets.Current()
+	if keyring == nil {
+		writeError(w, http.StatusServiceUnavailable, "Server master key not configured")
+		return
+	}
+	private, err := keyring.Open(key.PrivateKeyEncrypted)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to decrypt admin key")
+		return
+	}
+
+	// The key is only handed out once the download is on record
+	remoteAddr := r.RemoteAddr
+	userAgent := r.UserAgent()
+	_, err = s.DB().CreateAdminKeyDownload(r.Context(), meshdb.CreateAdminKeyDownloadParams{
+		MeshID:     meshID,
+		AdminKeyID: &key.ID,
+		PublicKey:  key.PublicKey,
+		UserID:     user.ID,
+		RemoteAddr: &remoteAddr,
+		UserAgent:  &userAgent,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record admin key download")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminKeyPrivateKeyResponse{
+		ID:         key.ID,
+		PublicKey:  string(key.PublicKey),
+		PrivateKey: base64.StdEncoding.EncodeToString(private),
+	})
+}
+
+// handleListAdminKeyDownloads handles listing the admin private key
+// downloads of a mesh, newest first
+func (s *Server) handleListAdminKeyDownloads(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user is the mesh owner
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelOwner); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	downloads, err := s.DB().ListAdminKeyDownloads(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list admin key downloads")
+		return
+	}
+	if downloads == nil {
+		downloads = []meshdb.AdminKeyDownload{}
+	}
+
+	writeJSON(w, http.StatusOK, downloads)
+}
@@ -0,0 +1,582 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/skandragon/meshmgr/internal/auth"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const (
+	defaultAdminListLimit = 100
+	maxAdminListLimit     = 1000
+
+	// impersonationExpiration is how long a support token issued by
+	// impersonation stays valid, regardless of the configured JWT lifetime
+	impersonationExpiration = time.Hour
+
+	// pgForeignKeyViolation is the SQLSTATE for a foreign key violation
+	pgForeignKeyViolation = "23503"
+)
+
+// Admin audit log actions
+const (
+	AuditActionDisable     = "disable"
+	AuditActionEnable      = "enable"
+	AuditActionDelete      = "delete"
+	AuditActionImpersonate = "impersonate"
+	AuditActionGrantAdmin  = "grant_admin"
+	AuditActionRevokeAdmin = "revoke_admin"
+
+	// AuditActionImpersonatedRequest is a request made with an
+	// impersonation token, and AuditActionEndImpersonation the end of
+	// the support session it was issued for
+	AuditActionImpersonatedRequest = "impersonated_request"
+	AuditActionEndImpersonation    = "end_impersonation"
+)
+
+// errImpersonationEnded means an impersonation token's support session was
+// revoked or has expired, or its admin is no longer a site admin
+var errImpersonationEnded = errors.New("impersonation ended")
+
+// UpdateAdminUserRequest represents a site admin change to a user. Fields
+// left out are not changed.
+type UpdateAdminUserRequest struct {
+	Disabled *bool `json:"disabled"`
+	IsAdmin  *bool `json:"is_admin"`
+}
+
+// ImpersonateResponse is a short-lived token acting as another user. The
+// impersonation ID is the support session, which can be ended on its own.
+type ImpersonateResponse struct {
+	Token           string       `json:"token"`
+	ImpersonationID int64        `json:"impersonation_id"`
+	User            *meshdb.User `json:"user"`
+	ExpiresAt       time.Time    `json:"expires_at"`
+}
+
+// parseAdminListParams reads the limit and offset query parameters
+func parseAdminListParams(r *http.Request) (limit, offset int32, ok bool) {
+	limit = defaultAdminListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || n <= 0 || n > maxAdminListLimit {
+			return 0, 0, false
+		}
+		limit = int32(n)
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		n, err := strconv.ParseInt(offsetStr, 10, 32)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		offset = int32(n)
+	}
+	return limit, offset, true
+}
+
+// requestOrigin returns the remote address and user agent of a request
+// for the audit log
+func requestOrigin(r *http.Request) (remoteAddr, userAgent *string) {
+	if r.RemoteAddr != "" {
+		remoteAddr = &r.RemoteAddr
+	}
+	if ua := r.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+	return remoteAddr, userAgent
+}
+
+// recordAdminAction adds an entry to the admin audit log
+func recordAdminAction(ctx context.Context, q *meshdb.Queries, r *http.Request, admin *meshdb.User, action string, target meshdb.User) error {
+	remoteAddr, userAgent := requestOrigin(r)
+	_, err := q.CreateAdminAuditLog(ctx, meshdb.CreateAdminAuditLogParams{
+		AdminUserID:  &admin.ID,
+		Action:       action,
+		TargetUserID: &target.ID,
+		TargetEmail:  target.Email,
+		RemoteAddr:   remoteAddr,
+		UserAgent:    userAgent,
+	})
+	return err
+}
+
+// recordImpersonatedRequest adds a request made with an impersonation token
+// to the admin audit log, under the admin acting as the user
+func recordImpersonatedRequest(ctx context.Context, q *meshdb.Queries, r *http.Request, impersonation *meshdb.Impersonation, target meshdb.User) error {
+	remoteAddr, userAgent := requestOrigin(r)
+	detail := r.Method + " " + r.URL.Path
+	_, err := q.CreateAdminAuditLog(ctx, meshdb.CreateAdminAuditLogParams{
+		AdminUserID:     &impersonation.AdminUserID,
+		Action:          AuditActionImpersonatedRequest,
+		TargetUserID:    &target.ID,
+		TargetEmail:     target.Email,
+		RemoteAddr:      remoteAddr,
+		UserAgent:       userAgent,
+		ImpersonationID: &impersonation.ID,
+		Detail:          &detail,
+	})
+	return err
+}
+
+// checkImpersonation returns the support session an impersonation token was
+// issued for, as long as it is still open and its admin is still a site
+// admin
+func (s *Server) checkImpersonation(ctx context.Context, claims *auth.Claims) (*meshdb.Impersonation, error) {
+	impersonation, err := s.DB().GetImpersonation(ctx, claims.ImpersonationID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errImpersonationEnded
+		}
+		return nil, err
+	}
+	if impersonation.AdminUserID != claims.ImpersonatedBy || impersonation.TargetUserID != claims.UserID {
+		return nil, errImpersonationEnded
+	}
+	if impersonation.RevokedAt != nil || !time.Now().Before(impersonation.ExpiresAt) {
+		return nil, errImpersonationEnded
+	}
+
+	admin, err := s.DB().GetUserByID(ctx, impersonation.AdminUserID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errImpersonationEnded
+		}
+		return nil, err
+	}
+	if !admin.IsAdmin || admin.DisabledAt != nil {
+		return nil, errImpersonationEnded
+	}
+	return &impersonation, nil
+}
+
+// endImpersonation revokes a support session and records who ended it. An
+// impersonation that was already revoked gives pgx.ErrNoRows.
+func (s *Server) endImpersonation(ctx context.Context, r *http.Request, adminID, impersonationID int64) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	impersonation, err := qtx.RevokeImpersonation(ctx, impersonationID)
+	if err != nil {
+		return err
+	}
+	target, err := qtx.GetUserByID(ctx, impersonation.TargetUserID)
+	if err != nil {
+		return err
+	}
+
+	remoteAddr, userAgent := requestOrigin(r)
+	if _, err := qtx.CreateAdminAuditLog(ctx, meshdb.CreateAdminAuditLogParams{
+		AdminUserID:     &adminID,
+		Action:          AuditActionEndImpersonation,
+		TargetUserID:    &target.ID,
+		TargetEmail:     target.Email,
+		RemoteAddr:      remoteAddr,
+		UserAgent:       userAgent,
+		ImpersonationID: &impersonation.ID,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// handleAdminListUsers handles listing every user
+func (s *Server) handleAdminListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAdminListParams(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid limit or offset")
+		return
+	}
+
+	users, err := s.DB().ListUsers(r.Context(), meshdb.ListUsersParams{
+		LimitVal:  limit,
+		OffsetVal: offset,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []meshdb.User{}
+	}
+
+	writeJSON(w, http.StatusOK, users)
+}
+
+// handleAdminUpdateUser handles disabling, enabling, promoting and demoting
+// a user
+func (s *Server) handleAdminUpdateUser(w http.ResponseWriter, r *http.Request) {
+	admin := getUserFromContext(r.Context())
+	if admin == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDStr := r.PathValue("userID")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req UpdateAdminUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Admins can't lock themselves out
+	if userID == admin.ID {
+		if req.Disabled != nil && *req.Disabled {
+			writeError(w, http.StatusBadRequest, "Cannot disable yourself")
+			return
+		}
+		if req.IsAdmin != nil && !*req.IsAdmin {
+			writeError(w, http.StatusBadRequest, "Cannot remove your own admin access")
+			return
+		}
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	user, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	if req.Disabled != nil && *req.Disabled != (user.DisabledAt != nil) {
+		user, err = qtx.SetUserDisabled(r.Context(), meshdb.SetUserDisabledParams{
+			ID:       userID,
+			Disabled: *req.Disabled,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update user")
+			return
+		}
+		action := AuditActionEnable
+		if *req.Disabled {
+			action = AuditActionDisable
+			if err := qtx.DeleteUserSessions(r.Context(), userID); err != nil {
+				writeError(w, http.StatusInternalServerError, "Failed to delete sessions")
+				return
+			}
+		}
+		if err := recordAdminAction(r.Context(), qtx, r, admin, action, user); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to record audit log")
+			return
+		}
+	}
+
+	if req.IsAdmin != nil && *req.IsAdmin != user.IsAdmin {
+		user, err = qtx.SetUserAdmin(r.Context(), meshdb.SetUserAdminParams{
+			ID:      userID,
+			IsAdmin: *req.IsAdmin,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update user")
+			return
+		}
+		action := AuditActionRevokeAdmin
+		if *req.IsAdmin {
+			action = AuditActionGrantAdmin
+		}
+		if err := recordAdminAction(r.Context(), qtx, r, admin, action, user); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to record audit log")
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// handleAdminDeleteUser handles deleting a user who owns no meshes
+func (s *Server) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	admin := getUserFromContext(r.Context())
+	if admin == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDStr := r.PathValue("userID")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if userID == admin.ID {
+		writeError(w, http.StatusBadRequest, "Cannot delete yourself")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	user, err := qtx.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	// Deleting the owner would delete their meshes with them
+	owned, err := qtx.CountMeshesOwnedByUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to count meshes")
+		return
+	}
+	if owned > 0 {
+		writeError(w, http.StatusConflict, "User still owns meshes; transfer or delete them first")
+		return
+	}
+
+	// The audit entry keeps the email after the user is gone
+	if err := recordAdminAction(r.Context(), qtx, r, admin, AuditActionDelete, user); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record audit log")
+		return
+	}
+
+	if err := qtx.DeleteUser(r.Context(), userID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			writeError(w, http.StatusConflict, "User is still referenced; disable them instead")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "User deleted successfully",
+	})
+}
+
+// handleAdminImpersonateUser handles issuing a short-lived token acting as
+// another user, for support. Every use is recorded in the audit log.
+func (s *Server) handleAdminImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	admin := getUserFromContext(r.Context())
+	if admin == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDStr := r.PathValue("userID")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if userID == admin.ID {
+		writeError(w, http.StatusBadRequest, "Cannot impersonate yourself")
+		return
+	}
+
+	user, err := s.DB().GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	// Impersonating another admin would hand out their site admin access
+	if user.IsAdmin {
+		writeError(w, http.StatusForbidden, "Cannot impersonate a site admin")
+		return
+	}
+	if user.DisabledAt != nil {
+		writeError(w, http.StatusBadRequest, "User is disabled")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	impersonation, err := qtx.CreateImpersonation(r.Context(), meshdb.CreateImpersonationParams{
+		AdminUserID:  admin.ID,
+		TargetUserID: user.ID,
+		ExpiresAt:    time.Now().Add(impersonationExpiration),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start impersonation")
+		return
+	}
+
+	if err := recordAdminAction(r.Context(), qtx, r, admin, AuditActionImpersonate, user); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record audit log")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	token, err := auth.GenerateImpersonationToken(user.ID, user.Email, admin.ID, impersonation.ID, s.config.Auth.JWTSecret, impersonationExpiration)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ImpersonateResponse{
+		Token:           token,
+		ImpersonationID: impersonation.ID,
+		User:            &user,
+		ExpiresAt:       impersonation.ExpiresAt,
+	})
+}
+
+// handleAdminListImpersonations handles listing the support sessions that
+// are still open, newest first
+func (s *Server) handleAdminListImpersonations(w http.ResponseWriter, r *http.Request) {
+	impersonations, err := s.DB().ListActiveImpersonations(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list impersonations")
+		return
+	}
+	if impersonations == nil {
+		impersonations = []meshdb.ListActiveImpersonationsRow{}
+	}
+
+	writeJSON(w, http.StatusOK, impersonations)
+}
+
+// handleAdminEndImpersonation handles revoking a support session, which
+// signs out its impersonation token without touching the user's own
+// sessions
+func (s *Server) handleAdminEndImpersonation(w http.ResponseWriter, r *http.Request) {
+	admin := getUserFromContext(r.Context())
+	if admin == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	impersonationIDStr := r.PathValue("impersonationID")
+	impersonationID, err := strconv.ParseInt(impersonationIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid impersonation ID")
+		return
+	}
+
+	if err := s.endImpersonation(r.Context(), r, admin.ID, impersonationID); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Impersonation not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to end impersonation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Impersonation ended",
+	})
+}
+
+// handleAdminListAuditLog handles listing site admin actions, newest first
+func (s *Server) handleAdminListAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAdminListParams(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid limit or offset")
+		return
+	}
+
+	entries, err := s.DB().ListAdminAuditLog(r.Context(), meshdb.ListAdminAuditLogParams{
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list audit log")
+		return
+	}
+	if entries == nil {
+		entries = []meshdb.ListAdminAuditLogRow{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleAdminListMeshes handles listing every mesh with its owner, so
+// support can find a mesh to view
+func (s *Server) handleAdminListMeshes(w http.ResponseWriter, r *http.Request) {
+	meshes, err := s.DB().ListAllMeshes(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list meshes")
+		return
+	}
+	if meshes == nil {
+		meshes = []meshdb.ListAllMeshesRow{}
+	}
+
+	writeJSON(w, http.StatusOK, meshes)
+}
@@ -62,6 +62,12 @@ func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// An API key would outlive the support session
+	if getImpersonationFromContext(r.Context()) != nil {
+		writeError(w, http.StatusForbidden, "Cannot create API keys while impersonating")
+		return
+	}
+
 	var req CreateAPIKeyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "Invalid request body")
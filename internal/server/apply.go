@@ -0,0 +1,347 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/secrets"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// EffectiveChannelSettings is a mesh channel's settings in the JSON form of
+// the ChannelSettings message in channel.proto
+type EffectiveChannelSettings struct {
+	PSK  []byte `json:"psk,omitempty"` // base64 encoded in JSON
+	Name string `json:"name,omitempty"`
+	ChannelSettings
+}
+
+// EffectiveChannel is a mesh channel in the JSON form of the Channel message
+type EffectiveChannel struct {
+	Index    int32                    `json:"index"`
+	Role     string                   `json:"role"`
+	Settings EffectiveChannelSettings `json:"settings"`
+}
+
+// EffectiveConfigResponse is the configuration a node should have, with mesh
+// settings, mesh defaults, node overrides and node columns merged. The config
+// and module_config documents use protobuf JSON field and enum names, so
+// they can be decoded directly into LocalConfig and LocalModuleConfig.
+type EffectiveConfigResponse struct {
+	NodeID         int64              `json:"node_id"`
+	MeshID         int64              `json:"mesh_id"`
+	HardwareID     string             `json:"hardware_id"`
+	NodeNum        *int64             `json:"node_num"`
+	ShortName      string             `json:"short_name"`
+	LongName       string             `json:"long_name"`
+	Unmessageable  bool               `json:"unmessageable"`
+	Config         map[string]any     `json:"config"`
+	ModuleConfig   map[string]any     `json:"module_config"`
+	Channels       []EffectiveChannel `json:"channels"`
+	PendingChanges bool               `json:"pending_changes"`
+}
+
+// MarkNodeAppliedRequest reports the values that were written to a device.
+// Fields left out were not applied and are recorded as unknown.
+type MarkNodeAppliedRequest struct {
+	ShortName     *string `json:"short_name,omitempty"`
+	LongName      *string `json:"long_name,omitempty"`
+	Role          *string `json:"role,omitempty"`
+	PublicKey     *string `json:"public_key,omitempty"`
+	PrivateKey    *string `json:"private_key,omitempty"`
+	Unmessageable *bool   `json:"unmessageable,omitempty"`
+	// AdminKeys are the base64 admin public keys the device now holds. It
+	// is nil if they weren't reported, and empty if the device holds none.
+	AdminKeys *[]string `json:"admin_keys,omitempty"`
+}
+
+// setConfigPath stores value in a nested document at a dotted path
+func setConfigPath(doc map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			current[part] = child
+		}
+		current = child
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// effectiveChannels converts the mesh channel set into device channels
+func effectiveChannels(channels []meshdb.MeshChannel) ([]EffectiveChannel, error) {
+	result := make([]EffectiveChannel, 0, len(channels))
+	for _, ch := range channels {
+		var settings ChannelSettings
+		if len(ch.Settings) > 0 {
+			if err := json.Unmarshal(ch.Settings, &settings); err != nil {
+				return nil, err
+			}
+		}
+		effective := EffectiveChannel{
+			Index: ch.ChannelIndex,
+			Role:  ch.ChannelRole,
+			Settings: EffectiveChannelSettings{
+				PSK:             ch.Psk,
+				ChannelSettings: settings.normalize(),
+			},
+		}
+		if ch.ChannelName != nil {
+			effective.Settings.Name = *ch.ChannelName
+		}
+		result = append(result, effective)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+	return result, nil
+}
+
+// buildEffectiveConfig merges everything the mesh wants for a node into one
+// document. It uses the same layering as the drift report, so a device
+// configured from it reports no drift.
+func buildEffectiveConfig(mesh meshdb.Mesh, node meshdb.Node, channels []meshdb.MeshChannel) (EffectiveConfigResponse, error) {
+	desired, err := desiredNodeConfig(mesh, node)
+	if err != nil {
+		return EffectiveConfigResponse{}, err
+	}
+
+	sections := map[string]map[string]any{
+		"config":        {},
+		"module_config": {},
+	}
+	for path, want := range desired {
+		root, rest, ok := strings.Cut(path, ".")
+		section, managed := sections[root]
+		if !ok || !managed {
+			continue
+		}
+		setConfigPath(section, rest, normalizeConfigValue(configField(path), want.value))
+	}
+
+	effectiveChans, err := effectiveChannels(channels)
+	if err != nil {
+		return EffectiveConfigResponse{}, err
+	}
+
+	return EffectiveConfigResponse{
+		NodeID:         node.ID,
+		MeshID:         node.MeshID,
+		HardwareID:     node.HardwareID,
+		NodeNum:        node.NodeNum,
+		ShortName:      node.Name,
+		LongName:       node.LongName,
+		Unmessageable:  node.Unmessageable,
+		Config:         sections["config"],
+		ModuleConfig:   sections["module_config"],
+		Channels:       effectiveChans,
+		PendingChanges: node.PendingChanges,
+	}, nil
+}
+
+// handleGetNodeEffectiveConfig handles fetching the config to write to a node.
+// It includes private keys and PSKs, so it requires admin access and is
+// recorded as a secret reveal.
+func (s *Server) handleGetNodeEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+
+	channels, err := s.DB().ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list channels")
+		return
+	}
+
+	adminKeys, err := s.DB().ListAdminKeysByMesh(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list admin keys")
+		return
+	}
+
+	resp, err := buildEffectiveConfig(mesh, node, channels)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to build effective config: "+err.Error())
+		return
+	}
+	setEffectiveAdminKeys(&resp, adminKeys)
+
+	// The secrets are only handed out once the reveal is on record
+	if err := s.recordNodeSecretReveal(r, user, node); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record secret reveal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleMarkNodeApplied records that config was written to a device, which
+// updates the applied_* columns and recomputes pending_changes
+func (s *Server) handleMarkNodeApplied(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	var req MarkNodeAppliedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var unmessageable pgtype.Bool
+	if req.Unmessageable != nil {
+		unmessageable = pgtype.Bool{Bool: *req.Unmessageable, Valid: true}
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	updatedNode, err := qtx.UpdateNodeAppliedState(r.Context(), meshdb.UpdateNodeAppliedStateParams{
+		ID:                   nodeID,
+		AppliedName:          req.ShortName,
+		AppliedLongName:      req.LongName,
+		AppliedRole:          req.Role,
+		AppliedPublicKey:     req.PublicKey,
+		AppliedPrivateKey:    (*secrets.Text)(req.PrivateKey),
+		AppliedUnmessageable: unmessageable,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update applied state")
+		return
+	}
+	if req.AdminKeys != nil {
+		if err := syncNodeAdminKeys(r.Context(), qtx, meshID, nodeID, *req.AdminKeys); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update admin keys")
+			return
+		}
+	}
+	// A device config imported before the push may still differ
+	updatedNode, err = refreshPendingChanges(r.Context(), qtx, updatedNode)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit applied state")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newNodeResponse(updatedNode))
+}
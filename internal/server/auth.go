@@ -17,6 +17,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -30,6 +31,10 @@ type contextKey string
 
 const userContextKey contextKey = "user"
 
+// impersonationContextKey holds the support session of a request made with
+// an impersonation token
+const impersonationContextKey contextKey = "impersonation"
+
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Email       string `json:"email"`
@@ -51,6 +56,11 @@ type AuthResponse struct {
 
 // handleRegister handles user registration
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if !s.config.Auth.RegistrationEnabled {
+		writeError(w, http.StatusForbidden, "Registration is disabled")
+		return
+	}
+
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "Invalid request body")
@@ -146,6 +156,11 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.DisabledAt != nil {
+		writeError(w, http.StatusForbidden, "Account disabled")
+		return
+	}
+
 	// Generate JWT token
 	token, err := auth.GenerateToken(user.ID, user.Email, s.config.Auth.JWTSecret, s.config.Auth.JWTExpiration)
 	if err != nil {
@@ -201,6 +216,19 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Signing out of an impersonation only ends that support session
+	if claims.IsImpersonation() {
+		err := s.endImpersonation(r.Context(), r, claims.ImpersonatedBy, claims.ImpersonationID)
+		if err != nil && err != pgx.ErrNoRows {
+			writeError(w, http.StatusInternalServerError, "Failed to logout")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"message": "Logged out successfully",
+		})
+		return
+	}
+
 	// Delete all sessions for the user
 	if err := s.DB().DeleteUserSessions(r.Context(), claims.UserID); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to logout")
@@ -248,6 +276,22 @@ func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.DisabledAt != nil {
+		writeError(w, http.StatusUnauthorized, "Account disabled")
+		return
+	}
+
+	if claims.IsImpersonation() {
+		if _, err := s.checkImpersonation(r.Context(), claims); err != nil {
+			if errors.Is(err, errImpersonationEnded) {
+				writeError(w, http.StatusUnauthorized, "Impersonation ended")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "Failed to check impersonation")
+			return
+		}
+	}
+
 	writeJSON(w, http.StatusOK, user)
 }
 
@@ -279,9 +323,33 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 				writeError(w, http.StatusUnauthorized, "User not found")
 				return
 			}
+			if user.DisabledAt != nil {
+				writeError(w, http.StatusUnauthorized, "Account disabled")
+				return
+			}
 
 			// Add user to context
 			ctx := context.WithValue(r.Context(), userContextKey, &user)
+
+			// Requests made while impersonating are only let through
+			// while the support session is open, and each is audited
+			if claims.IsImpersonation() {
+				impersonation, err := s.checkImpersonation(r.Context(), claims)
+				if err != nil {
+					if errors.Is(err, errImpersonationEnded) {
+						writeError(w, http.StatusUnauthorized, "Impersonation ended")
+						return
+					}
+					writeError(w, http.StatusInternalServerError, "Failed to check impersonation")
+					return
+				}
+				if err := recordImpersonatedRequest(r.Context(), s.DB(), r, impersonation, user); err != nil {
+					writeError(w, http.StatusInternalServerError, "Failed to record audit log")
+					return
+				}
+				ctx = context.WithValue(ctx, impersonationContextKey, impersonation)
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -325,6 +393,9 @@ func (s *Server) validateAPIKey(ctx context.Context, key string) (*meshdb.User,
 	if err != nil {
 		return nil, err
 	}
+	if user.DisabledAt != nil {
+		return nil, pgx.ErrNoRows
+	}
 
 	return &user, nil
 }
@@ -337,3 +408,13 @@ func getUserFromContext(ctx context.Context) *meshdb.User {
 	}
 	return user
 }
+
+// getImpersonationFromContext retrieves the support session from the
+// request context, or nil if the user is acting as themselves
+func getImpersonationFromContext(ctx context.Context) *meshdb.Impersonation {
+	impersonation, ok := ctx.Value(impersonationContextKey).(*meshdb.Impersonation)
+	if !ok {
+		return nil
+	}
+	return impersonation
+}
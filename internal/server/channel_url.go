@@ -0,0 +1,516 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	qrcode "github.com/skip2/go-qrcode"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// channelURLBase is the URL Meshtastic apps use to share a channel set.
+	// The ChannelSet protobuf follows the '#' as unpadded base64url.
+	channelURLBase = "https://meshtastic.org/e/"
+
+	// defaultQRCodeSize is the default width and height of a channel QR code, in pixels
+	defaultQRCodeSize = 256
+
+	// maxQRCodeSize is the largest QR code image we will render
+	maxQRCodeSize = 1024
+)
+
+// errNoChannelSet is returned when a mesh's channels cannot be expressed as a ChannelSet
+var errNoChannelSet = errors.New("cannot build channel set")
+
+// modemPresets maps the mesh modem_preset names to Config.LoRaConfig.ModemPreset
+var modemPresets = map[string]pb.Config_LoRaConfig_ModemPreset{
+	"LongFast":   pb.Config_LoRaConfig_LONG_FAST,
+	"LongSlow":   pb.Config_LoRaConfig_LONG_SLOW,
+	"LongMod":    pb.Config_LoRaConfig_LONG_MODERATE,
+	"MediumFast": pb.Config_LoRaConfig_MEDIUM_FAST,
+	"MediumSlow": pb.Config_LoRaConfig_MEDIUM_SLOW,
+	"ShortFast":  pb.Config_LoRaConfig_SHORT_FAST,
+	"ShortSlow":  pb.Config_LoRaConfig_SHORT_SLOW,
+	"ShortTurbo": pb.Config_LoRaConfig_SHORT_TURBO,
+}
+
+// modemPresetName returns the mesh modem_preset name for a ModemPreset.
+// VERY_LONG_SLOW is deprecated and maps to LongSlow, as in the preset name migration.
+func modemPresetName(preset pb.Config_LoRaConfig_ModemPreset) (string, bool) {
+	if preset == pb.Config_LoRaConfig_VERY_LONG_SLOW {
+		return "LongSlow", true
+	}
+	for name, p := range modemPresets {
+		if p == preset {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isKnownRegion reports whether code is one of the regions in loraConfig
+func isKnownRegion(code string) bool {
+	for _, region := range loraConfig.Regions {
+		if region.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ChannelURLResponse represents a mesh's channel set encoded as a Meshtastic URL
+type ChannelURLResponse struct {
+	URL          string `json:"url"`
+	ChannelCount int    `json:"channel_count"`
+}
+
+// ImportChannelURLRequest represents a request to import a Meshtastic channel URL
+type ImportChannelURLRequest struct {
+	URL string `json:"url"`
+}
+
+// ImportChannelURLResponse is returned after importing a channel URL
+type ImportChannelURLResponse struct {
+	Mesh     meshdb.Mesh       `json:"mesh"`
+	Channels []ChannelResponse `json:"channels"`
+}
+
+// buildChannelSet builds a ChannelSet from the mesh's enabled channels and LoRa settings.
+// The primary channel must exist, as apps treat the first entry as the primary channel.
+func buildChannelSet(mesh meshdb.Mesh, channels []meshdb.MeshChannel) (*pb.ChannelSet, error) {
+	set := &pb.ChannelSet{}
+	for _, ch := range channels {
+		if ch.ChannelRole == ChannelRoleDisabled {
+			continue
+		}
+		if len(set.Settings) == 0 && ch.ChannelRole != ChannelRolePrimary {
+			return nil, fmt.Errorf("mesh has no primary channel")
+		}
+
+		var settings ChannelSettings
+		if len(ch.Settings) > 0 {
+			if err := json.Unmarshal(ch.Settings, &settings); err != nil {
+				return nil, fmt.Errorf("channel %d: invalid settings: %w", ch.ChannelIndex, err)
+			}
+		}
+
+		cs := &pb.ChannelSettings{
+			Psk:             ch.Psk,
+			Id:              settings.ID,
+			UplinkEnabled:   settings.UplinkEnabled,
+			DownlinkEnabled: settings.DownlinkEnabled,
+		}
+		if ch.ChannelName != nil {
+			cs.Name = *ch.ChannelName
+		}
+		if settings.ModuleSettings != nil {
+			cs.ModuleSettings = &pb.ModuleSettings{
+				PositionPrecision: settings.ModuleSettings.PositionPrecision,
+				IsMuted:           settings.ModuleSettings.IsMuted,
+			}
+		}
+		set.Settings = append(set.Settings, cs)
+	}
+	if len(set.Settings) == 0 {
+		return nil, fmt.Errorf("mesh has no primary channel")
+	}
+
+	lora := &pb.Config_LoRaConfig{
+		UsePreset: mesh.UsePreset,
+		TxEnabled: true,
+	}
+	if mesh.LoraRegion != nil {
+		region, ok := pb.Config_LoRaConfig_RegionCode_value[*mesh.LoraRegion]
+		if !ok {
+			return nil, fmt.Errorf("unknown LoRa region %q", *mesh.LoraRegion)
+		}
+		lora.Region = pb.Config_LoRaConfig_RegionCode(region)
+	}
+	if mesh.ModemPreset != nil {
+		preset, ok := modemPresets[*mesh.ModemPreset]
+		if !ok {
+			return nil, fmt.Errorf("unknown modem preset %q", *mesh.ModemPreset)
+		}
+		lora.ModemPreset = preset
+	}
+	if mesh.HopLimit.Valid {
+		lora.HopLimit = uint32(mesh.HopLimit.Int32)
+	}
+	// The UI frequency slot matches LoRaConfig.channel_num: 0 = hash default, N = radio slot N-1
+	if mesh.FrequencySlot.Valid {
+		lora.ChannelNum = uint32(mesh.FrequencySlot.Int32)
+	}
+	set.LoraConfig = lora
+
+	return set, nil
+}
+
+// encodeChannelURL encodes a ChannelSet as a Meshtastic channel URL.
+// With add set, apps add the channels to the existing ones rather than replacing them.
+func encodeChannelURL(set *pb.ChannelSet, add bool) (string, error) {
+	data, err := proto.Marshal(set)
+	if err != nil {
+		return "", err
+	}
+
+	base := channelURLBase
+	if add {
+		base += "?add=true"
+	}
+	return base + "#" + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// parseChannelURL decodes the ChannelSet from a Meshtastic channel URL.
+// Padded and standard base64 are accepted too, as some apps produce them.
+func parseChannelURL(rawURL string) (*pb.ChannelSet, error) {
+	_, fragment, ok := strings.Cut(strings.TrimSpace(rawURL), "#")
+	if !ok || fragment == "" {
+		return nil, fmt.Errorf("URL has no channel set")
+	}
+
+	fragment = strings.TrimRight(fragment, "=")
+	fragment = strings.NewReplacer("+", "-", "/", "_").Replace(fragment)
+	data, err := base64.RawURLEncoding.DecodeString(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel set encoding: %w", err)
+	}
+
+	var set pb.ChannelSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid channel set: %w", err)
+	}
+	if len(set.Settings) == 0 {
+		return nil, fmt.Errorf("channel set has no channels")
+	}
+	if len(set.Settings) > maxChannels {
+		return nil, fmt.Errorf("channel set has %d channels, at most %d allowed", len(set.Settings), maxChannels)
+	}
+	return &set, nil
+}
+
+// channelSetUpsertParams converts the channels of a ChannelSet into mesh_channels
+// upsert parameters. The first channel becomes the primary, the rest secondaries.
+func channelSetUpsertParams(meshID int64, set *pb.ChannelSet) ([]meshdb.UpsertMeshChannelParams, error) {
+	params := make([]meshdb.UpsertMeshChannelParams, 0, len(set.Settings))
+	for i, cs := range set.Settings {
+		if len(cs.Name) > maxChannelNameBytes {
+			return nil, fmt.Errorf("channel %d: name must be at most %d bytes", i, maxChannelNameBytes)
+		}
+		if err := validatePSK(cs.Psk); err != nil {
+			return nil, fmt.Errorf("channel %d: %w", i, err)
+		}
+
+		settings := ChannelSettings{
+			ID:              cs.Id,
+			UplinkEnabled:   cs.UplinkEnabled,
+			DownlinkEnabled: cs.DownlinkEnabled,
+		}
+		if cs.ModuleSettings != nil {
+			settings.ModuleSettings = &ChannelModuleSettings{
+				PositionPrecision: cs.ModuleSettings.PositionPrecision,
+				IsMuted:           cs.ModuleSettings.IsMuted,
+			}
+		}
+		settingsJSON, err := json.Marshal(settings.normalize())
+		if err != nil {
+			return nil, err
+		}
+
+		role := ChannelRoleSecondary
+		if i == 0 {
+			role = ChannelRolePrimary
+		}
+		var name *string
+		if cs.Name != "" {
+			name = &cs.Name
+		}
+
+		params = append(params, meshdb.UpsertMeshChannelParams{
+			MeshID:       meshID,
+			ChannelIndex: int32(i),
+			ChannelRole:  role,
+			Psk:          cs.Psk,
+			ChannelName:  name,
+			Settings:     settingsJSON,
+		})
+	}
+	return params, nil
+}
+
+// channelSetLoRaParams converts the LoRa config of a ChannelSet into mesh LoRa settings
+func channelSetLoRaParams(meshID int64, lora *pb.Config_LoRaConfig) (meshdb.UpdateMeshLoRaConfigParams, error) {
+	region := lora.Region.String()
+	if !isKnownRegion(region) {
+		return meshdb.UpdateMeshLoRaConfigParams{}, fmt.Errorf("unsupported LoRa region %s", region)
+	}
+	preset, ok := modemPresetName(lora.ModemPreset)
+	if !ok {
+		return meshdb.UpdateMeshLoRaConfigParams{}, fmt.Errorf("unsupported modem preset %s", lora.ModemPreset)
+	}
+	if lora.HopLimit > 7 {
+		return meshdb.UpdateMeshLoRaConfigParams{}, fmt.Errorf("hop limit must be between 0 and 7")
+	}
+	if int(lora.ChannelNum) > GetMaxSlot(region, preset)+1 {
+		return meshdb.UpdateMeshLoRaConfigParams{}, fmt.Errorf("frequency slot out of range for region/preset")
+	}
+
+	return meshdb.UpdateMeshLoRaConfigParams{
+		ID:            meshID,
+		LoraRegion:    &region,
+		ModemPreset:   &preset,
+		FrequencySlot: pgtype.Int4{Int32: int32(lora.ChannelNum), Valid: true},
+		HopLimit:      pgtype.Int4{Int32: int32(lora.HopLimit), Valid: true},
+		UsePreset:     pgtype.Bool{Bool: lora.UsePreset, Valid: true},
+	}, nil
+}
+
+// meshChannelURL loads a mesh and its channels and encodes them as a channel URL
+func (s *Server) meshChannelURL(r *http.Request, meshID int64) (string, int, error) {
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		return "", 0, err
+	}
+	channels, err := s.DB().ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	set, err := buildChannelSet(mesh, channels)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", errNoChannelSet, err)
+	}
+	url, err := encodeChannelURL(set, r.URL.Query().Get("add") == "true")
+	if err != nil {
+		return "", 0, err
+	}
+	return url, len(set.Settings), nil
+}
+
+// handleGetChannelURL handles exporting a mesh's channels as a Meshtastic channel URL
+func (s *Server) handleGetChannelURL(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// The URL contains the channel PSKs, so require admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	url, count, err := s.meshChannelURL(r, meshID)
+	if err != nil {
+		if errors.Is(err, errNoChannelSet) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to build channel URL")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ChannelURLResponse{
+		URL:          url,
+		ChannelCount: count,
+	})
+}
+
+// handleGetChannelQRCode handles rendering a mesh's channel URL as a PNG QR code
+func (s *Server) handleGetChannelQRCode(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	size := defaultQRCodeSize
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size <= 0 || size > maxQRCodeSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Size must be between 1 and %d", maxQRCodeSize))
+			return
+		}
+	}
+
+	// The URL contains the channel PSKs, so require admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	url, _, err := s.meshChannelURL(r, meshID)
+	if err != nil {
+		if errors.Is(err, errNoChannelSet) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to build channel URL")
+		return
+	}
+
+	png, err := qrcode.Encode(url, qrcode.Medium, size)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to render QR code")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(png)
+}
+
+// handleImportChannelURL handles replacing a mesh's channels and LoRa settings
+// with those from a Meshtastic channel URL
+func (s *Server) handleImportChannelURL(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	level, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	var req ImportChannelURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	set, err := parseChannelURL(req.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid channel URL: %v", err))
+		return
+	}
+
+	channelParams, err := channelSetUpsertParams(meshID, set)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid channel URL: %v", err))
+		return
+	}
+
+	var loraParams *meshdb.UpdateMeshLoRaConfigParams
+	if set.LoraConfig != nil {
+		params, err := channelSetLoRaParams(meshID, set.LoraConfig)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid channel URL: %v", err))
+			return
+		}
+		loraParams = &params
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	// The URL describes the complete channel set, so replace the existing channels
+	if err := qtx.ImportMeshChannels(r.Context(), meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to replace channels")
+		return
+	}
+
+	channels := make([]ChannelResponse, 0, len(channelParams))
+	for _, params := range channelParams {
+		channel, err := qtx.UpsertMeshChannel(r.Context(), params)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to save channel")
+			return
+		}
+		channels = append(channels, newChannelResponse(channel, level))
+	}
+
+	var mesh meshdb.Mesh
+	if loraParams != nil {
+		mesh, err = qtx.UpdateMeshLoRaConfig(r.Context(), *loraParams)
+	} else {
+		mesh, err = qtx.GetMeshByID(r.Context(), meshID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update mesh")
+		return
+	}
+	if err := refreshMeshPendingChanges(r.Context(), qtx, meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit import")
+		return
+	}
+	s.channels.Invalidate(meshID)
+
+	writeJSON(w, http.StatusOK, ImportChannelURLResponse{
+		Mesh:     mesh,
+		Channels: channels,
+	})
+}
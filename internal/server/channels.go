@@ -0,0 +1,654 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const (
+	// maxChannels is the number of channel slots on a Meshtastic device
+	maxChannels = 8
+
+	// maxChannelNameBytes is the longest channel name allowed by channel.proto
+	// ("Less than 12 bytes")
+	maxChannelNameBytes = 11
+
+	// defaultGeneratedPSKLength is the PSK length used when generating a key (AES256)
+	defaultGeneratedPSKLength = 32
+)
+
+// Channel roles, matching the Channel.Role enum names in channel.proto
+const (
+	ChannelRoleDisabled  = "DISABLED"
+	ChannelRolePrimary   = "PRIMARY"
+	ChannelRoleSecondary = "SECONDARY"
+)
+
+// ChannelModuleSettings holds per-channel module settings (ModuleSettings in channel.proto)
+type ChannelModuleSettings struct {
+	PositionPrecision uint32 `json:"position_precision,omitempty"`
+	IsMuted           bool   `json:"is_muted,omitempty"`
+}
+
+// ChannelSettings holds the channel settings stored in mesh_channels.settings.
+// The PSK and name have their own columns; the JSON field names match the
+// ChannelSettings message in channel.proto.
+type ChannelSettings struct {
+	ID              uint32                 `json:"id,omitempty"`
+	UplinkEnabled   bool                   `json:"uplink_enabled,omitempty"`
+	DownlinkEnabled bool                   `json:"downlink_enabled,omitempty"`
+	ModuleSettings  *ChannelModuleSettings `json:"module_settings,omitempty"`
+}
+
+// UpsertChannelRequest represents a request to create or replace a mesh channel
+type UpsertChannelRequest struct {
+	Role        string           `json:"role"`
+	Name        *string          `json:"name,omitempty"`
+	PSK         []byte           `json:"psk,omitempty"` // base64 encoded in JSON
+	GeneratePSK bool             `json:"generate_psk,omitempty"`
+	PSKLength   *int             `json:"psk_length,omitempty"` // 16 or 32, used with generate_psk
+	Settings    *ChannelSettings `json:"settings,omitempty"`
+}
+
+// ChannelResponse represents a mesh channel as returned by the API
+type ChannelResponse struct {
+	ID           int64           `json:"id"`
+	MeshID       int64           `json:"mesh_id"`
+	ChannelIndex int32           `json:"channel_index"`
+	ChannelRole  string          `json:"channel_role"`
+	PSK          []byte          `json:"psk"`
+	PSKRedacted  bool            `json:"psk_redacted,omitempty"`
+	ChannelName  *string         `json:"channel_name"`
+	Settings     json.RawMessage `json:"settings"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// newChannelResponse builds a ChannelResponse, hiding the PSK from viewers
+func newChannelResponse(ch meshdb.MeshChannel, level AccessLevel) ChannelResponse {
+	resp := ChannelResponse{
+		ID:           ch.ID,
+		MeshID:       ch.MeshID,
+		ChannelIndex: ch.ChannelIndex,
+		ChannelRole:  ch.ChannelRole,
+		PSK:          ch.Psk,
+		ChannelName:  ch.ChannelName,
+		Settings:     ch.Settings,
+		CreatedAt:    ch.CreatedAt,
+		UpdatedAt:    ch.UpdatedAt,
+	}
+	if len(resp.Settings) == 0 {
+		resp.Settings = json.RawMessage("{}")
+	}
+	if !hasAccess(level, AccessLevelAdmin) {
+		resp.PSK = nil
+		resp.PSKRedacted = len(ch.Psk) > 0
+	}
+	return resp
+}
+
+// validateChannelRole checks that role is allowed for the channel index.
+// Index 0 must be the PRIMARY channel and all others SECONDARY or DISABLED.
+func validateChannelRole(index int32, role string) error {
+	switch role {
+	case ChannelRolePrimary:
+		if index != 0 {
+			return fmt.Errorf("only channel 0 can be PRIMARY")
+		}
+	case ChannelRoleSecondary, ChannelRoleDisabled:
+		if index == 0 {
+			return fmt.Errorf("channel 0 must be PRIMARY")
+		}
+	default:
+		return fmt.Errorf("role must be 'PRIMARY', 'SECONDARY' or 'DISABLED'")
+	}
+	return nil
+}
+
+// validatePSK checks that a PSK has a length Meshtastic accepts: 0 bytes (no crypto),
+// 1 byte (default key shorthand), 16 bytes (AES128) or 32 bytes (AES256)
+func validatePSK(psk []byte) error {
+	switch len(psk) {
+	case 0, 1, 16, 32:
+		return nil
+	}
+	return fmt.Errorf("PSK must be 0, 1, 16 or 32 bytes, got %d", len(psk))
+}
+
+// generatePSK generates a random PSK of the given length (16 or 32 bytes)
+func generatePSK(length int) ([]byte, error) {
+	if length != 16 && length != 32 {
+		return nil, fmt.Errorf("generated PSK length must be 16 or 32 bytes")
+	}
+	psk := make([]byte, length)
+	if _, err := rand.Read(psk); err != nil {
+		return nil, err
+	}
+	return psk, nil
+}
+
+// normalize returns settings with empty module settings removed, so that
+// settings decoded from different sources compare equal
+func (cs ChannelSettings) normalize() ChannelSettings {
+	if cs.ModuleSettings != nil && *cs.ModuleSettings == (ChannelModuleSettings{}) {
+		cs.ModuleSettings = nil
+	}
+	return cs
+}
+
+// deviceChannelRole is a Channel.Role as found in imported device JSON, which
+// may be encoded either as the enum number or its name
+type deviceChannelRole string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *deviceChannelRole) UnmarshalJSON(data []byte) error {
+	var num int
+	if err := json.Unmarshal(data, &num); err == nil {
+		switch num {
+		case 0:
+			*r = ChannelRoleDisabled
+		case 1:
+			*r = ChannelRolePrimary
+		case 2:
+			*r = ChannelRoleSecondary
+		default:
+			return fmt.Errorf("unknown channel role %d", num)
+		}
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*r = deviceChannelRole(name)
+	return nil
+}
+
+// deviceChannelSettings is the ChannelSettings message as found in imported device JSON
+type deviceChannelSettings struct {
+	ChannelSettings
+	PSK  []byte `json:"psk,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// deviceChannel is the Channel message as found in imported device JSON
+type deviceChannel struct {
+	Index    int32                  `json:"index,omitempty"`
+	Settings *deviceChannelSettings `json:"settings,omitempty"`
+	Role     deviceChannelRole      `json:"role,omitempty"`
+}
+
+// decodeDeviceChannels decodes the channels array of an imported device config.
+// Disabled channels are dropped, as the mesh channel set only stores channels in use.
+func decodeDeviceChannels(data json.RawMessage) ([]deviceChannel, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var channels []deviceChannel
+	if err := json.Unmarshal(data, &channels); err != nil {
+		return nil, err
+	}
+
+	enabled := make([]deviceChannel, 0, len(channels))
+	for _, ch := range channels {
+		if ch.Role == "" {
+			ch.Role = ChannelRoleDisabled
+		}
+		if ch.Role == ChannelRoleDisabled {
+			continue
+		}
+		if ch.Index < 0 || ch.Index >= maxChannels {
+			return nil, fmt.Errorf("channel index %d out of range", ch.Index)
+		}
+		if err := validateChannelRole(ch.Index, string(ch.Role)); err != nil {
+			return nil, fmt.Errorf("channel %d: %w", ch.Index, err)
+		}
+		if ch.Settings == nil {
+			ch.Settings = &deviceChannelSettings{}
+		}
+		if err := validatePSK(ch.Settings.PSK); err != nil {
+			return nil, fmt.Errorf("channel %d: %w", ch.Index, err)
+		}
+		enabled = append(enabled, ch)
+	}
+	return enabled, nil
+}
+
+// upsertParams converts a device channel into mesh_channels upsert parameters
+func (ch deviceChannel) upsertParams(meshID int64) (meshdb.UpsertMeshChannelParams, error) {
+	settingsJSON, err := json.Marshal(ch.Settings.ChannelSettings.normalize())
+	if err != nil {
+		return meshdb.UpsertMeshChannelParams{}, err
+	}
+
+	var name *string
+	if ch.Settings.Name != "" {
+		name = &ch.Settings.Name
+	}
+
+	return meshdb.UpsertMeshChannelParams{
+		MeshID:       meshID,
+		ChannelIndex: ch.Index,
+		ChannelRole:  string(ch.Role),
+		Psk:          ch.Settings.PSK,
+		ChannelName:  name,
+		Settings:     settingsJSON,
+	}, nil
+}
+
+// ChannelMismatch describes how a channel on a device differs from the
+// mesh's canonical channel set
+type ChannelMismatch struct {
+	Index      int32    `json:"index"`
+	Fields     []string `json:"fields"`
+	DeviceRole string   `json:"device_role"`
+	MeshRole   string   `json:"mesh_role"`
+}
+
+// diffChannels compares the enabled channels on a device against the mesh's
+// channels, returning one mismatch per channel index that differs
+func diffChannels(device []deviceChannel, mesh []meshdb.MeshChannel) []ChannelMismatch {
+	deviceByIndex := make(map[int32]deviceChannel, len(device))
+	for _, ch := range device {
+		deviceByIndex[ch.Index] = ch
+	}
+	meshByIndex := make(map[int32]meshdb.MeshChannel, len(mesh))
+	for _, ch := range mesh {
+		meshByIndex[ch.ChannelIndex] = ch
+	}
+
+	mismatches := []ChannelMismatch{}
+	for index := int32(0); index < maxChannels; index++ {
+		devCh, onDevice := deviceByIndex[index]
+		meshCh, inMesh := meshByIndex[index]
+		if !onDevice && !inMesh {
+			continue
+		}
+
+		mismatch := ChannelMismatch{
+			Index:      index,
+			DeviceRole: ChannelRoleDisabled,
+			MeshRole:   ChannelRoleDisabled,
+		}
+		if onDevice {
+			mismatch.DeviceRole = string(devCh.Role)
+		}
+		if inMesh {
+			mismatch.MeshRole = meshCh.ChannelRole
+		}
+
+		if !onDevice || !inMesh {
+			mismatch.Fields = []string{"role"}
+			mismatches = append(mismatches, mismatch)
+			continue
+		}
+
+		if mismatch.DeviceRole != mismatch.MeshRole {
+			mismatch.Fields = append(mismatch.Fields, "role")
+		}
+		meshName := ""
+		if meshCh.ChannelName != nil {
+			meshName = *meshCh.ChannelName
+		}
+		if devCh.Settings.Name != meshName {
+			mismatch.Fields = append(mismatch.Fields, "name")
+		}
+		if !bytes.Equal(devCh.Settings.PSK, meshCh.Psk) {
+			mismatch.Fields = append(mismatch.Fields, "psk")
+		}
+		var meshSettings ChannelSettings
+		if len(meshCh.Settings) > 0 {
+			_ = json.Unmarshal(meshCh.Settings, &meshSettings)
+		}
+		if !reflect.DeepEqual(devCh.Settings.ChannelSettings.normalize(), meshSettings.normalize()) {
+			mismatch.Fields = append(mismatch.Fields, "settings")
+		}
+
+		if len(mismatch.Fields) > 0 {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+// parseChannelIndex parses and range-checks the channel index path value
+func parseChannelIndex(r *http.Request) (int32, error) {
+	index, err := strconv.ParseInt(r.PathValue("index"), 10, 32)
+	if err != nil || index < 0 || index >= maxChannels {
+		return 0, fmt.Errorf("invalid channel index")
+	}
+	return int32(index), nil
+}
+
+// handleListChannels handles listing the channels of a mesh
+func (s *Server) handleListChannels(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	level, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	channels, err := s.DB().ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list channels")
+		return
+	}
+
+	resp := make([]ChannelResponse, 0, len(channels))
+	for _, ch := range channels {
+		resp = append(resp, newChannelResponse(ch, level))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetChannel handles getting a single mesh channel
+func (s *Server) handleGetChannel(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	index, err := parseChannelIndex(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid channel index")
+		return
+	}
+
+	// Check if user has at least viewer access
+	level, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	channel, err := s.DB().GetMeshChannel(r.Context(), meshdb.GetMeshChannelParams{
+		MeshID:       meshID,
+		ChannelIndex: index,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get channel")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newChannelResponse(channel, level))
+}
+
+// handleUpsertChannel handles creating or replacing a mesh channel
+func (s *Server) handleUpsertChannel(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	index, err := parseChannelIndex(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid channel index")
+		return
+	}
+
+	// Check if user has at least admin access
+	level, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	var req UpsertChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := validateChannelRole(index, req.Role); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Name != nil && len(*req.Name) > maxChannelNameBytes {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Channel name must be at most %d bytes", maxChannelNameBytes))
+		return
+	}
+
+	psk := req.PSK
+	if req.GeneratePSK {
+		if len(req.PSK) > 0 {
+			writeError(w, http.StatusBadRequest, "Cannot both provide and generate a PSK")
+			return
+		}
+		length := defaultGeneratedPSKLength
+		if req.PSKLength != nil {
+			length = *req.PSKLength
+		}
+		psk, err = generatePSK(length)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := validatePSK(psk); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Secondary channels are meaningless without a primary channel
+	if index != 0 {
+		if _, err := s.DB().GetPrimaryChannel(r.Context(), meshID); err != nil {
+			if err == pgx.ErrNoRows {
+				writeError(w, http.StatusBadRequest, "Primary channel must be configured first")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "Failed to get primary channel")
+			return
+		}
+	}
+
+	settings := ChannelSettings{}
+	if req.Settings != nil {
+		settings = *req.Settings
+	}
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to serialize channel settings")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	channel, err := qtx.UpsertMeshChannel(r.Context(), meshdb.UpsertMeshChannelParams{
+		MeshID:       meshID,
+		ChannelIndex: index,
+		ChannelRole:  req.Role,
+		Psk:          psk,
+		ChannelName:  req.Name,
+		Settings:     settingsJSON,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save channel")
+		return
+	}
+	// Every node of the mesh carries its channels
+	if err := refreshMeshPendingChanges(r.Context(), qtx, meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit channel")
+		return
+	}
+	s.channels.Invalidate(meshID)
+
+	writeJSON(w, http.StatusOK, newChannelResponse(channel, level))
+}
+
+// handleDeleteChannel handles deleting a mesh channel
+func (s *Server) handleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	index, err := parseChannelIndex(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid channel index")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	// Verify the channel exists
+	if _, err := s.DB().GetMeshChannel(r.Context(), meshdb.GetMeshChannelParams{
+		MeshID:       meshID,
+		ChannelIndex: index,
+	}); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get channel")
+		return
+	}
+
+	// The primary channel can only be removed once it is the last channel
+	if index == 0 {
+		count, err := s.DB().CountMeshChannels(r.Context(), meshID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to count channels")
+			return
+		}
+		if count > 1 {
+			writeError(w, http.StatusBadRequest, "Cannot delete the primary channel while secondary channels exist")
+			return
+		}
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	if err := qtx.DeleteMeshChannel(r.Context(), meshdb.DeleteMeshChannelParams{
+		MeshID:       meshID,
+		ChannelIndex: index,
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete channel")
+		return
+	}
+	// Every node of the mesh carries its channels
+	if err := refreshMeshPendingChanges(r.Context(), qtx, meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit channel")
+		return
+	}
+	s.channels.Invalidate(meshID)
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Channel deleted successfully",
+	})
+}
@@ -0,0 +1,686 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/secrets"
+	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Sources of a desired config value, from lowest to highest precedence
+const (
+	DriftSourceMesh         = "mesh"          // mesh LoRa settings
+	DriftSourceMeshDefault  = "mesh_default"  // meshes.config_defaults
+	DriftSourceNodeOverride = "node_override" // nodes.config_overrides
+	DriftSourceNode         = "node"          // node columns (name, role, keys, ...)
+	DriftSourceMeshChannels = "mesh_channels" // the mesh channel set
+)
+
+// driftSecretPaths are config paths whose values are only shown to mesh admins
+var driftSecretPaths = func() map[string]bool {
+	paths := make(map[string]bool, len(secrets.DeviceConfigSecretPaths))
+	for _, p := range secrets.DeviceConfigSecretPaths {
+		paths[p] = true
+	}
+	return paths
+}()
+
+// driftConfigRoots maps the top-level sections of a device config to the
+// protobuf messages they were encoded from, used to interpret enum values
+var driftConfigRoots = map[string]protoreflect.MessageDescriptor{
+	"config":        (&pb.LocalConfig{}).ProtoReflect().Descriptor(),
+	"module_config": (&pb.LocalModuleConfig{}).ProtoReflect().Descriptor(),
+}
+
+// Stand-ins for the device and applied values of a redacted secret
+const (
+	DriftSecretMatches = "matches"
+	DriftSecretDiffers = "differs"
+)
+
+// DriftEntry describes a single config value that differs between what the
+// mesh wants and what the device has. Secrets are always redacted.
+type DriftEntry struct {
+	Path     string `json:"path"`
+	Desired  any    `json:"desired"`
+	OnDevice any    `json:"on_device"`
+	Applied  any    `json:"applied,omitempty"`
+	Source   string `json:"source"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// NodeDriftResponse is the drift report for a single node
+type NodeDriftResponse struct {
+	NodeID           int64        `json:"node_id"`
+	MeshID           int64        `json:"mesh_id"`
+	Name             string       `json:"name"`
+	InSync           bool         `json:"in_sync"`
+	PendingChanges   bool         `json:"pending_changes"`
+	ConfigImportedAt *time.Time   `json:"config_imported_at"`
+	ConfigAppliedAt  *time.Time   `json:"config_applied_at"`
+	Drift            []DriftEntry `json:"drift"`
+}
+
+// NodeDriftSummary summarizes the drift of one node in a mesh drift report
+type NodeDriftSummary struct {
+	NodeID           int64      `json:"node_id"`
+	Name             string     `json:"name"`
+	LongName         string     `json:"long_name"`
+	InSync           bool       `json:"in_sync"`
+	PendingChanges   bool       `json:"pending_changes"`
+	DriftCount       int        `json:"drift_count"`
+	Paths            []string   `json:"paths"`
+	ConfigImportedAt *time.Time `json:"config_imported_at"`
+}
+
+// MeshDriftResponse is the drift summary for all nodes of a mesh
+type MeshDriftResponse struct {
+	MeshID       int64              `json:"mesh_id"`
+	NodeCount    int                `json:"node_count"`
+	NodesInSync  int                `json:"nodes_in_sync"`
+	NodesDrifted int                `json:"nodes_drifted"`
+	Nodes        []NodeDriftSummary `json:"nodes"`
+}
+
+// desiredValue is a desired config value and where it came from
+type desiredValue struct {
+	value   any
+	source  string
+	applied any
+}
+
+// jsonValue converts a Go value into the form encoding/json decodes it as,
+// so it compares equal to values read from stored JSON
+func jsonValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+// flattenConfig adds every leaf of a JSON config document to desired, keyed
+// by its dotted path. Later calls override values from earlier ones.
+func flattenConfig(desired map[string]desiredValue, prefix string, value any, source string) {
+	if obj, ok := value.(map[string]any); ok {
+		for key, child := range obj {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenConfig(desired, path, child, source)
+		}
+		return
+	}
+	if prefix != "" {
+		desired[prefix] = desiredValue{value: value, source: source}
+	}
+}
+
+// decodeConfigDocument decodes a JSONB config column, treating empty as no config
+func decodeConfigDocument(data []byte) (map[string]any, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// configField finds the protobuf field a dotted config path refers to, if any
+func configField(path string) protoreflect.FieldDescriptor {
+	parts := strings.Split(path, ".")
+	md, ok := driftConfigRoots[parts[0]]
+	if !ok {
+		return nil
+	}
+	var fd protoreflect.FieldDescriptor
+	for _, part := range parts[1:] {
+		if md == nil {
+			return nil
+		}
+		fd = md.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			return nil
+		}
+		md = fd.Message()
+	}
+	return fd
+}
+
+// normalizeConfigValue puts a config value in a canonical form for comparison.
+// Enums are shown by name, whether they were stored as a number or a name.
+func normalizeConfigValue(fd protoreflect.FieldDescriptor, value any) any {
+	if fd == nil || fd.Kind() != protoreflect.EnumKind || fd.IsList() {
+		return value
+	}
+	values := fd.Enum().Values()
+	switch v := value.(type) {
+	case float64:
+		if ev := values.ByNumber(protoreflect.EnumNumber(v)); ev != nil {
+			return string(ev.Name())
+		}
+	case string:
+		if ev := values.ByName(protoreflect.Name(strings.ToUpper(v))); ev != nil {
+			return string(ev.Name())
+		}
+	}
+	return value
+}
+
+// zeroConfigValue returns the value a device reports for a field it omitted.
+// The CLI encodes configs with encoding/json, which drops zero values.
+func zeroConfigValue(fd protoreflect.FieldDescriptor, desired any) any {
+	if fd != nil && !fd.IsList() && !fd.IsMap() {
+		switch fd.Kind() {
+		case protoreflect.BoolKind:
+			return false
+		case protoreflect.EnumKind:
+			return string(fd.Enum().Values().ByNumber(0).Name())
+		case protoreflect.StringKind, protoreflect.BytesKind:
+			return ""
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			return nil
+		default:
+			return float64(0)
+		}
+	}
+	switch desired.(type) {
+	case bool:
+		return false
+	case float64:
+		return float64(0)
+	case string:
+		return ""
+	}
+	return nil
+}
+
+// deviceValue looks up a dotted path in the device's raw config. known is false
+// if the device config doesn't cover the path's section at all.
+func deviceValue(raw map[string]any, path string, fd protoreflect.FieldDescriptor, desired any) (value any, known bool) {
+	parts := strings.Split(path, ".")
+	section, ok := raw[parts[0]]
+	if !ok || section == nil {
+		return nil, false
+	}
+
+	current := section
+	for _, part := range parts[1:] {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return zeroConfigValue(fd, desired), true
+		}
+		current, ok = obj[part]
+		if !ok || current == nil {
+			return zeroConfigValue(fd, desired), true
+		}
+	}
+	return current, true
+}
+
+// desiredNodeConfig collects the desired value of every config path for a node,
+// layering mesh LoRa settings, mesh defaults, node overrides and node columns
+func desiredNodeConfig(mesh meshdb.Mesh, node meshdb.Node) (map[string]desiredValue, error) {
+	desired := map[string]desiredValue{}
+
+	if mesh.LoraRegion != nil {
+		desired["config.lora.region"] = desiredValue{value: *mesh.LoraRegion, source: DriftSourceMesh}
+	}
+	if mesh.ModemPreset != nil {
+		if preset, ok := modemPresets[*mesh.ModemPreset]; ok {
+			desired["config.lora.modem_preset"] = desiredValue{value: preset.String(), source: DriftSourceMesh}
+		}
+	}
+	if mesh.HopLimit.Valid {
+		desired["config.lora.hop_limit"] = desiredValue{value: float64(mesh.HopLimit.Int32), source: DriftSourceMesh}
+	}
+	// The UI frequency slot matches LoRaConfig.channel_num
+	if mesh.FrequencySlot.Valid {
+		desired["config.lora.channel_num"] = desiredValue{value: float64(mesh.FrequencySlot.Int32), source: DriftSourceMesh}
+	}
+	desired["config.lora.use_preset"] = desiredValue{value: mesh.UsePreset, source: DriftSourceMesh}
+
+	defaults, err := decodeConfigDocument(mesh.ConfigDefaults)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mesh config defaults: %w", err)
+	}
+	flattenConfig(desired, "", defaults, DriftSourceMeshDefault)
+
+	overrides, err := decodeConfigDocument(node.ConfigOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node config overrides: %w", err)
+	}
+	flattenConfig(desired, "", overrides, DriftSourceNodeOverride)
+
+	// Node columns are the most specific, and also track what was last applied
+	desired["short_name"] = desiredValue{value: node.Name, source: DriftSourceNode, applied: jsonValue(node.AppliedName)}
+	desired["long_name"] = desiredValue{value: node.LongName, source: DriftSourceNode, applied: jsonValue(node.AppliedLongName)}
+	desired["unmessageable"] = desiredValue{value: node.Unmessageable, source: DriftSourceNode, applied: jsonValue(node.AppliedUnmessageable)}
+	if node.Role != nil {
+		desired["config.device.role"] = desiredValue{value: *node.Role, source: DriftSourceNode, applied: jsonValue(node.AppliedRole)}
+	}
+	if node.PublicKey != nil {
+		desired["config.security.public_key"] = desiredValue{value: *node.PublicKey, source: DriftSourceNode, applied: jsonValue(node.AppliedPublicKey)}
+	}
+	if node.PrivateKey != nil {
+		desired["config.security.private_key"] = desiredValue{value: string(*node.PrivateKey), source: DriftSourceNode, applied: jsonValue(node.AppliedPrivateKey)}
+	}
+
+	return desired, nil
+}
+
+// computeNodeDrift compares the desired configuration of a node against its
+// last imported device config. Where the device config doesn't cover a value,
+// the last applied value is used instead; a value that is neither imported
+// nor applied only counts as drift if the node has never been configured.
+func computeNodeDrift(mesh meshdb.Mesh, node meshdb.Node, channels []meshdb.MeshChannel) ([]DriftEntry, error) {
+	desired, err := desiredNodeConfig(mesh, node)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := decodeConfigDocument(node.RawDeviceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw device config: %w", err)
+	}
+	neverConfigured := node.ConfigImportedAt == nil && node.ConfigAppliedAt == nil
+
+	entries := []DriftEntry{}
+	for path, want := range desired {
+		fd := configField(path)
+		wantValue := normalizeConfigValue(fd, want.value)
+		applied := normalizeConfigValue(fd, want.applied)
+
+		onDevice, known := deviceValue(raw, path, fd, want.value)
+		onDevice = normalizeConfigValue(fd, onDevice)
+
+		var drifted bool
+		switch {
+		case known:
+			drifted = !reflect.DeepEqual(wantValue, onDevice)
+		case applied != nil:
+			drifted = !reflect.DeepEqual(wantValue, applied)
+		default:
+			drifted = neverConfigured
+		}
+		if !drifted {
+			continue
+		}
+
+		entries = append(entries, DriftEntry{
+			Path:     path,
+			Desired:  wantValue,
+			OnDevice: onDevice,
+			Applied:  applied,
+			Source:   want.source,
+		})
+	}
+
+	channelEntries, err := channelDrift(raw, channels)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, channelEntries...)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// channelDrift reports differences between the device's channels and the
+// mesh channel set. Meshes without channels don't manage them, so nothing is reported.
+func channelDrift(raw map[string]any, channels []meshdb.MeshChannel) ([]DriftEntry, error) {
+	if len(channels) == 0 || raw["channels"] == nil {
+		return nil, nil
+	}
+
+	rawChannels, err := json.Marshal(raw["channels"])
+	if err != nil {
+		return nil, err
+	}
+	deviceChannels, err := decodeDeviceChannels(rawChannels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device channels: %w", err)
+	}
+
+	deviceByIndex := make(map[int32]deviceChannel, len(deviceChannels))
+	for _, ch := range deviceChannels {
+		deviceByIndex[ch.Index] = ch
+	}
+	meshByIndex := make(map[int32]meshdb.MeshChannel, len(channels))
+	for _, ch := range channels {
+		meshByIndex[ch.ChannelIndex] = ch
+	}
+
+	var entries []DriftEntry
+	for _, mismatch := range diffChannels(deviceChannels, channels) {
+		devCh, onDevice := deviceByIndex[mismatch.Index]
+		meshCh, inMesh := meshByIndex[mismatch.Index]
+
+		for _, field := range mismatch.Fields {
+			entry := DriftEntry{
+				Path:   fmt.Sprintf("channels.%d.%s", mismatch.Index, field),
+				Source: DriftSourceMeshChannels,
+			}
+			switch field {
+			case "role":
+				entry.Desired = mismatch.MeshRole
+				entry.OnDevice = mismatch.DeviceRole
+			case "name":
+				entry.Desired = jsonValue(meshCh.ChannelName)
+				entry.OnDevice = devCh.Settings.Name
+			case "psk":
+				entry.Desired = base64.StdEncoding.EncodeToString(meshCh.Psk)
+				entry.OnDevice = base64.StdEncoding.EncodeToString(devCh.Settings.PSK)
+			case "settings":
+				var meshSettings ChannelSettings
+				if len(meshCh.Settings) > 0 {
+					_ = json.Unmarshal(meshCh.Settings, &meshSettings)
+				}
+				entry.Desired = jsonValue(meshSettings.normalize())
+				entry.OnDevice = jsonValue(devCh.Settings.ChannelSettings.normalize())
+			}
+			if !inMesh {
+				entry.Desired = nil
+			}
+			if !onDevice {
+				entry.OnDevice = nil
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// isSecretDriftPath reports whether a drift path holds a secret value
+func isSecretDriftPath(path string) bool {
+	if driftSecretPaths[path] {
+		return true
+	}
+	// Channel drift paths name the settings field directly, as in
+	// channels.0.psk
+	return strings.HasPrefix(path, "channels.") && strings.HasSuffix(path, "."+secrets.ChannelSecretField)
+}
+
+// redactDrift hides secret values from every user, whatever their access.
+// The device and applied values of a secret are replaced by whether they
+// match the desired one; admins read the secrets themselves from the node's
+// secrets endpoint, where each reveal is recorded.
+func redactDrift(entries []DriftEntry) []DriftEntry {
+	for i := range entries {
+		entry := &entries[i]
+		if !isSecretDriftPath(entry.Path) {
+			continue
+		}
+		entry.OnDevice = driftSecretState(entry.Desired, entry.OnDevice)
+		entry.Applied = driftSecretState(entry.Desired, entry.Applied)
+		entry.Desired = nil
+		entry.Redacted = true
+	}
+	return entries
+}
+
+// driftSecretState stands in for a secret value in a drift entry: nil if
+// there is none, otherwise whether it matches the desired value
+func driftSecretState(desired, value any) any {
+	switch {
+	case value == nil:
+		return nil
+	case reflect.DeepEqual(desired, value):
+		return DriftSecretMatches
+	default:
+		return DriftSecretDiffers
+	}
+}
+
+// syncPendingChanges sets the node's pending_changes flag from its drift
+func syncPendingChanges(ctx context.Context, q *meshdb.Queries, node meshdb.Node, drifted bool) (meshdb.Node, error) {
+	if node.PendingChanges == drifted {
+		return node, nil
+	}
+	return q.UpdateNode(ctx, meshdb.UpdateNodeParams{
+		ID:             node.ID,
+		PendingChanges: pgtype.Bool{Bool: drifted, Valid: true},
+	})
+}
+
+// refreshPendingChanges recomputes a node's pending_changes flag after a
+// write that changed its desired or device config. Drift reports only read
+// the flag, so every such write has to call this or refreshMeshPendingChanges.
+func refreshPendingChanges(ctx context.Context, q *meshdb.Queries, node meshdb.Node) (meshdb.Node, error) {
+	mesh, err := q.GetMeshByID(ctx, node.MeshID)
+	if err != nil {
+		return node, fmt.Errorf("failed to get mesh: %w", err)
+	}
+	channels, err := q.ListMeshChannels(ctx, node.MeshID)
+	if err != nil {
+		return node, fmt.Errorf("failed to list channels: %w", err)
+	}
+	entries, err := computeNodeDrift(mesh, node, channels)
+	if err != nil {
+		return node, fmt.Errorf("failed to compute drift for node %d: %w", node.ID, err)
+	}
+	return syncPendingChanges(ctx, q, node, len(entries) > 0)
+}
+
+// refreshMeshPendingChanges recomputes pending_changes for every node of a
+// mesh, after a change to its LoRa settings or channels
+func refreshMeshPendingChanges(ctx context.Context, q *meshdb.Queries, meshID int64) error {
+	mesh, err := q.GetMeshByID(ctx, meshID)
+	if err != nil {
+		return fmt.Errorf("failed to get mesh: %w", err)
+	}
+	channels, err := q.ListMeshChannels(ctx, meshID)
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+	nodes, err := q.ListNodesByMesh(ctx, meshID)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes {
+		entries, err := computeNodeDrift(mesh, node, channels)
+		if err != nil {
+			return fmt.Errorf("failed to compute drift for node %d: %w", node.ID, err)
+		}
+		if _, err := syncPendingChanges(ctx, q, node, len(entries) > 0); err != nil {
+			return fmt.Errorf("failed to update node %d: %w", node.ID, err)
+		}
+	}
+	return nil
+}
+
+// nodeDriftResponse builds the drift report for a node
+func nodeDriftResponse(node meshdb.Node, entries []DriftEntry) NodeDriftResponse {
+	return NodeDriftResponse{
+		NodeID:           node.ID,
+		MeshID:           node.MeshID,
+		Name:             node.Name,
+		InSync:           len(entries) == 0,
+		PendingChanges:   node.PendingChanges,
+		ConfigImportedAt: node.ConfigImportedAt,
+		ConfigAppliedAt:  node.ConfigAppliedAt,
+		Drift:            entries,
+	}
+}
+
+// handleGetNodeDrift handles reporting config drift for a single node
+func (s *Server) handleGetNodeDrift(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+
+	channels, err := s.DB().ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list channels")
+		return
+	}
+
+	entries, err := computeNodeDrift(mesh, node, channels)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to compute drift: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nodeDriftResponse(node, redactDrift(entries)))
+}
+
+// handleGetMeshDrift handles summarizing config drift across all nodes of a mesh
+func (s *Server) handleGetMeshDrift(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+
+	channels, err := s.DB().ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list channels")
+		return
+	}
+
+	nodes, err := s.DB().ListNodesByMesh(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list nodes")
+		return
+	}
+
+	resp := MeshDriftResponse{
+		MeshID:    meshID,
+		NodeCount: len(nodes),
+		Nodes:     make([]NodeDriftSummary, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		entries, err := computeNodeDrift(mesh, node, channels)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to compute drift for node %d: %v", node.ID, err))
+			return
+		}
+
+		paths := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			paths = append(paths, entry.Path)
+		}
+
+		if len(entries) == 0 {
+			resp.NodesInSync++
+		} else {
+			resp.NodesDrifted++
+		}
+		resp.Nodes = append(resp.Nodes, NodeDriftSummary{
+			NodeID:           node.ID,
+			Name:             node.Name,
+			LongName:         node.LongName,
+			InSync:           len(entries) == 0,
+			PendingChanges:   node.PendingChanges,
+			DriftCount:       len(entries),
+			Paths:            paths,
+			ConfigImportedAt: node.ConfigImportedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/skandragon/meshmgr/internal/gateway"
+)
+
+// GatewayStatusResponse reports the server's connection to its gateway radio
+type GatewayStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	gateway.Status
+}
+
+// handleGetGatewayStatus handles reporting the gateway radio connection
+func (s *Server) handleGetGatewayStatus(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if s.gateway == nil {
+		writeJSON(w, http.StatusOK, GatewayStatusResponse{})
+		return
+	}
+	writeJSON(w, http.StatusOK, GatewayStatusResponse{
+		Enabled: true,
+		Status:  s.gateway.Status(),
+	})
+}
@@ -14,17 +14,15 @@
 
 package server
 
-// TODO: Uncomment these imports when implementing mesh access control endpoints
-/*
 import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/skandragon/meshmgr/meshdb"
 )
-*/
 
 // GrantAccessRequest represents a request to grant mesh access
 type GrantAccessRequest struct {
@@ -37,11 +35,6 @@ type UpdateAccessRequest struct {
 	AccessLevel string `json:"access_level"`
 }
 
-// TODO: Implement mesh access control endpoints when needed
-// The following functions are commented out to avoid unused function warnings
-// They provide a complete implementation for mesh access control features
-
-/*
 // handleListMeshAccess handles listing all users with access to a mesh
 func (s *Server) handleListMeshAccess(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
@@ -117,9 +110,8 @@ func (s *Server) handleGrantMeshAccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate access level
-	if req.AccessLevel != "admin" && req.AccessLevel != "viewer" {
-		writeError(w, http.StatusBadRequest, "Access level must be 'admin' or 'viewer'")
+	if !isGrantableAccessLevel(req.AccessLevel) {
+		writeError(w, http.StatusBadRequest, "Access level must be 'owner', 'admin' or 'viewer'")
 		return
 	}
 
@@ -134,6 +126,17 @@ func (s *Server) handleGrantMeshAccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The mesh creator is always an owner and never has a mesh_access row
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+	if targetUser.ID == mesh.OwnerID {
+		writeError(w, http.StatusBadRequest, "User already owns this mesh")
+		return
+	}
+
 	// Grant access
 	grantedBy := user.ID
 	access, err := s.DB().GrantMeshAccess(r.Context(), meshdb.GrantMeshAccessParams{
@@ -143,6 +146,10 @@ func (s *Server) handleGrantMeshAccess(w http.ResponseWriter, r *http.Request) {
 		GrantedBy:   &grantedBy,
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			writeError(w, http.StatusConflict, "User already has access to this mesh")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "Failed to grant access")
 		return
 	}
@@ -193,9 +200,12 @@ func (s *Server) handleUpdateMeshAccess(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate access level
-	if req.AccessLevel != "admin" && req.AccessLevel != "viewer" {
-		writeError(w, http.StatusBadRequest, "Access level must be 'admin' or 'viewer'")
+	if !isGrantableAccessLevel(req.AccessLevel) {
+		writeError(w, http.StatusBadRequest, "Access level must be 'owner', 'admin' or 'viewer'")
+		return
+	}
+
+	if ok := s.checkNotLastOwner(w, r, meshID, targetUserID); !ok {
 		return
 	}
 
@@ -249,6 +259,23 @@ func (s *Server) handleRevokeMeshAccess(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if ok := s.checkNotLastOwner(w, r, meshID, targetUserID); !ok {
+		return
+	}
+
+	// Verify the access record exists before revoking it
+	if _, err := s.DB().GetMeshAccess(r.Context(), meshdb.GetMeshAccessParams{
+		MeshID: meshID,
+		UserID: targetUserID,
+	}); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Access record not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get access record")
+		return
+	}
+
 	// Revoke access
 	if err := s.DB().RevokeMeshAccess(r.Context(), meshdb.RevokeMeshAccessParams{
 		MeshID: meshID,
@@ -262,4 +289,33 @@ func (s *Server) handleRevokeMeshAccess(w http.ResponseWriter, r *http.Request)
 		"message": "Access revoked successfully",
 	})
 }
-*/
+
+// isGrantableAccessLevel reports whether level can be stored in mesh_access
+func isGrantableAccessLevel(level string) bool {
+	switch AccessLevel(level) {
+	case AccessLevelOwner, AccessLevelAdmin, AccessLevelViewer:
+		return true
+	}
+	return false
+}
+
+// checkNotLastOwner writes an error and returns false if changing the access of
+// targetUserID could leave the mesh without an owner.
+//
+// The mesh creator's ownership comes from meshes.owner_id rather than a
+// mesh_access row, which is what guarantees every mesh keeps at least one owner,
+// so the creator cannot be demoted or removed through the access endpoints.
+func (s *Server) checkNotLastOwner(w http.ResponseWriter, r *http.Request, meshID, targetUserID int64) bool {
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return false
+	}
+
+	if targetUserID == mesh.OwnerID {
+		writeError(w, http.StatusConflict, "Cannot change the mesh creator's access; every mesh must keep an owner")
+		return false
+	}
+
+	return true
+}
@@ -40,6 +40,12 @@ type UpdateMeshRequest struct {
 	LoraRegion    *string `json:"lora_region,omitempty"`
 	ModemPreset   *string `json:"modem_preset,omitempty"`
 	FrequencySlot *int32  `json:"frequency_slot,omitempty"`
+	// OfflineAfterSeconds is how long a node may be silent before it is
+	// marked offline
+	OfflineAfterSeconds *int32 `json:"offline_after_seconds,omitempty"`
+	// AdminKeyDownloadEnabled allows owners to download admin private keys
+	// the server generated. Only owners may change it.
+	AdminKeyDownloadEnabled *bool `json:"admin_key_download_enabled,omitempty"`
 }
 
 // handleListMeshes handles listing meshes for the current user
@@ -173,7 +179,8 @@ func (s *Server) handleUpdateMesh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if user has at least admin access
-	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+	level, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			writeError(w, http.StatusNotFound, "Mesh not found")
 			return
@@ -182,6 +189,11 @@ func (s *Server) handleUpdateMesh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.AdminKeyDownloadEnabled != nil && level != AccessLevelOwner {
+		writeError(w, http.StatusForbidden, "Only mesh owners can change admin key downloads")
+		return
+	}
+
 	// Validate frequency slot if provided
 	// UI uses 1-indexed slots: 0 = hash default, 1-N = radio slots 0 to N-1
 	// So valid UI range is 0 to maxRadioSlot + 1
@@ -215,25 +227,62 @@ func (s *Server) handleUpdateMesh(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.OfflineAfterSeconds != nil && *req.OfflineAfterSeconds <= 0 {
+		writeError(w, http.StatusBadRequest, "Offline threshold must be positive")
+		return
+	}
+
 	var freqSlot pgtype.Int4
 	if req.FrequencySlot != nil {
 		freqSlot = pgtype.Int4{Int32: *req.FrequencySlot, Valid: true}
 	}
+	var offlineAfter pgtype.Int4
+	if req.OfflineAfterSeconds != nil {
+		offlineAfter = pgtype.Int4{Int32: *req.OfflineAfterSeconds, Valid: true}
+	}
+	var downloadEnabled pgtype.Bool
+	if req.AdminKeyDownloadEnabled != nil {
+		downloadEnabled = pgtype.Bool{Bool: *req.AdminKeyDownloadEnabled, Valid: true}
+	}
 
 	params := meshdb.UpdateMeshParams{
-		ID:            meshID,
-		Name:          req.Name,
-		Description:   req.Description,
-		LoraRegion:    req.LoraRegion,
-		ModemPreset:   req.ModemPreset,
-		FrequencySlot: freqSlot,
+		ID:                      meshID,
+		Name:                    req.Name,
+		Description:             req.Description,
+		LoraRegion:              req.LoraRegion,
+		ModemPreset:             req.ModemPreset,
+		FrequencySlot:           freqSlot,
+		OfflineAfterSeconds:     offlineAfter,
+		AdminKeyDownloadEnabled: downloadEnabled,
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
 	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
 
-	updatedMesh, err := s.DB().UpdateMesh(r.Context(), params)
+	updatedMesh, err := qtx.UpdateMesh(r.Context(), params)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to update mesh")
 		return
 	}
+	// The LoRa settings are part of every node's config
+	if err := refreshMeshPendingChanges(r.Context(), qtx, meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit mesh")
+		return
+	}
+	// Unnamed channels are named after the modem preset
+	s.channels.Invalidate(meshID)
 
 	writeJSON(w, http.StatusOK, updatedMesh)
 }
@@ -267,6 +316,7 @@ func (s *Server) handleDeleteMesh(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "Failed to delete mesh")
 		return
 	}
+	s.channels.Invalidate(meshID)
 
 	writeJSON(w, http.StatusOK, map[string]string{
 		"message": "Mesh deleted successfully",
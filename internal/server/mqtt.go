@@ -0,0 +1,243 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// MQTTConfigRequest represents a request to set a mesh's MQTT subscription
+type MQTTConfigRequest struct {
+	BrokerURL string  `json:"broker_url"`
+	TopicRoot string  `json:"topic_root"`
+	Username  *string `json:"username,omitempty"`
+	// Password is kept unchanged when omitted
+	Password *string `json:"password,omitempty"`
+	Enabled  *bool   `json:"enabled,omitempty"`
+}
+
+// MQTTConfigResponse represents a mesh's MQTT subscription. The password is
+// never returned.
+type MQTTConfigResponse struct {
+	MeshID      int64     `json:"mesh_id"`
+	BrokerURL   string    `json:"broker_url"`
+	TopicRoot   string    `json:"topic_root"`
+	Username    *string   `json:"username"`
+	HasPassword bool      `json:"has_password"`
+	Enabled     bool      `json:"enabled"`
+	Connected   bool      `json:"connected"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// newMQTTConfigResponse builds an MQTTConfigResponse
+func (s *Server) newMQTTConfigResponse(cfg meshdb.MeshMqtt) MQTTConfigResponse {
+	resp := MQTTConfigResponse{
+		MeshID:      cfg.MeshID,
+		BrokerURL:   cfg.BrokerUrl,
+		TopicRoot:   cfg.TopicRoot,
+		Username:    cfg.Username,
+		HasPassword: cfg.Password != nil && *cfg.Password != "",
+		Enabled:     cfg.Enabled,
+		CreatedAt:   cfg.CreatedAt,
+		UpdatedAt:   cfg.UpdatedAt,
+	}
+	if sub := s.mqtt.Subscriber(cfg.MeshID); sub != nil {
+		resp.Connected = sub.Connected()
+	}
+	return resp
+}
+
+// validateBrokerURL checks that a broker URL is one the MQTT client can
+// connect to
+func validateBrokerURL(brokerURL string) error {
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return fmt.Errorf("invalid broker URL")
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+	default:
+		return fmt.Errorf("broker URL scheme must be tcp, mqtt, ssl, tls, mqtts, ws or wss")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("broker URL must include a host")
+	}
+	return nil
+}
+
+// validateTopicRoot checks that a topic root is a plain topic prefix
+func validateTopicRoot(topicRoot string) error {
+	if strings.Trim(topicRoot, "/") == "" {
+		return fmt.Errorf("topic_root is required")
+	}
+	if strings.ContainsAny(topicRoot, "#+") {
+		return fmt.Errorf("topic_root must not contain wildcards")
+	}
+	return nil
+}
+
+// handleGetMeshMQTT handles fetching a mesh's MQTT subscription
+func (s *Server) handleGetMeshMQTT(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access (the config holds credentials)
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	cfg, err := s.DB().GetMeshMQTT(r.Context(), meshID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "MQTT not configured")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get MQTT config")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.newMQTTConfigResponse(cfg))
+}
+
+// handleUpdateMeshMQTT handles setting a mesh's MQTT subscription, which
+// takes effect immediately
+func (s *Server) handleUpdateMeshMQTT(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	var req MQTTConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := validateBrokerURL(req.BrokerURL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateTopicRoot(req.TopicRoot); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	cfg, err := s.DB().UpsertMeshMQTT(r.Context(), meshdb.UpsertMeshMQTTParams{
+		MeshID:    meshID,
+		BrokerUrl: req.BrokerURL,
+		TopicRoot: req.TopicRoot,
+		Username:  req.Username,
+		Password:  req.Password,
+		Enabled:   enabled,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update MQTT config")
+		return
+	}
+
+	if err := s.mqtt.Reload(r.Context(), meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to restart MQTT subscription")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.newMQTTConfigResponse(cfg))
+}
+
+// handleDeleteMeshMQTT handles removing a mesh's MQTT subscription
+func (s *Server) handleDeleteMeshMQTT(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	if err := s.DB().DeleteMeshMQTT(r.Context(), meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete MQTT config")
+		return
+	}
+
+	if err := s.mqtt.Reload(r.Context(), meshID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to stop MQTT subscription")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "MQTT config deleted successfully",
+	})
+}
@@ -0,0 +1,615 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/internal/ota"
+	"github.com/skandragon/meshmgr/internal/secrets"
+	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/skandragon/meshmgr/meshtastic-cli/admin"
+	"github.com/skandragon/meshmgr/meshtastic-cli/meshcrypto"
+)
+
+// Apply job statuses
+const (
+	ApplyJobPending   = "pending"
+	ApplyJobRunning   = "running"
+	ApplyJobSucceeded = "succeeded"
+	ApplyJobFailed    = "failed"
+)
+
+// errNoMasterKey means a stored private key can't be opened because no
+// master key is configured
+var errNoMasterKey = errors.New("server master key not configured")
+
+const (
+	// applyJobTimeout bounds how long a push may take, retries included
+	applyJobTimeout = 15 * time.Minute
+
+	defaultApplyJobsLimit = 20
+	maxApplyJobsLimit     = 100
+)
+
+// ApplyJobResponse is an apply job with the log of every admin message sent
+type ApplyJobResponse struct {
+	meshdb.NodeApplyJob
+	Log []meshdb.NodeApplyJobLog `json:"log"`
+}
+
+// otaDesired converts an effective config into what the OTA push writes
+func otaDesired(effective EffectiveConfigResponse) (ota.Desired, error) {
+	desired := ota.Desired{
+		ShortName:     effective.ShortName,
+		LongName:      effective.LongName,
+		Unmessageable: effective.Unmessageable,
+		Config:        map[string]json.RawMessage{},
+		ModuleConfig:  map[string]json.RawMessage{},
+	}
+	for name, section := range effective.Config {
+		data, err := json.Marshal(section)
+		if err != nil {
+			return ota.Desired{}, err
+		}
+		desired.Config[name] = data
+	}
+	for name, section := range effective.ModuleConfig {
+		data, err := json.Marshal(section)
+		if err != nil {
+			return ota.Desired{}, err
+		}
+		desired.ModuleConfig[name] = data
+	}
+	for _, ch := range effective.Channels {
+		data, err := json.Marshal(ch)
+		if err != nil {
+			return ota.Desired{}, err
+		}
+		desired.Channels = append(desired.Channels, data)
+	}
+	return desired, nil
+}
+
+// nodePublicKey returns the public key a node has: the one last applied to
+// it, or the one configured if nothing has been applied
+func nodePublicKey(node meshdb.Node) ([]byte, error) {
+	encoded := node.AppliedPublicKey
+	if encoded == nil || *encoded == "" {
+		encoded = node.PublicKey
+	}
+	if encoded == nil || *encoded == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(*encoded)
+}
+
+// applySigningKey picks the mesh admin key that pushes are sent with and
+// returns its private key. Nodes decrypt admin messages with the public key
+// of the radio that sent them, so this must be the gateway radio's own
+// keypair: either the configured gateway admin key, or a key the server
+// generated for the mesh and loaded into the radio, opened with the master
+// key. The key is nil if the mesh has neither.
+func (s *Server) applySigningKey(ctx context.Context, meshID int64, radioKey []byte) (*meshdb.AdminKey, []byte, error) {
+	keys, err := s.DB().ListAdminKeysByMesh(ctx, meshID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(s.config.Gateway.AdminKey) > 0 {
+		public, err := meshcrypto.PublicKey(s.config.Gateway.AdminKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range keys {
+			if decoded, err := adminKeyPublicKey(key); err == nil && bytes.Equal(decoded, public) {
+				return &key, s.config.Gateway.AdminKey, nil
+			}
+		}
+	}
+
+	if len(radioKey) == 0 {
+		return nil, nil, nil
+	}
+	for _, key := range keys {
+		if !key.HasPrivateKey {
+			continue
+		}
+		if decoded, err := adminKeyPublicKey(key); err != nil || !bytes.Equal(decoded, radioKey) {
+			continue
+		}
+		keyring := secrets.Current()
+		if keyring == nil {
+			return nil, nil, errNoMasterKey
+		}
+		private, err := keyring.Open(key.PrivateKeyEncrypted)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt admin key %d: %w", key.ID, err)
+		}
+		return &key, private, nil
+	}
+	return nil, nil, nil
+}
+
+// handleApplyNode handles pushing a node's effective config to it over the
+// mesh through the gateway radio. The push runs in the background; the
+// job it returns reports its progress.
+func (s *Server) handleApplyNode(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	target, err := s.prepareApply(r.Context(), meshID)
+	if err != nil {
+		writeApplyError(w, err)
+		return
+	}
+	job, err := s.startApplyJob(r.Context(), target, node, user.ID)
+	if err != nil {
+		writeApplyError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// applyError is a reason an apply job can't be started, with the HTTP
+// status to report it with
+type applyError struct {
+	status  int
+	message string
+}
+
+func (e *applyError) Error() string {
+	return e.message
+}
+
+// writeApplyError reports why an apply job couldn't be started
+func writeApplyError(w http.ResponseWriter, err error) {
+	var ae *applyError
+	if errors.As(err, &ae) {
+		writeError(w, ae.status, ae.message)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// applyTarget is what every apply job in a mesh is sent with
+type applyTarget struct {
+	localNode uint32
+	adminKey  meshdb.AdminKey
+	// privateKey is the private half of adminKey
+	privateKey []byte
+	mesh       meshdb.Mesh
+	channels   []meshdb.MeshChannel
+	adminKeys  []meshdb.AdminKey
+}
+
+// prepareApply checks that the gateway can push config to nodes of a mesh
+// and loads what the pushes need
+func (s *Server) prepareApply(ctx context.Context, meshID int64) (*applyTarget, error) {
+	if s.gateway == nil {
+		return nil, &applyError{http.StatusServiceUnavailable, "Gateway not configured"}
+	}
+	status := s.gateway.Status()
+	if !status.ConfigComplete {
+		return nil, &applyError{http.StatusServiceUnavailable, "Gateway radio not connected"}
+	}
+
+	adminKey, privateKey, err := s.applySigningKey(ctx, meshID, status.PublicKey)
+	if err != nil {
+		if errors.Is(err, errNoMasterKey) {
+			return nil, &applyError{http.StatusServiceUnavailable, "Server master key not configured"}
+		}
+		return nil, &applyError{http.StatusInternalServerError, "Failed to load admin key"}
+	}
+	if adminKey == nil {
+		return nil, &applyError{http.StatusConflict, "Gateway radio's key is not a usable admin key of this mesh"}
+	}
+
+	mesh, err := s.DB().GetMeshByID(ctx, meshID)
+	if err != nil {
+		return nil, &applyError{http.StatusInternalServerError, "Failed to get mesh"}
+	}
+	channels, err := s.DB().ListMeshChannels(ctx, meshID)
+	if err != nil {
+		return nil, &applyError{http.StatusInternalServerError, "Failed to list channels"}
+	}
+	adminKeys, err := s.DB().ListAdminKeysByMesh(ctx, meshID)
+	if err != nil {
+		return nil, &applyError{http.StatusInternalServerError, "Failed to list admin keys"}
+	}
+
+	return &applyTarget{
+		localNode:  status.LocalNode,
+		adminKey:   *adminKey,
+		privateKey: privateKey,
+		mesh:       mesh,
+		channels:   channels,
+		adminKeys:  adminKeys,
+	}, nil
+}
+
+// startApplyJob creates an apply job for a node and starts pushing its
+// effective config in the background
+func (s *Server) startApplyJob(ctx context.Context, target *applyTarget, node meshdb.Node, userID int64) (meshdb.NodeApplyJob, error) {
+	if node.NodeNum == nil {
+		return meshdb.NodeApplyJob{}, &applyError{http.StatusBadRequest, "Node has no node number"}
+	}
+	nodeNum := uint32(*node.NodeNum)
+	nodeKey, err := nodePublicKey(node)
+	if err != nil || len(nodeKey) != meshcrypto.KeySize {
+		return meshdb.NodeApplyJob{}, &applyError{http.StatusBadRequest, "Node has no public key"}
+	}
+
+	effective, err := buildEffectiveConfig(target.mesh, node, target.channels)
+	if err != nil {
+		return meshdb.NodeApplyJob{}, &applyError{http.StatusInternalServerError, "Failed to build effective config: " + err.Error()}
+	}
+	setEffectiveAdminKeys(&effective, target.adminKeys)
+	desired, err := otaDesired(effective)
+	if err != nil {
+		return meshdb.NodeApplyJob{}, &applyError{http.StatusInternalServerError, "Failed to build effective config: " + err.Error()}
+	}
+
+	job, err := s.DB().CreateApplyJob(ctx, meshdb.CreateApplyJobParams{
+		NodeID:      node.ID,
+		AdminKeyID:  &target.adminKey.ID,
+		RequestedBy: userID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return meshdb.NodeApplyJob{}, &applyError{http.StatusConflict, "An apply job is already running for this node"}
+		}
+		return meshdb.NodeApplyJob{}, &applyError{http.StatusInternalServerError, "Failed to create apply job"}
+	}
+
+	cipher := admin.PKICipher{
+		PrivateKey: target.privateKey,
+		NodeKeys:   map[uint32][]byte{nodeNum: nodeKey},
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.runApplyJob(job, node.MeshID, target.adminKey.ID, target.localNode, nodeNum, cipher, desired)
+	}()
+
+	return job, nil
+}
+
+// runApplyJob pushes desired to a node through the gateway, logging every
+// admin message, and records the outcome. Shutting the server down cancels
+// it.
+func (s *Server) runApplyJob(job meshdb.NodeApplyJob, meshID, adminKeyID int64, localNode, nodeNum uint32, cipher admin.Cipher, desired ota.Desired) {
+	ctx, cancel := context.WithTimeout(s.jobsCtx, applyJobTimeout)
+	defer cancel()
+	q := s.DB()
+
+	if err := q.StartApplyJob(ctx, job.ID); err != nil {
+		log.Printf("Apply job %d: failed to start: %v", job.ID, err)
+	}
+
+	opts := []admin.Option{admin.WithLocalNode(localNode)}
+	if nodeNum != localNode {
+		opts = append(opts, admin.WithCipher(cipher))
+	}
+	client := admin.NewClient(s.gateway, opts...)
+
+	// Replies come back through the gateway's event stream
+	events, unsubscribe := s.gateway.Subscribe(100)
+	defer unsubscribe()
+	go func() {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event.Type == gateway.EventPacket {
+					client.HandlePacket(event.Packet)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	pusher := ota.New(client, ota.WithLog(func(step ota.Step) {
+		var detail *string
+		if step.Detail != "" {
+			detail = &step.Detail
+		}
+		err := q.AddApplyJobLog(ctx, meshdb.AddApplyJobLogParams{
+			JobID:   job.ID,
+			Message: step.Message,
+			Attempt: int32(step.Attempt),
+			Outcome: step.Outcome,
+			Detail:  detail,
+		})
+		if err != nil {
+			log.Printf("Apply job %d: failed to log %s: %v", job.ID, step.Message, err)
+		}
+	}))
+
+	applied, err := pusher.Push(ctx, nodeNum, desired)
+	if err == nil {
+		err = s.recordApplied(ctx, meshID, job.NodeID, adminKeyID, applied)
+	}
+
+	finish := meshdb.FinishApplyJobParams{ID: job.ID, Status: ApplyJobSucceeded}
+	if err != nil {
+		message := err.Error()
+		if s.jobsCtx.Err() != nil {
+			message = "interrupted by server shutdown"
+		}
+		finish.Status = ApplyJobFailed
+		finish.Error = &message
+	}
+	// The job is finished even if the push ran out of time
+	if _, err := q.FinishApplyJob(context.Background(), finish); err != nil {
+		log.Printf("Apply job %d: failed to finish: %v", job.ID, err)
+	}
+}
+
+// recordApplied stores what a push wrote to a node in its applied_*
+// columns, and which admin keys the node now holds. If the push didn't
+// manage admin keys, the node at least accepts the one it was sent with.
+func (s *Server) recordApplied(ctx context.Context, meshID, nodeID, adminKeyID int64, applied ota.Applied) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	node, err := qtx.UpdateNodeAppliedState(ctx, meshdb.UpdateNodeAppliedStateParams{
+		ID:                   nodeID,
+		AppliedName:          &applied.ShortName,
+		AppliedLongName:      &applied.LongName,
+		AppliedRole:          applied.Role,
+		AppliedPublicKey:     applied.PublicKey,
+		AppliedPrivateKey:    (*secrets.Text)(applied.PrivateKey),
+		AppliedUnmessageable: pgtype.Bool{Bool: applied.Unmessageable, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update applied state: %w", err)
+	}
+	if _, err := refreshPendingChanges(ctx, qtx, node); err != nil {
+		return err
+	}
+	if applied.AdminKeys != nil {
+		if err := syncNodeAdminKeys(ctx, qtx, meshID, nodeID, applied.AdminKeys); err != nil {
+			return err
+		}
+	} else {
+		_, err = qtx.AssignAdminKeyToNode(ctx, meshdb.AssignAdminKeyToNodeParams{
+			NodeID:     nodeID,
+			AdminKeyID: adminKeyID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record admin key: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// handleListNodeApplyJobs handles listing a node's apply jobs, newest first
+func (s *Server) handleListNodeApplyJobs(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	limit := int32(defaultApplyJobsLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || n <= 0 || n > maxApplyJobsLimit {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = int32(n)
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	jobs, err := s.DB().ListNodeApplyJobs(r.Context(), meshdb.ListNodeApplyJobsParams{
+		NodeID:   nodeID,
+		RowLimit: limit,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list apply jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []meshdb.NodeApplyJob{}
+	}
+
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// handleGetNodeApplyJob handles getting an apply job with its message log
+func (s *Server) handleGetNodeApplyJob(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	jobIDStr := r.PathValue("jobID")
+	jobID, err := strconv.ParseInt(jobIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	job, err := s.DB().GetApplyJob(r.Context(), jobID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Apply job not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get apply job")
+		return
+	}
+
+	if job.NodeID != nodeID {
+		writeError(w, http.StatusNotFound, "Apply job not found")
+		return
+	}
+
+	entries, err := s.DB().ListApplyJobLog(r.Context(), jobID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get apply job log")
+		return
+	}
+	if entries == nil {
+		entries = []meshdb.NodeApplyJobLog{}
+	}
+
+	writeJSON(w, http.StatusOK, ApplyJobResponse{NodeApplyJob: job, Log: entries})
+}
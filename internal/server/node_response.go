@@ -0,0 +1,299 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/secrets"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// NodeResponse represents a node as returned by the API. Private keys are
+// left out, and the secrets in the raw device config blanked, for every
+// role; the *_redacted flags say whether there was one to hide. Admins read
+// them from the node's secrets endpoint.
+type NodeResponse struct {
+	ID                        int64         `json:"id"`
+	MeshID                    int64         `json:"mesh_id"`
+	HardwareID                string        `json:"hardware_id"`
+	Name                      string        `json:"name"`
+	LongName                  string        `json:"long_name"`
+	Role                      *string       `json:"role"`
+	PublicKey                 *string       `json:"public_key"`
+	PrivateKeyRedacted        bool          `json:"private_key_redacted,omitempty"`
+	LastSeen                  *time.Time    `json:"last_seen"`
+	Status                    *string       `json:"status"`
+	CreatedAt                 time.Time     `json:"created_at"`
+	UpdatedAt                 time.Time     `json:"updated_at"`
+	AppliedName               *string       `json:"applied_name"`
+	AppliedLongName           *string       `json:"applied_long_name"`
+	AppliedRole               *string       `json:"applied_role"`
+	AppliedPublicKey          *string       `json:"applied_public_key"`
+	AppliedPrivateKeyRedacted bool          `json:"applied_private_key_redacted,omitempty"`
+	AppliedUnmessageable      pgtype.Bool   `json:"applied_unmessageable"`
+	Unmessageable             bool          `json:"unmessageable"`
+	ConfigAppliedAt           *time.Time    `json:"config_applied_at"`
+	PendingChanges            bool          `json:"pending_changes"`
+	NodeNum                   *int64        `json:"node_num"`
+	DeviceID                  []byte        `json:"device_id"`
+	FirmwareVersion           *string       `json:"firmware_version"`
+	HwModel                   pgtype.Int4   `json:"hw_model"`
+	ShortName                 *string       `json:"short_name"`
+	RawDeviceConfig           []byte        `json:"raw_device_config"`
+	RawDeviceConfigRedacted   bool          `json:"raw_device_config_redacted,omitempty"`
+	ConfigOverrides           []byte        `json:"config_overrides"`
+	ConfigImportedAt          *time.Time    `json:"config_imported_at"`
+	Latitude                  pgtype.Float8 `json:"latitude"`
+	Longitude                 pgtype.Float8 `json:"longitude"`
+	Altitude                  pgtype.Int4   `json:"altitude"`
+	PositionPrecision         pgtype.Int4   `json:"position_precision"`
+	PositionAt                *time.Time    `json:"position_at"`
+}
+
+// NodeSecretsResponse holds the secrets left out of NodeResponse
+type NodeSecretsResponse struct {
+	NodeID            int64   `json:"node_id"`
+	PrivateKey        *string `json:"private_key"`
+	AppliedPrivateKey *string `json:"applied_private_key"`
+	RawDeviceConfig   []byte  `json:"raw_device_config"`
+}
+
+// newNodeResponse builds a NodeResponse, hiding the node's secrets
+func newNodeResponse(node meshdb.Node) NodeResponse {
+	resp := NodeResponse{
+		ID:                        node.ID,
+		MeshID:                    node.MeshID,
+		HardwareID:                node.HardwareID,
+		Name:                      node.Name,
+		LongName:                  node.LongName,
+		Role:                      node.Role,
+		PublicKey:                 node.PublicKey,
+		PrivateKeyRedacted:        node.PrivateKey != nil && *node.PrivateKey != "",
+		LastSeen:                  node.LastSeen,
+		Status:                    node.Status,
+		CreatedAt:                 node.CreatedAt,
+		UpdatedAt:                 node.UpdatedAt,
+		AppliedName:               node.AppliedName,
+		AppliedLongName:           node.AppliedLongName,
+		AppliedRole:               node.AppliedRole,
+		AppliedPublicKey:          node.AppliedPublicKey,
+		AppliedPrivateKeyRedacted: node.AppliedPrivateKey != nil && *node.AppliedPrivateKey != "",
+		AppliedUnmessageable:      node.AppliedUnmessageable,
+		Unmessageable:             node.Unmessageable,
+		ConfigAppliedAt:           node.ConfigAppliedAt,
+		PendingChanges:            node.PendingChanges,
+		NodeNum:                   node.NodeNum,
+		DeviceID:                  node.DeviceID,
+		FirmwareVersion:           node.FirmwareVersion,
+		HwModel:                   node.HwModel,
+		ShortName:                 node.ShortName,
+		ConfigOverrides:           node.ConfigOverrides,
+		ConfigImportedAt:          node.ConfigImportedAt,
+		Latitude:                  node.Latitude,
+		Longitude:                 node.Longitude,
+		Altitude:                  node.Altitude,
+		PositionPrecision:         node.PositionPrecision,
+		PositionAt:                node.PositionAt,
+	}
+	resp.RawDeviceConfig, resp.RawDeviceConfigRedacted = redactDeviceConfig(node.RawDeviceConfig)
+	return resp
+}
+
+// newNodeResponses builds a NodeResponse for each node
+func newNodeResponses(nodes []meshdb.Node) []NodeResponse {
+	resp := make([]NodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		resp = append(resp, newNodeResponse(node))
+	}
+	return resp
+}
+
+// redactDeviceConfig removes the private key, Wi-Fi PSK, MQTT password and
+// channel PSKs from a raw device config, reporting whether any were found.
+// A config that can't be parsed is dropped entirely.
+func redactDeviceConfig(raw []byte) ([]byte, bool) {
+	if len(raw) == 0 {
+		return raw, false
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, true
+	}
+
+	redacted := false
+	for _, path := range secrets.DeviceConfigSecretPaths {
+		if deleteConfigPath(doc, path) {
+			redacted = true
+		}
+	}
+	if channels, ok := doc["channels"].([]any); ok {
+		for _, ch := range channels {
+			if channel, ok := ch.(map[string]any); ok && deleteConfigPath(channel, secrets.ChannelSecretPath) {
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		return raw, false
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, true
+	}
+	return data, true
+}
+
+// deleteConfigPath removes the value at a dotted path in a nested
+// document, reporting whether there was one
+func deleteConfigPath(doc map[string]any, path string) bool {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		current = child
+	}
+	last := parts[len(parts)-1]
+	if _, ok := current[last]; !ok {
+		return false
+	}
+	delete(current, last)
+	return true
+}
+
+// recordNodeSecretReveal records that a user was handed a node's secrets
+func (s *Server) recordNodeSecretReveal(r *http.Request, user *meshdb.User, node meshdb.Node) error {
+	remoteAddr := r.RemoteAddr
+	userAgent := r.UserAgent()
+	_, err := s.DB().CreateNodeSecretReveal(r.Context(), meshdb.CreateNodeSecretRevealParams{
+		MeshID:     node.MeshID,
+		NodeID:     &node.ID,
+		HardwareID: node.HardwareID,
+		UserID:     user.ID,
+		RemoteAddr: &remoteAddr,
+		UserAgent:  &userAgent,
+	})
+	return err
+}
+
+// handleGetNodeSecrets handles revealing a node's private keys and its
+// unredacted device config to mesh admins
+func (s *Server) handleGetNodeSecrets(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least admin access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelAdmin); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	// The secrets are only handed out once the reveal is on record
+	if err := s.recordNodeSecretReveal(r, user, node); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record secret reveal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NodeSecretsResponse{
+		NodeID:            node.ID,
+		PrivateKey:        (*string)(node.PrivateKey),
+		AppliedPrivateKey: (*string)(node.AppliedPrivateKey),
+		RawDeviceConfig:   node.RawDeviceConfig,
+	})
+}
+
+// handleListNodeSecretReveals handles listing the node secret reveals of a
+// mesh, newest first
+func (s *Server) handleListNodeSecretReveals(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user is the mesh owner
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelOwner); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	reveals, err := s.DB().ListNodeSecretReveals(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list node secret reveals")
+		return
+	}
+	if reveals == nil {
+		reveals = []meshdb.NodeSecretReveal{}
+	}
+
+	writeJSON(w, http.StatusOK, reveals)
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const (
+	defaultStatusHistoryLimit = 100
+	maxStatusHistoryLimit     = 1000
+
+	// defaultStatusChangesWindow is how far back status changes are counted
+	// when no since time is given
+	defaultStatusChangesWindow = 24 * time.Hour
+)
+
+// handleGetNodeStatusHistory handles listing a node's status transitions,
+// newest first
+func (s *Server) handleGetNodeStatusHistory(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	limit := int32(defaultStatusHistoryLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || n <= 0 || n > maxStatusHistoryLimit {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = int32(n)
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	history, err := s.DB().ListNodeStatusHistory(r.Context(), meshdb.ListNodeStatusHistoryParams{
+		NodeID:   nodeID,
+		RowLimit: limit,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list status history")
+		return
+	}
+	if history == nil {
+		history = []meshdb.NodeStatusHistory{}
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+// handleGetMeshStatusChanges handles counting status transitions per node
+// since a time (RFC 3339, default 24 hours ago), busiest first, so nodes
+// that keep dropping off the mesh stand out
+func (s *Server) handleGetMeshStatusChanges(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	since := time.Now().Add(-defaultStatusChangesWindow)
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid since time")
+			return
+		}
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	changes, err := s.DB().ListMeshStatusChanges(r.Context(), meshdb.ListMeshStatusChangesParams{
+		MeshID: meshID,
+		Since:  since,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list status changes")
+		return
+	}
+	if changes == nil {
+		changes = []meshdb.ListMeshStatusChangesRow{}
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+}
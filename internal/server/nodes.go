@@ -18,10 +18,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/ingest"
+	"github.com/skandragon/meshmgr/internal/secrets"
 	"github.com/skandragon/meshmgr/meshdb"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
 )
 
 // CreateNodeRequest represents a request to create a node
@@ -84,7 +88,7 @@ func (s *Server) handleListNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, nodes)
+	writeJSON(w, http.StatusOK, newNodeResponses(nodes))
 }
 
 // handleGetNode handles getting a single node
@@ -135,7 +139,7 @@ func (s *Server) handleGetNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, node)
+	writeJSON(w, http.StatusOK, newNodeResponse(node))
 }
 
 // handleCreateNode handles creating a new node
@@ -190,14 +194,24 @@ func (s *Server) handleCreateNode(w http.ResponseWriter, r *http.Request) {
 		unmessageable = *req.Unmessageable
 	}
 
-	node, err := s.DB().CreateNode(r.Context(), meshdb.CreateNodeParams{
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	node, err := qtx.CreateNode(r.Context(), meshdb.CreateNodeParams{
 		MeshID:        meshID,
 		HardwareID:    req.HardwareID,
 		Name:          req.Name,
 		LongName:      req.LongName,
 		Role:          req.Role,
 		PublicKey:     req.PublicKey,
-		PrivateKey:    req.PrivateKey,
+		PrivateKey:    (*secrets.Text)(req.PrivateKey),
 		Status:        req.Status,
 		Unmessageable: unmessageable,
 	})
@@ -205,8 +219,18 @@ func (s *Server) handleCreateNode(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "Failed to create node")
 		return
 	}
+	node, err = refreshPendingChanges(r.Context(), qtx, node)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
 
-	writeJSON(w, http.StatusCreated, node)
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit node")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newNodeResponse(node))
 }
 
 // handleUpdateNode handles updating a node
@@ -274,13 +298,23 @@ func (s *Server) handleUpdateNode(w http.ResponseWriter, r *http.Request) {
 		pendingChanges = pgtype.Bool{Bool: *req.PendingChanges, Valid: true}
 	}
 
-	updatedNode, err := s.DB().UpdateNode(r.Context(), meshdb.UpdateNodeParams{
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	updatedNode, err := qtx.UpdateNode(r.Context(), meshdb.UpdateNodeParams{
 		ID:             nodeID,
 		Name:           req.Name,
 		LongName:       req.LongName,
 		Role:           req.Role,
 		PublicKey:      req.PublicKey,
-		PrivateKey:     req.PrivateKey,
+		PrivateKey:     (*secrets.Text)(req.PrivateKey),
 		Status:         req.Status,
 		Unmessageable:  unmessageable,
 		PendingChanges: pendingChanges,
@@ -290,8 +324,21 @@ func (s *Server) handleUpdateNode(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "Failed to update node")
 		return
 	}
+	// An explicit pending_changes wins over the computed one
+	if req.PendingChanges == nil {
+		updatedNode, err = refreshPendingChanges(r.Context(), qtx, updatedNode)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit node")
+		return
+	}
 
-	writeJSON(w, http.StatusOK, updatedNode)
+	writeJSON(w, http.StatusOK, newNodeResponse(updatedNode))
 }
 
 // handleUpdateNodeStatus handles updating a node's status
@@ -353,8 +400,18 @@ func (s *Server) handleUpdateNodeStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
 	// Update the node status (this also updates last_seen)
-	updatedNode, err := s.DB().UpdateNodeStatus(r.Context(), meshdb.UpdateNodeStatusParams{
+	updatedNode, err := qtx.UpdateNodeStatus(r.Context(), meshdb.UpdateNodeStatusParams{
 		ID:     nodeID,
 		Status: &req.Status,
 	})
@@ -362,8 +419,17 @@ func (s *Server) handleUpdateNodeStatus(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusInternalServerError, "Failed to update node status")
 		return
 	}
+	if err := ingest.RecordStatusChange(r.Context(), qtx, nodeID, node.Status, updatedNode.Status, ingest.StatusSourceManual); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record status change")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update node status")
+		return
+	}
 
-	writeJSON(w, http.StatusOK, updatedNode)
+	writeJSON(w, http.StatusOK, newNodeResponse(updatedNode))
 }
 
 // handleDeleteNode handles deleting a node
@@ -427,19 +493,35 @@ func (s *Server) handleDeleteNode(w http.ResponseWriter, r *http.Request) {
 
 // ImportNodeConfigRequest represents the device config JSON from meshtastic-cli
 type ImportNodeConfigRequest struct {
-	NodeNum          int64           `json:"node_num"`
-	DeviceID         []byte          `json:"device_id"`  // MAC address, base64 encoded in JSON
-	HardwareID       string          `json:"hardware_id"`
-	LongName         string          `json:"long_name"`
-	ShortName        string          `json:"short_name"`
-	Metadata         json.RawMessage `json:"metadata,omitempty"`
-	Config           json.RawMessage `json:"config,omitempty"`
-	ModuleConfig     json.RawMessage `json:"module_config,omitempty"`
-	Channels         json.RawMessage `json:"channels,omitempty"`
-	ConfigComplete   bool            `json:"config_complete"`
+	NodeNum        int64           `json:"node_num"`
+	DeviceID       []byte          `json:"device_id"` // MAC address, base64 encoded in JSON
+	HardwareID     string          `json:"hardware_id"`
+	LongName       string          `json:"long_name"`
+	ShortName      string          `json:"short_name"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	Config         json.RawMessage `json:"config,omitempty"`
+	ModuleConfig   json.RawMessage `json:"module_config,omitempty"`
+	Channels       json.RawMessage `json:"channels,omitempty"`
+	DeviceMetrics  json.RawMessage `json:"device_metrics,omitempty"`
+	Position       json.RawMessage `json:"position,omitempty"`
+	ConfigComplete bool            `json:"config_complete"`
+}
+
+// ImportNodeConfigResponse represents the result of a node config import
+type ImportNodeConfigResponse struct {
+	NodeResponse
+	// ChannelsImported is true if the device's channels replaced the mesh channel set
+	ChannelsImported bool `json:"channels_imported"`
+	// ChannelMismatches lists device channels that differ from the mesh channel set
+	ChannelMismatches []ChannelMismatch `json:"channel_mismatches"`
 }
 
-// handleImportNodeConfig handles importing configuration from a device scan
+// handleImportNodeConfig handles importing configuration from a device scan.
+//
+// The device's channels become the mesh channel set when the mesh has no
+// channels yet, or when the import_channels=true query parameter is given.
+// Otherwise they are compared against the mesh channel set and any
+// differences are reported in the response.
 func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
 	if user == nil {
@@ -480,6 +562,39 @@ func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	forceChannelImport := false
+	if v := r.URL.Query().Get("import_channels"); v != "" {
+		forceChannelImport, err = strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid import_channels value")
+			return
+		}
+	}
+
+	deviceChannels, err := decodeDeviceChannels(req.Channels)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid channels: "+err.Error())
+		return
+	}
+
+	var deviceMetrics *pb.DeviceMetrics
+	if len(req.DeviceMetrics) > 0 && string(req.DeviceMetrics) != "null" {
+		deviceMetrics = &pb.DeviceMetrics{}
+		if err := json.Unmarshal(req.DeviceMetrics, deviceMetrics); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid device_metrics")
+			return
+		}
+	}
+
+	var position *pb.Position
+	if len(req.Position) > 0 && string(req.Position) != "null" {
+		position = &pb.Position{}
+		if err := json.Unmarshal(req.Position, position); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid position")
+			return
+		}
+	}
+
 	// Build the raw_device_config JSON
 	rawConfig := map[string]interface{}{
 		"node_num":        req.NodeNum,
@@ -499,8 +614,10 @@ func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Extract security keys if present
+	// Extract security keys if present. adminKeys stays nil unless the
+	// device reported its security config.
 	var publicKey, privateKey *string
+	var adminKeys []string
 	if req.Config != nil {
 		var configData map[string]interface{}
 		if err := json.Unmarshal(req.Config, &configData); err == nil {
@@ -511,6 +628,14 @@ func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request)
 				if prk, ok := security["private_key"].(string); ok && prk != "" {
 					privateKey = &prk
 				}
+				adminKeys = []string{}
+				if keys, ok := security["admin_key"].([]interface{}); ok {
+					for _, key := range keys {
+						if ak, ok := key.(string); ok && ak != "" {
+							adminKeys = append(adminKeys, ak)
+						}
+					}
+				}
 			}
 		}
 	}
@@ -549,8 +674,31 @@ func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request)
 		hwModelValue = pgtype.Int4{Int32: *hwModel, Valid: true}
 	}
 
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(r.Context())
+	}()
+	qtx := s.DB().WithTx(tx)
+
+	// The previous status, if the node already exists, for its history
+	var previousStatus *string
+	existing, err := qtx.GetNodeByHardwareID(r.Context(), meshdb.GetNodeByHardwareIDParams{
+		MeshID:     meshID,
+		HardwareID: req.HardwareID,
+	})
+	if err == nil {
+		previousStatus = existing.Status
+	} else if err != pgx.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
 	// Import the node config (upsert)
-	node, err := s.DB().ImportNodeConfig(r.Context(), meshdb.ImportNodeConfigParams{
+	node, err := qtx.ImportNodeConfig(r.Context(), meshdb.ImportNodeConfigParams{
 		MeshID:          meshID,
 		HardwareID:      req.HardwareID,
 		NodeNum:         nodeNum,
@@ -561,13 +709,101 @@ func (s *Server) handleImportNodeConfig(w http.ResponseWriter, r *http.Request)
 		FirmwareVersion: firmwareVersion,
 		HwModel:         hwModelValue,
 		PublicKey:       publicKey,
-		PrivateKey:      privateKey,
+		PrivateKey:      (*secrets.Text)(privateKey),
 		RawDeviceConfig: rawConfigJSON,
 	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to import node config")
 		return
 	}
+	if err := ingest.RecordStatusChange(r.Context(), qtx, node.ID, previousStatus, node.Status, ingest.StatusSourceImport); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to record status change")
+		return
+	}
+	if adminKeys != nil {
+		if err := syncNodeAdminKeys(r.Context(), qtx, meshID, node.ID, adminKeys); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update admin keys")
+			return
+		}
+	}
+	if deviceMetrics != nil {
+		telemetry := &pb.Telemetry{
+			Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: deviceMetrics},
+		}
+		if err := ingest.StoreTelemetry(r.Context(), qtx, node.ID, time.Now(), telemetry); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to store telemetry")
+			return
+		}
+	}
+	if position != nil {
+		if err := ingest.StorePosition(r.Context(), qtx, node.ID, time.Now(), position); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to store position")
+			return
+		}
+	}
+
+	resp := ImportNodeConfigResponse{
+		ChannelMismatches: []ChannelMismatch{},
+	}
+
+	channelCount, err := qtx.CountMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to count channels")
+		return
+	}
+
+	if (channelCount == 0 || forceChannelImport) && len(deviceChannels) > 0 {
+		if err := qtx.ImportMeshChannels(r.Context(), meshID); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to replace channels")
+			return
+		}
+		for _, ch := range deviceChannels {
+			params, err := ch.upsertParams(meshID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "Failed to serialize channel settings")
+				return
+			}
+			if _, err := qtx.UpsertMeshChannel(r.Context(), params); err != nil {
+				writeError(w, http.StatusInternalServerError, "Failed to import channels")
+				return
+			}
+		}
+		resp.ChannelsImported = true
+	}
+
+	meshChannels, err := qtx.ListMeshChannels(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list channels")
+		return
+	}
+	if !resp.ChannelsImported {
+		resp.ChannelMismatches = diffChannels(deviceChannels, meshChannels)
+	}
+
+	// The imported config is what is on the device now, so recompute
+	// pending_changes. Imported channels change what every node should have.
+	if resp.ChannelsImported {
+		if err := refreshMeshPendingChanges(r.Context(), qtx, meshID); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+			return
+		}
+		node, err = qtx.GetNode(r.Context(), node.ID)
+	} else {
+		node, err = refreshPendingChanges(r.Context(), qtx, node)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update pending changes: "+err.Error())
+		return
+	}
+	resp.NodeResponse = newNodeResponse(node)
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to commit import")
+		return
+	}
+	if resp.ChannelsImported {
+		s.channels.Invalidate(meshID)
+	}
 
-	writeJSON(w, http.StatusOK, node)
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -53,7 +53,7 @@ func (s *Server) checkMeshAccess(ctx context.Context, userID, meshID int64, minL
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return "", nil // No access
+			return s.siteAdminAccess(ctx, userID, minLevel)
 		}
 		return "", err
 	}
@@ -68,6 +68,27 @@ func (s *Server) checkMeshAccess(ctx context.Context, userID, meshID int64, minL
 	return "", nil
 }
 
+// siteAdminAccess lets site administrators view any mesh for support. They
+// get no more than viewer access to meshes they have not been granted.
+func (s *Server) siteAdminAccess(ctx context.Context, userID int64, minLevel AccessLevel) (AccessLevel, error) {
+	if minLevel != AccessLevelViewer {
+		return "", nil
+	}
+
+	user, err := s.DB().GetUserByID(ctx, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	if !user.IsAdmin {
+		return "", nil
+	}
+
+	return AccessLevelViewer, nil
+}
+
 // hasAccess checks if userLevel meets the minimum required level
 func hasAccess(userLevel, minLevel AccessLevel) bool {
 	levels := map[AccessLevel]int{
@@ -0,0 +1,216 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// defaultPositionWindow is how far back a node's track goes when no from
+// time is given
+const defaultPositionWindow = 24 * time.Hour
+
+// GeoJSONFeatureCollection is a GeoJSON (RFC 7946) feature collection
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a GeoJSON feature with a point geometry
+type GeoJSONFeature struct {
+	Type       string         `json:"type"`
+	ID         int64          `json:"id"`
+	Geometry   GeoJSONPoint   `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// GeoJSONPoint is a GeoJSON point: longitude, latitude and, if known,
+// altitude
+type GeoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// nodePositionFeature converts a node's latest position to a feature
+func nodePositionFeature(row meshdb.ListMeshNodePositionsRow) GeoJSONFeature {
+	coordinates := []float64{row.Longitude, row.Latitude}
+	if row.Altitude.Valid {
+		coordinates = append(coordinates, float64(row.Altitude.Int32))
+	}
+
+	properties := map[string]any{
+		"node_id":        row.ID,
+		"hardware_id":    row.HardwareID,
+		"name":           row.Name,
+		"long_name":      row.LongName,
+		"short_name":     row.ShortName,
+		"role":           row.Role,
+		"status":         row.Status,
+		"last_seen":      row.LastSeen,
+		"position_at":    row.PositionAt,
+		"precision_bits": nil,
+		"battery_level":  nil,
+	}
+	if row.PositionPrecision.Valid {
+		properties["precision_bits"] = row.PositionPrecision.Int32
+	}
+	if row.BatteryLevel.Valid {
+		properties["battery_level"] = row.BatteryLevel.Float64
+	}
+
+	return GeoJSONFeature{
+		Type:       "Feature",
+		ID:         row.ID,
+		Geometry:   GeoJSONPoint{Type: "Point", Coordinates: coordinates},
+		Properties: properties,
+	}
+}
+
+// handleGetMeshPositionsGeoJSON handles exporting the latest position of
+// each located node in a mesh as a GeoJSON feature collection
+func (s *Server) handleGetMeshPositionsGeoJSON(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	rows, err := s.DB().ListMeshNodePositions(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list positions")
+		return
+	}
+
+	collection := GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]GeoJSONFeature, len(rows)),
+	}
+	for i, row := range rows {
+		collection.Features[i] = nodePositionFeature(row)
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(collection)
+}
+
+// handleGetNodePositions handles listing a node's position reports between
+// from and to (RFC 3339, default the last 24 hours), oldest first
+func (s *Server) handleGetNodePositions(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now()
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid to time")
+			return
+		}
+	}
+	from := to.Add(-defaultPositionWindow)
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid from time")
+			return
+		}
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "From time must be before to time")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	positions, err := s.DB().ListNodePositions(r.Context(), meshdb.ListNodePositionsParams{
+		NodeID:   nodeID,
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list positions")
+		return
+	}
+	if positions == nil {
+		positions = []meshdb.NodePosition{}
+	}
+
+	writeJSON(w, http.StatusOK, positions)
+}
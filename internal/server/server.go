@@ -16,12 +16,19 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/internal/ingest"
+	"github.com/skandragon/meshmgr/internal/migrate"
+	"github.com/skandragon/meshmgr/internal/mqtt"
+	"github.com/skandragon/meshmgr/internal/secrets"
 	"github.com/skandragon/meshmgr/meshdb"
 )
 
@@ -30,9 +37,32 @@ type Server struct {
 	config *config.Config
 	db     *pgxpool.Pool
 	mux    *http.ServeMux
+
+	// gateway is the connection to a local radio, nil if none is configured
+	gateway *gateway.Gateway
+
+	// channels caches the channel keys ingest decrypts packets with.
+	// Handlers that change a mesh's channels or preset invalidate it.
+	channels *ingest.ChannelCache
+
+	// mqtt holds the MQTT subscriptions of meshes that have one
+	mqtt *mqtt.Manager
+
+	// stopBackground stops the gateway and other background work
+	stopBackground context.CancelFunc
+
+	// httpServer serves the API once Start is called
+	httpServer *http.Server
+
+	// Apply jobs run under jobsCtx, which is cancelled on shutdown, and
+	// are tracked in jobs so shutdown can wait for them to be recorded
+	jobsCtx  context.Context
+	stopJobs context.CancelFunc
+	jobs     sync.WaitGroup
 }
 
-// New creates a new Server instance
+// New creates a new Server instance. It sets the process-wide keyring the
+// secrets column types use, so only one Server may run per process.
 func New(cfg *config.Config) (*Server, error) {
 	// Create database connection pool
 	pool, err := pgxpool.New(context.Background(), cfg.Database.ConnectionString())
@@ -46,14 +76,59 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	// Bring the schema up to date, if asked to
+	if cfg.Database.AutoMigrate {
+		migrations, err := migrate.Embedded()
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		applied, err := migrate.New(pool, migrations).Up(context.Background())
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if applied > 0 {
+			log.Printf("Applied %d database migrations", applied)
+		}
+	}
+
+	// Secrets in the database are sealed and opened with the master keys
+	keyring, err := cfg.Secrets.Keyring()
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to load master keys: %w", err)
+	}
+	secrets.SetKeyring(keyring)
+	secrets.SetPlaintext(cfg.Secrets.AllowPlaintext)
+	if keyring == nil {
+		if err := checkPlaintextSecrets(context.Background(), meshdb.New(pool), cfg.Secrets.AllowPlaintext); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	channels := ingest.NewChannelCache(pool)
 	s := &Server{
-		config: cfg,
-		db:     pool,
-		mux:    http.NewServeMux(),
+		config:   cfg,
+		db:       pool,
+		mux:      http.NewServeMux(),
+		channels: channels,
+		mqtt:     mqtt.NewManager(pool, channels),
+	}
+	s.jobsCtx, s.stopJobs = context.WithCancel(context.Background())
+	if cfg.Gateway.Enabled() {
+		s.gateway = gateway.FromConfig(&cfg.Gateway)
 	}
 
 	s.setupRoutes()
 
+	// Apply middleware to the entire mux
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: chain(s.mux, corsMiddleware, loggingMiddleware, recovererMiddleware),
+	}
+
 	return s, nil
 }
 
@@ -76,6 +151,9 @@ func (s *Server) setupRoutes() {
 	// LoRa configuration (public)
 	s.mux.HandleFunc("GET /api/lora-config", s.handleGetLoRaConfig)
 
+	// Gateway routes (protected)
+	s.mux.HandleFunc("GET /api/gateway", s.withAuth(s.handleGetGatewayStatus))
+
 	// Mesh routes (protected)
 	s.mux.HandleFunc("GET /api/meshes", s.withAuth(s.handleListMeshes))
 	s.mux.HandleFunc("POST /api/meshes", s.withAuth(s.handleCreateMesh))
@@ -83,11 +161,39 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("PUT /api/meshes/{meshID}", s.withAuth(s.handleUpdateMesh))
 	s.mux.HandleFunc("DELETE /api/meshes/{meshID}", s.withAuth(s.handleDeleteMesh))
 
+	// Mesh MQTT routes (protected)
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/mqtt", s.withAuth(s.handleGetMeshMQTT))
+	s.mux.HandleFunc("PUT /api/meshes/{meshID}/mqtt", s.withAuth(s.handleUpdateMeshMQTT))
+	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/mqtt", s.withAuth(s.handleDeleteMeshMQTT))
+
+	// Mesh access routes (protected)
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/access", s.withAuth(s.handleListMeshAccess))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/access", s.withAuth(s.handleGrantMeshAccess))
+	s.mux.HandleFunc("PUT /api/meshes/{meshID}/access/{userID}", s.withAuth(s.handleUpdateMeshAccess))
+	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/access/{userID}", s.withAuth(s.handleRevokeMeshAccess))
+
+	// Channel routes (protected)
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/channels", s.withAuth(s.handleListChannels))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/channels/{index}", s.withAuth(s.handleGetChannel))
+	s.mux.HandleFunc("PUT /api/meshes/{meshID}/channels/{index}", s.withAuth(s.handleUpsertChannel))
+	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/channels/{index}", s.withAuth(s.handleDeleteChannel))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/channel-url", s.withAuth(s.handleGetChannelURL))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/channel-url", s.withAuth(s.handleImportChannelURL))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/channel-url.png", s.withAuth(s.handleGetChannelQRCode))
+
 	// Admin keys routes (protected)
 	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-keys", s.withAuth(s.handleListAdminKeys))
 	s.mux.HandleFunc("POST /api/meshes/{meshID}/admin-keys", s.withAuth(s.handleCreateAdminKey))
 	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-keys/{keyID}", s.withAuth(s.handleGetAdminKey))
 	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/admin-keys/{keyID}", s.withAuth(s.handleDeleteAdminKey))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-keys/{keyID}/private-key", s.withAuth(s.handleDownloadAdminPrivateKey))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-key-downloads", s.withAuth(s.handleListAdminKeyDownloads))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-key-rotations", s.withAuth(s.handleListAdminKeyRotations))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/admin-key-rotations", s.withAuth(s.handleCreateAdminKeyRotation))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/admin-key-rotations/{rotationID}", s.withAuth(s.handleGetAdminKeyRotation))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/admin-key-rotations/{rotationID}/push", s.withAuth(s.handlePushAdminKeyRotation))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/admin-key-rotations/{rotationID}/complete", s.withAuth(s.handleCompleteAdminKeyRotation))
+	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/admin-key-rotations/{rotationID}", s.withAuth(s.handleCancelAdminKeyRotation))
 
 	// Nodes routes (protected)
 	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes", s.withAuth(s.handleListNodes))
@@ -97,6 +203,32 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("PUT /api/meshes/{meshID}/nodes/{nodeID}", s.withAuth(s.handleUpdateNode))
 	s.mux.HandleFunc("PATCH /api/meshes/{meshID}/nodes/{nodeID}/status", s.withAuth(s.handleUpdateNodeStatus))
 	s.mux.HandleFunc("DELETE /api/meshes/{meshID}/nodes/{nodeID}", s.withAuth(s.handleDeleteNode))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/drift", s.withAuth(s.handleGetNodeDrift))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/effective-config", s.withAuth(s.handleGetNodeEffectiveConfig))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/secrets", s.withAuth(s.handleGetNodeSecrets))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/node-secret-reveals", s.withAuth(s.handleListNodeSecretReveals))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/nodes/{nodeID}/applied", s.withAuth(s.handleMarkNodeApplied))
+	s.mux.HandleFunc("POST /api/meshes/{meshID}/nodes/{nodeID}/apply", s.withAuth(s.handleApplyNode))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/apply-jobs", s.withAuth(s.handleListNodeApplyJobs))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/apply-jobs/{jobID}", s.withAuth(s.handleGetNodeApplyJob))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/status-history", s.withAuth(s.handleGetNodeStatusHistory))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/telemetry", s.withAuth(s.handleGetNodeTelemetry))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/telemetry/latest", s.withAuth(s.handleGetNodeLatestTelemetry))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/nodes/{nodeID}/positions", s.withAuth(s.handleGetNodePositions))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/drift", s.withAuth(s.handleGetMeshDrift))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/status-changes", s.withAuth(s.handleGetMeshStatusChanges))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/positions.geojson", s.withAuth(s.handleGetMeshPositionsGeoJSON))
+	s.mux.HandleFunc("GET /api/meshes/{meshID}/topology", s.withAuth(s.handleGetMeshTopology))
+
+	// Site administration
+	s.mux.HandleFunc("GET /api/admin/users", s.withAdmin(s.handleAdminListUsers))
+	s.mux.HandleFunc("PUT /api/admin/users/{userID}", s.withAdmin(s.handleAdminUpdateUser))
+	s.mux.HandleFunc("DELETE /api/admin/users/{userID}", s.withAdmin(s.handleAdminDeleteUser))
+	s.mux.HandleFunc("POST /api/admin/users/{userID}/impersonate", s.withAdmin(s.handleAdminImpersonateUser))
+	s.mux.HandleFunc("GET /api/admin/impersonations", s.withAdmin(s.handleAdminListImpersonations))
+	s.mux.HandleFunc("DELETE /api/admin/impersonations/{impersonationID}", s.withAdmin(s.handleAdminEndImpersonation))
+	s.mux.HandleFunc("GET /api/admin/audit-log", s.withAdmin(s.handleAdminListAuditLog))
+	s.mux.HandleFunc("GET /api/admin/meshes", s.withAdmin(s.handleAdminListMeshes))
 }
 
 // withAuth wraps a handler with authentication middleware
@@ -106,19 +238,119 @@ func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// withAdmin wraps a handler with authentication middleware and only lets
+// site administrators through, never on an impersonation token
+func (s *Server) withAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return s.withAuth(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r.Context())
+		if user == nil || !user.IsAdmin || getImpersonationFromContext(r.Context()) != nil {
+			writeError(w, http.StatusForbidden, "Site admin access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// checkPlaintextSecrets decides whether a server with no master key can
+// start. Without one, secrets can only be written if plaintext is allowed,
+// so a database that already holds secrets, such as one upgraded from a
+// release that stored them in plaintext, would fail every node import and
+// config write that carries one. That is refused here rather than later.
+func checkPlaintextSecrets(ctx context.Context, q *meshdb.Queries, allowPlaintext bool) error {
+	if allowPlaintext {
+		log.Printf("WARNING: No MASTER_KEY set, storing node private keys and channel PSKs in plaintext")
+		return nil
+	}
+
+	stored, err := q.CountStoredSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count stored secrets: %w", err)
+	}
+	if stored > 0 {
+		return fmt.Errorf("the database holds %d stored secrets but no MASTER_KEY is set: set MASTER_KEY and run meshmgr reencrypt, or set SECRETS_ALLOW_PLAINTEXT=true", stored)
+	}
+	log.Printf("WARNING: No MASTER_KEY set, node private keys and channel PSKs can't be stored")
+	return nil
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	log.Printf("Starting server on %s", addr)
+	log.Printf("Starting server on %s", s.httpServer.Addr)
 
-	// Apply middleware to the entire mux
-	handler := chain(s.mux, corsMiddleware, loggingMiddleware, recovererMiddleware)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+
+	// Pushes run in this process, so any left unfinished died with the
+	// last one
+	if n, err := s.DB().FailInterruptedApplyJobs(ctx); err != nil {
+		log.Printf("Failed to clean up apply jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted apply jobs as failed", n)
+	}
+	go ingest.RunSweeper(ctx, s.db, ingest.DefaultSweepInterval)
+	go ingest.RunPruner(ctx, s.db, s.config.Retention, ingest.DefaultPruneInterval)
+	go func() {
+		if err := s.mqtt.Run(ctx); err != nil {
+			log.Printf("MQTT: %v", err)
+		}
+	}()
+
+	if s.gateway != nil {
+		opts := []ingest.Option{ingest.WithChannelCache(s.channels)}
+		if s.config.Gateway.MeshID != 0 {
+			opts = append(opts, ingest.WithMesh(s.config.Gateway.MeshID))
+		}
+		// Subscribe before connecting so the first config download is seen
+		events, unsubscribe := s.gateway.Subscribe(1000)
+		go func() {
+			defer unsubscribe()
+			ingest.New(s.db, opts...).Run(ctx, events)
+		}()
+		go func() {
+			_ = s.gateway.Run(ctx)
+		}()
+	}
 
-	return http.ListenAndServe(addr, handler)
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-// Close closes the server and database connections
+// Shutdown stops accepting requests, waits for those in flight, then
+// cancels running apply jobs and waits for them to record that they failed
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+	if waitErr := s.stopApplyJobs(ctx); err == nil {
+		err = waitErr
+	}
+	return err
+}
+
+// stopApplyJobs cancels running apply jobs and waits until they finish or
+// ctx is done
+func (s *Server) stopApplyJobs(ctx context.Context) error {
+	s.stopJobs()
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("apply jobs still running: %w", ctx.Err())
+	}
+}
+
+// Close closes the server and database connections. Apply jobs are
+// cancelled and waited for first, so they can record their outcome.
 func (s *Server) Close() {
+	_ = s.stopApplyJobs(context.Background())
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
 	if s.db != nil {
 		s.db.Close()
 	}
@@ -17,22 +17,35 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/orlangure/gnomock"
 	"github.com/orlangure/gnomock/preset/postgres"
 	"github.com/skandragon/meshmgr/internal/config"
+	"github.com/skandragon/meshmgr/internal/gateway"
+	"github.com/skandragon/meshmgr/internal/ingest"
+	"github.com/skandragon/meshmgr/internal/migrate"
+	"github.com/skandragon/meshmgr/internal/mqtt"
+	"github.com/skandragon/meshmgr/internal/mqtt/mqtttest"
+	"github.com/skandragon/meshmgr/internal/ota"
+	"github.com/skandragon/meshmgr/internal/secrets"
 	"github.com/skandragon/meshmgr/meshdb"
+	"github.com/skandragon/meshmgr/meshtastic-cli/meshcrypto"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // testServer wraps Server and the gnomock container for testing
@@ -94,16 +107,38 @@ func setupTestServer(t *testing.T) *testServer {
 			JWTSecret:     "test-secret-key",
 			JWTExpiration: 24 * time.Hour,
 			BCryptCost:    4, // Use low cost for faster tests
+
+			RegistrationEnabled: true,
+		},
+		Secrets: config.SecretsConfig{
+			MasterKey:   bytes.Repeat([]byte{0x5a}, 32),
+			MasterKeyID: "1",
 		},
 	}
+	keyring, err := cfg.Secrets.Keyring()
+	require.NoError(t, err)
+	// The keyring is process-wide, so these tests can't run in parallel
+	secrets.SetKeyring(keyring)
+	secrets.SetPlaintext(false)
+	t.Cleanup(func() {
+		secrets.SetKeyring(nil)
+		secrets.SetPlaintext(false)
+	})
 
 	// Create server
+	channels := ingest.NewChannelCache(pool)
 	srv := &Server{
-		config: cfg,
-		db:     pool,
-		mux:    http.NewServeMux(),
+		config:   cfg,
+		db:       pool,
+		mux:      http.NewServeMux(),
+		channels: channels,
+		mqtt:     mqtt.NewManager(pool, channels),
 	}
+	srv.jobsCtx, srv.stopJobs = context.WithCancel(context.Background())
 	srv.setupRoutes()
+	t.Cleanup(func() {
+		_ = srv.stopApplyJobs(context.Background())
+	})
 
 	return &testServer{
 		server:    srv,
@@ -116,26 +151,10 @@ func setupTestServer(t *testing.T) *testServer {
 func runMigrations(t *testing.T, pool *pgxpool.Pool) {
 	t.Helper()
 
-	migrations := []string{
-		"1760580803_initial_schema.up.sql",
-		"1760587537_add_nodes.up.sql",
-		"1760605927_add_mesh_lora_config.up.sql",
-		"1760605928_add_node_state_tracking.up.sql",
-		"1760605929_add_node_admin_keys_mapping.up.sql",
-		"1760631305_update_preset_names.up.sql",
-		"1760632000_update_frequency_slot_range.up.sql",
-		"1760640000_add_device_config_storage.up.sql",
-		"1760650000_add_api_keys.up.sql",
-	}
-
-	for _, migration := range migrations {
-		migrationPath := filepath.Join("..", "..", "meshdb", "migrations", migration)
-		migrationSQL, err := os.ReadFile(migrationPath)
-		require.NoError(t, err)
-
-		_, err = pool.Exec(context.Background(), string(migrationSQL))
-		require.NoError(t, err)
-	}
+	migrations, err := migrate.Embedded()
+	require.NoError(t, err)
+	_, err = migrate.New(pool, migrations).Up(context.Background())
+	require.NoError(t, err)
 }
 
 // makeRequest is a helper to make HTTP requests to the test server
@@ -161,6 +180,34 @@ func (ts *testServer) makeRequest(t *testing.T, method, path string, body interf
 	return rr
 }
 
+// registerUser is a helper that registers a user and returns the auth response
+func (ts *testServer) registerUser(t *testing.T, email, displayName string) AuthResponse {
+	t.Helper()
+
+	rr := ts.makeRequest(t, "POST", "/api/auth/register", RegisterRequest{
+		Email:       email,
+		Password:    "password",
+		DisplayName: displayName,
+	}, "")
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var authResp AuthResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &authResp))
+	return authResp
+}
+
+// createMesh is a helper that creates a mesh owned by the token's user
+func (ts *testServer) createMesh(t *testing.T, token, name string) meshdb.Mesh {
+	t.Helper()
+
+	rr := ts.makeRequest(t, "POST", "/api/meshes", CreateMeshRequest{Name: name}, token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var mesh meshdb.Mesh
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &mesh))
+	return mesh
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	ts := setupTestServer(t)
 
@@ -535,9 +582,7 @@ func TestLogoutEndpoint(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
-// TestMeshAccessManagement is disabled because mesh access sharing feature was removed
-func TestMeshAccessManagement_DISABLED(t *testing.T) {
-	t.Skip("Mesh access feature removed")
+func TestMeshAccessManagement(t *testing.T) {
 	ts := setupTestServer(t)
 	var err error
 
@@ -626,6 +671,108 @@ func TestMeshAccessManagement_DISABLED(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 }
 
+func TestMeshAccessOwnerOnly(t *testing.T) {
+	ts := setupTestServer(t)
+
+	owner := ts.registerUser(t, "owner@example.com", "Owner User")
+	admin := ts.registerUser(t, "admin@example.com", "Admin User")
+	ts.registerUser(t, "other@example.com", "Other User")
+	meshID := ts.createMesh(t, owner.Token, "Shared Mesh").ID
+
+	accessPath := fmt.Sprintf("/api/meshes/%d/access", meshID)
+
+	rr := ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "admin@example.com",
+		AccessLevel: "admin",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	// Granting the same user twice conflicts
+	rr = ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "admin@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Invalid access levels are rejected
+	rr = ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "other@example.com",
+		AccessLevel: "superuser",
+	}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Unknown users are reported as not found
+	rr = ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "nobody@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// A mesh admin can view collaborators but cannot manage them
+	rr = ts.makeRequest(t, "GET", accessPath, nil, admin.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "other@example.com",
+		AccessLevel: "viewer",
+	}, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("%s/%d", accessPath, admin.User.ID), UpdateAccessRequest{AccessLevel: "owner"}, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/%d", accessPath, admin.User.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Revoking a user without access is reported as not found
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/%d", accessPath, owner.User.ID+1000), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestMeshAccessLastOwner(t *testing.T) {
+	ts := setupTestServer(t)
+
+	creator := ts.registerUser(t, "creator@example.com", "Creator User")
+	coOwner := ts.registerUser(t, "coowner@example.com", "Co-Owner User")
+	meshID := ts.createMesh(t, creator.Token, "Owned Mesh").ID
+
+	accessPath := fmt.Sprintf("/api/meshes/%d/access", meshID)
+
+	// The creator already owns the mesh and cannot be granted access
+	rr := ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "creator@example.com",
+		AccessLevel: "viewer",
+	}, creator.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Grant a second owner, who can then manage access
+	rr = ts.makeRequest(t, "POST", accessPath, GrantAccessRequest{
+		UserEmail:   "coowner@example.com",
+		AccessLevel: "owner",
+	}, creator.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	// Neither owner can demote or remove the creator
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("%s/%d", accessPath, creator.User.ID), UpdateAccessRequest{AccessLevel: "viewer"}, coOwner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/%d", accessPath, creator.User.ID), nil, coOwner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/%d", accessPath, creator.User.ID), nil, creator.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Other owners can be demoted and removed, and the creator stays in control
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("%s/%d", accessPath, coOwner.User.ID), UpdateAccessRequest{AccessLevel: "viewer"}, creator.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/%d", accessPath, coOwner.User.ID), nil, creator.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d", meshID), UpdateMeshRequest{}, creator.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
 func TestAdminKeys(t *testing.T) {
 	ts := setupTestServer(t)
 	var err error
@@ -823,3 +970,2318 @@ func TestNodeManagement(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, nodes, 0)
 }
+
+func TestMeshChannels(t *testing.T) {
+	ts := setupTestServer(t)
+
+	owner := ts.registerUser(t, "channels@example.com", "Channel Owner")
+	viewer := ts.registerUser(t, "channel-viewer@example.com", "Channel Viewer")
+	meshID := ts.createMesh(t, owner.Token, "Channel Mesh").ID
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", meshID), GrantAccessRequest{
+		UserEmail:   "channel-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	channelPath := func(index int) string {
+		return fmt.Sprintf("/api/meshes/%d/channels/%d", meshID, index)
+	}
+	name := func(s string) *string { return &s }
+
+	// Secondary channels require a primary channel
+	rr = ts.makeRequest(t, "PUT", channelPath(1), UpsertChannelRequest{Role: "SECONDARY", Name: name("Admin")}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Validation failures
+	invalid := []struct {
+		name  string
+		index int
+		req   UpsertChannelRequest
+	}{
+		{"channel 0 must be primary", 0, UpsertChannelRequest{Role: "SECONDARY"}},
+		{"only channel 0 can be primary", 2, UpsertChannelRequest{Role: "PRIMARY"}},
+		{"unknown role", 0, UpsertChannelRequest{Role: "SCANNING"}},
+		{"name too long", 0, UpsertChannelRequest{Role: "PRIMARY", Name: name("ThisNameIsTooLong")}},
+		{"bad psk length", 0, UpsertChannelRequest{Role: "PRIMARY", PSK: make([]byte, 8)}},
+		{"psk and generate", 0, UpsertChannelRequest{Role: "PRIMARY", PSK: make([]byte, 16), GeneratePSK: true}},
+		{"bad generated length", 0, UpsertChannelRequest{Role: "PRIMARY", GeneratePSK: true, PSKLength: func() *int { n := 1; return &n }()}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := ts.makeRequest(t, "PUT", channelPath(tt.index), tt.req, owner.Token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	rr = ts.makeRequest(t, "PUT", channelPath(8), UpsertChannelRequest{Role: "SECONDARY"}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Create the primary channel with the default key shorthand
+	rr = ts.makeRequest(t, "PUT", channelPath(0), UpsertChannelRequest{
+		Role:     "PRIMARY",
+		Name:     name("LongFast"),
+		PSK:      []byte{1},
+		Settings: &ChannelSettings{UplinkEnabled: true},
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Create a secondary channel with a generated key
+	rr = ts.makeRequest(t, "PUT", channelPath(1), UpsertChannelRequest{
+		Role:        "SECONDARY",
+		Name:        name("Admin"),
+		GeneratePSK: true,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var channel ChannelResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channel))
+	assert.Len(t, channel.PSK, 32)
+	assert.Equal(t, "SECONDARY", channel.ChannelRole)
+
+	// Admins see PSKs
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/channels", meshID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var channels []ChannelResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channels))
+	require.Len(t, channels, 2)
+	assert.Equal(t, []byte{1}, channels[0].PSK)
+	assert.JSONEq(t, `{"uplink_enabled":true}`, string(channels[0].Settings))
+	assert.Len(t, channels[1].PSK, 32)
+
+	// Viewers get redacted PSKs
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/channels", meshID), nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channels))
+	require.Len(t, channels, 2)
+	for _, ch := range channels {
+		assert.Nil(t, ch.PSK)
+		assert.True(t, ch.PSKRedacted)
+	}
+
+	rr = ts.makeRequest(t, "GET", channelPath(1), nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channel))
+	assert.Nil(t, channel.PSK)
+
+	// Viewers cannot modify channels
+	rr = ts.makeRequest(t, "PUT", channelPath(2), UpsertChannelRequest{Role: "SECONDARY"}, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", channelPath(1), nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// The primary channel cannot be deleted while a secondary exists
+	rr = ts.makeRequest(t, "DELETE", channelPath(0), nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", channelPath(1), nil, owner.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", channelPath(1), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", channelPath(0), nil, owner.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestImportNodeConfigChannels(t *testing.T) {
+	ts := setupTestServer(t)
+
+	owner := ts.registerUser(t, "import@example.com", "Import User")
+	meshID := ts.createMesh(t, owner.Token, "Import Mesh").ID
+	importPath := fmt.Sprintf("/api/meshes/%d/nodes/import", meshID)
+
+	// Channels as encoded by meshtastic-cli: roles are enum numbers, PSKs base64
+	deviceChannels := json.RawMessage(`[
+		{"settings": {"psk": "AQ==", "name": "LongFast", "uplink_enabled": true}, "role": 1},
+		{"index": 1, "settings": {"psk": "AAAAAAAAAAAAAAAAAAAAAA==", "name": "Admin"}, "role": 2},
+		{"index": 2, "settings": {}}
+	]`)
+
+	// The first import populates the mesh channel set
+	rr := ts.makeRequest(t, "POST", importPath, ImportNodeConfigRequest{
+		NodeNum:    1234,
+		HardwareID: "!000004d2",
+		LongName:   "First Node",
+		ShortName:  "N1",
+		Channels:   deviceChannels,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp ImportNodeConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.ChannelsImported)
+	assert.Empty(t, resp.ChannelMismatches)
+	assert.Equal(t, "!000004d2", resp.HardwareID)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/channels", meshID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var channels []ChannelResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channels))
+	require.Len(t, channels, 2)
+	assert.Equal(t, "PRIMARY", channels[0].ChannelRole)
+	assert.Equal(t, "LongFast", *channels[0].ChannelName)
+	assert.Equal(t, []byte{1}, channels[0].PSK)
+	assert.JSONEq(t, `{"uplink_enabled":true}`, string(channels[0].Settings))
+	assert.Equal(t, "SECONDARY", channels[1].ChannelRole)
+	assert.Len(t, channels[1].PSK, 16)
+
+	// A matching device reports no differences
+	rr = ts.makeRequest(t, "POST", importPath, ImportNodeConfigRequest{
+		NodeNum:    5678,
+		HardwareID: "!0000162e",
+		LongName:   "Second Node",
+		Channels:   deviceChannels,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.False(t, resp.ChannelsImported)
+	assert.Empty(t, resp.ChannelMismatches)
+
+	// A differing device is reported without changing the mesh channels
+	differentChannels := json.RawMessage(`[
+		{"settings": {"psk": "Ag==", "name": "LongFast", "uplink_enabled": true}, "role": 1},
+		{"index": 2, "settings": {"name": "Extra"}, "role": 2}
+	]`)
+	rr = ts.makeRequest(t, "POST", importPath, ImportNodeConfigRequest{
+		NodeNum:    9012,
+		HardwareID: "!00002334",
+		LongName:   "Third Node",
+		Channels:   differentChannels,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.False(t, resp.ChannelsImported)
+	require.Len(t, resp.ChannelMismatches, 3)
+	assert.Equal(t, ChannelMismatch{Index: 0, Fields: []string{"psk"}, DeviceRole: "PRIMARY", MeshRole: "PRIMARY"}, resp.ChannelMismatches[0])
+	assert.Equal(t, ChannelMismatch{Index: 1, Fields: []string{"role"}, DeviceRole: "DISABLED", MeshRole: "SECONDARY"}, resp.ChannelMismatches[1])
+	assert.Equal(t, ChannelMismatch{Index: 2, Fields: []string{"role"}, DeviceRole: "SECONDARY", MeshRole: "DISABLED"}, resp.ChannelMismatches[2])
+
+	// Forcing the import replaces the mesh channel set
+	rr = ts.makeRequest(t, "POST", importPath+"?import_channels=true", ImportNodeConfigRequest{
+		NodeNum:    9012,
+		HardwareID: "!00002334",
+		LongName:   "Third Node",
+		Channels:   differentChannels,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.ChannelsImported)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/channels", meshID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &channels))
+	require.Len(t, channels, 2)
+	assert.Equal(t, []byte{2}, channels[0].PSK)
+	assert.Equal(t, int32(2), channels[1].ChannelIndex)
+
+	// Invalid channel data is rejected
+	rr = ts.makeRequest(t, "POST", importPath, ImportNodeConfigRequest{
+		HardwareID: "!00000001",
+		LongName:   "Bad Node",
+		Channels:   json.RawMessage(`[{"index": 3, "role": 1}]`),
+	}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestParseChannelURL(t *testing.T) {
+	// The default LongFast channel as shared by the Meshtastic apps
+	set, err := parseChannelURL("https://meshtastic.org/e/#CgMSAQESBggBQANIAQ")
+	require.NoError(t, err)
+	require.Len(t, set.Settings, 1)
+	assert.Equal(t, []byte{1}, set.Settings[0].Psk)
+	require.NotNil(t, set.LoraConfig)
+	assert.True(t, set.LoraConfig.UsePreset)
+	assert.True(t, set.LoraConfig.TxEnabled)
+	assert.Equal(t, uint32(3), set.LoraConfig.HopLimit)
+
+	// Padded, standard base64 and "add" URLs decode the same way
+	for _, url := range []string{
+		"https://meshtastic.org/e/#CgMSAQESBggBQANIAQ==",
+		"https://meshtastic.org/e/?add=true#CgMSAQESBggBQANIAQ",
+	} {
+		other, err := parseChannelURL(url)
+		require.NoError(t, err, url)
+		assert.Equal(t, set.Settings[0].Psk, other.Settings[0].Psk)
+	}
+
+	for _, url := range []string{
+		"https://meshtastic.org/e/",
+		"https://meshtastic.org/e/#",
+		"https://meshtastic.org/e/#not*base64",
+		"https://meshtastic.org/e/#EgYIAUADSAE", // LoRa config only
+	} {
+		_, err := parseChannelURL(url)
+		assert.Error(t, err, url)
+	}
+}
+
+func TestChannelURL(t *testing.T) {
+	ts := setupTestServer(t)
+
+	owner := ts.registerUser(t, "channel-url@example.com", "Channel URL Owner")
+	viewer := ts.registerUser(t, "channel-url-viewer@example.com", "Channel URL Viewer")
+	meshID := ts.createMesh(t, owner.Token, "URL Mesh").ID
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", meshID), GrantAccessRequest{
+		UserEmail:   "channel-url-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	urlPath := fmt.Sprintf("/api/meshes/%d/channel-url", meshID)
+	name := func(s string) *string { return &s }
+
+	// No channels yet
+	rr = ts.makeRequest(t, "GET", urlPath, nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d/channels/0", meshID), UpsertChannelRequest{
+		Role: "PRIMARY",
+		Name: name("Ops"),
+		PSK:  bytes.Repeat([]byte{0x42}, 16),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d/channels/1", meshID), UpsertChannelRequest{
+		Role:     "SECONDARY",
+		Name:     name("Admin"),
+		PSK:      []byte{1},
+		Settings: &ChannelSettings{UplinkEnabled: true},
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	slot := int32(20)
+	region := "US"
+	preset := "MediumFast"
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d", meshID), UpdateMeshRequest{
+		LoraRegion:    &region,
+		ModemPreset:   &preset,
+		FrequencySlot: &slot,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Viewers cannot see the URL, as it contains the PSKs
+	rr = ts.makeRequest(t, "GET", urlPath, nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = ts.makeRequest(t, "GET", urlPath+".png", nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", urlPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var exported ChannelURLResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &exported))
+	assert.Equal(t, 2, exported.ChannelCount)
+	assert.True(t, strings.HasPrefix(exported.URL, "https://meshtastic.org/e/#"))
+
+	set, err := parseChannelURL(exported.URL)
+	require.NoError(t, err)
+	require.Len(t, set.Settings, 2)
+	assert.Equal(t, "Ops", set.Settings[0].Name)
+	assert.Equal(t, "Admin", set.Settings[1].Name)
+	assert.True(t, set.Settings[1].UplinkEnabled)
+	assert.Equal(t, "US", set.LoraConfig.Region.String())
+	assert.Equal(t, "MEDIUM_FAST", set.LoraConfig.ModemPreset.String())
+	assert.Equal(t, uint32(20), set.LoraConfig.ChannelNum)
+	assert.Equal(t, uint32(3), set.LoraConfig.HopLimit)
+
+	rr = ts.makeRequest(t, "GET", urlPath+".png?size=128", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(rr.Body.Bytes(), []byte("\x89PNG")))
+
+	// Importing the default LongFast URL into another mesh replaces its channels and LoRa settings
+	otherID := ts.createMesh(t, owner.Token, "Imported Mesh").ID
+	importPath := fmt.Sprintf("/api/meshes/%d/channel-url", otherID)
+
+	rr = ts.makeRequest(t, "POST", importPath, ImportChannelURLRequest{URL: "https://example.com/no-fragment"}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = ts.makeRequest(t, "POST", importPath, ImportChannelURLRequest{URL: exported.URL}, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", importPath, ImportChannelURLRequest{URL: exported.URL}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var imported ImportChannelURLResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+	require.Len(t, imported.Channels, 2)
+	assert.Equal(t, "PRIMARY", imported.Channels[0].ChannelRole)
+	assert.Equal(t, bytes.Repeat([]byte{0x42}, 16), imported.Channels[0].PSK)
+	assert.Equal(t, "SECONDARY", imported.Channels[1].ChannelRole)
+	assert.JSONEq(t, `{"uplink_enabled":true}`, string(imported.Channels[1].Settings))
+	require.NotNil(t, imported.Mesh.ModemPreset)
+	assert.Equal(t, "MediumFast", *imported.Mesh.ModemPreset)
+	assert.Equal(t, int32(20), imported.Mesh.FrequencySlot.Int32)
+
+	rr = ts.makeRequest(t, "POST", importPath, ImportChannelURLRequest{URL: "https://meshtastic.org/e/#CgMSAQESBggBQANIAQ"}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+	require.Len(t, imported.Channels, 1)
+	assert.Equal(t, []byte{1}, imported.Channels[0].PSK)
+	require.NotNil(t, imported.Mesh.LoraRegion)
+	assert.Equal(t, "UNSET", *imported.Mesh.LoraRegion)
+	assert.Equal(t, "LongFast", *imported.Mesh.ModemPreset)
+	assert.Equal(t, int32(0), imported.Mesh.FrequencySlot.Int32)
+}
+
+func TestComputeNodeDrift(t *testing.T) {
+	region := "US"
+	preset := "LongFast"
+	role := "ROUTER"
+	mesh := meshdb.Mesh{
+		LoraRegion:     &region,
+		ModemPreset:    &preset,
+		HopLimit:       pgtype.Int4{Int32: 3, Valid: true},
+		FrequencySlot:  pgtype.Int4{Int32: 0, Valid: true},
+		UsePreset:      true,
+		ConfigDefaults: []byte(`{"config": {"position": {"gps_mode": "DISABLED", "position_broadcast_secs": 900}}}`),
+	}
+	imported := time.Now()
+	node := meshdb.Node{
+		Name:             "N1",
+		LongName:         "Node One",
+		Role:             &role,
+		ConfigOverrides:  []byte(`{"config": {"position": {"position_broadcast_secs": 3600}}}`),
+		ConfigImportedAt: &imported,
+		// Device JSON as encoded by meshtastic-cli: enums are numbers, zero values omitted
+		RawDeviceConfig: []byte(`{
+			"long_name": "Node One",
+			"short_name": "N1",
+			"config": {
+				"device": {"role": 2},
+				"lora": {"use_preset": true, "region": 1, "hop_limit": 5},
+				"position": {"position_broadcast_secs": 900}
+			}
+		}`),
+	}
+
+	entries, err := computeNodeDrift(mesh, node, nil)
+	require.NoError(t, err)
+
+	byPath := map[string]DriftEntry{}
+	for _, entry := range entries {
+		byPath[entry.Path] = entry
+	}
+	assert.Len(t, byPath, 2, "unexpected drift: %+v", entries)
+
+	// Mesh LoRa settings
+	require.Contains(t, byPath, "config.lora.hop_limit")
+	assert.Equal(t, float64(3), byPath["config.lora.hop_limit"].Desired)
+	assert.Equal(t, float64(5), byPath["config.lora.hop_limit"].OnDevice)
+	assert.Equal(t, DriftSourceMesh, byPath["config.lora.hop_limit"].Source)
+
+	// Node overrides win over mesh defaults
+	require.Contains(t, byPath, "config.position.position_broadcast_secs")
+	assert.Equal(t, float64(3600), byPath["config.position.position_broadcast_secs"].Desired)
+	assert.Equal(t, DriftSourceNodeOverride, byPath["config.position.position_broadcast_secs"].Source)
+
+	// Enums compare by name whether stored as numbers or names, omitted values are zero
+	node.RawDeviceConfig = []byte(`{"long_name": "Node One", "short_name": "N1", "config": {"lora": {"use_preset": true, "region": 1, "hop_limit": 3}}}`)
+	node.ConfigOverrides = nil
+	entries, err = computeNodeDrift(mesh, node, nil)
+	require.NoError(t, err)
+	byPath = map[string]DriftEntry{}
+	for _, entry := range entries {
+		byPath[entry.Path] = entry
+	}
+	require.Contains(t, byPath, "config.device.role")
+	assert.Equal(t, "ROUTER", byPath["config.device.role"].Desired)
+	assert.Equal(t, "CLIENT", byPath["config.device.role"].OnDevice)
+	assert.NotContains(t, byPath, "config.position.gps_mode")
+	require.Contains(t, byPath, "config.position.position_broadcast_secs")
+	assert.Equal(t, float64(900), byPath["config.position.position_broadcast_secs"].Desired)
+	assert.Equal(t, float64(0), byPath["config.position.position_broadcast_secs"].OnDevice)
+	assert.Len(t, byPath, 2, "unexpected drift: %+v", entries)
+
+	// Values the device doesn't report fall back to what was applied
+	node.RawDeviceConfig = nil
+	mesh.ConfigDefaults = nil
+	node.Unmessageable = true
+	node.AppliedUnmessageable = pgtype.Bool{Bool: false, Valid: true}
+	appliedName := "N1"
+	appliedLongName := "Node One"
+	node.AppliedName = &appliedName
+	node.AppliedLongName = &appliedLongName
+	node.AppliedRole = &role
+	entries, err = computeNodeDrift(mesh, node, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "unmessageable", entries[0].Path)
+	assert.Equal(t, true, entries[0].Desired)
+	assert.Equal(t, false, entries[0].Applied)
+
+	// Nobody sees secrets, only whether they match
+	redacted := redactDrift([]DriftEntry{
+		{Path: "config.security.private_key", Desired: "a", OnDevice: "b", Applied: "a"},
+		{Path: "channels.1.psk", Desired: "a"},
+		{Path: "long_name", Desired: "a", OnDevice: "b"},
+	})
+	assert.True(t, redacted[0].Redacted)
+	assert.Nil(t, redacted[0].Desired)
+	assert.Equal(t, DriftSecretDiffers, redacted[0].OnDevice)
+	assert.Equal(t, DriftSecretMatches, redacted[0].Applied)
+	assert.True(t, redacted[1].Redacted)
+	assert.Nil(t, redacted[1].Desired)
+	assert.Nil(t, redacted[1].OnDevice)
+	assert.False(t, redacted[2].Redacted)
+	assert.Equal(t, "a", redacted[2].Desired)
+}
+
+func TestNodeDrift(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+
+	owner := ts.registerUser(t, "drift@example.com", "Drift Owner")
+	viewer := ts.registerUser(t, "drift-viewer@example.com", "Drift Viewer")
+	meshID := ts.createMesh(t, owner.Token, "Drift Mesh").ID
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", meshID), GrantAccessRequest{
+		UserEmail:   "drift-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	// Import a node whose LoRa settings match the mesh
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes/import", meshID), ImportNodeConfigRequest{
+		NodeNum:    1234,
+		HardwareID: "!000004d2",
+		LongName:   "Drift Node",
+		ShortName:  "DN",
+		Config: json.RawMessage(`{
+			"lora": {"use_preset": true, "region": 1, "hop_limit": 3},
+			"security": {"private_key": "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0MTI="}
+		}`),
+		Channels: json.RawMessage(`[{"settings": {"psk": "AQ==", "name": "LongFast"}, "role": 1}]`),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var imported ImportNodeConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+	assert.False(t, imported.PendingChanges)
+
+	driftPath := fmt.Sprintf("/api/meshes/%d/nodes/%d/drift", meshID, imported.ID)
+	rr = ts.makeRequest(t, "GET", driftPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var report NodeDriftResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.True(t, report.InSync, "unexpected drift: %+v", report.Drift)
+	assert.Empty(t, report.Drift)
+
+	// Changing the mesh hop limit, a node override and the channel PSK makes the node drift
+	_, err := ts.server.DB().UpdateMeshLoRaConfig(ctx, meshdb.UpdateMeshLoRaConfigParams{
+		ID:       meshID,
+		HopLimit: pgtype.Int4{Int32: 5, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Drift reports are read-only: pending_changes is kept by the writes that
+	// go through the API, not by whoever looks at the report
+	rr = ts.makeRequest(t, "GET", driftPath, nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.False(t, report.InSync)
+	assert.False(t, report.PendingChanges)
+	stored, err := ts.server.DB().GetNode(ctx, imported.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.PendingChanges)
+
+	_, err = ts.server.DB().UpdateNodeConfigOverrides(ctx, meshdb.UpdateNodeConfigOverridesParams{
+		ID:              imported.ID,
+		ConfigOverrides: []byte(`{"config": {"display": {"screen_on_secs": 30}}}`),
+	})
+	require.NoError(t, err)
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d/channels/0", meshID), UpsertChannelRequest{
+		Role: "PRIMARY",
+		Name: func(s string) *string { return &s }("LongFast"),
+		PSK:  bytes.Repeat([]byte{7}, 16),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Saving the channel recomputes pending_changes for the mesh's nodes
+	rr = ts.makeRequest(t, "GET", driftPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.False(t, report.InSync)
+	assert.True(t, report.PendingChanges)
+	paths := []string{}
+	for _, entry := range report.Drift {
+		paths = append(paths, entry.Path)
+	}
+	assert.Equal(t, []string{"channels.0.psk", "config.display.screen_on_secs", "config.lora.hop_limit"}, paths)
+	assert.True(t, report.Drift[0].Redacted)
+	assert.Nil(t, report.Drift[0].Desired)
+	assert.Equal(t, DriftSecretDiffers, report.Drift[0].OnDevice)
+	assert.NotContains(t, rr.Body.String(), base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 16)))
+
+	// Viewers get the same report
+	rr = ts.makeRequest(t, "GET", driftPath, nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	require.Len(t, report.Drift, 3)
+	assert.True(t, report.Drift[0].Redacted)
+	assert.Nil(t, report.Drift[0].Desired)
+	assert.Equal(t, DriftSecretDiffers, report.Drift[0].OnDevice)
+
+	// Mesh summary
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/drift", meshID), nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var summary MeshDriftResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary.NodeCount)
+	assert.Equal(t, 1, summary.NodesDrifted)
+	assert.Equal(t, 0, summary.NodesInSync)
+	require.Len(t, summary.Nodes, 1)
+	assert.Equal(t, 3, summary.Nodes[0].DriftCount)
+
+	// Reverting the changes brings the node back in sync and clears pending_changes
+	_, err = ts.server.DB().UpdateMeshLoRaConfig(ctx, meshdb.UpdateMeshLoRaConfigParams{
+		ID:       meshID,
+		HopLimit: pgtype.Int4{Int32: 3, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = ts.server.DB().UpdateNodeConfigOverrides(ctx, meshdb.UpdateNodeConfigOverridesParams{
+		ID:              imported.ID,
+		ConfigOverrides: []byte(`{}`),
+	})
+	require.NoError(t, err)
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d/channels/0", meshID), UpsertChannelRequest{
+		Role: "PRIMARY",
+		Name: func(s string) *string { return &s }("LongFast"),
+		PSK:  []byte{1},
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", driftPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.True(t, report.InSync)
+	assert.False(t, report.PendingChanges)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/nodes/%d/drift", meshID, imported.ID+1000), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestBuildEffectiveConfig(t *testing.T) {
+	region := "US"
+	preset := "MediumFast"
+	role := "ROUTER"
+	publicKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	channelName := "Ops"
+	mesh := meshdb.Mesh{
+		LoraRegion:     &region,
+		ModemPreset:    &preset,
+		HopLimit:       pgtype.Int4{Int32: 4, Valid: true},
+		FrequencySlot:  pgtype.Int4{Int32: 20, Valid: true},
+		UsePreset:      true,
+		ConfigDefaults: []byte(`{"config": {"position": {"gps_mode": 2, "position_broadcast_secs": 900}}, "module_config": {"mqtt": {"enabled": true}}}`),
+	}
+	node := meshdb.Node{
+		ID:              7,
+		MeshID:          3,
+		Name:            "RT1",
+		LongName:        "Router One",
+		Role:            &role,
+		PublicKey:       &publicKey,
+		ConfigOverrides: []byte(`{"config": {"position": {"position_broadcast_secs": 3600}}}`),
+	}
+	channels := []meshdb.MeshChannel{
+		{ChannelIndex: 1, ChannelRole: ChannelRoleSecondary, Psk: bytes.Repeat([]byte{2}, 16), Settings: []byte(`{"uplink_enabled": true}`)},
+		{ChannelIndex: 0, ChannelRole: ChannelRolePrimary, Psk: []byte{1}, ChannelName: &channelName},
+	}
+
+	effective, err := buildEffectiveConfig(mesh, node, channels)
+	require.NoError(t, err)
+	assert.Equal(t, "RT1", effective.ShortName)
+	assert.Equal(t, "Router One", effective.LongName)
+
+	// The documents decode as protobuf JSON
+	data, err := json.Marshal(effective.Config)
+	require.NoError(t, err)
+	var config pb.LocalConfig
+	require.NoError(t, protojson.Unmarshal(data, &config))
+	assert.Equal(t, pb.Config_LoRaConfig_US, config.Lora.Region)
+	assert.Equal(t, pb.Config_LoRaConfig_MEDIUM_FAST, config.Lora.ModemPreset)
+	assert.Equal(t, uint32(4), config.Lora.HopLimit)
+	assert.Equal(t, uint32(20), config.Lora.ChannelNum)
+	assert.True(t, config.Lora.UsePreset)
+	assert.Equal(t, pb.Config_DeviceConfig_ROUTER, config.Device.Role)
+	assert.Equal(t, pb.Config_PositionConfig_NOT_PRESENT, config.Position.GpsMode)
+	assert.Equal(t, uint32(3600), config.Position.PositionBroadcastSecs)
+	assert.Equal(t, bytes.Repeat([]byte{1}, 32), config.Security.PublicKey)
+
+	data, err = json.Marshal(effective.ModuleConfig)
+	require.NoError(t, err)
+	var moduleConfig pb.LocalModuleConfig
+	require.NoError(t, protojson.Unmarshal(data, &moduleConfig))
+	assert.True(t, moduleConfig.Mqtt.Enabled)
+
+	// Channels are sorted by index and decode as Channel messages
+	require.Len(t, effective.Channels, 2)
+	data, err = json.Marshal(effective.Channels[0])
+	require.NoError(t, err)
+	var primary pb.Channel
+	require.NoError(t, protojson.Unmarshal(data, &primary))
+	assert.Equal(t, pb.Channel_PRIMARY, primary.Role)
+	assert.Equal(t, "Ops", primary.Settings.Name)
+	assert.Equal(t, []byte{1}, primary.Settings.Psk)
+	data, err = json.Marshal(effective.Channels[1])
+	require.NoError(t, err)
+	var secondary pb.Channel
+	require.NoError(t, protojson.Unmarshal(data, &secondary))
+	assert.Equal(t, int32(1), secondary.Index)
+	assert.Equal(t, pb.Channel_SECONDARY, secondary.Role)
+	assert.True(t, secondary.Settings.UplinkEnabled)
+}
+
+func TestNodeApply(t *testing.T) {
+	ts := setupTestServer(t)
+
+	owner := ts.registerUser(t, "apply@example.com", "Apply Owner")
+	viewer := ts.registerUser(t, "apply-viewer@example.com", "Apply Viewer")
+	meshID := ts.createMesh(t, owner.Token, "Apply Mesh").ID
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", meshID), GrantAccessRequest{
+		UserEmail:   "apply-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", meshID), CreateNodeRequest{
+		HardwareID: "!0000162e",
+		Name:       "AP1",
+		LongName:   "Apply Node",
+		Role:       func(s string) *string { return &s }("CLIENT_MUTE"),
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+
+	configPath := fmt.Sprintf("/api/meshes/%d/nodes/%d/effective-config", meshID, node.ID)
+	rr = ts.makeRequest(t, "GET", configPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var effective EffectiveConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &effective))
+	assert.Equal(t, "AP1", effective.ShortName)
+	assert.Equal(t, "CLIENT_MUTE", effective.Config["device"].(map[string]any)["role"])
+
+	// The effective config holds secrets, so viewers can't fetch it
+	rr = ts.makeRequest(t, "GET", configPath, nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Reporting the applied values records them and clears pending_changes
+	_, err := ts.server.DB().UpdateNode(context.Background(), meshdb.UpdateNodeParams{
+		ID:             node.ID,
+		PendingChanges: pgtype.Bool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	appliedPath := fmt.Sprintf("/api/meshes/%d/nodes/%d/applied", meshID, node.ID)
+	rr = ts.makeRequest(t, "POST", appliedPath, MarkNodeAppliedRequest{
+		ShortName:     func(s string) *string { return &s }("AP1"),
+		LongName:      func(s string) *string { return &s }("Apply Node"),
+		Role:          func(s string) *string { return &s }("CLIENT_MUTE"),
+		Unmessageable: func(b bool) *bool { return &b }(false),
+	}, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", appliedPath, MarkNodeAppliedRequest{
+		ShortName:     func(s string) *string { return &s }("AP1"),
+		LongName:      func(s string) *string { return &s }("Apply Node"),
+		Role:          func(s string) *string { return &s }("CLIENT_MUTE"),
+		Unmessageable: func(b bool) *bool { return &b }(false),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var applied meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &applied))
+	assert.False(t, applied.PendingChanges)
+	require.NotNil(t, applied.ConfigAppliedAt)
+	require.NotNil(t, applied.AppliedName)
+	assert.Equal(t, "AP1", *applied.AppliedName)
+	require.NotNil(t, applied.AppliedRole)
+	assert.Equal(t, "CLIENT_MUTE", *applied.AppliedRole)
+	assert.True(t, applied.AppliedUnmessageable.Valid)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/nodes/%d/effective-config", meshID, node.ID+1000), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGatewayStatus(t *testing.T) {
+	ts := setupTestServer(t)
+	user := ts.registerUser(t, "gateway@example.com", "Gateway User")
+
+	rr := ts.makeRequest(t, "GET", "/api/gateway", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// No radio is configured for the test server
+	rr = ts.makeRequest(t, "GET", "/api/gateway", nil, user.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var status GatewayStatusResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.False(t, status.Enabled)
+	assert.False(t, status.Connected)
+
+	ts.server.gateway = gateway.New("radio.test:4403", gateway.TCPDialer("radio.test"))
+	rr = ts.makeRequest(t, "GET", "/api/gateway", nil, user.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "radio.test:4403", status.Address)
+	assert.False(t, status.Connected)
+}
+
+func TestNodeStatusFromTraffic(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "status-owner@example.com", "Status Owner")
+	viewer := ts.registerUser(t, "status-viewer@example.com", "Status Viewer")
+	mesh := ts.createMesh(t, owner.Token, "Status Mesh")
+	other := ts.createMesh(t, owner.Token, "Other Mesh")
+	assert.Equal(t, int32(7200), mesh.OfflineAfterSeconds)
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", mesh.ID), GrantAccessRequest{
+		UserEmail:   "status-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+		HardwareID: "!00001234",
+		Name:       "HILL",
+		LongName:   "Hilltop",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+	_, err := ts.db.Exec(ctx, "UPDATE nodes SET node_num = $1 WHERE id = $2", 0x1234, node.ID)
+	require.NoError(t, err)
+
+	getNode := func() meshdb.Node {
+		t.Helper()
+		n, err := ts.server.DB().GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		return n
+	}
+
+	// Traffic from the node brings it online
+	ingester := ingest.New(ts.db)
+	err = ingester.HandleEvent(ctx, gateway.Event{
+		Type:   gateway.EventPacket,
+		Time:   time.Now(),
+		Packet: &pb.MeshPacket{From: 0x1234, To: 0xffffffff},
+	})
+	require.NoError(t, err)
+	n := getNode()
+	require.NotNil(t, n.Status)
+	assert.Equal(t, "online", *n.Status)
+	require.NotNil(t, n.LastSeen)
+	assert.WithinDuration(t, time.Now(), *n.LastSeen, time.Minute)
+
+	// Further traffic updates last_seen without another transition
+	require.NoError(t, ingester.NodeSeen(ctx, 0x1234, time.Now()))
+
+	// A scoped ingester only touches its own mesh
+	require.NoError(t, ingest.New(ts.db, ingest.WithMesh(other.ID)).NodeSeen(ctx, 0x1234, time.Now().Add(time.Hour)))
+	assert.WithinDuration(t, time.Now(), *getNode().LastSeen, time.Minute)
+
+	// The threshold is per mesh and must be positive
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d", mesh.ID), UpdateMeshRequest{
+		OfflineAfterSeconds: func(v int32) *int32 { return &v }(0),
+	}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d", mesh.ID), UpdateMeshRequest{
+		OfflineAfterSeconds: func(v int32) *int32 { return &v }(600),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// A node silent for longer than the threshold is swept offline
+	_, err = ts.db.Exec(ctx, "UPDATE nodes SET last_seen = NOW() - INTERVAL '11 minutes' WHERE id = $1", node.ID)
+	require.NoError(t, err)
+	swept, err := ingest.Sweep(ctx, ts.db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, swept)
+	assert.Equal(t, "offline", *getNode().Status)
+
+	// Old news from a radio's node database doesn't bring it back
+	require.NoError(t, ingester.NodeSeen(ctx, 0x1234, time.Now().Add(-time.Hour)))
+	n = getNode()
+	assert.Equal(t, "offline", *n.Status)
+	assert.WithinDuration(t, time.Now().Add(-11*time.Minute), *n.LastSeen, time.Minute)
+
+	require.NoError(t, ingester.NodeSeen(ctx, 0x1234, time.Now()))
+	assert.Equal(t, "online", *getNode().Status)
+
+	// Statuses set by hand are recorded and left alone by traffic
+	rr = ts.makeRequest(t, "PATCH", fmt.Sprintf("/api/meshes/%d/nodes/%d/status", mesh.ID, node.ID),
+		UpdateNodeStatusRequest{Status: "maintenance"}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, ingester.NodeSeen(ctx, 0x1234, time.Now()))
+	assert.Equal(t, "maintenance", *getNode().Status)
+
+	historyPath := fmt.Sprintf("/api/meshes/%d/nodes/%d/status-history", mesh.ID, node.ID)
+	rr = ts.makeRequest(t, "GET", historyPath, nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var history []meshdb.NodeStatusHistory
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+	require.Len(t, history, 4)
+	assert.Equal(t, "maintenance", history[0].NewStatus)
+	assert.Equal(t, ingest.StatusSourceManual, history[0].Source)
+	assert.Equal(t, ingest.StatusSourceTraffic, history[1].Source)
+	assert.Equal(t, "offline", history[2].NewStatus)
+	assert.Equal(t, ingest.StatusSourceSweeper, history[2].Source)
+	assert.Nil(t, history[3].OldStatus)
+	assert.Equal(t, "online", history[3].NewStatus)
+
+	rr = ts.makeRequest(t, "GET", historyPath+"?limit=2", nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+	assert.Len(t, history, 2)
+
+	rr = ts.makeRequest(t, "GET", historyPath+"?limit=0", nil, viewer.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/status-changes", mesh.ID), nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var changes []meshdb.ListMeshStatusChangesRow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, node.ID, changes[0].NodeID)
+	assert.Equal(t, int64(4), changes[0].Changes)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/status-changes?since=yesterday", mesh.ID), nil, viewer.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Other meshes' history is hidden
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/nodes/%d/status-history", other.ID, node.ID), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestNodeTelemetry(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "telemetry-owner@example.com", "Telemetry Owner")
+	outsider := ts.registerUser(t, "telemetry-outsider@example.com", "Telemetry Outsider")
+	mesh := ts.createMesh(t, owner.Token, "Telemetry Mesh")
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+		HardwareID: "!00005678",
+		Name:       "BATT",
+		LongName:   "Battery Node",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+	_, err := ts.db.Exec(ctx, "UPDATE nodes SET node_num = $1 WHERE id = $2", 0x5678, node.ID)
+	require.NoError(t, err)
+
+	// Reports heard over the air are stored per metric
+	base := time.Now().Add(-time.Hour).Truncate(time.Hour)
+	ingester := ingest.New(ts.db)
+	for i, level := range []uint32{90, 80, 70} {
+		err := ingester.HandleEvent(ctx, gateway.Event{
+			Type:   gateway.EventPacket,
+			Time:   base.Add(time.Duration(i) * 10 * time.Minute),
+			Packet: &pb.MeshPacket{From: 0x5678},
+			Payload: &pb.Telemetry{
+				Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: &pb.DeviceMetrics{
+					BatteryLevel: &level,
+				}},
+			},
+		})
+		require.NoError(t, err)
+	}
+	temperature := float32(21.5)
+	require.NoError(t, ingester.Telemetry(ctx, 0x5678, time.Now(), &pb.Telemetry{
+		Variant: &pb.Telemetry_EnvironmentMetrics{EnvironmentMetrics: &pb.EnvironmentMetrics{
+			Temperature: &temperature,
+		}},
+	}))
+
+	telemetryPath := fmt.Sprintf("/api/meshes/%d/nodes/%d/telemetry", mesh.ID, node.ID)
+	from := base.Add(-time.Minute).Format(time.RFC3339)
+	to := base.Add(time.Hour).Format(time.RFC3339)
+
+	rr = ts.makeRequest(t, "GET", telemetryPath+"?metric=battery_level&bucket=1h&from="+from+"&to="+to, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp TelemetryResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, int64(3600), resp.BucketSeconds)
+	require.Len(t, resp.Points, 1)
+	assert.InDelta(t, 80, resp.Points[0].Avg, 0.001)
+	assert.InDelta(t, 70, resp.Points[0].Min, 0.001)
+	assert.InDelta(t, 90, resp.Points[0].Max, 0.001)
+	assert.Equal(t, int64(3), resp.Points[0].Samples)
+
+	rr = ts.makeRequest(t, "GET", telemetryPath+"?metric=battery_level&bucket=10m&from="+from+"&to="+to, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Points, 3)
+
+	rr = ts.makeRequest(t, "GET", telemetryPath+"/latest", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var latest []meshdb.ListNodeTelemetryMetricsRow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &latest))
+	require.Len(t, latest, 2)
+	assert.Equal(t, "battery_level", latest[0].Metric)
+	assert.InDelta(t, 70, latest[0].Value, 0.001)
+	assert.Equal(t, "environment.temperature", latest[1].Metric)
+
+	// Bad queries
+	rr = ts.makeRequest(t, "GET", telemetryPath, nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = ts.makeRequest(t, "GET", telemetryPath+"?metric=battery_level&bucket=1s&from=2020-01-01T00:00:00Z", nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = ts.makeRequest(t, "GET", telemetryPath+"?metric=battery_level&from="+to+"&to="+from, nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Only mesh members can read it
+	rr = ts.makeRequest(t, "GET", telemetryPath+"?metric=battery_level", nil, outsider.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Expired telemetry is pruned
+	pruned, err := ingest.Prune(ctx, ts.db, config.RetentionConfig{Telemetry: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pruned)
+}
+
+func TestTelemetryBucket(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bucket, err := telemetryBucket(from, from.Add(24*time.Hour), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 173*time.Second, bucket)
+
+	bucket, err = telemetryBucket(from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, bucket)
+
+	bucket, err = telemetryBucket(from, from.Add(time.Hour), 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, bucket)
+
+	_, err = telemetryBucket(from, from.Add(365*24*time.Hour), time.Minute)
+	assert.Error(t, err)
+}
+
+func TestNodePositions(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "position-owner@example.com", "Position Owner")
+	outsider := ts.registerUser(t, "position-outsider@example.com", "Position Outsider")
+	mesh := ts.createMesh(t, owner.Token, "Position Mesh")
+
+	createNode := func(hardwareID, name string, nodeNum int64) meshdb.Node {
+		t.Helper()
+		rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+			HardwareID: hardwareID,
+			Name:       name,
+			LongName:   name + " Node",
+		}, owner.Token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var node meshdb.Node
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+		_, err := ts.db.Exec(ctx, "UPDATE nodes SET node_num = $1 WHERE id = $2", nodeNum, node.ID)
+		require.NoError(t, err)
+		return node
+	}
+	peak := createNode("!0000a001", "PEAK", 0xa001)
+	createNode("!0000a002", "LOST", 0xa002)
+
+	position := func(lat, lon, alt int32, at time.Time) *pb.Position {
+		return &pb.Position{
+			LatitudeI:     &lat,
+			LongitudeI:    &lon,
+			Altitude:      &alt,
+			Time:          uint32(at.Unix()),
+			PrecisionBits: 32,
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	ingester := ingest.New(ts.db)
+	err := ingester.HandleEvent(ctx, gateway.Event{
+		Type:    gateway.EventPacket,
+		Time:    now,
+		Packet:  &pb.MeshPacket{From: 0xa001},
+		Payload: position(377749000, -1224194000, 120, now.Add(-time.Minute)),
+	})
+	require.NoError(t, err)
+
+	// An older report joins the track but doesn't replace the latest position
+	require.NoError(t, ingester.Position(ctx, 0xa001, now, position(377000000, -1224000000, 100, now.Add(-time.Hour))))
+	// Reports without a fix are ignored
+	require.NoError(t, ingester.Position(ctx, 0xa002, now, &pb.Position{}))
+
+	battery := uint32(64)
+	require.NoError(t, ingester.Telemetry(ctx, 0xa001, now, &pb.Telemetry{
+		Variant: &pb.Telemetry_DeviceMetrics{DeviceMetrics: &pb.DeviceMetrics{BatteryLevel: &battery}},
+	}))
+
+	n, err := ts.server.DB().GetNode(ctx, peak.ID)
+	require.NoError(t, err)
+	require.True(t, n.Latitude.Valid)
+	assert.InDelta(t, 37.7749, n.Latitude.Float64, 0.00001)
+	assert.InDelta(t, -122.4194, n.Longitude.Float64, 0.00001)
+
+	rr := ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/positions.geojson", mesh.ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/geo+json", rr.Header().Get("Content-Type"))
+	var collection GeoJSONFeatureCollection
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 1)
+	feature := collection.Features[0]
+	assert.Equal(t, "Point", feature.Geometry.Type)
+	require.Len(t, feature.Geometry.Coordinates, 3)
+	assert.InDelta(t, -122.4194, feature.Geometry.Coordinates[0], 0.00001)
+	assert.InDelta(t, 37.7749, feature.Geometry.Coordinates[1], 0.00001)
+	assert.Equal(t, 120.0, feature.Geometry.Coordinates[2])
+	assert.Equal(t, "PEAK", feature.Properties["name"])
+	assert.Equal(t, 64.0, feature.Properties["battery_level"])
+	assert.Equal(t, 32.0, feature.Properties["precision_bits"])
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/nodes/%d/positions", mesh.ID, peak.ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var track []meshdb.NodePosition
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &track))
+	require.Len(t, track, 2)
+	assert.True(t, track[0].RecordedAt.Before(track[1].RecordedAt))
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/positions.geojson", mesh.ID), nil, outsider.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestMeshTopology(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "topology-owner@example.com", "Topology Owner")
+	mesh := ts.createMesh(t, owner.Token, "Topology Mesh")
+
+	for _, n := range []struct {
+		hardwareID string
+		name       string
+		num        int64
+	}{
+		{"!0000b001", "BASE", 0xb001},
+		{"!0000b002", "RELAY", 0xb002},
+	} {
+		rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+			HardwareID: n.hardwareID,
+			Name:       n.name,
+			LongName:   n.name + " Node",
+		}, owner.Token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var node meshdb.Node
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+		_, err := ts.db.Exec(ctx, "UPDATE nodes SET node_num = $1 WHERE id = $2", n.num, node.ID)
+		require.NoError(t, err)
+	}
+
+	ingester := ingest.New(ts.db)
+	err := ingester.HandleEvent(ctx, gateway.Event{
+		Type:   gateway.EventPacket,
+		Time:   time.Now(),
+		Packet: &pb.MeshPacket{From: 0xb001},
+		Payload: &pb.NeighborInfo{
+			NodeId:    0xb001,
+			Neighbors: []*pb.Neighbor{{NodeId: 0xb002, Snr: 7.25}, {NodeId: 0xc003, Snr: -2}},
+		},
+	})
+	require.NoError(t, err)
+
+	// A traceroute reply from RELAY to BASE, direct both ways
+	err = ingester.HandleEvent(ctx, gateway.Event{
+		Type: gateway.EventPacket,
+		Time: time.Now(),
+		Packet: &pb.MeshPacket{
+			From: 0xb002,
+			To:   0xb001,
+			PayloadVariant: &pb.MeshPacket_Decoded{Decoded: &pb.Data{
+				Portnum:   pb.PortNum_TRACEROUTE_APP,
+				RequestId: 42,
+			}},
+		},
+		Payload: &pb.RouteDiscovery{SnrTowards: []int32{20}},
+	})
+	require.NoError(t, err)
+
+	rr := ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/topology", mesh.ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var topo TopologyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &topo))
+	require.Len(t, topo.Nodes, 3)
+	assert.Equal(t, "BASE", topo.Nodes[0].Name)
+	assert.Nil(t, topo.Nodes[2].NodeID)
+	require.Len(t, topo.Edges, 3)
+	assert.Equal(t, int64(0xb001), topo.Edges[0].From)
+	assert.Equal(t, int64(0xb002), topo.Edges[0].To)
+	assert.Equal(t, float32(5), *topo.Edges[0].SNR)
+	assert.Equal(t, ingest.EdgeSourceTraceroute, topo.Edges[0].Source)
+	assert.Equal(t, int64(0xb002), topo.Edges[1].From)
+	assert.Equal(t, ingest.EdgeSourceNeighborInfo, topo.Edges[1].Source)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/topology?format=dot", mesh.ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/vnd.graphviz", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `"!0000b002" -> "!0000b001" [label="7.25 dB"];`)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/topology?format=svg", mesh.ID), nil, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Edges not heard again age out
+	_, err = ts.db.Exec(ctx, "UPDATE topology_edges SET last_heard = NOW() - INTERVAL '2 days' WHERE from_num = $1", 0xc003)
+	require.NoError(t, err)
+	pruned, err := ingest.Prune(ctx, ts.db, config.RetentionConfig{Topology: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
+
+func TestWriteTopologyDOT(t *testing.T) {
+	id := int64(1)
+	snr := float32(-3.5)
+	var b strings.Builder
+	err := writeTopologyDOT(&b, `My "Mesh"`, TopologyResponse{
+		Nodes: []TopologyNode{
+			{NodeNum: 0xa, NodeID: &id, Name: "A", LongName: "Alpha"},
+			{NodeNum: 0xb, Name: "!0000000b"},
+		},
+		Edges: []TopologyEdge{{From: 0xb, To: 0xa, SNR: &snr}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `digraph "My \"Mesh\"" {
+  "!0000000a" [label="A\nAlpha"];
+  "!0000000b" [label="!0000000b", style=dashed];
+  "!0000000b" -> "!0000000a" [label="-3.50 dB"];
+}
+`, b.String())
+}
+
+func TestMeshMQTT(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	owner := ts.registerUser(t, "mqtt-owner@example.com", "MQTT Owner")
+	viewer := ts.registerUser(t, "mqtt-viewer@example.com", "MQTT Viewer")
+	mesh := ts.createMesh(t, owner.Token, "MQTT Mesh")
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", mesh.ID), GrantAccessRequest{
+		UserEmail:   "mqtt-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	psk := bytes.Repeat([]byte{0x5a}, 16)
+	name := "ranch"
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/meshes/%d/channels/0", mesh.ID), UpsertChannelRequest{
+		Role: ChannelRolePrimary,
+		Name: &name,
+		PSK:  psk,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+		HardwareID: "!0000d001",
+		Name:       "FAR",
+		LongName:   "Far Away",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+	_, err := ts.db.Exec(ctx, "UPDATE nodes SET node_num = $1 WHERE id = $2", 0xd001, node.ID)
+	require.NoError(t, err)
+
+	go func() {
+		_ = ts.server.mqtt.Run(ctx)
+	}()
+
+	broker := mqtttest.NewBroker(t, mqtttest.WithCredentials("meshdev", "large4cats"))
+	mqttPath := fmt.Sprintf("/api/meshes/%d/mqtt", mesh.ID)
+
+	rr = ts.makeRequest(t, "GET", mqttPath, nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	username, password := "meshdev", "large4cats"
+	rr = ts.makeRequest(t, "PUT", mqttPath, MQTTConfigRequest{
+		BrokerURL: "http://broker.example.com",
+		TopicRoot: "msh/US/2/e/",
+	}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = ts.makeRequest(t, "PUT", mqttPath, MQTTConfigRequest{
+		BrokerURL: broker.URL,
+		TopicRoot: "msh/US/#",
+	}, owner.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "PUT", mqttPath, MQTTConfigRequest{
+		BrokerURL: broker.URL,
+		TopicRoot: "msh/US/2/e/",
+		Username:  &username,
+		Password:  &password,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), password)
+	var cfg MQTTConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cfg))
+	assert.True(t, cfg.HasPassword)
+	assert.True(t, cfg.Enabled)
+
+	// Viewers can't see the credentials
+	rr = ts.makeRequest(t, "GET", mqttPath, nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	sub := ts.server.mqtt.Subscriber(mesh.ID)
+	require.NotNil(t, sub)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	require.NoError(t, sub.WaitConnected(waitCtx))
+
+	// A gateway uplinks an encrypted position report
+	lat, lon := int32(450000000), int32(-930000000)
+	position, err := proto.Marshal(&pb.Position{LatitudeI: &lat, LongitudeI: &lon})
+	require.NoError(t, err)
+	packet := &pb.MeshPacket{
+		From: 0xd001,
+		To:   0xffffffff,
+		Id:   777,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: &pb.Data{
+			Portnum: pb.PortNum_POSITION_APP,
+			Payload: position,
+		}},
+	}
+	channel, err := meshcrypto.NewChannel(name, psk)
+	require.NoError(t, err)
+	require.NoError(t, channel.EncryptPacket(packet))
+	envelope, err := proto.Marshal(&pb.ServiceEnvelope{
+		Packet:    packet,
+		ChannelId: "ranch",
+		GatewayId: "!0000beef",
+	})
+	require.NoError(t, err)
+	broker.Publish(t, "msh/US/2/e/ranch/!0000beef", envelope)
+
+	require.Eventually(t, func() bool {
+		n, err := ts.server.DB().GetNode(ctx, node.ID)
+		return err == nil && n.Latitude.Valid
+	}, 5*time.Second, 20*time.Millisecond)
+	n, err := ts.server.DB().GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 45.0, n.Latitude.Float64, 0.00001)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, "online", *n.Status)
+
+	// Disabling the subscription stops it
+	disabled := false
+	rr = ts.makeRequest(t, "PUT", mqttPath, MQTTConfigRequest{
+		BrokerURL: broker.URL,
+		TopicRoot: "msh/US/2/e/",
+		Enabled:   &disabled,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cfg))
+	assert.True(t, cfg.HasPassword)
+	assert.Nil(t, ts.server.mqtt.Subscriber(mesh.ID))
+
+	rr = ts.makeRequest(t, "DELETE", mqttPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", mqttPath, nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestNodeApplyJobs(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "apply-jobs-owner@example.com", "Apply Owner")
+	viewer := ts.registerUser(t, "apply-jobs-viewer@example.com", "Apply Viewer")
+	mesh := ts.createMesh(t, owner.Token, "Apply Jobs Mesh")
+
+	rr := ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/access", mesh.ID), GrantAccessRequest{
+		UserEmail:   "apply-jobs-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), CreateNodeRequest{
+		HardwareID: "!0000a001",
+		Name:       "APLY",
+		LongName:   "Apply Node",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+
+	nodePath := fmt.Sprintf("/api/meshes/%d/nodes/%d", mesh.ID, node.ID)
+
+	// Pushing needs admin access and a gateway radio
+	rr = ts.makeRequest(t, "POST", nodePath+"/apply", nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = ts.makeRequest(t, "POST", nodePath+"/apply", nil, owner.Token)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", nodePath+"/apply-jobs", nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, "[]", rr.Body.String())
+
+	// Jobs are normally created and run by the push
+	q := ts.server.DB()
+	job, err := q.CreateApplyJob(ctx, meshdb.CreateApplyJobParams{NodeID: node.ID, RequestedBy: owner.User.ID})
+	require.NoError(t, err)
+	assert.Equal(t, ApplyJobPending, job.Status)
+
+	// Only one job per node may be active
+	_, err = q.CreateApplyJob(ctx, meshdb.CreateApplyJobParams{NodeID: node.ID, RequestedBy: owner.User.ID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	require.NoError(t, q.StartApplyJob(ctx, job.ID))
+	nak := "ADMIN_PUBLIC_KEY_UNAUTHORIZED"
+	require.NoError(t, q.AddApplyJobLog(ctx, meshdb.AddApplyJobLogParams{
+		JobID:   job.ID,
+		Message: "begin_edit_settings",
+		Attempt: 1,
+		Outcome: ota.OutcomeNAK,
+		Detail:  &nak,
+	}))
+	message := "begin_edit_settings: request rejected: ADMIN_PUBLIC_KEY_UNAUTHORIZED"
+	_, err = q.FinishApplyJob(ctx, meshdb.FinishApplyJobParams{ID: job.ID, Status: ApplyJobFailed, Error: &message})
+	require.NoError(t, err)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("%s/apply-jobs/%d", nodePath, job.ID), nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp ApplyJobResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, ApplyJobFailed, resp.Status)
+	assert.Equal(t, message, *resp.Error)
+	require.Len(t, resp.Log, 1)
+	assert.Equal(t, ota.OutcomeNAK, resp.Log[0].Outcome)
+
+	rr = ts.makeRequest(t, "GET", nodePath+"/apply-jobs", nil, viewer.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var jobs []meshdb.NodeApplyJob
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 1)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("%s/apply-jobs/%d", nodePath, job.ID+1000), nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// A finished job makes room for the next, and restarts fail jobs left running
+	_, err = q.CreateApplyJob(ctx, meshdb.CreateApplyJobParams{NodeID: node.ID, RequestedBy: owner.User.ID})
+	require.NoError(t, err)
+	n, err := q.FailInterruptedApplyJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestOTADesired(t *testing.T) {
+	effective := EffectiveConfigResponse{
+		ShortName: "NODE",
+		LongName:  "Node",
+		Config: map[string]any{
+			"device": map[string]any{"role": "ROUTER"},
+		},
+		ModuleConfig: map[string]any{
+			"neighbor_info": map[string]any{"enabled": true},
+		},
+		Channels: []EffectiveChannel{{
+			Index:    0,
+			Role:     ChannelRolePrimary,
+			Settings: EffectiveChannelSettings{PSK: []byte{1}, Name: "ranch"},
+		}},
+	}
+
+	desired, err := otaDesired(effective)
+	require.NoError(t, err)
+	assert.Equal(t, "NODE", desired.ShortName)
+	assert.JSONEq(t, `{"role":"ROUTER"}`, string(desired.Config["device"]))
+	assert.JSONEq(t, `{"enabled":true}`, string(desired.ModuleConfig["neighbor_info"]))
+	require.Len(t, desired.Channels, 1)
+
+	// Channels decode as protobuf Channel messages
+	ch := &pb.Channel{}
+	require.NoError(t, protojson.Unmarshal(desired.Channels[0], ch))
+	assert.Equal(t, "ranch", ch.Settings.Name)
+	assert.Equal(t, []byte{1}, ch.Settings.Psk)
+}
+
+func TestAdminKeyRotation(t *testing.T) {
+	ts := setupTestServer(t)
+	owner := ts.registerUser(t, "rotation-owner@example.com", "Rotation Owner")
+	mesh := ts.createMesh(t, owner.Token, "Rotation Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	oldPublic := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	newPublic := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	rr := ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{PublicKey: oldPublic}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var oldKey meshdb.AdminKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &oldKey))
+
+	var nodes []meshdb.Node
+	for i, name := range []string{"ROTA", "ROTB"} {
+		rr = ts.makeRequest(t, "POST", meshPath+"/nodes", CreateNodeRequest{
+			HardwareID: fmt.Sprintf("!0000b00%d", i),
+			Name:       name,
+		}, owner.Token)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var node meshdb.Node
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+		nodes = append(nodes, node)
+
+		// Both nodes were set up with the old key over serial
+		rr = ts.makeRequest(t, "POST", fmt.Sprintf("%s/nodes/%d/applied", meshPath, node.ID), MarkNodeAppliedRequest{
+			AdminKeys: &[]string{oldPublic},
+		}, owner.Token)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// The old key is the only way to reach the nodes
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/admin-keys/%d", meshPath, oldKey.ID), nil, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-key-rotations", CreateAdminKeyRotationRequest{
+		OldKeyID:     oldKey.ID,
+		NewPublicKey: newPublic,
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var rotation AdminKeyRotationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotation))
+	assert.Equal(t, RotationInProgress, rotation.Status)
+	assert.Equal(t, 2, rotation.NodeCount)
+	assert.Equal(t, 0, rotation.ConfirmedCount)
+	for _, node := range rotation.Nodes {
+		assert.True(t, node.HasOldKey)
+		assert.False(t, node.HasNewKey)
+	}
+	rotationPath := fmt.Sprintf("%s/admin-key-rotations/%d", meshPath, rotation.ID)
+
+	// One rotation at a time
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-key-rotations", CreateAdminKeyRotationRequest{
+		OldKeyID: oldKey.ID,
+		NewKeyID: &rotation.NewKeyID,
+	}, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Nodes are configured with both keys while the rotation runs
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("%s/nodes/%d/effective-config", meshPath, nodes[0].ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var effective EffectiveConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &effective))
+	security := effective.Config["security"].(map[string]any)
+	assert.Equal(t, []any{oldPublic, newPublic}, security["admin_key"])
+
+	// Pushing needs the gateway
+	rr = ts.makeRequest(t, "POST", rotationPath+"/push", nil, owner.Token)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", rotationPath+"/complete", nil, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("%s/nodes/%d/applied", meshPath, nodes[0].ID), MarkNodeAppliedRequest{
+		AdminKeys: &[]string{oldPublic, newPublic},
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", rotationPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotation))
+	assert.Equal(t, 1, rotation.ConfirmedCount)
+
+	rr = ts.makeRequest(t, "POST", rotationPath+"/complete", nil, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("%s/nodes/%d/applied", meshPath, nodes[1].ID), MarkNodeAppliedRequest{
+		AdminKeys: &[]string{newPublic},
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", rotationPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotation))
+	assert.Equal(t, 2, rotation.ConfirmedCount)
+
+	// Every node has the new key, so the old one is retired
+	rr = ts.makeRequest(t, "POST", rotationPath+"/complete", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var completed meshdb.AdminKeyRotation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &completed))
+	assert.Equal(t, RotationCompleted, completed.Status)
+	assert.Nil(t, completed.OldKeyID)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("%s/admin-keys/%d", meshPath, oldKey.ID), nil, owner.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("%s/admin-keys/%d", meshPath, rotation.NewKeyID), nil, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", meshPath+"/admin-key-rotations", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var rotations []meshdb.AdminKeyRotation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotations))
+	assert.Len(t, rotations, 1)
+}
+
+func TestAppliedAdminKeys(t *testing.T) {
+	ts := setupTestServer(t)
+	owner := ts.registerUser(t, "applied-keys-owner@example.com", "Applied Keys Owner")
+	mesh := ts.createMesh(t, owner.Token, "Applied Keys Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	public := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+	rr := ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{PublicKey: public}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var key meshdb.AdminKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &key))
+
+	rr = ts.makeRequest(t, "POST", meshPath+"/nodes", CreateNodeRequest{HardwareID: "!0000c001", Name: "KEYS"}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var node meshdb.Node
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &node))
+	appliedPath := fmt.Sprintf("%s/nodes/%d/applied", meshPath, node.ID)
+	keyPath := fmt.Sprintf("%s/admin-keys/%d", meshPath, key.ID)
+
+	rr = ts.makeRequest(t, "POST", appliedPath, MarkNodeAppliedRequest{AdminKeys: &[]string{public}}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", keyPath, nil, owner.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Leaving admin_keys out, or sending null, says nothing about them
+	for _, body := range []string{`{}`, `{"admin_keys": null}`} {
+		rr = ts.makeRequest(t, "POST", appliedPath, json.RawMessage(body), owner.Token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		rr = ts.makeRequest(t, "DELETE", keyPath, nil, owner.Token)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	}
+
+	// An empty list means the device no longer holds the key
+	rr = ts.makeRequest(t, "POST", appliedPath, json.RawMessage(`{"admin_keys": []}`), owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", keyPath, nil, owner.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSetEffectiveAdminKeys(t *testing.T) {
+	first := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	second := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	// Keys are listed newest first and written oldest first, and ones that
+	// aren't X25519 keys are left out
+	keys := []meshdb.AdminKey{
+		{ID: 3, PublicKey: []byte(second)},
+		{ID: 2, PublicKey: []byte("ssh-rsa AAAAB3NzaC1yc2E")},
+		{ID: 1, PublicKey: []byte(first)},
+	}
+	resp := EffectiveConfigResponse{Config: map[string]any{}}
+	setEffectiveAdminKeys(&resp, keys)
+	security := resp.Config["security"].(map[string]any)
+	assert.Equal(t, []string{first, second}, security["admin_key"])
+
+	// Without usable keys the node's own are left alone
+	resp = EffectiveConfigResponse{Config: map[string]any{}}
+	setEffectiveAdminKeys(&resp, keys[1:2])
+	assert.NotContains(t, resp.Config, "security")
+}
+
+func TestGeneratedAdminKeys(t *testing.T) {
+	ts := setupTestServer(t)
+	owner := ts.registerUser(t, "keygen-owner@example.com", "Keygen Owner")
+	admin := ts.registerUser(t, "keygen-admin@example.com", "Keygen Admin")
+	mesh := ts.createMesh(t, owner.Token, "Keygen Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	rr := ts.makeRequest(t, "POST", meshPath+"/access", GrantAccessRequest{
+		UserEmail:   "keygen-admin@example.com",
+		AccessLevel: "admin",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{
+		PublicKey: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+		Generate:  true,
+	}, admin.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	name := "Generated"
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{
+		KeyName:  &name,
+		Generate: true,
+	}, admin.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "private_key_encrypted")
+	var key meshdb.AdminKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &key))
+	assert.True(t, key.HasPrivateKey)
+
+	// The stored private key is encrypted
+	stored, err := ts.server.DB().GetAdminKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.PrivateKeyEncrypted)
+
+	keyPath := fmt.Sprintf("%s/admin-keys/%d/private-key", meshPath, key.ID)
+
+	// Only owners can download
+	rr = ts.makeRequest(t, "GET", keyPath, nil, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", keyPath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var download AdminKeyPrivateKeyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &download))
+	private, err := base64.StdEncoding.DecodeString(download.PrivateKey)
+	require.NoError(t, err)
+	public, err := meshcrypto.PublicKey(private)
+	require.NoError(t, err)
+	assert.Equal(t, string(key.PublicKey), base64.StdEncoding.EncodeToString(public))
+
+	rr = ts.makeRequest(t, "GET", meshPath+"/admin-key-downloads", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var downloads []meshdb.AdminKeyDownload
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &downloads))
+	require.Len(t, downloads, 1)
+	assert.Equal(t, owner.User.ID, downloads[0].UserID)
+	assert.Equal(t, key.ID, *downloads[0].AdminKeyID)
+
+	rr = ts.makeRequest(t, "GET", meshPath+"/admin-key-downloads", nil, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Only owners can turn downloads off, and then nobody can download
+	disabled := false
+	rr = ts.makeRequest(t, "PUT", meshPath, UpdateMeshRequest{AdminKeyDownloadEnabled: &disabled}, admin.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = ts.makeRequest(t, "PUT", meshPath, UpdateMeshRequest{AdminKeyDownloadEnabled: &disabled}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", keyPath, nil, owner.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Keys added by public key have nothing to download
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{
+		PublicKey: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var plain meshdb.AdminKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &plain))
+	assert.False(t, plain.HasPrivateKey)
+
+	// Generating needs the master key
+	secrets.SetKeyring(nil)
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{Generate: true}, owner.Token)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestApplySigningKey(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "signing-owner@example.com", "Signing Owner")
+	mesh := ts.createMesh(t, owner.Token, "Signing Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	rr := ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{Generate: true}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var generated meshdb.AdminKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &generated))
+	generatedPublic, err := adminKeyPublicKey(generated)
+	require.NoError(t, err)
+
+	// A radio holding the generated key signs with it
+	key, private, err := ts.server.applySigningKey(ctx, mesh.ID, generatedPublic)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, generated.ID, key.ID)
+	public, err := meshcrypto.PublicKey(private)
+	require.NoError(t, err)
+	assert.Equal(t, generatedPublic, public)
+
+	// Any other radio key can't be used
+	key, _, err = ts.server.applySigningKey(ctx, mesh.ID, bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	// The configured gateway admin key is preferred when the mesh has it
+	gatewayKey := bytes.Repeat([]byte{0x07}, 32)
+	gatewayPublic, err := meshcrypto.PublicKey(gatewayKey)
+	require.NoError(t, err)
+	rr = ts.makeRequest(t, "POST", meshPath+"/admin-keys", CreateAdminKeyRequest{
+		PublicKey: base64.StdEncoding.EncodeToString(gatewayPublic),
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	ts.server.config.Gateway.AdminKey = gatewayKey
+	key, private, err = ts.server.applySigningKey(ctx, mesh.ID, generatedPublic)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, gatewayKey, private)
+
+	// Stored keys can't be opened without the master key
+	ts.server.config.Gateway.AdminKey = nil
+	secrets.SetKeyring(nil)
+	_, _, err = ts.server.applySigningKey(ctx, mesh.ID, generatedPublic)
+	assert.ErrorIs(t, err, errNoMasterKey)
+}
+
+func TestNodeSecretsRedacted(t *testing.T) {
+	ts := setupTestServer(t)
+	owner := ts.registerUser(t, "secrets-owner@example.com", "Secrets Owner")
+	viewer := ts.registerUser(t, "secrets-viewer@example.com", "Secrets Viewer")
+	admin := ts.registerUser(t, "secrets-admin@example.com", "Secrets Admin")
+	mesh := ts.createMesh(t, owner.Token, "Secrets Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	rr := ts.makeRequest(t, "POST", meshPath+"/access", GrantAccessRequest{
+		UserEmail:   "secrets-viewer@example.com",
+		AccessLevel: "viewer",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	rr = ts.makeRequest(t, "POST", meshPath+"/access", GrantAccessRequest{
+		UserEmail:   "secrets-admin@example.com",
+		AccessLevel: "admin",
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	privateKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x11}, 32))
+	appliedKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x22}, 32))
+	channelPSK := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x33}, 16))
+	meshPSK := bytes.Repeat([]byte{0x44}, 16)
+	wifiPSK := "wifi-secret-psk"
+	mqttPassword := "mqtt-secret-password"
+	secrets := []string{privateKey, appliedKey, channelPSK, base64.StdEncoding.EncodeToString(meshPSK), wifiPSK, mqttPassword}
+
+	// The mesh wants a different channel PSK than the device has, so the
+	// drift report has a secret to hide
+	rr = ts.makeRequest(t, "PUT", meshPath+"/channels/0", UpsertChannelRequest{
+		Role: "PRIMARY",
+		Name: func(s string) *string { return &s }("ranch"),
+		PSK:  meshPSK,
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// assertNoSecrets checks a response for every secret, in the clear and
+	// inside base64 encoded raw configs
+	assertNoSecrets := func(t *testing.T, route string, body []byte) {
+		t.Helper()
+		docs := []string{string(body)}
+		var nodes []NodeResponse
+		var node NodeResponse
+		if json.Unmarshal(body, &nodes) == nil {
+			for _, n := range nodes {
+				docs = append(docs, string(n.RawDeviceConfig))
+			}
+		} else if json.Unmarshal(body, &node) == nil {
+			docs = append(docs, string(node.RawDeviceConfig))
+		}
+		for _, doc := range docs {
+			for _, secret := range secrets {
+				assert.NotContains(t, doc, secret, "%s leaks a secret", route)
+			}
+		}
+	}
+
+	rr = ts.makeRequest(t, "POST", meshPath+"/nodes/import", ImportNodeConfigRequest{
+		NodeNum:    0x0c0ffee0,
+		HardwareID: "!0c0ffee0",
+		LongName:   "Secret Node",
+		ShortName:  "SCRT",
+		Config: json.RawMessage(fmt.Sprintf(`{
+			"security": {"private_key": %q},
+			"network": {"wifi_ssid": "ranch", "wifi_psk": %q}
+		}`, privateKey, wifiPSK)),
+		ModuleConfig: json.RawMessage(fmt.Sprintf(`{"mqtt": {"username": "node", "password": %q}}`, mqttPassword)),
+		Channels:     json.RawMessage(fmt.Sprintf(`[{"settings": {"psk": %q, "name": "ranch"}, "role": 1}]`, channelPSK)),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assertNoSecrets(t, "import", rr.Body.Bytes())
+	var imported ImportNodeConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+	assert.True(t, imported.PrivateKeyRedacted)
+	assert.True(t, imported.RawDeviceConfigRedacted)
+	assert.Contains(t, string(imported.RawDeviceConfig), "ranch")
+	nodePath := fmt.Sprintf("%s/nodes/%d", meshPath, imported.ID)
+
+	rr = ts.makeRequest(t, "POST", nodePath+"/applied", MarkNodeAppliedRequest{PrivateKey: &appliedKey}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assertNoSecrets(t, "applied", rr.Body.Bytes())
+
+	rr = ts.makeRequest(t, "POST", meshPath+"/nodes", CreateNodeRequest{
+		HardwareID: "!0c0ffee1",
+		Name:       "NEW",
+		PrivateKey: &privateKey,
+	}, owner.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	assertNoSecrets(t, "create", rr.Body.Bytes())
+
+	name := "Renamed"
+	rr = ts.makeRequest(t, "PUT", nodePath, UpdateNodeRequest{Name: &name, PrivateKey: &privateKey}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assertNoSecrets(t, "update", rr.Body.Bytes())
+
+	rr = ts.makeRequest(t, "PATCH", nodePath+"/status", UpdateNodeStatusRequest{Status: "online"}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assertNoSecrets(t, "status", rr.Body.Bytes())
+
+	// Every node route a viewer can read, and the node routes admins read
+	viewerRoutes := []string{
+		meshPath + "/nodes",
+		nodePath,
+		nodePath + "/drift",
+		nodePath + "/status-history",
+		nodePath + "/telemetry",
+		nodePath + "/telemetry/latest",
+		nodePath + "/positions",
+		nodePath + "/apply-jobs",
+		meshPath + "/drift",
+	}
+	for _, route := range viewerRoutes {
+		rr = ts.makeRequest(t, "GET", route, nil, viewer.Token)
+		assert.Equal(t, http.StatusOK, rr.Code, route)
+		assertNoSecrets(t, route, rr.Body.Bytes())
+	}
+	// Nor do the routes owners and admins read, drift included
+	for _, token := range []string{owner.Token, admin.Token} {
+		for _, route := range []string{meshPath + "/nodes", nodePath, nodePath + "/drift", meshPath + "/drift"} {
+			rr = ts.makeRequest(t, "GET", route, nil, token)
+			assert.Equal(t, http.StatusOK, rr.Code, route)
+			assertNoSecrets(t, route, rr.Body.Bytes())
+		}
+	}
+	rr = ts.makeRequest(t, "GET", nodePath+"/drift", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var drift NodeDriftResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drift))
+	require.NotEmpty(t, drift.Drift)
+	for _, entry := range drift.Drift {
+		if isSecretDriftPath(entry.Path) {
+			assert.True(t, entry.Redacted, entry.Path)
+		}
+	}
+
+	// Node responses carry only the redaction flags, never a key field
+	rr = ts.makeRequest(t, "GET", nodePath, nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), `"private_key":`)
+	assert.NotContains(t, rr.Body.String(), `"applied_private_key":`)
+	assert.Contains(t, rr.Body.String(), `"private_key_redacted":true`)
+
+	// Secrets are only on the admin routes
+	for _, route := range []string{nodePath + "/secrets", nodePath + "/effective-config"} {
+		rr = ts.makeRequest(t, "GET", route, nil, viewer.Token)
+		assert.Equal(t, http.StatusNotFound, rr.Code, route)
+	}
+
+	// Nothing handed out secrets so far, so nothing was recorded
+	rr = ts.makeRequest(t, "GET", meshPath+"/node-secret-reveals", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var reveals []meshdb.NodeSecretReveal
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &reveals))
+	assert.Empty(t, reveals)
+
+	rr = ts.makeRequest(t, "GET", nodePath+"/secrets", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var revealed NodeSecretsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &revealed))
+	assert.Equal(t, privateKey, *revealed.PrivateKey)
+	assert.Equal(t, appliedKey, *revealed.AppliedPrivateKey)
+	assert.Contains(t, string(revealed.RawDeviceConfig), wifiPSK)
+	assert.Contains(t, string(revealed.RawDeviceConfig), mqttPassword)
+
+	rr = ts.makeRequest(t, "GET", nodePath+"/effective-config", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), privateKey)
+
+	// Every reveal is recorded, and only owners read the record
+	rr = ts.makeRequest(t, "GET", meshPath+"/node-secret-reveals", nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &reveals))
+	require.Len(t, reveals, 2)
+	assert.Equal(t, admin.User.ID, reveals[0].UserID)
+	assert.Equal(t, owner.User.ID, reveals[1].UserID)
+	for _, reveal := range reveals {
+		assert.Equal(t, imported.ID, *reveal.NodeID)
+		assert.Equal(t, "!0c0ffee0", reveal.HardwareID)
+	}
+
+	rr = ts.makeRequest(t, "GET", meshPath+"/node-secret-reveals", nil, viewer.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRedactDeviceConfig(t *testing.T) {
+	raw := []byte(`{
+		"long_name": "Node",
+		"config": {
+			"security": {"public_key": "cHVibGlj", "private_key": "c2VjcmV0"},
+			"network": {"wifi_ssid": "ranch", "wifi_psk": "hunter2"}
+		},
+		"module_config": {"mqtt": {"username": "node", "password": "hunter3"}},
+		"channels": [{"index": 0, "settings": {"name": "ranch", "psk": "AQ=="}}, {"index": 1}]
+	}`)
+	redacted, ok := redactDeviceConfig(raw)
+	assert.True(t, ok)
+	assert.JSONEq(t, `{
+		"long_name": "Node",
+		"config": {
+			"security": {"public_key": "cHVibGlj"},
+			"network": {"wifi_ssid": "ranch"}
+		},
+		"module_config": {"mqtt": {"username": "node"}},
+		"channels": [{"index": 0, "settings": {"name": "ranch"}}, {"index": 1}]
+	}`, string(redacted))
+
+	// Configs without secrets are returned as they are
+	plain := []byte(`{"config": {"lora": {"hop_limit": 3}}}`)
+	redacted, ok = redactDeviceConfig(plain)
+	assert.False(t, ok)
+	assert.Equal(t, plain, redacted)
+
+	// Anything unreadable is withheld
+	redacted, ok = redactDeviceConfig([]byte("not json"))
+	assert.True(t, ok)
+	assert.Nil(t, redacted)
+}
+
+func TestIsSecretDriftPath(t *testing.T) {
+	for _, path := range secrets.DeviceConfigSecretPaths {
+		assert.True(t, isSecretDriftPath(path), path)
+	}
+	assert.True(t, isSecretDriftPath("channels.1.psk"))
+	assert.False(t, isSecretDriftPath("channels.1.name"))
+	assert.False(t, isSecretDriftPath("config.security.public_key"))
+}
+
+func TestNodeResponseFields(t *testing.T) {
+	secret := "c2VjcmV0c2VjcmV0"
+	key := secrets.Text(secret)
+	node := meshdb.Node{
+		ID:                1,
+		HardwareID:        "!00000001",
+		PrivateKey:        &key,
+		AppliedPrivateKey: &key,
+		RawDeviceConfig:   []byte(`{"config": {"security": {"private_key": "` + secret + `"}}}`),
+	}
+	resp := newNodeResponse(node)
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+	assert.NotContains(t, string(resp.RawDeviceConfig), secret)
+	assert.True(t, resp.PrivateKeyRedacted)
+	assert.True(t, resp.AppliedPrivateKeyRedacted)
+	assert.True(t, resp.RawDeviceConfigRedacted)
+
+	// Every node column but the private keys is in the response under the
+	// same name, so clients reading meshdb.Node keep working
+	var nodeFields, respFields map[string]any
+	data, err = json.Marshal(node)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &nodeFields))
+	data, err = json.Marshal(resp)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &respFields))
+	for field := range nodeFields {
+		if field == "private_key" || field == "applied_private_key" {
+			assert.NotContains(t, respFields, field)
+			assert.Contains(t, respFields, field+"_redacted")
+			continue
+		}
+		assert.Contains(t, respFields, field)
+	}
+}
+
+func TestSecretsEncryptedAtRest(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	owner := ts.registerUser(t, "sealed-owner@example.com", "Sealed Owner")
+	mesh := ts.createMesh(t, owner.Token, "Sealed Mesh")
+	meshPath := fmt.Sprintf("/api/meshes/%d", mesh.ID)
+
+	privateKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x44}, 32))
+	wifiPSK := "sealed-wifi-psk"
+	rr := ts.makeRequest(t, "POST", meshPath+"/nodes/import", ImportNodeConfigRequest{
+		NodeNum:    0x0c0ffee2,
+		HardwareID: "!0c0ffee2",
+		LongName:   "Sealed Node",
+		ShortName:  "SEAL",
+		Config: json.RawMessage(fmt.Sprintf(`{
+			"security": {"private_key": %q},
+			"network": {"wifi_ssid": "ranch", "wifi_psk": %q}
+		}`, privateKey, wifiPSK)),
+	}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var imported ImportNodeConfigResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+
+	psk := bytes.Repeat([]byte{0x55}, 16)
+	rr = ts.makeRequest(t, "PUT", meshPath+"/channels/0", UpsertChannelRequest{Role: ChannelRolePrimary, PSK: psk}, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// The database only holds sealed values
+	var storedKey, storedConfig string
+	err := ts.db.QueryRow(ctx, "SELECT private_key, raw_device_config::text FROM nodes WHERE id = $1", imported.ID).Scan(&storedKey, &storedConfig)
+	require.NoError(t, err)
+	id, ok := secrets.SealedKeyID([]byte(storedKey))
+	assert.True(t, ok)
+	assert.Equal(t, "1", id)
+	assert.NotContains(t, storedConfig, privateKey)
+	assert.NotContains(t, storedConfig, wifiPSK)
+	assert.Contains(t, storedConfig, "ranch")
+
+	var storedPSK []byte
+	err = ts.db.QueryRow(ctx, "SELECT psk FROM mesh_channels WHERE mesh_id = $1 AND channel_index = 0", mesh.ID).Scan(&storedPSK)
+	require.NoError(t, err)
+	assert.True(t, secrets.IsSealed(storedPSK))
+
+	// After the master key is rotated, values sealed under the old key
+	// still read while it is kept as an old key
+	ts.server.config.Secrets.OldMasterKeys = map[string][]byte{"1": ts.server.config.Secrets.MasterKey}
+	ts.server.config.Secrets.MasterKey = bytes.Repeat([]byte{0x6b}, 32)
+	ts.server.config.Secrets.MasterKeyID = "2"
+	keyring, err := ts.server.config.Secrets.Keyring()
+	require.NoError(t, err)
+	secrets.SetKeyring(keyring)
+
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("%s/nodes/%d/secrets", meshPath, imported.ID), nil, owner.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var revealed NodeSecretsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &revealed))
+	require.NotNil(t, revealed.PrivateKey)
+	assert.Equal(t, privateKey, *revealed.PrivateKey)
+	assert.Contains(t, string(revealed.RawDeviceConfig), wifiPSK)
+
+	channel, err := ts.server.DB().GetPrimaryChannel(ctx, mesh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, psk, []byte(channel.Psk))
+}
+
+func TestMigrations(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+
+	migrations, err := migrate.Embedded()
+	require.NoError(t, err)
+	migrator := migrate.New(ts.db, migrations)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), status.Version)
+	assert.Empty(t, status.Pending)
+
+	// Already up to date
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	reverted, err := migrator.Down(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reverted)
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-3].Version, status.Version)
+	assert.Len(t, status.Pending, 2)
+
+	// Every down migration works, and the schema can be rebuilt after
+	reverted, err = migrator.To(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations)-2, reverted)
+	var tables int
+	require.NoError(t, ts.db.QueryRow(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name <> 'schema_migrations'").Scan(&tables))
+	assert.Equal(t, 0, tables)
+
+	_, err = migrator.To(ctx, 12345)
+	assert.Error(t, err)
+
+	applied, err = migrator.To(ctx, migrations[0].Version)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, applied)
+
+	// A dirty schema, left by a failed golang-migrate run, is refused
+	_, err = ts.db.Exec(ctx, "UPDATE schema_migrations SET dirty = TRUE")
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, migrate.ErrDirty)
+}
+
+func TestDisabledUser(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	user := ts.registerUser(t, "disabled@example.com", "Disabled User")
+
+	rr := ts.makeRequest(t, "POST", "/api/user/api-keys", CreateAPIKeyRequest{KeyName: "script"}, user.Token)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var created CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	disabled, err := ts.server.DB().SetUserDisabled(ctx, meshdb.SetUserDisabledParams{ID: user.User.ID, Disabled: true})
+	require.NoError(t, err)
+	require.NotNil(t, disabled.DisabledAt)
+
+	// Disabling again keeps the original time
+	again, err := ts.server.DB().SetUserDisabled(ctx, meshdb.SetUserDisabledParams{ID: user.User.ID, Disabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, disabled.DisabledAt, again.DisabledAt)
+
+	// Neither logging in nor existing tokens and API keys work
+	rr = ts.makeRequest(t, "POST", "/api/auth/login", LoginRequest{Email: "disabled@example.com", Password: "password"}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, user.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/auth/me", nil, user.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, created.APIKey)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	enabled, err := ts.server.DB().SetUserDisabled(ctx, meshdb.SetUserDisabledParams{ID: user.User.ID, Disabled: false})
+	require.NoError(t, err)
+	assert.Nil(t, enabled.DisabledAt)
+
+	rr = ts.makeRequest(t, "POST", "/api/auth/login", LoginRequest{Email: "disabled@example.com", Password: "password"}, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, created.APIKey)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSiteAdmin(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+	admin := ts.registerUser(t, "siteadmin@example.com", "Site Admin")
+	owner := ts.registerUser(t, "meshowner@example.com", "Mesh Owner")
+	other := ts.registerUser(t, "other@example.com", "Other User")
+	mesh := ts.createMesh(t, owner.Token, "Customer Mesh")
+
+	// Only site admins reach the admin API
+	rr := ts.makeRequest(t, "GET", "/api/admin/users", nil, admin.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	_, err := ts.server.DB().SetUserAdmin(ctx, meshdb.SetUserAdminParams{ID: admin.User.ID, IsAdmin: true})
+	require.NoError(t, err)
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/users", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var users []meshdb.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &users))
+	assert.Len(t, users, 3)
+	assert.NotContains(t, rr.Body.String(), "password_hash")
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/users?limit=0", nil, admin.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/meshes", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var meshes []meshdb.ListAllMeshesRow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &meshes))
+	require.Len(t, meshes, 1)
+	assert.Equal(t, "meshowner@example.com", meshes[0].OwnerEmail)
+
+	// Site admins can view any mesh, but not change it
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d", mesh.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d/nodes", mesh.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("/api/meshes/%d", mesh.ID), nil, admin.Token)
+	assert.NotEqual(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", fmt.Sprintf("/api/meshes/%d", mesh.ID), nil, other.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Admins can't lock themselves out
+	disable := true
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/admin/users/%d", admin.User.ID), UpdateAdminUserRequest{Disabled: &disable}, admin.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "PUT", fmt.Sprintf("/api/admin/users/%d", other.User.ID), UpdateAdminUserRequest{Disabled: &disable}, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var updated meshdb.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.NotNil(t, updated.DisabledAt)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, other.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Disabled users can't be impersonated
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/admin/users/%d/impersonate", other.User.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/admin/users/%d/impersonate", owner.User.ID), nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var impersonated ImpersonateResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &impersonated))
+	assert.Equal(t, owner.User.ID, impersonated.User.ID)
+	assert.WithinDuration(t, time.Now().Add(impersonationExpiration), impersonated.ExpiresAt, time.Minute)
+	rr = ts.makeRequest(t, "GET", "/api/auth/me", nil, impersonated.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "meshowner@example.com")
+
+	// Owners of meshes can't be deleted
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("/api/admin/users/%d", owner.User.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", fmt.Sprintf("/api/admin/users/%d", other.User.ID), nil, admin.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	_, err = ts.server.DB().GetUserByID(ctx, other.User.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/audit-log", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var entries []meshdb.ListAdminAuditLogRow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	require.Len(t, entries, 3)
+	assert.Equal(t, AuditActionDelete, entries[0].Action)
+	assert.Nil(t, entries[0].TargetUserID)
+	assert.Equal(t, "other@example.com", entries[0].TargetEmail)
+	assert.Equal(t, AuditActionImpersonate, entries[1].Action)
+	assert.Equal(t, AuditActionDisable, entries[2].Action)
+	require.NotNil(t, entries[2].AdminEmail)
+	assert.Equal(t, "siteadmin@example.com", *entries[2].AdminEmail)
+
+	// Impersonation tokens are audited, kept out of the admin API, and can
+	// be ended without signing the user out
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, impersonated.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/admin/users", nil, impersonated.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = ts.makeRequest(t, "POST", "/api/user/api-keys", CreateAPIKeyRequest{KeyName: "backdoor"}, impersonated.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/impersonations", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var open []meshdb.ListActiveImpersonationsRow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &open))
+	require.Len(t, open, 1)
+	assert.Equal(t, impersonated.ImpersonationID, open[0].ID)
+	assert.Equal(t, "meshowner@example.com", open[0].TargetEmail)
+
+	rr = ts.makeRequest(t, "POST", "/api/auth/logout", nil, impersonated.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, impersonated.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/auth/me", nil, impersonated.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, owner.Token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = ts.makeRequest(t, "POST", fmt.Sprintf("/api/admin/users/%d/impersonate", owner.User.ID), nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &impersonated))
+	endPath := fmt.Sprintf("/api/admin/impersonations/%d", impersonated.ImpersonationID)
+	rr = ts.makeRequest(t, "DELETE", endPath, nil, impersonated.Token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", endPath, nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = ts.makeRequest(t, "GET", "/api/meshes", nil, impersonated.Token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = ts.makeRequest(t, "DELETE", endPath, nil, admin.Token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ts.makeRequest(t, "GET", "/api/admin/audit-log", nil, admin.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	var requests []string
+	for _, entry := range entries {
+		if entry.Action == AuditActionImpersonatedRequest {
+			require.NotNil(t, entry.Detail)
+			assert.Equal(t, admin.User.ID, *entry.AdminUserID)
+			assert.Equal(t, owner.User.ID, *entry.TargetUserID)
+			requests = append(requests, *entry.Detail)
+		}
+	}
+	assert.Equal(t, []string{
+		"DELETE " + endPath,
+		"POST /api/user/api-keys",
+		"GET /api/admin/users",
+		"GET /api/meshes",
+	}, requests)
+	assert.Equal(t, AuditActionEndImpersonation, entries[0].Action)
+
+	// Open registration can be turned off
+	ts.server.config.Auth.RegistrationEnabled = false
+	rr = ts.makeRequest(t, "POST", "/api/auth/register", RegisterRequest{
+		Email:       "late@example.com",
+		Password:    "password",
+		DisplayName: "Late",
+	}, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
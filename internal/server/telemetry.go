@@ -0,0 +1,263 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+const (
+	// defaultTelemetryWindow is how far back telemetry is returned when no
+	// from time is given
+	defaultTelemetryWindow = 24 * time.Hour
+
+	// targetTelemetryPoints is roughly how many points are returned when no
+	// bucket width is given
+	targetTelemetryPoints = 500
+
+	// maxTelemetryPoints limits how many buckets a query may ask for
+	maxTelemetryPoints = 10000
+
+	minTelemetryBucket = time.Minute
+)
+
+var errTooManyBuckets = errors.New("too many buckets")
+
+// TelemetryPoint is one downsampled bucket of a metric
+type TelemetryPoint struct {
+	Time    time.Time `json:"time"`
+	Avg     float64   `json:"avg"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Samples int64     `json:"samples"`
+}
+
+// TelemetryResponse is a downsampled metric series
+type TelemetryResponse struct {
+	NodeID        int64            `json:"node_id"`
+	Metric        string           `json:"metric"`
+	From          time.Time        `json:"from"`
+	To            time.Time        `json:"to"`
+	BucketSeconds int64            `json:"bucket_seconds"`
+	Points        []TelemetryPoint `json:"points"`
+}
+
+// telemetryBucket picks the bucket width for a time range. An explicit
+// width is used as given unless it would produce too many points; without
+// one, the range is split into about targetTelemetryPoints buckets.
+func telemetryBucket(from, to time.Time, requested time.Duration) (time.Duration, error) {
+	span := to.Sub(from)
+	if requested > 0 {
+		if span/requested > maxTelemetryPoints {
+			return 0, errTooManyBuckets
+		}
+		return requested, nil
+	}
+	bucket := (span / targetTelemetryPoints).Round(time.Second)
+	if bucket < minTelemetryBucket {
+		bucket = minTelemetryBucket
+	}
+	return bucket, nil
+}
+
+// handleGetNodeTelemetry handles fetching one metric of a node's telemetry,
+// averaged into time buckets. from and to are RFC 3339 (default the last 24
+// hours) and bucket is a duration such as 5m or 1h.
+func (s *Server) handleGetNodeTelemetry(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if metric == "" {
+		writeError(w, http.StatusBadRequest, "Metric is required")
+		return
+	}
+
+	to := time.Now()
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid to time")
+			return
+		}
+	}
+	from := to.Add(-defaultTelemetryWindow)
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid from time")
+			return
+		}
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "From time must be before to time")
+		return
+	}
+
+	var requested time.Duration
+	if bucketStr := query.Get("bucket"); bucketStr != "" {
+		requested, err = time.ParseDuration(bucketStr)
+		if err != nil || requested < time.Second {
+			writeError(w, http.StatusBadRequest, "Invalid bucket")
+			return
+		}
+	}
+	bucket, err := telemetryBucket(from, to, requested)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bucket is too small for the time range")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	rows, err := s.DB().ListNodeTelemetryBuckets(r.Context(), meshdb.ListNodeTelemetryBucketsParams{
+		BucketSeconds: bucket.Seconds(),
+		NodeID:        nodeID,
+		Metric:        metric,
+		FromTime:      from,
+		ToTime:        to,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get telemetry")
+		return
+	}
+
+	points := make([]TelemetryPoint, len(rows))
+	for i, row := range rows {
+		points[i] = TelemetryPoint{
+			Time:    row.Bucket,
+			Avg:     row.AvgValue,
+			Min:     row.MinValue,
+			Max:     row.MaxValue,
+			Samples: row.Samples,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, TelemetryResponse{
+		NodeID:        nodeID,
+		Metric:        metric,
+		From:          from,
+		To:            to,
+		BucketSeconds: int64(bucket.Seconds()),
+		Points:        points,
+	})
+}
+
+// handleGetNodeLatestTelemetry handles listing the metrics a node has
+// reported, with the most recent value of each
+func (s *Server) handleGetNodeLatestTelemetry(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	nodeIDStr := r.PathValue("nodeID")
+	nodeID, err := strconv.ParseInt(nodeIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	node, err := s.DB().GetNode(r.Context(), nodeID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Node not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get node")
+		return
+	}
+
+	if node.MeshID != meshID {
+		writeError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	latest, err := s.DB().ListNodeTelemetryMetrics(r.Context(), nodeID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get telemetry")
+		return
+	}
+	if latest == nil {
+		latest = []meshdb.ListNodeTelemetryMetricsRow{}
+	}
+
+	writeJSON(w, http.StatusOK, latest)
+}
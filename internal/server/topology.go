@@ -0,0 +1,203 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/skandragon/meshmgr/meshdb"
+)
+
+// TopologyNode is a node in the mesh graph. Nodes heard as neighbors but
+// not managed in the mesh have no ID or name.
+type TopologyNode struct {
+	NodeNum  int64   `json:"node_num"`
+	NodeID   *int64  `json:"node_id"`
+	Name     string  `json:"name"`
+	LongName string  `json:"long_name"`
+	Status   *string `json:"status"`
+}
+
+// TopologyEdge is a radio link: To has heard From
+type TopologyEdge struct {
+	From      int64     `json:"from"`
+	To        int64     `json:"to"`
+	SNR       *float32  `json:"snr"`
+	Source    string    `json:"source"`
+	LastHeard time.Time `json:"last_heard"`
+}
+
+// TopologyResponse is the mesh graph
+type TopologyResponse struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// nodeNumID is the conventional !hex name of a node number
+func nodeNumID(num int64) string {
+	return fmt.Sprintf("!%08x", uint32(num))
+}
+
+// buildTopology joins the mesh's nodes to its edges. Every managed node
+// with a node_num is included, linked or not, along with any unmanaged
+// node an edge refers to.
+func buildTopology(nodes []meshdb.Node, edges []meshdb.TopologyEdge) TopologyResponse {
+	byNum := make(map[int64]TopologyNode)
+	for _, n := range nodes {
+		if n.NodeNum == nil {
+			continue
+		}
+		id := n.ID
+		byNum[*n.NodeNum] = TopologyNode{
+			NodeNum:  *n.NodeNum,
+			NodeID:   &id,
+			Name:     n.Name,
+			LongName: n.LongName,
+			Status:   n.Status,
+		}
+	}
+
+	topo := TopologyResponse{
+		Nodes: []TopologyNode{},
+		Edges: make([]TopologyEdge, len(edges)),
+	}
+	for i, e := range edges {
+		for _, num := range []int64{e.FromNum, e.ToNum} {
+			if _, ok := byNum[num]; !ok {
+				byNum[num] = TopologyNode{NodeNum: num, Name: nodeNumID(num)}
+			}
+		}
+		edge := TopologyEdge{
+			From:      e.FromNum,
+			To:        e.ToNum,
+			Source:    e.Source,
+			LastHeard: e.LastHeard,
+		}
+		if e.Snr.Valid {
+			snr := e.Snr.Float32
+			edge.SNR = &snr
+		}
+		topo.Edges[i] = edge
+	}
+
+	for _, n := range byNum {
+		topo.Nodes = append(topo.Nodes, n)
+	}
+	sort.Slice(topo.Nodes, func(i, j int) bool {
+		return topo.Nodes[i].NodeNum < topo.Nodes[j].NodeNum
+	})
+	return topo
+}
+
+// dotQuote quotes a string as a Graphviz ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeTopologyDOT writes the mesh graph in Graphviz DOT. Unmanaged nodes
+// are dashed and edges are labelled with their SNR.
+func writeTopologyDOT(w io.Writer, name string, topo TopologyResponse) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+	for _, n := range topo.Nodes {
+		label := n.Name
+		if n.LongName != "" {
+			label += "\n" + n.LongName
+		}
+		attrs := "label=" + dotQuote(label)
+		if n.NodeID == nil {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(nodeNumID(n.NodeNum)), attrs)
+	}
+	for _, e := range topo.Edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(nodeNumID(e.From)), dotQuote(nodeNumID(e.To)))
+		if e.SNR != nil {
+			fmt.Fprintf(&b, " [label=%s]", dotQuote(fmt.Sprintf("%.2f dB", *e.SNR)))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// handleGetMeshTopology handles fetching which nodes hear which, as JSON or,
+// with format=dot, as a Graphviz graph
+func (s *Server) handleGetMeshTopology(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	meshIDStr := r.PathValue("meshID")
+	meshID, err := strconv.ParseInt(meshIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mesh ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		writeError(w, http.StatusBadRequest, "Invalid format")
+		return
+	}
+
+	// Check if user has at least viewer access
+	if _, err := s.requireMeshAccess(r.Context(), user.ID, meshID, AccessLevelViewer); err != nil {
+		if err == pgx.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Mesh not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	mesh, err := s.DB().GetMeshByID(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get mesh")
+		return
+	}
+
+	nodes, err := s.DB().ListNodesByMesh(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list nodes")
+		return
+	}
+	edges, err := s.DB().ListTopologyEdges(r.Context(), meshID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list topology")
+		return
+	}
+	topo := buildTopology(nodes, edges)
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(http.StatusOK)
+		_ = writeTopologyDOT(w, mesh.Name, topo)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, topo)
+}
@@ -0,0 +1,116 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: admin_audit_log.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+)
+
+const createAdminAuditLog = `-- name: CreateAdminAuditLog :one
+INSERT INTO admin_audit_log (admin_user_id, action, target_user_id, target_email, remote_addr, user_agent, impersonation_id, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, admin_user_id, action, target_user_id, target_email, remote_addr, user_agent, created_at, impersonation_id, detail
+`
+
+type CreateAdminAuditLogParams struct {
+	AdminUserID     *int64  `json:"admin_user_id"`
+	Action          string  `json:"action"`
+	TargetUserID    *int64  `json:"target_user_id"`
+	TargetEmail     string  `json:"target_email"`
+	RemoteAddr      *string `json:"remote_addr"`
+	UserAgent       *string `json:"user_agent"`
+	ImpersonationID *int64  `json:"impersonation_id"`
+	Detail          *string `json:"detail"`
+}
+
+func (q *Queries) CreateAdminAuditLog(ctx context.Context, arg CreateAdminAuditLogParams) (AdminAuditLog, error) {
+	row := q.db.QueryRow(ctx, createAdminAuditLog,
+		arg.AdminUserID,
+		arg.Action,
+		arg.TargetUserID,
+		arg.TargetEmail,
+		arg.RemoteAddr,
+		arg.UserAgent,
+		arg.ImpersonationID,
+		arg.Detail,
+	)
+	var i AdminAuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.AdminUserID,
+		&i.Action,
+		&i.TargetUserID,
+		&i.TargetEmail,
+		&i.RemoteAddr,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.ImpersonationID,
+		&i.Detail,
+	)
+	return i, err
+}
+
+const listAdminAuditLog = `-- name: ListAdminAuditLog :many
+SELECT
+    l.id, l.admin_user_id, l.action, l.target_user_id, l.target_email, l.remote_addr, l.user_agent, l.created_at, l.impersonation_id, l.detail,
+    u.email AS admin_email
+FROM admin_audit_log l
+LEFT JOIN users u ON u.id = l.admin_user_id
+ORDER BY l.created_at DESC, l.id DESC
+LIMIT $2 OFFSET $1
+`
+
+type ListAdminAuditLogParams struct {
+	RowOffset int32 `json:"row_offset"`
+	RowLimit  int32 `json:"row_limit"`
+}
+
+type ListAdminAuditLogRow struct {
+	ID              int64     `json:"id"`
+	AdminUserID     *int64    `json:"admin_user_id"`
+	Action          string    `json:"action"`
+	TargetUserID    *int64    `json:"target_user_id"`
+	TargetEmail     string    `json:"target_email"`
+	RemoteAddr      *string   `json:"remote_addr"`
+	UserAgent       *string   `json:"user_agent"`
+	CreatedAt       time.Time `json:"created_at"`
+	ImpersonationID *int64    `json:"impersonation_id"`
+	Detail          *string   `json:"detail"`
+	AdminEmail      *string   `json:"admin_email"`
+}
+
+func (q *Queries) ListAdminAuditLog(ctx context.Context, arg ListAdminAuditLogParams) ([]ListAdminAuditLogRow, error) {
+	rows, err := q.db.Query(ctx, listAdminAuditLog, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAdminAuditLogRow
+	for rows.Next() {
+		var i ListAdminAuditLogRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdminUserID,
+			&i.Action,
+			&i.TargetUserID,
+			&i.TargetEmail,
+			&i.RemoteAddr,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.ImpersonationID,
+			&i.Detail,
+			&i.AdminEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
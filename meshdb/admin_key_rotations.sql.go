@@ -0,0 +1,199 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: admin_key_rotations.sql
+
+package meshdb
+
+import (
+	"context"
+)
+
+const createAdminKeyRotation = `-- name: CreateAdminKeyRotation :one
+INSERT INTO admin_key_rotations (mesh_id, old_key_id, new_key_id, started_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (mesh_id) WHERE status = 'in_progress' DO NOTHING
+RETURNING id, mesh_id, old_key_id, new_key_id, status, started_by, created_at, finished_at
+`
+
+type CreateAdminKeyRotationParams struct {
+	MeshID    int64  `json:"mesh_id"`
+	OldKeyID  *int64 `json:"old_key_id"`
+	NewKeyID  int64  `json:"new_key_id"`
+	StartedBy int64  `json:"started_by"`
+}
+
+// Returns no rows if the mesh already has a rotation in progress
+func (q *Queries) CreateAdminKeyRotation(ctx context.Context, arg CreateAdminKeyRotationParams) (AdminKeyRotation, error) {
+	row := q.db.QueryRow(ctx, createAdminKeyRotation,
+		arg.MeshID,
+		arg.OldKeyID,
+		arg.NewKeyID,
+		arg.StartedBy,
+	)
+	var i AdminKeyRotation
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.OldKeyID,
+		&i.NewKeyID,
+		&i.Status,
+		&i.StartedBy,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const finishAdminKeyRotation = `-- name: FinishAdminKeyRotation :one
+UPDATE admin_key_rotations
+SET status = $1, finished_at = NOW()
+WHERE id = $2 AND status = 'in_progress'
+RETURNING id, mesh_id, old_key_id, new_key_id, status, started_by, created_at, finished_at
+`
+
+type FinishAdminKeyRotationParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) FinishAdminKeyRotation(ctx context.Context, arg FinishAdminKeyRotationParams) (AdminKeyRotation, error) {
+	row := q.db.QueryRow(ctx, finishAdminKeyRotation, arg.Status, arg.ID)
+	var i AdminKeyRotation
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.OldKeyID,
+		&i.NewKeyID,
+		&i.Status,
+		&i.StartedBy,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getAdminKeyRotation = `-- name: GetAdminKeyRotation :one
+SELECT id, mesh_id, old_key_id, new_key_id, status, started_by, created_at, finished_at FROM admin_key_rotations
+WHERE id = $1
+`
+
+func (q *Queries) GetAdminKeyRotation(ctx context.Context, id int64) (AdminKeyRotation, error) {
+	row := q.db.QueryRow(ctx, getAdminKeyRotation, id)
+	var i AdminKeyRotation
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.OldKeyID,
+		&i.NewKeyID,
+		&i.Status,
+		&i.StartedBy,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const listAdminKeyRotationNodes = `-- name: ListAdminKeyRotationNodes :many
+SELECT
+    n.id AS node_id,
+    n.name,
+    n.hardware_id,
+    EXISTS (
+        SELECT 1 FROM node_admin_keys nak
+        WHERE nak.node_id = n.id AND nak.admin_key_id = $1 AND nak.is_current
+    ) AS has_new_key,
+    EXISTS (
+        SELECT 1 FROM node_admin_keys nak
+        WHERE nak.node_id = n.id AND nak.admin_key_id = $2 AND nak.is_current
+    ) AS has_old_key,
+    j.status AS last_apply_status,
+    j.error AS last_apply_error
+FROM nodes n
+LEFT JOIN node_apply_jobs j ON j.id = (
+    SELECT MAX(id) FROM node_apply_jobs WHERE node_id = n.id
+)
+WHERE n.mesh_id = $3
+ORDER BY n.name ASC
+`
+
+type ListAdminKeyRotationNodesParams struct {
+	NewKeyID int64  `json:"new_key_id"`
+	OldKeyID *int64 `json:"old_key_id"`
+	MeshID   int64  `json:"mesh_id"`
+}
+
+type ListAdminKeyRotationNodesRow struct {
+	NodeID          int64   `json:"node_id"`
+	Name            string  `json:"name"`
+	HardwareID      string  `json:"hardware_id"`
+	HasNewKey       bool    `json:"has_new_key"`
+	HasOldKey       bool    `json:"has_old_key"`
+	LastApplyStatus *string `json:"last_apply_status"`
+	LastApplyError  *string `json:"last_apply_error"`
+}
+
+// Every node of a mesh with whether it holds the old and new keys of a
+// rotation, and how its last apply job went
+func (q *Queries) ListAdminKeyRotationNodes(ctx context.Context, arg ListAdminKeyRotationNodesParams) ([]ListAdminKeyRotationNodesRow, error) {
+	rows, err := q.db.Query(ctx, listAdminKeyRotationNodes, arg.NewKeyID, arg.OldKeyID, arg.MeshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAdminKeyRotationNodesRow
+	for rows.Next() {
+		var i ListAdminKeyRotationNodesRow
+		if err := rows.Scan(
+			&i.NodeID,
+			&i.Name,
+			&i.HardwareID,
+			&i.HasNewKey,
+			&i.HasOldKey,
+			&i.LastApplyStatus,
+			&i.LastApplyError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAdminKeyRotations = `-- name: ListAdminKeyRotations :many
+SELECT id, mesh_id, old_key_id, new_key_id, status, started_by, created_at, finished_at FROM admin_key_rotations
+WHERE mesh_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAdminKeyRotations(ctx context.Context, meshID int64) ([]AdminKeyRotation, error) {
+	rows, err := q.db.Query(ctx, listAdminKeyRotations, meshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminKeyRotation
+	for rows.Next() {
+		var i AdminKeyRotation
+		if err := rows.Scan(
+			&i.ID,
+			&i.MeshID,
+			&i.OldKeyID,
+			&i.NewKeyID,
+			&i.Status,
+			&i.StartedBy,
+			&i.CreatedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -24,7 +24,7 @@ func (q *Queries) CountAdminKeysByMesh(ctx context.Context, meshID int64) (int64
 const createAdminKey = `-- name: CreateAdminKey :one
 INSERT INTO admin_keys (mesh_id, public_key, key_name, added_by)
 VALUES ($1, $2, $3, $4)
-RETURNING id, mesh_id, public_key, key_name, added_by, created_at
+RETURNING id, mesh_id, public_key, key_name, added_by, created_at, private_key_encrypted, has_private_key
 `
 
 type CreateAdminKeyParams struct {
@@ -49,6 +49,83 @@ func (q *Queries) CreateAdminKey(ctx context.Context, arg CreateAdminKeyParams)
 		&i.KeyName,
 		&i.AddedBy,
 		&i.CreatedAt,
+		&i.PrivateKeyEncrypted,
+		&i.HasPrivateKey,
+	)
+	return i, err
+}
+
+const createAdminKeyDownload = `-- name: CreateAdminKeyDownload :one
+INSERT INTO admin_key_downloads (mesh_id, admin_key_id, public_key, user_id, remote_addr, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, mesh_id, admin_key_id, public_key, user_id, remote_addr, user_agent, created_at
+`
+
+type CreateAdminKeyDownloadParams struct {
+	MeshID     int64   `json:"mesh_id"`
+	AdminKeyID *int64  `json:"admin_key_id"`
+	PublicKey  []byte  `json:"public_key"`
+	UserID     int64   `json:"user_id"`
+	RemoteAddr *string `json:"remote_addr"`
+	UserAgent  *string `json:"user_agent"`
+}
+
+func (q *Queries) CreateAdminKeyDownload(ctx context.Context, arg CreateAdminKeyDownloadParams) (AdminKeyDownload, error) {
+	row := q.db.QueryRow(ctx, createAdminKeyDownload,
+		arg.MeshID,
+		arg.AdminKeyID,
+		arg.PublicKey,
+		arg.UserID,
+		arg.RemoteAddr,
+		arg.UserAgent,
+	)
+	var i AdminKeyDownload
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.AdminKeyID,
+		&i.PublicKey,
+		&i.UserID,
+		&i.RemoteAddr,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGeneratedAdminKey = `-- name: CreateGeneratedAdminKey :one
+INSERT INTO admin_keys (mesh_id, public_key, key_name, added_by, private_key_encrypted)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, mesh_id, public_key, key_name, added_by, created_at, private_key_encrypted, has_private_key
+`
+
+type CreateGeneratedAdminKeyParams struct {
+	MeshID              int64   `json:"mesh_id"`
+	PublicKey           []byte  `json:"public_key"`
+	KeyName             *string `json:"key_name"`
+	AddedBy             int64   `json:"added_by"`
+	PrivateKeyEncrypted []byte  `json:"-"`
+}
+
+// Adds a key the server generated, with its encrypted private key
+func (q *Queries) CreateGeneratedAdminKey(ctx context.Context, arg CreateGeneratedAdminKeyParams) (AdminKey, error) {
+	row := q.db.QueryRow(ctx, createGeneratedAdminKey,
+		arg.MeshID,
+		arg.PublicKey,
+		arg.KeyName,
+		arg.AddedBy,
+		arg.PrivateKeyEncrypted,
+	)
+	var i AdminKey
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.PublicKey,
+		&i.KeyName,
+		&i.AddedBy,
+		&i.CreatedAt,
+		&i.PrivateKeyEncrypted,
+		&i.HasPrivateKey,
 	)
 	return i, err
 }
@@ -64,7 +141,7 @@ func (q *Queries) DeleteAdminKey(ctx context.Context, id int64) error {
 }
 
 const getAdminKey = `-- name: GetAdminKey :one
-SELECT id, mesh_id, public_key, key_name, added_by, created_at FROM admin_keys
+SELECT id, mesh_id, public_key, key_name, added_by, created_at, private_key_encrypted, has_private_key FROM admin_keys
 WHERE id = $1
 `
 
@@ -78,12 +155,49 @@ func (q *Queries) GetAdminKey(ctx context.Context, id int64) (AdminKey, error) {
 		&i.KeyName,
 		&i.AddedBy,
 		&i.CreatedAt,
+		&i.PrivateKeyEncrypted,
+		&i.HasPrivateKey,
 	)
 	return i, err
 }
 
+const listAdminKeyDownloads = `-- name: ListAdminKeyDownloads :many
+SELECT id, mesh_id, admin_key_id, public_key, user_id, remote_addr, user_agent, created_at FROM admin_key_downloads
+WHERE mesh_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAdminKeyDownloads(ctx context.Context, meshID int64) ([]AdminKeyDownload, error) {
+	rows, err := q.db.Query(ctx, listAdminKeyDownloads, meshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminKeyDownload
+	for rows.Next() {
+		var i AdminKeyDownload
+		if err := rows.Scan(
+			&i.ID,
+			&i.MeshID,
+			&i.AdminKeyID,
+			&i.PublicKey,
+			&i.UserID,
+			&i.RemoteAddr,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAdminKeysByMesh = `-- name: ListAdminKeysByMesh :many
-SELECT id, mesh_id, public_key, key_name, added_by, created_at FROM admin_keys
+SELECT id, mesh_id, public_key, key_name, added_by, created_at, private_key_encrypted, has_private_key FROM admin_keys
 WHERE mesh_id = $1
 ORDER BY created_at DESC
 `
@@ -104,6 +218,8 @@ func (q *Queries) ListAdminKeysByMesh(ctx context.Context, meshID int64) ([]Admi
 			&i.KeyName,
 			&i.AddedBy,
 			&i.CreatedAt,
+			&i.PrivateKeyEncrypted,
+			&i.HasPrivateKey,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,129 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: impersonations.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+)
+
+const createImpersonation = `-- name: CreateImpersonation :one
+INSERT INTO impersonations (admin_user_id, target_user_id, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, admin_user_id, target_user_id, expires_at, revoked_at, created_at
+`
+
+type CreateImpersonationParams struct {
+	AdminUserID  int64     `json:"admin_user_id"`
+	TargetUserID int64     `json:"target_user_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateImpersonation(ctx context.Context, arg CreateImpersonationParams) (Impersonation, error) {
+	row := q.db.QueryRow(ctx, createImpersonation, arg.AdminUserID, arg.TargetUserID, arg.ExpiresAt)
+	var i Impersonation
+	err := row.Scan(
+		&i.ID,
+		&i.AdminUserID,
+		&i.TargetUserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImpersonation = `-- name: GetImpersonation :one
+SELECT id, admin_user_id, target_user_id, expires_at, revoked_at, created_at FROM impersonations
+WHERE id = $1
+`
+
+func (q *Queries) GetImpersonation(ctx context.Context, id int64) (Impersonation, error) {
+	row := q.db.QueryRow(ctx, getImpersonation, id)
+	var i Impersonation
+	err := row.Scan(
+		&i.ID,
+		&i.AdminUserID,
+		&i.TargetUserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listActiveImpersonations = `-- name: ListActiveImpersonations :many
+SELECT
+    i.id, i.admin_user_id, i.target_user_id, i.expires_at, i.revoked_at, i.created_at,
+    a.email AS admin_email,
+    t.email AS target_email
+FROM impersonations i
+JOIN users a ON a.id = i.admin_user_id
+JOIN users t ON t.id = i.target_user_id
+WHERE i.revoked_at IS NULL AND i.expires_at > NOW()
+ORDER BY i.created_at DESC
+`
+
+type ListActiveImpersonationsRow struct {
+	ID           int64      `json:"id"`
+	AdminUserID  int64      `json:"admin_user_id"`
+	TargetUserID int64      `json:"target_user_id"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	AdminEmail   string     `json:"admin_email"`
+	TargetEmail  string     `json:"target_email"`
+}
+
+func (q *Queries) ListActiveImpersonations(ctx context.Context) ([]ListActiveImpersonationsRow, error) {
+	rows, err := q.db.Query(ctx, listActiveImpersonations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveImpersonationsRow
+	for rows.Next() {
+		var i ListActiveImpersonationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdminUserID,
+			&i.TargetUserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.AdminEmail,
+			&i.TargetEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeImpersonation = `-- name: RevokeImpersonation :one
+UPDATE impersonations
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING id, admin_user_id, target_user_id, expires_at, revoked_at, created_at
+`
+
+func (q *Queries) RevokeImpersonation(ctx context.Context, id int64) (Impersonation, error) {
+	row := q.db.QueryRow(ctx, revokeImpersonation, id)
+	var i Impersonation
+	err := row.Scan(
+		&i.ID,
+		&i.AdminUserID,
+		&i.TargetUserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
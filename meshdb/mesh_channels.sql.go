@@ -7,6 +7,8 @@ package meshdb
 
 import (
 	"context"
+
+	"github.com/skandragon/meshmgr/internal/secrets"
 )
 
 const countMeshChannels = `-- name: CountMeshChannels :one
@@ -160,12 +162,12 @@ RETURNING id, mesh_id, channel_index, channel_role, psk, channel_name, settings,
 `
 
 type UpsertMeshChannelParams struct {
-	MeshID       int64   `json:"mesh_id"`
-	ChannelIndex int32   `json:"channel_index"`
-	ChannelRole  string  `json:"channel_role"`
-	Psk          []byte  `json:"psk"`
-	ChannelName  *string `json:"channel_name"`
-	Settings     []byte  `json:"settings"`
+	MeshID       int64         `json:"mesh_id"`
+	ChannelIndex int32         `json:"channel_index"`
+	ChannelRole  string        `json:"channel_role"`
+	Psk          secrets.Bytes `json:"psk"`
+	ChannelName  *string       `json:"channel_name"`
+	Settings     []byte        `json:"settings"`
 }
 
 // Insert or update a mesh channel
@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: mesh_mqtt.sql
+
+package meshdb
+
+import (
+	"context"
+)
+
+const deleteMeshMQTT = `-- name: DeleteMeshMQTT :exec
+DELETE FROM mesh_mqtt
+WHERE mesh_id = $1
+`
+
+func (q *Queries) DeleteMeshMQTT(ctx context.Context, meshID int64) error {
+	_, err := q.db.Exec(ctx, deleteMeshMQTT, meshID)
+	return err
+}
+
+const getMeshMQTT = `-- name: GetMeshMQTT :one
+SELECT mesh_id, broker_url, topic_root, username, password, enabled, created_at, updated_at FROM mesh_mqtt
+WHERE mesh_id = $1
+`
+
+func (q *Queries) GetMeshMQTT(ctx context.Context, meshID int64) (MeshMqtt, error) {
+	row := q.db.QueryRow(ctx, getMeshMQTT, meshID)
+	var i MeshMqtt
+	err := row.Scan(
+		&i.MeshID,
+		&i.BrokerUrl,
+		&i.TopicRoot,
+		&i.Username,
+		&i.Password,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listEnabledMeshMQTT = `-- name: ListEnabledMeshMQTT :many
+SELECT mesh_id, broker_url, topic_root, username, password, enabled, created_at, updated_at FROM mesh_mqtt
+WHERE enabled = TRUE
+ORDER BY mesh_id ASC
+`
+
+func (q *Queries) ListEnabledMeshMQTT(ctx context.Context) ([]MeshMqtt, error) {
+	rows, err := q.db.Query(ctx, listEnabledMeshMQTT)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MeshMqtt
+	for rows.Next() {
+		var i MeshMqtt
+		if err := rows.Scan(
+			&i.MeshID,
+			&i.BrokerUrl,
+			&i.TopicRoot,
+			&i.Username,
+			&i.Password,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertMeshMQTT = `-- name: UpsertMeshMQTT :one
+INSERT INTO mesh_mqtt (mesh_id, broker_url, topic_root, username, password, enabled)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (mesh_id) DO UPDATE
+SET broker_url = EXCLUDED.broker_url,
+    topic_root = EXCLUDED.topic_root,
+    username = EXCLUDED.username,
+    password = COALESCE(EXCLUDED.password, mesh_mqtt.password),
+    enabled = EXCLUDED.enabled,
+    updated_at = NOW()
+RETURNING mesh_id, broker_url, topic_root, username, password, enabled, created_at, updated_at
+`
+
+type UpsertMeshMQTTParams struct {
+	MeshID    int64   `json:"mesh_id"`
+	BrokerUrl string  `json:"broker_url"`
+	TopicRoot string  `json:"topic_root"`
+	Username  *string `json:"username"`
+	Password  *string `json:"password"`
+	Enabled   bool    `json:"enabled"`
+}
+
+// A null password keeps the stored one
+func (q *Queries) UpsertMeshMQTT(ctx context.Context, arg UpsertMeshMQTTParams) (MeshMqtt, error) {
+	row := q.db.QueryRow(ctx, upsertMeshMQTT,
+		arg.MeshID,
+		arg.BrokerUrl,
+		arg.TopicRoot,
+		arg.Username,
+		arg.Password,
+		arg.Enabled,
+	)
+	var i MeshMqtt
+	err := row.Scan(
+		&i.MeshID,
+		&i.BrokerUrl,
+		&i.TopicRoot,
+		&i.Username,
+		&i.Password,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -15,7 +15,7 @@ import (
 const createMesh = `-- name: CreateMesh :one
 INSERT INTO meshes (owner_id, name, description, lora_region, modem_preset, frequency_slot)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults
+RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled
 `
 
 type CreateMeshParams struct {
@@ -52,6 +52,8 @@ func (q *Queries) CreateMesh(ctx context.Context, arg CreateMeshParams) (Mesh, e
 		&i.ChannelNum,
 		&i.UsePreset,
 		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
 	)
 	return i, err
 }
@@ -67,7 +69,7 @@ func (q *Queries) DeleteMesh(ctx context.Context, id int64) error {
 }
 
 const getMeshByID = `-- name: GetMeshByID :one
-SELECT id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults FROM meshes
+SELECT id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled FROM meshes
 WHERE id = $1
 `
 
@@ -89,6 +91,8 @@ func (q *Queries) GetMeshByID(ctx context.Context, id int64) (Mesh, error) {
 		&i.ChannelNum,
 		&i.UsePreset,
 		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
 	)
 	return i, err
 }
@@ -153,8 +157,58 @@ func (q *Queries) GetMeshWithDefaults(ctx context.Context, id int64) (GetMeshWit
 	return i, err
 }
 
+const listAllMeshes = `-- name: ListAllMeshes :many
+SELECT
+    m.id,
+    m.name,
+    m.owner_id,
+    u.email AS owner_email,
+    (SELECT COUNT(*) FROM nodes n WHERE n.mesh_id = m.id) AS node_count,
+    m.created_at
+FROM meshes m
+JOIN users u ON u.id = m.owner_id
+ORDER BY m.id
+`
+
+type ListAllMeshesRow struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	OwnerID    int64     `json:"owner_id"`
+	OwnerEmail string    `json:"owner_email"`
+	NodeCount  int64     `json:"node_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Every mesh with its owner and node count, for operators
+func (q *Queries) ListAllMeshes(ctx context.Context) ([]ListAllMeshesRow, error) {
+	rows, err := q.db.Query(ctx, listAllMeshes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllMeshesRow
+	for rows.Next() {
+		var i ListAllMeshesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OwnerID,
+			&i.OwnerEmail,
+			&i.NodeCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMeshesByOwner = `-- name: ListMeshesByOwner :many
-SELECT id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults FROM meshes
+SELECT id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled FROM meshes
 WHERE owner_id = $1
 ORDER BY created_at DESC
 `
@@ -183,6 +237,8 @@ func (q *Queries) ListMeshesByOwner(ctx context.Context, ownerID int64) ([]Mesh,
 			&i.ChannelNum,
 			&i.UsePreset,
 			&i.ConfigDefaults,
+			&i.OfflineAfterSeconds,
+			&i.AdminKeyDownloadEnabled,
 		); err != nil {
 			return nil, err
 		}
@@ -195,7 +251,7 @@ func (q *Queries) ListMeshesByOwner(ctx context.Context, ownerID int64) ([]Mesh,
 }
 
 const listMeshesByUser = `-- name: ListMeshesByUser :many
-SELECT DISTINCT m.id, m.owner_id, m.name, m.description, m.created_at, m.updated_at, m.lora_region, m.modem_preset, m.frequency_slot, m.hop_limit, m.tx_power, m.channel_num, m.use_preset, m.config_defaults FROM meshes m
+SELECT DISTINCT m.id, m.owner_id, m.name, m.description, m.created_at, m.updated_at, m.lora_region, m.modem_preset, m.frequency_slot, m.hop_limit, m.tx_power, m.channel_num, m.use_preset, m.config_defaults, m.offline_after_seconds, m.admin_key_download_enabled FROM meshes m
 LEFT JOIN mesh_access ma ON m.id = ma.mesh_id
 WHERE m.owner_id = $1 OR ma.user_id = $1
 ORDER BY m.created_at DESC
@@ -225,6 +281,8 @@ func (q *Queries) ListMeshesByUser(ctx context.Context, userID int64) ([]Mesh, e
 			&i.ChannelNum,
 			&i.UsePreset,
 			&i.ConfigDefaults,
+			&i.OfflineAfterSeconds,
+			&i.AdminKeyDownloadEnabled,
 		); err != nil {
 			return nil, err
 		}
@@ -244,18 +302,22 @@ SET
     lora_region = COALESCE($3, lora_region),
     modem_preset = COALESCE($4, modem_preset),
     frequency_slot = COALESCE($5, frequency_slot),
+    offline_after_seconds = COALESCE($6, offline_after_seconds),
+    admin_key_download_enabled = COALESCE($7, admin_key_download_enabled),
     updated_at = NOW()
-WHERE id = $6
-RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults
+WHERE id = $8
+RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled
 `
 
 type UpdateMeshParams struct {
-	Name          *string     `json:"name"`
-	Description   *string     `json:"description"`
-	LoraRegion    *string     `json:"lora_region"`
-	ModemPreset   *string     `json:"modem_preset"`
-	FrequencySlot pgtype.Int4 `json:"frequency_slot"`
-	ID            int64       `json:"id"`
+	Name                    *string     `json:"name"`
+	Description             *string     `json:"description"`
+	LoraRegion              *string     `json:"lora_region"`
+	ModemPreset             *string     `json:"modem_preset"`
+	FrequencySlot           pgtype.Int4 `json:"frequency_slot"`
+	OfflineAfterSeconds     pgtype.Int4 `json:"offline_after_seconds"`
+	AdminKeyDownloadEnabled pgtype.Bool `json:"admin_key_download_enabled"`
+	ID                      int64       `json:"id"`
 }
 
 func (q *Queries) UpdateMesh(ctx context.Context, arg UpdateMeshParams) (Mesh, error) {
@@ -265,6 +327,8 @@ func (q *Queries) UpdateMesh(ctx context.Context, arg UpdateMeshParams) (Mesh, e
 		arg.LoraRegion,
 		arg.ModemPreset,
 		arg.FrequencySlot,
+		arg.OfflineAfterSeconds,
+		arg.AdminKeyDownloadEnabled,
 		arg.ID,
 	)
 	var i Mesh
@@ -283,6 +347,8 @@ func (q *Queries) UpdateMesh(ctx context.Context, arg UpdateMeshParams) (Mesh, e
 		&i.ChannelNum,
 		&i.UsePreset,
 		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
 	)
 	return i, err
 }
@@ -293,7 +359,7 @@ SET
     config_defaults = $1,
     updated_at = NOW()
 WHERE id = $2
-RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults
+RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled
 `
 
 type UpdateMeshConfigDefaultsParams struct {
@@ -320,6 +386,8 @@ func (q *Queries) UpdateMeshConfigDefaults(ctx context.Context, arg UpdateMeshCo
 		&i.ChannelNum,
 		&i.UsePreset,
 		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
 	)
 	return i, err
 }
@@ -336,7 +404,7 @@ SET
     use_preset = COALESCE($7, use_preset),
     updated_at = NOW()
 WHERE id = $8
-RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults
+RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled
 `
 
 type UpdateMeshLoRaConfigParams struct {
@@ -378,6 +446,46 @@ func (q *Queries) UpdateMeshLoRaConfig(ctx context.Context, arg UpdateMeshLoRaCo
 		&i.ChannelNum,
 		&i.UsePreset,
 		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
+	)
+	return i, err
+}
+
+const updateMeshOwner = `-- name: UpdateMeshOwner :one
+UPDATE meshes
+SET
+    owner_id = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, owner_id, name, description, created_at, updated_at, lora_region, modem_preset, frequency_slot, hop_limit, tx_power, channel_num, use_preset, config_defaults, offline_after_seconds, admin_key_download_enabled
+`
+
+type UpdateMeshOwnerParams struct {
+	OwnerID int64 `json:"owner_id"`
+	ID      int64 `json:"id"`
+}
+
+func (q *Queries) UpdateMeshOwner(ctx context.Context, arg UpdateMeshOwnerParams) (Mesh, error) {
+	row := q.db.QueryRow(ctx, updateMeshOwner, arg.OwnerID, arg.ID)
+	var i Mesh
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LoraRegion,
+		&i.ModemPreset,
+		&i.FrequencySlot,
+		&i.HopLimit,
+		&i.TxPower,
+		&i.ChannelNum,
+		&i.UsePreset,
+		&i.ConfigDefaults,
+		&i.OfflineAfterSeconds,
+		&i.AdminKeyDownloadEnabled,
 	)
 	return i, err
 }
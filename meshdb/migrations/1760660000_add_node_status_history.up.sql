@@ -0,0 +1,32 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+-- How long a node may be silent before it is marked offline
+ALTER TABLE meshes ADD COLUMN offline_after_seconds INTEGER DEFAULT 7200 NOT NULL;
+
+ALTER TABLE meshes ADD CONSTRAINT check_offline_after_seconds
+    CHECK (offline_after_seconds > 0);
+
+-- Record every node status transition
+CREATE TABLE node_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    node_id BIGINT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    -- What made the change: traffic, sweeper, import or manual
+    source TEXT NOT NULL CHECK (source IN ('traffic', 'sweeper', 'import', 'manual')),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_node_status_history_node_id ON node_status_history(node_id, changed_at DESC);
@@ -0,0 +1,29 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+-- Telemetry samples, one row per metric. Device metrics use their field
+-- names (battery_level, voltage, ...); other telemetry is prefixed with its
+-- kind (environment.temperature, power.ch1_voltage, ...).
+CREATE TABLE node_telemetry (
+    node_id BIGINT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    -- The same report heard twice (say, over the gateway and MQTT) is
+    -- stored once
+    PRIMARY KEY (node_id, metric, recorded_at)
+);
+
+-- Retention pruning deletes by age across all nodes
+CREATE INDEX idx_node_telemetry_recorded_at ON node_telemetry(recorded_at);
@@ -0,0 +1,36 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+-- Position reports, one row per report
+CREATE TABLE node_positions (
+    node_id BIGINT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    -- Meters above mean sea level
+    altitude INTEGER,
+    -- Significant bits of latitude and longitude the node chose to share;
+    -- 32 is full precision
+    precision_bits INTEGER,
+    PRIMARY KEY (node_id, recorded_at)
+);
+
+CREATE INDEX idx_node_positions_recorded_at ON node_positions(recorded_at);
+
+-- The most recent position of each node, for maps
+ALTER TABLE nodes ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE nodes ADD COLUMN longitude DOUBLE PRECISION;
+ALTER TABLE nodes ADD COLUMN altitude INTEGER;
+ALTER TABLE nodes ADD COLUMN position_precision INTEGER;
+ALTER TABLE nodes ADD COLUMN position_at TIMESTAMPTZ;
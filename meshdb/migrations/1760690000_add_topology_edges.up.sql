@@ -0,0 +1,30 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+-- Radio links between nodes: to_num has heard from_num. Nodes are named by
+-- node_num rather than nodes.id, since neighbors are often nodes the mesh
+-- does not manage.
+CREATE TABLE topology_edges (
+    mesh_id BIGINT NOT NULL REFERENCES meshes(id) ON DELETE CASCADE,
+    from_num BIGINT NOT NULL,
+    to_num BIGINT NOT NULL,
+    -- Signal-to-noise ratio in dB at the receiver, if known
+    snr REAL,
+    source TEXT NOT NULL CHECK (source IN ('neighborinfo', 'traceroute')),
+    last_heard TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (mesh_id, from_num, to_num)
+);
+
+-- Stale edges are aged out by last_heard across all meshes
+CREATE INDEX idx_topology_edges_last_heard ON topology_edges(last_heard);
@@ -0,0 +1,29 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+-- MQTT uplink subscription for a mesh. Kept out of meshes so the
+-- credentials never ride along with mesh responses.
+CREATE TABLE mesh_mqtt (
+    mesh_id BIGINT PRIMARY KEY REFERENCES meshes(id) ON DELETE CASCADE,
+    -- tcp://, ssl://, ws:// or wss:// broker URL
+    broker_url TEXT NOT NULL,
+    -- Topic the mesh's gateways publish encrypted envelopes under, such as
+    -- msh/US/2/e/
+    topic_root TEXT NOT NULL,
+    username TEXT,
+    password TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
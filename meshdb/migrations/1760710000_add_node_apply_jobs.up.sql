@@ -0,0 +1,47 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+-- Over-the-air pushes of a node's effective config through the gateway radio
+CREATE TABLE node_apply_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    node_id BIGINT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    -- The mesh admin key the admin messages were sent with
+    admin_key_id BIGINT REFERENCES admin_keys(id) ON DELETE SET NULL,
+    requested_by BIGINT NOT NULL REFERENCES users(id),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_node_apply_jobs_node_id ON node_apply_jobs(node_id, created_at DESC);
+
+-- Only one push to a node at a time
+CREATE UNIQUE INDEX idx_node_apply_jobs_active ON node_apply_jobs(node_id)
+    WHERE status IN ('pending', 'running');
+
+-- Each attempt at each admin message of a job, and how it ended
+CREATE TABLE node_apply_job_log (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES node_apply_jobs(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('ack', 'nak', 'timeout', 'error')),
+    -- NAK reason or error
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_node_apply_job_log_job_id ON node_apply_job_log(job_id, id);
@@ -0,0 +1,33 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+-- Replacing one mesh admin key with another on every node of the mesh. The
+-- old key is kept until every node has confirmed the new one.
+CREATE TABLE admin_key_rotations (
+    id BIGSERIAL PRIMARY KEY,
+    mesh_id BIGINT NOT NULL REFERENCES meshes(id) ON DELETE CASCADE,
+    -- Cleared when the old key is deleted on completion
+    old_key_id BIGINT REFERENCES admin_keys(id) ON DELETE SET NULL,
+    new_key_id BIGINT NOT NULL REFERENCES admin_keys(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'in_progress'
+        CHECK (status IN ('in_progress', 'completed', 'cancelled')),
+    started_by BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_admin_key_rotations_mesh_id ON admin_key_rotations(mesh_id, created_at DESC);
+
+-- Only one rotation per mesh at a time
+CREATE UNIQUE INDEX idx_admin_key_rotations_active ON admin_key_rotations(mesh_id)
+    WHERE status = 'in_progress';
@@ -0,0 +1,17 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+DROP TABLE IF EXISTS admin_key_downloads;
+ALTER TABLE meshes DROP COLUMN IF EXISTS admin_key_download_enabled;
+ALTER TABLE admin_keys DROP COLUMN IF EXISTS has_private_key;
+ALTER TABLE admin_keys DROP COLUMN IF EXISTS private_key_encrypted;
@@ -0,0 +1,36 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+-- Admin keys generated by the server keep their private key, encrypted
+-- under the server master key
+ALTER TABLE admin_keys ADD COLUMN private_key_encrypted BYTEA;
+ALTER TABLE admin_keys ADD COLUMN has_private_key BOOLEAN NOT NULL
+    GENERATED ALWAYS AS (private_key_encrypted IS NOT NULL) STORED;
+
+-- Owners may turn off downloading generated private keys
+ALTER TABLE meshes ADD COLUMN admin_key_download_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Every download of an admin private key. Rows outlive the key they
+-- record.
+CREATE TABLE admin_key_downloads (
+    id BIGSERIAL PRIMARY KEY,
+    mesh_id BIGINT NOT NULL REFERENCES meshes(id) ON DELETE CASCADE,
+    admin_key_id BIGINT REFERENCES admin_keys(id) ON DELETE SET NULL,
+    public_key BYTEA NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    remote_addr TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_key_downloads_mesh_id ON admin_key_downloads(mesh_id, created_at DESC);
@@ -0,0 +1,27 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+-- Every reveal of a node's private keys and unredacted device config.
+-- Rows outlive the node they record.
+CREATE TABLE node_secret_reveals (
+    id BIGSERIAL PRIMARY KEY,
+    mesh_id BIGINT NOT NULL REFERENCES meshes(id) ON DELETE CASCADE,
+    node_id BIGINT REFERENCES nodes(id) ON DELETE SET NULL,
+    hardware_id TEXT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    remote_addr TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_node_secret_reveals_mesh_id ON node_secret_reveals(mesh_id, created_at DESC);
@@ -0,0 +1,20 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+DELETE FROM admin_audit_log WHERE action IN ('impersonated_request', 'end_impersonation');
+ALTER TABLE admin_audit_log DROP CONSTRAINT admin_audit_log_action_check;
+ALTER TABLE admin_audit_log ADD CONSTRAINT admin_audit_log_action_check
+    CHECK (action IN ('disable', 'enable', 'delete', 'impersonate', 'grant_admin', 'revoke_admin'));
+ALTER TABLE admin_audit_log DROP COLUMN IF EXISTS detail;
+ALTER TABLE admin_audit_log DROP COLUMN IF EXISTS impersonation_id;
+DROP TABLE IF EXISTS impersonations;
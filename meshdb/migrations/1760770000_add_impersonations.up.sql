@@ -0,0 +1,32 @@
+-- Copyright (C) 2025 Michael Graff
+--
+-- This program is free software: you can redistribute it and/or modify
+-- it under the terms of the GNU Affero General Public License as
+-- published by the Free Software Foundation, version 3.
+--
+-- This program is distributed in the hope that it will be useful,
+-- but WITHOUT ANY WARRANTY; without even the implied warranty of
+-- MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+-- GNU Affero General Public License for more details.
+--
+-- You should have received a copy of the GNU Affero General Public License
+-- along with this program. If not, see <http://www.gnu.org/licenses/>.
+-- Support sessions site admins open by impersonating a user. Each can be
+-- revoked on its own, without ending the user's own sessions.
+CREATE TABLE impersonations (
+    id BIGSERIAL PRIMARY KEY,
+    admin_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_impersonations_expires_at ON impersonations(expires_at DESC);
+
+-- Every request made while impersonating is logged, with what it was
+ALTER TABLE admin_audit_log ADD COLUMN impersonation_id BIGINT REFERENCES impersonations(id) ON DELETE SET NULL;
+ALTER TABLE admin_audit_log ADD COLUMN detail TEXT;
+ALTER TABLE admin_audit_log DROP CONSTRAINT admin_audit_log_action_check;
+ALTER TABLE admin_audit_log ADD CONSTRAINT admin_audit_log_action_check
+    CHECK (action IN ('disable', 'enable', 'delete', 'impersonate', 'grant_admin', 'revoke_admin', 'impersonated_request', 'end_impersonation'));
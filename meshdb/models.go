@@ -8,32 +8,81 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/secrets"
 )
 
+type AdminAuditLog struct {
+	ID              int64     `json:"id"`
+	AdminUserID     *int64    `json:"admin_user_id"`
+	Action          string    `json:"action"`
+	TargetUserID    *int64    `json:"target_user_id"`
+	TargetEmail     string    `json:"target_email"`
+	RemoteAddr      *string   `json:"remote_addr"`
+	UserAgent       *string   `json:"user_agent"`
+	CreatedAt       time.Time `json:"created_at"`
+	ImpersonationID *int64    `json:"impersonation_id"`
+	Detail          *string   `json:"detail"`
+}
+
 type AdminKey struct {
-	ID        int64     `json:"id"`
-	MeshID    int64     `json:"mesh_id"`
-	PublicKey []byte    `json:"public_key"`
-	KeyName   *string   `json:"key_name"`
-	AddedBy   int64     `json:"added_by"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                  int64     `json:"id"`
+	MeshID              int64     `json:"mesh_id"`
+	PublicKey           []byte    `json:"public_key"`
+	KeyName             *string   `json:"key_name"`
+	AddedBy             int64     `json:"added_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	PrivateKeyEncrypted []byte    `json:"-"`
+	HasPrivateKey       bool      `json:"has_private_key"`
+}
+
+type AdminKeyDownload struct {
+	ID         int64     `json:"id"`
+	MeshID     int64     `json:"mesh_id"`
+	AdminKeyID *int64    `json:"admin_key_id"`
+	PublicKey  []byte    `json:"public_key"`
+	UserID     int64     `json:"user_id"`
+	RemoteAddr *string   `json:"remote_addr"`
+	UserAgent  *string   `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AdminKeyRotation struct {
+	ID         int64      `json:"id"`
+	MeshID     int64      `json:"mesh_id"`
+	OldKeyID   *int64     `json:"old_key_id"`
+	NewKeyID   int64      `json:"new_key_id"`
+	Status     string     `json:"status"`
+	StartedBy  int64      `json:"started_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type Impersonation struct {
+	ID           int64      `json:"id"`
+	AdminUserID  int64      `json:"admin_user_id"`
+	TargetUserID int64      `json:"target_user_id"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
 }
 
 type Mesh struct {
-	ID             int64       `json:"id"`
-	OwnerID        int64       `json:"owner_id"`
-	Name           string      `json:"name"`
-	Description    *string     `json:"description"`
-	CreatedAt      time.Time   `json:"created_at"`
-	UpdatedAt      time.Time   `json:"updated_at"`
-	LoraRegion     *string     `json:"lora_region"`
-	ModemPreset    *string     `json:"modem_preset"`
-	FrequencySlot  pgtype.Int4 `json:"frequency_slot"`
-	HopLimit       pgtype.Int4 `json:"hop_limit"`
-	TxPower        pgtype.Int4 `json:"tx_power"`
-	ChannelNum     pgtype.Int4 `json:"channel_num"`
-	UsePreset      bool        `json:"use_preset"`
-	ConfigDefaults []byte      `json:"config_defaults"`
+	ID                      int64       `json:"id"`
+	OwnerID                 int64       `json:"owner_id"`
+	Name                    string      `json:"name"`
+	Description             *string     `json:"description"`
+	CreatedAt               time.Time   `json:"created_at"`
+	UpdatedAt               time.Time   `json:"updated_at"`
+	LoraRegion              *string     `json:"lora_region"`
+	ModemPreset             *string     `json:"modem_preset"`
+	FrequencySlot           pgtype.Int4 `json:"frequency_slot"`
+	HopLimit                pgtype.Int4 `json:"hop_limit"`
+	TxPower                 pgtype.Int4 `json:"tx_power"`
+	ChannelNum              pgtype.Int4 `json:"channel_num"`
+	UsePreset               bool        `json:"use_preset"`
+	ConfigDefaults          []byte      `json:"config_defaults"`
+	OfflineAfterSeconds     int32       `json:"offline_after_seconds"`
+	AdminKeyDownloadEnabled bool        `json:"admin_key_download_enabled"`
 }
 
 type MeshAccess struct {
@@ -46,47 +95,63 @@ type MeshAccess struct {
 }
 
 type MeshChannel struct {
-	ID           int64     `json:"id"`
-	MeshID       int64     `json:"mesh_id"`
-	ChannelIndex int32     `json:"channel_index"`
-	ChannelRole  string    `json:"channel_role"`
-	Psk          []byte    `json:"psk"`
-	ChannelName  *string   `json:"channel_name"`
-	Settings     []byte    `json:"settings"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64         `json:"id"`
+	MeshID       int64         `json:"mesh_id"`
+	ChannelIndex int32         `json:"channel_index"`
+	ChannelRole  string        `json:"channel_role"`
+	Psk          secrets.Bytes `json:"psk"`
+	ChannelName  *string       `json:"channel_name"`
+	Settings     []byte        `json:"settings"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type MeshMqtt struct {
+	MeshID    int64     `json:"mesh_id"`
+	BrokerUrl string    `json:"broker_url"`
+	TopicRoot string    `json:"topic_root"`
+	Username  *string   `json:"username"`
+	Password  *string   `json:"password"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Node struct {
-	ID                   int64       `json:"id"`
-	MeshID               int64       `json:"mesh_id"`
-	HardwareID           string      `json:"hardware_id"`
-	Name                 string      `json:"name"`
-	LongName             string      `json:"long_name"`
-	Role                 *string     `json:"role"`
-	PublicKey            *string     `json:"public_key"`
-	PrivateKey           *string     `json:"private_key"`
-	LastSeen             *time.Time  `json:"last_seen"`
-	Status               *string     `json:"status"`
-	CreatedAt            time.Time   `json:"created_at"`
-	UpdatedAt            time.Time   `json:"updated_at"`
-	AppliedName          *string     `json:"applied_name"`
-	AppliedLongName      *string     `json:"applied_long_name"`
-	AppliedRole          *string     `json:"applied_role"`
-	AppliedPublicKey     *string     `json:"applied_public_key"`
-	AppliedPrivateKey    *string     `json:"applied_private_key"`
-	AppliedUnmessageable pgtype.Bool `json:"applied_unmessageable"`
-	Unmessageable        bool        `json:"unmessageable"`
-	ConfigAppliedAt      *time.Time  `json:"config_applied_at"`
-	PendingChanges       bool        `json:"pending_changes"`
-	NodeNum              *int64      `json:"node_num"`
-	DeviceID             []byte      `json:"device_id"`
-	FirmwareVersion      *string     `json:"firmware_version"`
-	HwModel              pgtype.Int4 `json:"hw_model"`
-	ShortName            *string     `json:"short_name"`
-	RawDeviceConfig      []byte      `json:"raw_device_config"`
-	ConfigOverrides      []byte      `json:"config_overrides"`
-	ConfigImportedAt     *time.Time  `json:"config_imported_at"`
+	ID                   int64                `json:"id"`
+	MeshID               int64                `json:"mesh_id"`
+	HardwareID           string               `json:"hardware_id"`
+	Name                 string               `json:"name"`
+	LongName             string               `json:"long_name"`
+	Role                 *string              `json:"role"`
+	PublicKey            *string              `json:"public_key"`
+	PrivateKey           *secrets.Text        `json:"private_key"`
+	LastSeen             *time.Time           `json:"last_seen"`
+	Status               *string              `json:"status"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+	AppliedName          *string              `json:"applied_name"`
+	AppliedLongName      *string              `json:"applied_long_name"`
+	AppliedRole          *string              `json:"applied_role"`
+	AppliedPublicKey     *string              `json:"applied_public_key"`
+	AppliedPrivateKey    *secrets.Text        `json:"applied_private_key"`
+	AppliedUnmessageable pgtype.Bool          `json:"applied_unmessageable"`
+	Unmessageable        bool                 `json:"unmessageable"`
+	ConfigAppliedAt      *time.Time           `json:"config_applied_at"`
+	PendingChanges       bool                 `json:"pending_changes"`
+	NodeNum              *int64               `json:"node_num"`
+	DeviceID             []byte               `json:"device_id"`
+	FirmwareVersion      *string              `json:"firmware_version"`
+	HwModel              pgtype.Int4          `json:"hw_model"`
+	ShortName            *string              `json:"short_name"`
+	RawDeviceConfig      secrets.DeviceConfig `json:"raw_device_config"`
+	ConfigOverrides      []byte               `json:"config_overrides"`
+	ConfigImportedAt     *time.Time           `json:"config_imported_at"`
+	Latitude             pgtype.Float8        `json:"latitude"`
+	Longitude            pgtype.Float8        `json:"longitude"`
+	Altitude             pgtype.Int4          `json:"altitude"`
+	PositionPrecision    pgtype.Int4          `json:"position_precision"`
+	PositionAt           *time.Time           `json:"position_at"`
 }
 
 type NodeAdminKey struct {
@@ -97,6 +162,64 @@ type NodeAdminKey struct {
 	IsCurrent  bool      `json:"is_current"`
 }
 
+type NodeApplyJob struct {
+	ID          int64      `json:"id"`
+	NodeID      int64      `json:"node_id"`
+	AdminKeyID  *int64     `json:"admin_key_id"`
+	RequestedBy int64      `json:"requested_by"`
+	Status      string     `json:"status"`
+	Error       *string    `json:"error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+type NodeApplyJobLog struct {
+	ID        int64     `json:"id"`
+	JobID     int64     `json:"job_id"`
+	Message   string    `json:"message"`
+	Attempt   int32     `json:"attempt"`
+	Outcome   string    `json:"outcome"`
+	Detail    *string   `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NodePosition struct {
+	NodeID        int64       `json:"node_id"`
+	RecordedAt    time.Time   `json:"recorded_at"`
+	Latitude      float64     `json:"latitude"`
+	Longitude     float64     `json:"longitude"`
+	Altitude      pgtype.Int4 `json:"altitude"`
+	PrecisionBits pgtype.Int4 `json:"precision_bits"`
+}
+
+type NodeSecretReveal struct {
+	ID         int64     `json:"id"`
+	MeshID     int64     `json:"mesh_id"`
+	NodeID     *int64    `json:"node_id"`
+	HardwareID string    `json:"hardware_id"`
+	UserID     int64     `json:"user_id"`
+	RemoteAddr *string   `json:"remote_addr"`
+	UserAgent  *string   `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type NodeStatusHistory struct {
+	ID        int64     `json:"id"`
+	NodeID    int64     `json:"node_id"`
+	OldStatus *string   `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Source    string    `json:"source"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type NodeTelemetry struct {
+	NodeID     int64     `json:"node_id"`
+	Metric     string    `json:"metric"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Value      float64   `json:"value"`
+}
+
 type Session struct {
 	ID        int64     `json:"id"`
 	UserID    int64     `json:"user_id"`
@@ -105,13 +228,24 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type TopologyEdge struct {
+	MeshID    int64         `json:"mesh_id"`
+	FromNum   int64         `json:"from_num"`
+	ToNum     int64         `json:"to_num"`
+	Snr       pgtype.Float4 `json:"snr"`
+	Source    string        `json:"source"`
+	LastHeard time.Time     `json:"last_heard"`
+}
+
 type User struct {
-	ID           int64     `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
-	DisplayName  string    `json:"display_name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64      `json:"id"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"`
+	DisplayName  string     `json:"display_name"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+	IsAdmin      bool       `json:"is_admin"`
 }
 
 type UserApiKey struct {
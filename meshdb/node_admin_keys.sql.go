@@ -189,6 +189,49 @@ func (q *Queries) ListNodesForAdminKey(ctx context.Context, adminKeyID int64) ([
 	return items, nil
 }
 
+const listNodesOnlyReachableByAdminKey = `-- name: ListNodesOnlyReachableByAdminKey :many
+SELECT n.id, n.name, n.hardware_id
+FROM nodes n
+JOIN node_admin_keys nak ON nak.node_id = n.id
+WHERE nak.admin_key_id = $1
+  AND nak.is_current
+  AND NOT EXISTS (
+      SELECT 1 FROM node_admin_keys other
+      WHERE other.node_id = n.id
+        AND other.is_current
+        AND other.admin_key_id <> $1
+  )
+ORDER BY n.name ASC
+`
+
+type ListNodesOnlyReachableByAdminKeyRow struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	HardwareID string `json:"hardware_id"`
+}
+
+// Nodes that hold an admin key and no other, which would be cut off from
+// remote administration without it
+func (q *Queries) ListNodesOnlyReachableByAdminKey(ctx context.Context, adminKeyID int64) ([]ListNodesOnlyReachableByAdminKeyRow, error) {
+	rows, err := q.db.Query(ctx, listNodesOnlyReachableByAdminKey, adminKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNodesOnlyReachableByAdminKeyRow
+	for rows.Next() {
+		var i ListNodesOnlyReachableByAdminKeyRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.HardwareID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markAdminKeyNotCurrent = `-- name: MarkAdminKeyNotCurrent :exec
 UPDATE node_admin_keys
 SET is_current = FALSE
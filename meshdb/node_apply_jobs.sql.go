@@ -0,0 +1,218 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: node_apply_jobs.sql
+
+package meshdb
+
+import (
+	"context"
+)
+
+const addApplyJobLog = `-- name: AddApplyJobLog :exec
+INSERT INTO node_apply_job_log (job_id, message, attempt, outcome, detail)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type AddApplyJobLogParams struct {
+	JobID   int64   `json:"job_id"`
+	Message string  `json:"message"`
+	Attempt int32   `json:"attempt"`
+	Outcome string  `json:"outcome"`
+	Detail  *string `json:"detail"`
+}
+
+func (q *Queries) AddApplyJobLog(ctx context.Context, arg AddApplyJobLogParams) error {
+	_, err := q.db.Exec(ctx, addApplyJobLog,
+		arg.JobID,
+		arg.Message,
+		arg.Attempt,
+		arg.Outcome,
+		arg.Detail,
+	)
+	return err
+}
+
+const createApplyJob = `-- name: CreateApplyJob :one
+INSERT INTO node_apply_jobs (node_id, admin_key_id, requested_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (node_id) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING id, node_id, admin_key_id, requested_by, status, error, created_at, started_at, finished_at
+`
+
+type CreateApplyJobParams struct {
+	NodeID      int64  `json:"node_id"`
+	AdminKeyID  *int64 `json:"admin_key_id"`
+	RequestedBy int64  `json:"requested_by"`
+}
+
+// Returns no rows if the node already has a job pending or running
+func (q *Queries) CreateApplyJob(ctx context.Context, arg CreateApplyJobParams) (NodeApplyJob, error) {
+	row := q.db.QueryRow(ctx, createApplyJob, arg.NodeID, arg.AdminKeyID, arg.RequestedBy)
+	var i NodeApplyJob
+	err := row.Scan(
+		&i.ID,
+		&i.NodeID,
+		&i.AdminKeyID,
+		&i.RequestedBy,
+		&i.Status,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const failInterruptedApplyJobs = `-- name: FailInterruptedApplyJobs :execrows
+UPDATE node_apply_jobs
+SET status = 'failed', error = 'interrupted by server restart', finished_at = NOW()
+WHERE status IN ('pending', 'running')
+`
+
+func (q *Queries) FailInterruptedApplyJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, failInterruptedApplyJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const finishApplyJob = `-- name: FinishApplyJob :one
+UPDATE node_apply_jobs
+SET status = $1, error = $2, finished_at = NOW()
+WHERE id = $3
+RETURNING id, node_id, admin_key_id, requested_by, status, error, created_at, started_at, finished_at
+`
+
+type FinishApplyJobParams struct {
+	Status string  `json:"status"`
+	Error  *string `json:"error"`
+	ID     int64   `json:"id"`
+}
+
+func (q *Queries) FinishApplyJob(ctx context.Context, arg FinishApplyJobParams) (NodeApplyJob, error) {
+	row := q.db.QueryRow(ctx, finishApplyJob, arg.Status, arg.Error, arg.ID)
+	var i NodeApplyJob
+	err := row.Scan(
+		&i.ID,
+		&i.NodeID,
+		&i.AdminKeyID,
+		&i.RequestedBy,
+		&i.Status,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getApplyJob = `-- name: GetApplyJob :one
+SELECT id, node_id, admin_key_id, requested_by, status, error, created_at, started_at, finished_at FROM node_apply_jobs
+WHERE id = $1
+`
+
+func (q *Queries) GetApplyJob(ctx context.Context, id int64) (NodeApplyJob, error) {
+	row := q.db.QueryRow(ctx, getApplyJob, id)
+	var i NodeApplyJob
+	err := row.Scan(
+		&i.ID,
+		&i.NodeID,
+		&i.AdminKeyID,
+		&i.RequestedBy,
+		&i.Status,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const listApplyJobLog = `-- name: ListApplyJobLog :many
+SELECT id, job_id, message, attempt, outcome, detail, created_at FROM node_apply_job_log
+WHERE job_id = $1
+ORDER BY id ASC
+`
+
+func (q *Queries) ListApplyJobLog(ctx context.Context, jobID int64) ([]NodeApplyJobLog, error) {
+	rows, err := q.db.Query(ctx, listApplyJobLog, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodeApplyJobLog
+	for rows.Next() {
+		var i NodeApplyJobLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Message,
+			&i.Attempt,
+			&i.Outcome,
+			&i.Detail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodeApplyJobs = `-- name: ListNodeApplyJobs :many
+SELECT id, node_id, admin_key_id, requested_by, status, error, created_at, started_at, finished_at FROM node_apply_jobs
+WHERE node_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListNodeApplyJobsParams struct {
+	NodeID   int64 `json:"node_id"`
+	RowLimit int32 `json:"row_limit"`
+}
+
+func (q *Queries) ListNodeApplyJobs(ctx context.Context, arg ListNodeApplyJobsParams) ([]NodeApplyJob, error) {
+	rows, err := q.db.Query(ctx, listNodeApplyJobs, arg.NodeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodeApplyJob
+	for rows.Next() {
+		var i NodeApplyJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.NodeID,
+			&i.AdminKeyID,
+			&i.RequestedBy,
+			&i.Status,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const startApplyJob = `-- name: StartApplyJob :exec
+UPDATE node_apply_jobs
+SET status = 'running', started_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) StartApplyJob(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, startApplyJob, id)
+	return err
+}
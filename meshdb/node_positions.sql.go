@@ -0,0 +1,203 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: node_positions.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deletePositionsBefore = `-- name: DeletePositionsBefore :execrows
+DELETE FROM node_positions
+WHERE recorded_at < $1
+`
+
+func (q *Queries) DeletePositionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePositionsBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertNodePosition = `-- name: InsertNodePosition :exec
+INSERT INTO node_positions (node_id, recorded_at, latitude, longitude, altitude, precision_bits)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (node_id, recorded_at) DO NOTHING
+`
+
+type InsertNodePositionParams struct {
+	NodeID        int64       `json:"node_id"`
+	RecordedAt    time.Time   `json:"recorded_at"`
+	Latitude      float64     `json:"latitude"`
+	Longitude     float64     `json:"longitude"`
+	Altitude      pgtype.Int4 `json:"altitude"`
+	PrecisionBits pgtype.Int4 `json:"precision_bits"`
+}
+
+func (q *Queries) InsertNodePosition(ctx context.Context, arg InsertNodePositionParams) error {
+	_, err := q.db.Exec(ctx, insertNodePosition,
+		arg.NodeID,
+		arg.RecordedAt,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Altitude,
+		arg.PrecisionBits,
+	)
+	return err
+}
+
+const listMeshNodePositions = `-- name: ListMeshNodePositions :many
+SELECT
+    n.id, n.hardware_id, n.name, n.long_name, n.short_name, n.role, n.status, n.last_seen,
+    n.latitude::float8 AS latitude,
+    n.longitude::float8 AS longitude,
+    n.altitude, n.position_precision, n.position_at,
+    battery.value AS battery_level
+FROM nodes n
+LEFT JOIN node_telemetry battery
+    ON battery.node_id = n.id
+    AND battery.metric = 'battery_level'
+    AND battery.recorded_at = (
+        SELECT MAX(t.recorded_at) FROM node_telemetry t
+        WHERE t.node_id = n.id AND t.metric = 'battery_level'
+    )
+WHERE n.mesh_id = $1
+  AND n.latitude IS NOT NULL
+  AND n.longitude IS NOT NULL
+ORDER BY n.name ASC
+`
+
+type ListMeshNodePositionsRow struct {
+	ID                int64         `json:"id"`
+	HardwareID        string        `json:"hardware_id"`
+	Name              string        `json:"name"`
+	LongName          string        `json:"long_name"`
+	ShortName         *string       `json:"short_name"`
+	Role              *string       `json:"role"`
+	Status            *string       `json:"status"`
+	LastSeen          *time.Time    `json:"last_seen"`
+	Latitude          float64       `json:"latitude"`
+	Longitude         float64       `json:"longitude"`
+	Altitude          pgtype.Int4   `json:"altitude"`
+	PositionPrecision pgtype.Int4   `json:"position_precision"`
+	PositionAt        *time.Time    `json:"position_at"`
+	BatteryLevel      pgtype.Float8 `json:"battery_level"`
+}
+
+// The latest position of each located node in a mesh, with its latest
+// battery level
+func (q *Queries) ListMeshNodePositions(ctx context.Context, meshID int64) ([]ListMeshNodePositionsRow, error) {
+	rows, err := q.db.Query(ctx, listMeshNodePositions, meshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeshNodePositionsRow
+	for rows.Next() {
+		var i ListMeshNodePositionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HardwareID,
+			&i.Name,
+			&i.LongName,
+			&i.ShortName,
+			&i.Role,
+			&i.Status,
+			&i.LastSeen,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Altitude,
+			&i.PositionPrecision,
+			&i.PositionAt,
+			&i.BatteryLevel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodePositions = `-- name: ListNodePositions :many
+SELECT node_id, recorded_at, latitude, longitude, altitude, precision_bits FROM node_positions
+WHERE node_id = $1
+  AND recorded_at >= $2
+  AND recorded_at < $3
+ORDER BY recorded_at ASC
+`
+
+type ListNodePositionsParams struct {
+	NodeID   int64     `json:"node_id"`
+	FromTime time.Time `json:"from_time"`
+	ToTime   time.Time `json:"to_time"`
+}
+
+// A node's track between two times, oldest first
+func (q *Queries) ListNodePositions(ctx context.Context, arg ListNodePositionsParams) ([]NodePosition, error) {
+	rows, err := q.db.Query(ctx, listNodePositions, arg.NodeID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodePosition
+	for rows.Next() {
+		var i NodePosition
+		if err := rows.Scan(
+			&i.NodeID,
+			&i.RecordedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Altitude,
+			&i.PrecisionBits,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateNodeLatestPosition = `-- name: UpdateNodeLatestPosition :exec
+UPDATE nodes
+SET latitude = $1::float8,
+    longitude = $2::float8,
+    altitude = $3,
+    position_precision = $4,
+    position_at = $5::timestamptz
+WHERE id = $6
+  AND (position_at IS NULL OR position_at <= $5::timestamptz)
+`
+
+type UpdateNodeLatestPositionParams struct {
+	Latitude      float64     `json:"latitude"`
+	Longitude     float64     `json:"longitude"`
+	Altitude      pgtype.Int4 `json:"altitude"`
+	PrecisionBits pgtype.Int4 `json:"precision_bits"`
+	RecordedAt    time.Time   `json:"recorded_at"`
+	NodeID        int64       `json:"node_id"`
+}
+
+// Reports can arrive out of order; only a newer one replaces the latest
+func (q *Queries) UpdateNodeLatestPosition(ctx context.Context, arg UpdateNodeLatestPositionParams) error {
+	_, err := q.db.Exec(ctx, updateNodeLatestPosition,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Altitude,
+		arg.PrecisionBits,
+		arg.RecordedAt,
+		arg.NodeID,
+	)
+	return err
+}
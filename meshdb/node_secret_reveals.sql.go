@@ -0,0 +1,83 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: node_secret_reveals.sql
+
+package meshdb
+
+import (
+	"context"
+)
+
+const createNodeSecretReveal = `-- name: CreateNodeSecretReveal :one
+INSERT INTO node_secret_reveals (mesh_id, node_id, hardware_id, user_id, remote_addr, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, mesh_id, node_id, hardware_id, user_id, remote_addr, user_agent, created_at
+`
+
+type CreateNodeSecretRevealParams struct {
+	MeshID     int64   `json:"mesh_id"`
+	NodeID     *int64  `json:"node_id"`
+	HardwareID string  `json:"hardware_id"`
+	UserID     int64   `json:"user_id"`
+	RemoteAddr *string `json:"remote_addr"`
+	UserAgent  *string `json:"user_agent"`
+}
+
+func (q *Queries) CreateNodeSecretReveal(ctx context.Context, arg CreateNodeSecretRevealParams) (NodeSecretReveal, error) {
+	row := q.db.QueryRow(ctx, createNodeSecretReveal,
+		arg.MeshID,
+		arg.NodeID,
+		arg.HardwareID,
+		arg.UserID,
+		arg.RemoteAddr,
+		arg.UserAgent,
+	)
+	var i NodeSecretReveal
+	err := row.Scan(
+		&i.ID,
+		&i.MeshID,
+		&i.NodeID,
+		&i.HardwareID,
+		&i.UserID,
+		&i.RemoteAddr,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listNodeSecretReveals = `-- name: ListNodeSecretReveals :many
+SELECT id, mesh_id, node_id, hardware_id, user_id, remote_addr, user_agent, created_at FROM node_secret_reveals
+WHERE mesh_id = $1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListNodeSecretReveals(ctx context.Context, meshID int64) ([]NodeSecretReveal, error) {
+	rows, err := q.db.Query(ctx, listNodeSecretReveals, meshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodeSecretReveal
+	for rows.Next() {
+		var i NodeSecretReveal
+		if err := rows.Scan(
+			&i.ID,
+			&i.MeshID,
+			&i.NodeID,
+			&i.HardwareID,
+			&i.UserID,
+			&i.RemoteAddr,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,140 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: node_status_history.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+)
+
+const createNodeStatusHistory = `-- name: CreateNodeStatusHistory :one
+INSERT INTO node_status_history (node_id, old_status, new_status, source)
+VALUES ($1, $2, $3, $4)
+RETURNING id, node_id, old_status, new_status, source, changed_at
+`
+
+type CreateNodeStatusHistoryParams struct {
+	NodeID    int64   `json:"node_id"`
+	OldStatus *string `json:"old_status"`
+	NewStatus string  `json:"new_status"`
+	Source    string  `json:"source"`
+}
+
+func (q *Queries) CreateNodeStatusHistory(ctx context.Context, arg CreateNodeStatusHistoryParams) (NodeStatusHistory, error) {
+	row := q.db.QueryRow(ctx, createNodeStatusHistory,
+		arg.NodeID,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.Source,
+	)
+	var i NodeStatusHistory
+	err := row.Scan(
+		&i.ID,
+		&i.NodeID,
+		&i.OldStatus,
+		&i.NewStatus,
+		&i.Source,
+		&i.ChangedAt,
+	)
+	return i, err
+}
+
+const listMeshStatusChanges = `-- name: ListMeshStatusChanges :many
+SELECT
+    n.id AS node_id,
+    n.name,
+    n.long_name,
+    n.status,
+    COUNT(h.id) AS changes,
+    MAX(h.changed_at)::timestamptz AS last_change
+FROM nodes n
+JOIN node_status_history h ON h.node_id = n.id
+WHERE n.mesh_id = $1 AND h.changed_at >= $2
+GROUP BY n.id
+ORDER BY changes DESC, n.name ASC
+`
+
+type ListMeshStatusChangesParams struct {
+	MeshID int64     `json:"mesh_id"`
+	Since  time.Time `json:"since"`
+}
+
+type ListMeshStatusChangesRow struct {
+	NodeID     int64     `json:"node_id"`
+	Name       string    `json:"name"`
+	LongName   string    `json:"long_name"`
+	Status     *string   `json:"status"`
+	Changes    int64     `json:"changes"`
+	LastChange time.Time `json:"last_change"`
+}
+
+// Count status transitions per node since a time, busiest first, to find
+// nodes that keep dropping off the mesh
+func (q *Queries) ListMeshStatusChanges(ctx context.Context, arg ListMeshStatusChangesParams) ([]ListMeshStatusChangesRow, error) {
+	rows, err := q.db.Query(ctx, listMeshStatusChanges, arg.MeshID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeshStatusChangesRow
+	for rows.Next() {
+		var i ListMeshStatusChangesRow
+		if err := rows.Scan(
+			&i.NodeID,
+			&i.Name,
+			&i.LongName,
+			&i.Status,
+			&i.Changes,
+			&i.LastChange,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodeStatusHistory = `-- name: ListNodeStatusHistory :many
+SELECT id, node_id, old_status, new_status, source, changed_at FROM node_status_history
+WHERE node_id = $1
+ORDER BY changed_at DESC, id DESC
+LIMIT $2
+`
+
+type ListNodeStatusHistoryParams struct {
+	NodeID   int64 `json:"node_id"`
+	RowLimit int32 `json:"row_limit"`
+}
+
+func (q *Queries) ListNodeStatusHistory(ctx context.Context, arg ListNodeStatusHistoryParams) ([]NodeStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listNodeStatusHistory, arg.NodeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NodeStatusHistory
+	for rows.Next() {
+		var i NodeStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.NodeID,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Source,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
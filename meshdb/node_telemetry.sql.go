@@ -0,0 +1,148 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: node_telemetry.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+)
+
+const deleteTelemetryBefore = `-- name: DeleteTelemetryBefore :execrows
+DELETE FROM node_telemetry
+WHERE recorded_at < $1
+`
+
+func (q *Queries) DeleteTelemetryBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTelemetryBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertNodeTelemetry = `-- name: InsertNodeTelemetry :exec
+INSERT INTO node_telemetry (node_id, metric, recorded_at, value)
+SELECT $1, unnest($2::text[]), $3, unnest($4::float8[])
+ON CONFLICT (node_id, metric, recorded_at) DO NOTHING
+`
+
+type InsertNodeTelemetryParams struct {
+	NodeID       int64     `json:"node_id"`
+	Metrics      []string  `json:"metrics"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	MetricValues []float64 `json:"metric_values"`
+}
+
+// Store one telemetry report as a row per metric; metrics and metric_values
+// are parallel arrays
+func (q *Queries) InsertNodeTelemetry(ctx context.Context, arg InsertNodeTelemetryParams) error {
+	_, err := q.db.Exec(ctx, insertNodeTelemetry,
+		arg.NodeID,
+		arg.Metrics,
+		arg.RecordedAt,
+		arg.MetricValues,
+	)
+	return err
+}
+
+const listNodeTelemetryBuckets = `-- name: ListNodeTelemetryBuckets :many
+SELECT
+    date_bin(make_interval(secs => $1::float8), recorded_at, TIMESTAMPTZ '2000-01-01 00:00:00+00')::timestamptz AS bucket,
+    AVG(value)::float8 AS avg_value,
+    MIN(value)::float8 AS min_value,
+    MAX(value)::float8 AS max_value,
+    COUNT(*) AS samples
+FROM node_telemetry
+WHERE node_id = $2
+  AND metric = $3
+  AND recorded_at >= $4
+  AND recorded_at < $5
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+type ListNodeTelemetryBucketsParams struct {
+	BucketSeconds float64   `json:"bucket_seconds"`
+	NodeID        int64     `json:"node_id"`
+	Metric        string    `json:"metric"`
+	FromTime      time.Time `json:"from_time"`
+	ToTime        time.Time `json:"to_time"`
+}
+
+type ListNodeTelemetryBucketsRow struct {
+	Bucket   time.Time `json:"bucket"`
+	AvgValue float64   `json:"avg_value"`
+	MinValue float64   `json:"min_value"`
+	MaxValue float64   `json:"max_value"`
+	Samples  int64     `json:"samples"`
+}
+
+// Downsample a metric into fixed-width time buckets
+func (q *Queries) ListNodeTelemetryBuckets(ctx context.Context, arg ListNodeTelemetryBucketsParams) ([]ListNodeTelemetryBucketsRow, error) {
+	rows, err := q.db.Query(ctx, listNodeTelemetryBuckets,
+		arg.BucketSeconds,
+		arg.NodeID,
+		arg.Metric,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNodeTelemetryBucketsRow
+	for rows.Next() {
+		var i ListNodeTelemetryBucketsRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.AvgValue,
+			&i.MinValue,
+			&i.MaxValue,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodeTelemetryMetrics = `-- name: ListNodeTelemetryMetrics :many
+SELECT DISTINCT ON (metric) metric, recorded_at, value
+FROM node_telemetry
+WHERE node_id = $1
+ORDER BY metric ASC, recorded_at DESC
+`
+
+type ListNodeTelemetryMetricsRow struct {
+	Metric     string    `json:"metric"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Value      float64   `json:"value"`
+}
+
+// The metrics a node has reported, with the latest value of each
+func (q *Queries) ListNodeTelemetryMetrics(ctx context.Context, nodeID int64) ([]ListNodeTelemetryMetricsRow, error) {
+	rows, err := q.db.Query(ctx, listNodeTelemetryMetrics, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNodeTelemetryMetricsRow
+	for rows.Next() {
+		var i ListNodeTelemetryMetricsRow
+		if err := rows.Scan(&i.Metric, &i.RecordedAt, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
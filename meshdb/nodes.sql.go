@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skandragon/meshmgr/internal/secrets"
 )
 
 const countNodesByMesh = `-- name: CountNodesByMesh :one
@@ -27,19 +28,19 @@ func (q *Queries) CountNodesByMesh(ctx context.Context, meshID int64) (int64, er
 const createNode = `-- name: CreateNode :one
 INSERT INTO nodes (mesh_id, hardware_id, name, long_name, role, public_key, private_key, status, unmessageable)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type CreateNodeParams struct {
-	MeshID        int64   `json:"mesh_id"`
-	HardwareID    string  `json:"hardware_id"`
-	Name          string  `json:"name"`
-	LongName      string  `json:"long_name"`
-	Role          *string `json:"role"`
-	PublicKey     *string `json:"public_key"`
-	PrivateKey    *string `json:"private_key"`
-	Status        *string `json:"status"`
-	Unmessageable bool    `json:"unmessageable"`
+	MeshID        int64         `json:"mesh_id"`
+	HardwareID    string        `json:"hardware_id"`
+	Name          string        `json:"name"`
+	LongName      string        `json:"long_name"`
+	Role          *string       `json:"role"`
+	PublicKey     *string       `json:"public_key"`
+	PrivateKey    *secrets.Text `json:"private_key"`
+	Status        *string       `json:"status"`
+	Unmessageable bool          `json:"unmessageable"`
 }
 
 func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error) {
@@ -85,6 +86,11 @@ func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, e
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -100,7 +106,7 @@ func (q *Queries) DeleteNode(ctx context.Context, id int64) error {
 }
 
 const getNode = `-- name: GetNode :one
-SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at FROM nodes
+SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at FROM nodes
 WHERE id = $1
 `
 
@@ -137,12 +143,17 @@ func (q *Queries) GetNode(ctx context.Context, id int64) (Node, error) {
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
 
 const getNodeByHardwareID = `-- name: GetNodeByHardwareID :one
-SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at FROM nodes
+SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at FROM nodes
 WHERE mesh_id = $1 AND hardware_id = $2
 `
 
@@ -184,6 +195,11 @@ func (q *Queries) GetNodeByHardwareID(ctx context.Context, arg GetNodeByHardware
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -208,18 +224,18 @@ WHERE n.id = $1
 `
 
 type GetNodeEffectiveConfigRow struct {
-	ID               int64       `json:"id"`
-	HardwareID       string      `json:"hardware_id"`
-	NodeNum          *int64      `json:"node_num"`
-	DeviceID         []byte      `json:"device_id"`
-	Name             string      `json:"name"`
-	LongName         string      `json:"long_name"`
-	ShortName        *string     `json:"short_name"`
-	EffectiveConfig  interface{} `json:"effective_config"`
-	RawDeviceConfig  []byte      `json:"raw_device_config"`
-	ConfigImportedAt *time.Time  `json:"config_imported_at"`
-	ConfigAppliedAt  *time.Time  `json:"config_applied_at"`
-	PendingChanges   bool        `json:"pending_changes"`
+	ID               int64                `json:"id"`
+	HardwareID       string               `json:"hardware_id"`
+	NodeNum          *int64               `json:"node_num"`
+	DeviceID         []byte               `json:"device_id"`
+	Name             string               `json:"name"`
+	LongName         string               `json:"long_name"`
+	ShortName        *string              `json:"short_name"`
+	EffectiveConfig  interface{}          `json:"effective_config"`
+	RawDeviceConfig  secrets.DeviceConfig `json:"raw_device_config"`
+	ConfigImportedAt *time.Time           `json:"config_imported_at"`
+	ConfigAppliedAt  *time.Time           `json:"config_applied_at"`
+	PendingChanges   bool                 `json:"pending_changes"`
 }
 
 // Get the effective config for a node (merging mesh defaults with node overrides)
@@ -293,22 +309,22 @@ DO UPDATE SET
     last_seen = NOW(),
     status = 'online',
     updated_at = NOW()
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type ImportNodeConfigParams struct {
-	MeshID          int64       `json:"mesh_id"`
-	HardwareID      string      `json:"hardware_id"`
-	NodeNum         *int64      `json:"node_num"`
-	DeviceID        []byte      `json:"device_id"`
-	Name            string      `json:"name"`
-	LongName        string      `json:"long_name"`
-	ShortName       *string     `json:"short_name"`
-	FirmwareVersion *string     `json:"firmware_version"`
-	HwModel         pgtype.Int4 `json:"hw_model"`
-	PublicKey       *string     `json:"public_key"`
-	PrivateKey      *string     `json:"private_key"`
-	RawDeviceConfig []byte      `json:"raw_device_config"`
+	MeshID          int64                `json:"mesh_id"`
+	HardwareID      string               `json:"hardware_id"`
+	NodeNum         *int64               `json:"node_num"`
+	DeviceID        []byte               `json:"device_id"`
+	Name            string               `json:"name"`
+	LongName        string               `json:"long_name"`
+	ShortName       *string              `json:"short_name"`
+	FirmwareVersion *string              `json:"firmware_version"`
+	HwModel         pgtype.Int4          `json:"hw_model"`
+	PublicKey       *string              `json:"public_key"`
+	PrivateKey      *secrets.Text        `json:"private_key"`
+	RawDeviceConfig secrets.DeviceConfig `json:"raw_device_config"`
 }
 
 // Import or update node configuration from device scan
@@ -358,12 +374,84 @@ func (q *Queries) ImportNodeConfig(ctx context.Context, arg ImportNodeConfigPara
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
 
+const listMeshIDsByNodeNum = `-- name: ListMeshIDsByNodeNum :many
+SELECT DISTINCT mesh_id FROM nodes
+WHERE node_num = $1
+  AND ($2::BIGINT IS NULL OR mesh_id = $2)
+ORDER BY mesh_id ASC
+`
+
+type ListMeshIDsByNodeNumParams struct {
+	NodeNum *int64 `json:"node_num"`
+	MeshID  *int64 `json:"mesh_id"`
+}
+
+// Meshes with a node of this node_num, limited to one mesh when mesh_id is
+// not null
+func (q *Queries) ListMeshIDsByNodeNum(ctx context.Context, arg ListMeshIDsByNodeNumParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listMeshIDsByNodeNum, arg.NodeNum, arg.MeshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var mesh_id int64
+		if err := rows.Scan(&mesh_id); err != nil {
+			return nil, err
+		}
+		items = append(items, mesh_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodeIDsByNodeNum = `-- name: ListNodeIDsByNodeNum :many
+SELECT id FROM nodes
+WHERE node_num = $1
+  AND ($2::BIGINT IS NULL OR mesh_id = $2)
+ORDER BY id ASC
+`
+
+type ListNodeIDsByNodeNumParams struct {
+	NodeNum *int64 `json:"node_num"`
+	MeshID  *int64 `json:"mesh_id"`
+}
+
+// Nodes with a node_num, in one mesh or in every mesh when mesh_id is null
+func (q *Queries) ListNodeIDsByNodeNum(ctx context.Context, arg ListNodeIDsByNodeNumParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listNodeIDsByNodeNum, arg.NodeNum, arg.MeshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listNodesByMesh = `-- name: ListNodesByMesh :many
-SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at FROM nodes
+SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at FROM nodes
 WHERE mesh_id = $1
 ORDER BY name ASC
 `
@@ -407,6 +495,11 @@ func (q *Queries) ListNodesByMesh(ctx context.Context, meshID int64) ([]Node, er
 			&i.RawDeviceConfig,
 			&i.ConfigOverrides,
 			&i.ConfigImportedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Altitude,
+			&i.PositionPrecision,
+			&i.PositionAt,
 		); err != nil {
 			return nil, err
 		}
@@ -419,7 +512,7 @@ func (q *Queries) ListNodesByMesh(ctx context.Context, meshID int64) ([]Node, er
 }
 
 const listNodesWithPendingChanges = `-- name: ListNodesWithPendingChanges :many
-SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at FROM nodes
+SELECT id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at FROM nodes
 WHERE mesh_id = $1 AND pending_changes = TRUE
 ORDER BY name ASC
 `
@@ -463,6 +556,79 @@ func (q *Queries) ListNodesWithPendingChanges(ctx context.Context, meshID int64)
 			&i.RawDeviceConfig,
 			&i.ConfigOverrides,
 			&i.ConfigImportedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Altitude,
+			&i.PositionPrecision,
+			&i.PositionAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNodeSeen = `-- name: MarkNodeSeen :many
+WITH seen AS (
+    SELECT
+        n.id,
+        n.status,
+        CASE
+            WHEN (n.status IS NULL OR n.status = 'offline')
+                AND $1::timestamptz > NOW() - make_interval(secs => m.offline_after_seconds)
+            THEN 'online'
+            ELSE n.status
+        END AS new_status
+    FROM nodes n
+    JOIN meshes m ON m.id = n.mesh_id
+    WHERE n.node_num = $2
+      AND ($3::BIGINT IS NULL OR n.mesh_id = $3)
+    FOR UPDATE OF n
+)
+UPDATE nodes
+SET
+    last_seen = GREATEST(nodes.last_seen, $1::timestamptz),
+    status = seen.new_status
+FROM seen
+WHERE nodes.id = seen.id
+RETURNING nodes.id, nodes.mesh_id, seen.status AS old_status, nodes.status AS new_status
+`
+
+type MarkNodeSeenParams struct {
+	SeenAt  time.Time `json:"seen_at"`
+	NodeNum *int64    `json:"node_num"`
+	MeshID  *int64    `json:"mesh_id"`
+}
+
+type MarkNodeSeenRow struct {
+	ID        int64   `json:"id"`
+	MeshID    int64   `json:"mesh_id"`
+	OldStatus *string `json:"old_status"`
+	NewStatus *string `json:"new_status"`
+}
+
+// Record traffic heard from a node_num, in one mesh or in every mesh when
+// mesh_id is null. Nodes with no status or marked offline come online if
+// seen_at is within their mesh's silence threshold; other statuses were set
+// by hand and are left alone.
+func (q *Queries) MarkNodeSeen(ctx context.Context, arg MarkNodeSeenParams) ([]MarkNodeSeenRow, error) {
+	rows, err := q.db.Query(ctx, markNodeSeen, arg.SeenAt, arg.NodeNum, arg.MeshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MarkNodeSeenRow
+	for rows.Next() {
+		var i MarkNodeSeenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MeshID,
+			&i.OldStatus,
+			&i.NewStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -474,6 +640,43 @@ func (q *Queries) ListNodesWithPendingChanges(ctx context.Context, meshID int64)
 	return items, nil
 }
 
+const markSilentNodesOffline = `-- name: MarkSilentNodesOffline :many
+UPDATE nodes
+SET status = 'offline'
+FROM meshes m
+WHERE m.id = nodes.mesh_id
+  AND nodes.status = 'online'
+  AND COALESCE(nodes.last_seen, nodes.created_at) < NOW() - make_interval(secs => m.offline_after_seconds)
+RETURNING nodes.id, nodes.mesh_id
+`
+
+type MarkSilentNodesOfflineRow struct {
+	ID     int64 `json:"id"`
+	MeshID int64 `json:"mesh_id"`
+}
+
+// Mark online nodes offline once they have been silent for longer than
+// their mesh's threshold
+func (q *Queries) MarkSilentNodesOffline(ctx context.Context) ([]MarkSilentNodesOfflineRow, error) {
+	rows, err := q.db.Query(ctx, markSilentNodesOffline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MarkSilentNodesOfflineRow
+	for rows.Next() {
+		var i MarkSilentNodesOfflineRow
+		if err := rows.Scan(&i.ID, &i.MeshID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateNode = `-- name: UpdateNode :one
 UPDATE nodes
 SET
@@ -488,20 +691,20 @@ SET
     pending_changes = COALESCE($9, pending_changes),
     updated_at = NOW()
 WHERE id = $10
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type UpdateNodeParams struct {
-	Name           *string     `json:"name"`
-	LongName       *string     `json:"long_name"`
-	Role           *string     `json:"role"`
-	PublicKey      *string     `json:"public_key"`
-	PrivateKey     *string     `json:"private_key"`
-	Status         *string     `json:"status"`
-	Unmessageable  pgtype.Bool `json:"unmessageable"`
-	LastSeen       *time.Time  `json:"last_seen"`
-	PendingChanges pgtype.Bool `json:"pending_changes"`
-	ID             int64       `json:"id"`
+	Name           *string       `json:"name"`
+	LongName       *string       `json:"long_name"`
+	Role           *string       `json:"role"`
+	PublicKey      *string       `json:"public_key"`
+	PrivateKey     *secrets.Text `json:"private_key"`
+	Status         *string       `json:"status"`
+	Unmessageable  pgtype.Bool   `json:"unmessageable"`
+	LastSeen       *time.Time    `json:"last_seen"`
+	PendingChanges pgtype.Bool   `json:"pending_changes"`
+	ID             int64         `json:"id"`
 }
 
 func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error) {
@@ -548,6 +751,11 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, e
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -565,17 +773,17 @@ SET
     pending_changes = FALSE,
     updated_at = NOW()
 WHERE id = $7
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type UpdateNodeAppliedStateParams struct {
-	AppliedName          *string     `json:"applied_name"`
-	AppliedLongName      *string     `json:"applied_long_name"`
-	AppliedRole          *string     `json:"applied_role"`
-	AppliedPublicKey     *string     `json:"applied_public_key"`
-	AppliedPrivateKey    *string     `json:"applied_private_key"`
-	AppliedUnmessageable pgtype.Bool `json:"applied_unmessageable"`
-	ID                   int64       `json:"id"`
+	AppliedName          *string       `json:"applied_name"`
+	AppliedLongName      *string       `json:"applied_long_name"`
+	AppliedRole          *string       `json:"applied_role"`
+	AppliedPublicKey     *string       `json:"applied_public_key"`
+	AppliedPrivateKey    *secrets.Text `json:"applied_private_key"`
+	AppliedUnmessageable pgtype.Bool   `json:"applied_unmessageable"`
+	ID                   int64         `json:"id"`
 }
 
 func (q *Queries) UpdateNodeAppliedState(ctx context.Context, arg UpdateNodeAppliedStateParams) (Node, error) {
@@ -619,6 +827,11 @@ func (q *Queries) UpdateNodeAppliedState(ctx context.Context, arg UpdateNodeAppl
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -630,7 +843,7 @@ SET
     pending_changes = TRUE,
     updated_at = NOW()
 WHERE id = $2
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type UpdateNodeConfigOverridesParams struct {
@@ -672,6 +885,11 @@ func (q *Queries) UpdateNodeConfigOverrides(ctx context.Context, arg UpdateNodeC
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -683,7 +901,7 @@ SET
     last_seen = NOW(),
     updated_at = NOW()
 WHERE id = $2
-RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at
+RETURNING id, mesh_id, hardware_id, name, long_name, role, public_key, private_key, last_seen, status, created_at, updated_at, applied_name, applied_long_name, applied_role, applied_public_key, applied_private_key, applied_unmessageable, unmessageable, config_applied_at, pending_changes, node_num, device_id, firmware_version, hw_model, short_name, raw_device_config, config_overrides, config_imported_at, latitude, longitude, altitude, position_precision, position_at
 `
 
 type UpdateNodeStatusParams struct {
@@ -724,6 +942,11 @@ func (q *Queries) UpdateNodeStatus(ctx context.Context, arg UpdateNodeStatusPara
 		&i.RawDeviceConfig,
 		&i.ConfigOverrides,
 		&i.ConfigImportedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+		&i.PositionPrecision,
+		&i.PositionAt,
 	)
 	return i, err
 }
@@ -6,18 +6,35 @@ package meshdb
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AddApplyJobLog(ctx context.Context, arg AddApplyJobLogParams) error
 	AssignAdminKeyToNode(ctx context.Context, arg AssignAdminKeyToNodeParams) (NodeAdminKey, error)
 	CheckUserMeshAccess(ctx context.Context, arg CheckUserMeshAccessParams) (string, error)
 	CountAdminKeysByMesh(ctx context.Context, meshID int64) (int64, error)
 	CountMeshChannels(ctx context.Context, meshID int64) (int64, error)
+	CountMeshesOwnedByUser(ctx context.Context, ownerID int64) (int64, error)
 	CountNodesByMesh(ctx context.Context, meshID int64) (int64, error)
+	// Node private keys and channel PSKs already stored, which a server with
+	// no master key could not write again
+	CountStoredSecrets(ctx context.Context) (int64, error)
 	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (UserApiKey, error)
+	CreateAdminAuditLog(ctx context.Context, arg CreateAdminAuditLogParams) (AdminAuditLog, error)
 	CreateAdminKey(ctx context.Context, arg CreateAdminKeyParams) (AdminKey, error)
+	CreateAdminKeyDownload(ctx context.Context, arg CreateAdminKeyDownloadParams) (AdminKeyDownload, error)
+	// Returns no rows if the mesh already has a rotation in progress
+	CreateAdminKeyRotation(ctx context.Context, arg CreateAdminKeyRotationParams) (AdminKeyRotation, error)
+	// Returns no rows if the node already has a job pending or running
+	CreateApplyJob(ctx context.Context, arg CreateApplyJobParams) (NodeApplyJob, error)
+	// Adds a key the server generated, with its encrypted private key
+	CreateGeneratedAdminKey(ctx context.Context, arg CreateGeneratedAdminKeyParams) (AdminKey, error)
+	CreateImpersonation(ctx context.Context, arg CreateImpersonationParams) (Impersonation, error)
 	CreateMesh(ctx context.Context, arg CreateMeshParams) (Mesh, error)
 	CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error)
+	CreateNodeSecretReveal(ctx context.Context, arg CreateNodeSecretRevealParams) (NodeSecretReveal, error)
+	CreateNodeStatusHistory(ctx context.Context, arg CreateNodeStatusHistoryParams) (NodeStatusHistory, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAPIKey(ctx context.Context, id int64) error
@@ -26,18 +43,29 @@ type Querier interface {
 	DeleteExpiredSessions(ctx context.Context) error
 	DeleteMesh(ctx context.Context, id int64) error
 	DeleteMeshChannel(ctx context.Context, arg DeleteMeshChannelParams) error
+	DeleteMeshMQTT(ctx context.Context, meshID int64) error
 	DeleteNode(ctx context.Context, id int64) error
 	DeleteNodeAdminKeyMapping(ctx context.Context, arg DeleteNodeAdminKeyMappingParams) error
+	DeletePositionsBefore(ctx context.Context, before time.Time) (int64, error)
 	DeleteSession(ctx context.Context, token string) error
+	DeleteTelemetryBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteTopologyEdgesBefore(ctx context.Context, before time.Time) (int64, error)
 	DeleteUser(ctx context.Context, id int64) error
 	DeleteUserSessions(ctx context.Context, userID int64) error
+	FailInterruptedApplyJobs(ctx context.Context) (int64, error)
+	FinishAdminKeyRotation(ctx context.Context, arg FinishAdminKeyRotationParams) (AdminKeyRotation, error)
+	FinishApplyJob(ctx context.Context, arg FinishApplyJobParams) (NodeApplyJob, error)
 	GetAPIKey(ctx context.Context, id int64) (UserApiKey, error)
 	GetAPIKeyByHash(ctx context.Context, keyHash string) (UserApiKey, error)
 	GetAdminKey(ctx context.Context, id int64) (AdminKey, error)
+	GetAdminKeyRotation(ctx context.Context, id int64) (AdminKeyRotation, error)
+	GetApplyJob(ctx context.Context, id int64) (NodeApplyJob, error)
 	GetCurrentAdminKeysForNode(ctx context.Context, nodeID int64) ([]GetCurrentAdminKeysForNodeRow, error)
+	GetImpersonation(ctx context.Context, id int64) (Impersonation, error)
 	GetMeshAccess(ctx context.Context, arg GetMeshAccessParams) (MeshAccess, error)
 	GetMeshByID(ctx context.Context, id int64) (Mesh, error)
 	GetMeshChannel(ctx context.Context, arg GetMeshChannelParams) (MeshChannel, error)
+	GetMeshMQTT(ctx context.Context, meshID int64) (MeshMqtt, error)
 	// Get mesh with all config defaults
 	GetMeshWithDefaults(ctx context.Context, id int64) (GetMeshWithDefaultsRow, error)
 	GetNode(ctx context.Context, id int64) (Node, error)
@@ -57,36 +85,102 @@ type Querier interface {
 	ImportMeshChannels(ctx context.Context, meshID int64) error
 	// Import or update node configuration from device scan
 	ImportNodeConfig(ctx context.Context, arg ImportNodeConfigParams) (Node, error)
+	InsertNodePosition(ctx context.Context, arg InsertNodePositionParams) error
+	// Store one telemetry report as a row per metric; metrics and metric_values
+	// are parallel arrays
+	InsertNodeTelemetry(ctx context.Context, arg InsertNodeTelemetryParams) error
 	ListAPIKeysByUser(ctx context.Context, userID int64) ([]UserApiKey, error)
+	ListActiveImpersonations(ctx context.Context) ([]ListActiveImpersonationsRow, error)
+	ListAdminAuditLog(ctx context.Context, arg ListAdminAuditLogParams) ([]ListAdminAuditLogRow, error)
+	ListAdminKeyDownloads(ctx context.Context, meshID int64) ([]AdminKeyDownload, error)
+	ListAdminKeyPrivateKeys(ctx context.Context) ([]ListAdminKeyPrivateKeysRow, error)
+	// Every node of a mesh with whether it holds the old and new keys of a
+	// rotation, and how its last apply job went
+	ListAdminKeyRotationNodes(ctx context.Context, arg ListAdminKeyRotationNodesParams) ([]ListAdminKeyRotationNodesRow, error)
+	ListAdminKeyRotations(ctx context.Context, meshID int64) ([]AdminKeyRotation, error)
 	ListAdminKeysByMesh(ctx context.Context, meshID int64) ([]AdminKey, error)
 	ListAdminKeysForNode(ctx context.Context, nodeID int64) ([]ListAdminKeysForNodeRow, error)
+	// Every mesh with its owner and node count, for operators
+	ListAllMeshes(ctx context.Context) ([]ListAllMeshesRow, error)
+	ListApplyJobLog(ctx context.Context, jobID int64) ([]NodeApplyJobLog, error)
+	ListEnabledMeshMQTT(ctx context.Context) ([]MeshMqtt, error)
 	ListMeshAccessByMesh(ctx context.Context, meshID int64) ([]ListMeshAccessByMeshRow, error)
 	ListMeshAccessByUser(ctx context.Context, userID int64) ([]ListMeshAccessByUserRow, error)
+	ListMeshChannelSecrets(ctx context.Context) ([]ListMeshChannelSecretsRow, error)
 	ListMeshChannels(ctx context.Context, meshID int64) ([]MeshChannel, error)
+	// Meshes with a node of this node_num, limited to one mesh when mesh_id is
+	// not null
+	ListMeshIDsByNodeNum(ctx context.Context, arg ListMeshIDsByNodeNumParams) ([]int64, error)
+	// The latest position of each located node in a mesh, with its latest
+	// battery level
+	ListMeshNodePositions(ctx context.Context, meshID int64) ([]ListMeshNodePositionsRow, error)
+	// Count status transitions per node since a time, busiest first, to find
+	// nodes that keep dropping off the mesh
+	ListMeshStatusChanges(ctx context.Context, arg ListMeshStatusChangesParams) ([]ListMeshStatusChangesRow, error)
 	ListMeshesByOwner(ctx context.Context, ownerID int64) ([]Mesh, error)
 	ListMeshesByUser(ctx context.Context, userID int64) ([]Mesh, error)
+	ListNodeApplyJobs(ctx context.Context, arg ListNodeApplyJobsParams) ([]NodeApplyJob, error)
+	// Nodes with a node_num, in one mesh or in every mesh when mesh_id is null
+	ListNodeIDsByNodeNum(ctx context.Context, arg ListNodeIDsByNodeNumParams) ([]int64, error)
+	// A node's track between two times, oldest first
+	ListNodePositions(ctx context.Context, arg ListNodePositionsParams) ([]NodePosition, error)
+	ListNodeSecretReveals(ctx context.Context, meshID int64) ([]NodeSecretReveal, error)
+	// Secrets of every node, for re-encrypting them under a new master key
+	ListNodeSecrets(ctx context.Context) ([]ListNodeSecretsRow, error)
+	ListNodeStatusHistory(ctx context.Context, arg ListNodeStatusHistoryParams) ([]NodeStatusHistory, error)
+	// Downsample a metric into fixed-width time buckets
+	ListNodeTelemetryBuckets(ctx context.Context, arg ListNodeTelemetryBucketsParams) ([]ListNodeTelemetryBucketsRow, error)
+	// The metrics a node has reported, with the latest value of each
+	ListNodeTelemetryMetrics(ctx context.Context, nodeID int64) ([]ListNodeTelemetryMetricsRow, error)
 	ListNodesByMesh(ctx context.Context, meshID int64) ([]Node, error)
 	ListNodesForAdminKey(ctx context.Context, adminKeyID int64) ([]ListNodesForAdminKeyRow, error)
+	// Nodes that hold an admin key and no other, which would be cut off from
+	// remote administration without it
+	ListNodesOnlyReachableByAdminKey(ctx context.Context, adminKeyID int64) ([]ListNodesOnlyReachableByAdminKeyRow, error)
 	ListNodesWithPendingChanges(ctx context.Context, meshID int64) ([]Node, error)
+	ListTopologyEdges(ctx context.Context, meshID int64) ([]TopologyEdge, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
 	MarkAdminKeyNotCurrent(ctx context.Context, arg MarkAdminKeyNotCurrentParams) error
+	// Record traffic heard from a node_num, in one mesh or in every mesh when
+	// mesh_id is null. Nodes with no status or marked offline come online if
+	// seen_at is within their mesh's silence threshold; other statuses were set
+	// by hand and are left alone.
+	MarkNodeSeen(ctx context.Context, arg MarkNodeSeenParams) ([]MarkNodeSeenRow, error)
+	// Mark online nodes offline once they have been silent for longer than
+	// their mesh's threshold
+	MarkSilentNodesOffline(ctx context.Context) ([]MarkSilentNodesOfflineRow, error)
+	RevokeImpersonation(ctx context.Context, id int64) (Impersonation, error)
 	RevokeMeshAccess(ctx context.Context, arg RevokeMeshAccessParams) error
+	SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error)
+	// Disable or re-enable a user, keeping when they were first disabled
+	SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (User, error)
+	StartApplyJob(ctx context.Context, id int64) error
 	UpdateAPIKeyHash(ctx context.Context, arg UpdateAPIKeyHashParams) (UserApiKey, error)
 	UpdateAPIKeyLastUsed(ctx context.Context, id int64) error
+	UpdateAdminKeyPrivateKey(ctx context.Context, arg UpdateAdminKeyPrivateKeyParams) error
 	UpdateMesh(ctx context.Context, arg UpdateMeshParams) (Mesh, error)
 	UpdateMeshAccess(ctx context.Context, arg UpdateMeshAccessParams) (MeshAccess, error)
+	UpdateMeshChannelSecret(ctx context.Context, arg UpdateMeshChannelSecretParams) error
 	// Update mesh-wide default configuration
 	UpdateMeshConfigDefaults(ctx context.Context, arg UpdateMeshConfigDefaultsParams) (Mesh, error)
 	// Update LoRa-specific configuration for a mesh
 	UpdateMeshLoRaConfig(ctx context.Context, arg UpdateMeshLoRaConfigParams) (Mesh, error)
+	UpdateMeshOwner(ctx context.Context, arg UpdateMeshOwnerParams) (Mesh, error)
 	UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error)
 	UpdateNodeAppliedState(ctx context.Context, arg UpdateNodeAppliedStateParams) (Node, error)
 	// Update node-specific config overrides
 	UpdateNodeConfigOverrides(ctx context.Context, arg UpdateNodeConfigOverridesParams) (Node, error)
+	// Reports can arrive out of order; only a newer one replaces the latest
+	UpdateNodeLatestPosition(ctx context.Context, arg UpdateNodeLatestPositionParams) error
+	UpdateNodeSecrets(ctx context.Context, arg UpdateNodeSecretsParams) error
 	UpdateNodeStatus(ctx context.Context, arg UpdateNodeStatusParams) (Node, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	// Insert or update a mesh channel
 	UpsertMeshChannel(ctx context.Context, arg UpsertMeshChannelParams) (MeshChannel, error)
+	// A null password keeps the stored one
+	UpsertMeshMQTT(ctx context.Context, arg UpsertMeshMQTTParams) (MeshMqtt, error)
+	// Reports can arrive out of order; an older one doesn't replace a newer one
+	UpsertTopologyEdge(ctx context.Context, arg UpsertTopologyEdgeParams) error
 }
 
 var _ Querier = (*Queries)(nil)
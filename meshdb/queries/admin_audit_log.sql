@@ -0,0 +1,13 @@
+-- name: CreateAdminAuditLog :one
+INSERT INTO admin_audit_log (admin_user_id, action, target_user_id, target_email, remote_addr, user_agent, impersonation_id, detail)
+VALUES (@admin_user_id, @action, @target_user_id, @target_email, @remote_addr, @user_agent, @impersonation_id, @detail)
+RETURNING *;
+
+-- name: ListAdminAuditLog :many
+SELECT
+    l.*,
+    u.email AS admin_email
+FROM admin_audit_log l
+LEFT JOIN users u ON u.id = l.admin_user_id
+ORDER BY l.created_at DESC, l.id DESC
+LIMIT @row_limit OFFSET @row_offset;
@@ -0,0 +1,45 @@
+-- name: CreateAdminKeyRotation :one
+-- Returns no rows if the mesh already has a rotation in progress
+INSERT INTO admin_key_rotations (mesh_id, old_key_id, new_key_id, started_by)
+VALUES (@mesh_id, @old_key_id, @new_key_id, @started_by)
+ON CONFLICT (mesh_id) WHERE status = 'in_progress' DO NOTHING
+RETURNING *;
+
+-- name: GetAdminKeyRotation :one
+SELECT * FROM admin_key_rotations
+WHERE id = @id;
+
+-- name: ListAdminKeyRotations :many
+SELECT * FROM admin_key_rotations
+WHERE mesh_id = @mesh_id
+ORDER BY created_at DESC;
+
+-- name: FinishAdminKeyRotation :one
+UPDATE admin_key_rotations
+SET status = @status, finished_at = NOW()
+WHERE id = @id AND status = 'in_progress'
+RETURNING *;
+
+-- name: ListAdminKeyRotationNodes :many
+-- Every node of a mesh with whether it holds the old and new keys of a
+-- rotation, and how its last apply job went
+SELECT
+    n.id AS node_id,
+    n.name,
+    n.hardware_id,
+    EXISTS (
+        SELECT 1 FROM node_admin_keys nak
+        WHERE nak.node_id = n.id AND nak.admin_key_id = @new_key_id AND nak.is_current
+    ) AS has_new_key,
+    EXISTS (
+        SELECT 1 FROM node_admin_keys nak
+        WHERE nak.node_id = n.id AND nak.admin_key_id = sqlc.narg('old_key_id') AND nak.is_current
+    ) AS has_old_key,
+    j.status AS last_apply_status,
+    j.error AS last_apply_error
+FROM nodes n
+LEFT JOIN node_apply_jobs j ON j.id = (
+    SELECT MAX(id) FROM node_apply_jobs WHERE node_id = n.id
+)
+WHERE n.mesh_id = @mesh_id
+ORDER BY n.name ASC;
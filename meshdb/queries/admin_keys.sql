@@ -3,6 +3,12 @@ INSERT INTO admin_keys (mesh_id, public_key, key_name, added_by)
 VALUES (@mesh_id, @public_key, @key_name, @added_by)
 RETURNING *;
 
+-- name: CreateGeneratedAdminKey :one
+-- Adds a key the server generated, with its encrypted private key
+INSERT INTO admin_keys (mesh_id, public_key, key_name, added_by, private_key_encrypted)
+VALUES (@mesh_id, @public_key, @key_name, @added_by, @private_key_encrypted)
+RETURNING *;
+
 -- name: GetAdminKey :one
 SELECT * FROM admin_keys
 WHERE id = @id;
@@ -19,3 +25,13 @@ WHERE id = @id;
 -- name: CountAdminKeysByMesh :one
 SELECT COUNT(*) FROM admin_keys
 WHERE mesh_id = @mesh_id;
+
+-- name: CreateAdminKeyDownload :one
+INSERT INTO admin_key_downloads (mesh_id, admin_key_id, public_key, user_id, remote_addr, user_agent)
+VALUES (@mesh_id, @admin_key_id, @public_key, @user_id, @remote_addr, @user_agent)
+RETURNING *;
+
+-- name: ListAdminKeyDownloads :many
+SELECT * FROM admin_key_downloads
+WHERE mesh_id = @mesh_id
+ORDER BY created_at DESC;
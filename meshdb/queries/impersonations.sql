@@ -0,0 +1,25 @@
+-- name: CreateImpersonation :one
+INSERT INTO impersonations (admin_user_id, target_user_id, expires_at)
+VALUES (@admin_user_id, @target_user_id, @expires_at)
+RETURNING *;
+
+-- name: GetImpersonation :one
+SELECT * FROM impersonations
+WHERE id = @id;
+
+-- name: RevokeImpersonation :one
+UPDATE impersonations
+SET revoked_at = NOW()
+WHERE id = @id AND revoked_at IS NULL
+RETURNING *;
+
+-- name: ListActiveImpersonations :many
+SELECT
+    i.*,
+    a.email AS admin_email,
+    t.email AS target_email
+FROM impersonations i
+JOIN users a ON a.id = i.admin_user_id
+JOIN users t ON t.id = i.target_user_id
+WHERE i.revoked_at IS NULL AND i.expires_at > NOW()
+ORDER BY i.created_at DESC;
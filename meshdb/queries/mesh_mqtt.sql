@@ -0,0 +1,25 @@
+-- name: GetMeshMQTT :one
+SELECT * FROM mesh_mqtt
+WHERE mesh_id = @mesh_id;
+
+-- name: UpsertMeshMQTT :one
+-- A null password keeps the stored one
+INSERT INTO mesh_mqtt (mesh_id, broker_url, topic_root, username, password, enabled)
+VALUES (@mesh_id, @broker_url, @topic_root, sqlc.narg('username'), sqlc.narg('password'), @enabled)
+ON CONFLICT (mesh_id) DO UPDATE
+SET broker_url = EXCLUDED.broker_url,
+    topic_root = EXCLUDED.topic_root,
+    username = EXCLUDED.username,
+    password = COALESCE(EXCLUDED.password, mesh_mqtt.password),
+    enabled = EXCLUDED.enabled,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteMeshMQTT :exec
+DELETE FROM mesh_mqtt
+WHERE mesh_id = @mesh_id;
+
+-- name: ListEnabledMeshMQTT :many
+SELECT * FROM mesh_mqtt
+WHERE enabled = TRUE
+ORDER BY mesh_id ASC;
@@ -15,6 +15,8 @@ SET
     lora_region = COALESCE(sqlc.narg('lora_region'), lora_region),
     modem_preset = COALESCE(sqlc.narg('modem_preset'), modem_preset),
     frequency_slot = COALESCE(sqlc.narg('frequency_slot'), frequency_slot),
+    offline_after_seconds = COALESCE(sqlc.narg('offline_after_seconds'), offline_after_seconds),
+    admin_key_download_enabled = COALESCE(sqlc.narg('admin_key_download_enabled'), admin_key_download_enabled),
     updated_at = NOW()
 WHERE id = @id
 RETURNING *;
@@ -77,3 +79,24 @@ SELECT
     updated_at
 FROM meshes
 WHERE id = @id;
+
+-- name: ListAllMeshes :many
+-- Every mesh with its owner and node count, for operators
+SELECT
+    m.id,
+    m.name,
+    m.owner_id,
+    u.email AS owner_email,
+    (SELECT COUNT(*) FROM nodes n WHERE n.mesh_id = m.id) AS node_count,
+    m.created_at
+FROM meshes m
+JOIN users u ON u.id = m.owner_id
+ORDER BY m.id;
+
+-- name: UpdateMeshOwner :one
+UPDATE meshes
+SET
+    owner_id = @owner_id,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
@@ -34,3 +34,19 @@ ORDER BY nak.applied_at DESC;
 -- name: DeleteNodeAdminKeyMapping :exec
 DELETE FROM node_admin_keys
 WHERE node_id = @node_id AND admin_key_id = @admin_key_id;
+
+-- name: ListNodesOnlyReachableByAdminKey :many
+-- Nodes that hold an admin key and no other, which would be cut off from
+-- remote administration without it
+SELECT n.id, n.name, n.hardware_id
+FROM nodes n
+JOIN node_admin_keys nak ON nak.node_id = n.id
+WHERE nak.admin_key_id = @admin_key_id
+  AND nak.is_current
+  AND NOT EXISTS (
+      SELECT 1 FROM node_admin_keys other
+      WHERE other.node_id = n.id
+        AND other.is_current
+        AND other.admin_key_id <> @admin_key_id
+  )
+ORDER BY n.name ASC;
@@ -0,0 +1,41 @@
+-- name: CreateApplyJob :one
+-- Returns no rows if the node already has a job pending or running
+INSERT INTO node_apply_jobs (node_id, admin_key_id, requested_by)
+VALUES (@node_id, @admin_key_id, @requested_by)
+ON CONFLICT (node_id) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING *;
+
+-- name: GetApplyJob :one
+SELECT * FROM node_apply_jobs
+WHERE id = @id;
+
+-- name: ListNodeApplyJobs :many
+SELECT * FROM node_apply_jobs
+WHERE node_id = @node_id
+ORDER BY created_at DESC
+LIMIT @row_limit;
+
+-- name: StartApplyJob :exec
+UPDATE node_apply_jobs
+SET status = 'running', started_at = NOW()
+WHERE id = @id;
+
+-- name: FinishApplyJob :one
+UPDATE node_apply_jobs
+SET status = @status, error = sqlc.narg('error'), finished_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: FailInterruptedApplyJobs :execrows
+UPDATE node_apply_jobs
+SET status = 'failed', error = 'interrupted by server restart', finished_at = NOW()
+WHERE status IN ('pending', 'running');
+
+-- name: AddApplyJobLog :exec
+INSERT INTO node_apply_job_log (job_id, message, attempt, outcome, detail)
+VALUES (@job_id, @message, @attempt, @outcome, sqlc.narg('detail'));
+
+-- name: ListApplyJobLog :many
+SELECT * FROM node_apply_job_log
+WHERE job_id = @job_id
+ORDER BY id ASC;
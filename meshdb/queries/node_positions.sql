@@ -0,0 +1,49 @@
+-- name: InsertNodePosition :exec
+INSERT INTO node_positions (node_id, recorded_at, latitude, longitude, altitude, precision_bits)
+VALUES (@node_id, @recorded_at, @latitude, @longitude, sqlc.narg('altitude'), sqlc.narg('precision_bits'))
+ON CONFLICT (node_id, recorded_at) DO NOTHING;
+
+-- name: UpdateNodeLatestPosition :exec
+-- Reports can arrive out of order; only a newer one replaces the latest
+UPDATE nodes
+SET latitude = @latitude::float8,
+    longitude = @longitude::float8,
+    altitude = sqlc.narg('altitude'),
+    position_precision = sqlc.narg('precision_bits'),
+    position_at = @recorded_at::timestamptz
+WHERE id = @node_id
+  AND (position_at IS NULL OR position_at <= @recorded_at::timestamptz);
+
+-- name: ListNodePositions :many
+-- A node's track between two times, oldest first
+SELECT * FROM node_positions
+WHERE node_id = @node_id
+  AND recorded_at >= @from_time
+  AND recorded_at < @to_time
+ORDER BY recorded_at ASC;
+
+-- name: ListMeshNodePositions :many
+-- The latest position of each located node in a mesh, with its latest
+-- battery level
+SELECT
+    n.id, n.hardware_id, n.name, n.long_name, n.short_name, n.role, n.status, n.last_seen,
+    n.latitude::float8 AS latitude,
+    n.longitude::float8 AS longitude,
+    n.altitude, n.position_precision, n.position_at,
+    battery.value AS battery_level
+FROM nodes n
+LEFT JOIN node_telemetry battery
+    ON battery.node_id = n.id
+    AND battery.metric = 'battery_level'
+    AND battery.recorded_at = (
+        SELECT MAX(t.recorded_at) FROM node_telemetry t
+        WHERE t.node_id = n.id AND t.metric = 'battery_level'
+    )
+WHERE n.mesh_id = @mesh_id
+  AND n.latitude IS NOT NULL
+  AND n.longitude IS NOT NULL
+ORDER BY n.name ASC;
+
+-- name: DeletePositionsBefore :execrows
+DELETE FROM node_positions
+WHERE recorded_at < @before;
@@ -0,0 +1,9 @@
+-- name: CreateNodeSecretReveal :one
+INSERT INTO node_secret_reveals (mesh_id, node_id, hardware_id, user_id, remote_addr, user_agent)
+VALUES (@mesh_id, @node_id, @hardware_id, @user_id, @remote_addr, @user_agent)
+RETURNING *;
+
+-- name: ListNodeSecretReveals :many
+SELECT * FROM node_secret_reveals
+WHERE mesh_id = @mesh_id
+ORDER BY created_at DESC, id DESC;
@@ -0,0 +1,26 @@
+-- name: CreateNodeStatusHistory :one
+INSERT INTO node_status_history (node_id, old_status, new_status, source)
+VALUES (@node_id, @old_status, @new_status, @source)
+RETURNING *;
+
+-- name: ListNodeStatusHistory :many
+SELECT * FROM node_status_history
+WHERE node_id = @node_id
+ORDER BY changed_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: ListMeshStatusChanges :many
+-- Count status transitions per node since a time, busiest first, to find
+-- nodes that keep dropping off the mesh
+SELECT
+    n.id AS node_id,
+    n.name,
+    n.long_name,
+    n.status,
+    COUNT(h.id) AS changes,
+    MAX(h.changed_at)::timestamptz AS last_change
+FROM nodes n
+JOIN node_status_history h ON h.node_id = n.id
+WHERE n.mesh_id = @mesh_id AND h.changed_at >= @since
+GROUP BY n.id
+ORDER BY changes DESC, n.name ASC;
@@ -0,0 +1,33 @@
+-- name: InsertNodeTelemetry :exec
+-- Store one telemetry report as a row per metric; metrics and metric_values
+-- are parallel arrays
+INSERT INTO node_telemetry (node_id, metric, recorded_at, value)
+SELECT @node_id, unnest(@metrics::text[]), @recorded_at, unnest(@metric_values::float8[])
+ON CONFLICT (node_id, metric, recorded_at) DO NOTHING;
+
+-- name: ListNodeTelemetryBuckets :many
+-- Downsample a metric into fixed-width time buckets
+SELECT
+    date_bin(make_interval(secs => @bucket_seconds::float8), recorded_at, TIMESTAMPTZ '2000-01-01 00:00:00+00')::timestamptz AS bucket,
+    AVG(value)::float8 AS avg_value,
+    MIN(value)::float8 AS min_value,
+    MAX(value)::float8 AS max_value,
+    COUNT(*) AS samples
+FROM node_telemetry
+WHERE node_id = @node_id
+  AND metric = @metric
+  AND recorded_at >= @from_time
+  AND recorded_at < @to_time
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: ListNodeTelemetryMetrics :many
+-- The metrics a node has reported, with the latest value of each
+SELECT DISTINCT ON (metric) metric, recorded_at, value
+FROM node_telemetry
+WHERE node_id = @node_id
+ORDER BY metric ASC, recorded_at DESC;
+
+-- name: DeleteTelemetryBefore :execrows
+DELETE FROM node_telemetry
+WHERE recorded_at < @before;
@@ -150,3 +150,58 @@ SELECT
 FROM nodes n
 JOIN meshes m ON n.mesh_id = m.id
 WHERE n.id = @id;
+
+-- name: MarkNodeSeen :many
+-- Record traffic heard from a node_num, in one mesh or in every mesh when
+-- mesh_id is null. Nodes with no status or marked offline come online if
+-- seen_at is within their mesh's silence threshold; other statuses were set
+-- by hand and are left alone.
+WITH seen AS (
+    SELECT
+        n.id,
+        n.status,
+        CASE
+            WHEN (n.status IS NULL OR n.status = 'offline')
+                AND @seen_at::timestamptz > NOW() - make_interval(secs => m.offline_after_seconds)
+            THEN 'online'
+            ELSE n.status
+        END AS new_status
+    FROM nodes n
+    JOIN meshes m ON m.id = n.mesh_id
+    WHERE n.node_num = @node_num
+      AND (sqlc.narg('mesh_id')::BIGINT IS NULL OR n.mesh_id = sqlc.narg('mesh_id'))
+    FOR UPDATE OF n
+)
+UPDATE nodes
+SET
+    last_seen = GREATEST(nodes.last_seen, @seen_at::timestamptz),
+    status = seen.new_status
+FROM seen
+WHERE nodes.id = seen.id
+RETURNING nodes.id, nodes.mesh_id, seen.status AS old_status, nodes.status AS new_status;
+
+-- name: MarkSilentNodesOffline :many
+-- Mark online nodes offline once they have been silent for longer than
+-- their mesh's threshold
+UPDATE nodes
+SET status = 'offline'
+FROM meshes m
+WHERE m.id = nodes.mesh_id
+  AND nodes.status = 'online'
+  AND COALESCE(nodes.last_seen, nodes.created_at) < NOW() - make_interval(secs => m.offline_after_seconds)
+RETURNING nodes.id, nodes.mesh_id;
+
+-- name: ListNodeIDsByNodeNum :many
+-- Nodes with a node_num, in one mesh or in every mesh when mesh_id is null
+SELECT id FROM nodes
+WHERE node_num = @node_num
+  AND (sqlc.narg('mesh_id')::BIGINT IS NULL OR mesh_id = sqlc.narg('mesh_id'))
+ORDER BY id ASC;
+
+-- name: ListMeshIDsByNodeNum :many
+-- Meshes with a node of this node_num, limited to one mesh when mesh_id is
+-- not null
+SELECT DISTINCT mesh_id FROM nodes
+WHERE node_num = @node_num
+  AND (sqlc.narg('mesh_id')::BIGINT IS NULL OR mesh_id = sqlc.narg('mesh_id'))
+ORDER BY mesh_id ASC;
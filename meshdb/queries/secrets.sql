@@ -0,0 +1,39 @@
+-- name: ListNodeSecrets :many
+-- Secrets of every node, for re-encrypting them under a new master key
+SELECT id, private_key, applied_private_key, raw_device_config
+FROM nodes
+ORDER BY id;
+
+-- name: UpdateNodeSecrets :exec
+UPDATE nodes SET
+    private_key = @private_key,
+    applied_private_key = @applied_private_key,
+    raw_device_config = @raw_device_config
+WHERE id = @id;
+
+-- name: ListMeshChannelSecrets :many
+SELECT id, psk
+FROM mesh_channels
+ORDER BY id;
+
+-- name: UpdateMeshChannelSecret :exec
+UPDATE mesh_channels SET psk = @psk
+WHERE id = @id;
+
+-- name: ListAdminKeyPrivateKeys :many
+SELECT id, private_key_encrypted
+FROM admin_keys
+WHERE private_key_encrypted IS NOT NULL
+ORDER BY id;
+
+-- name: UpdateAdminKeyPrivateKey :exec
+UPDATE admin_keys SET private_key_encrypted = @private_key_encrypted
+WHERE id = @id;
+
+-- name: CountStoredSecrets :one
+-- Node private keys and channel PSKs already stored, which a server with
+-- no master key could not write again
+SELECT (
+    (SELECT COUNT(*) FROM nodes WHERE private_key IS NOT NULL OR applied_private_key IS NOT NULL)
+    + (SELECT COUNT(*) FROM mesh_channels WHERE length(psk) > 0)
+)::bigint AS count;
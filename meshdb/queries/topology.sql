@@ -0,0 +1,18 @@
+-- name: UpsertTopologyEdge :exec
+-- Reports can arrive out of order; an older one doesn't replace a newer one
+INSERT INTO topology_edges (mesh_id, from_num, to_num, snr, source, last_heard)
+VALUES (@mesh_id, @from_num, @to_num, sqlc.narg('snr'), @source, @last_heard)
+ON CONFLICT (mesh_id, from_num, to_num) DO UPDATE
+SET snr = EXCLUDED.snr,
+    source = EXCLUDED.source,
+    last_heard = EXCLUDED.last_heard
+WHERE topology_edges.last_heard <= EXCLUDED.last_heard;
+
+-- name: ListTopologyEdges :many
+SELECT * FROM topology_edges
+WHERE mesh_id = @mesh_id
+ORDER BY from_num ASC, to_num ASC;
+
+-- name: DeleteTopologyEdgesBefore :execrows
+DELETE FROM topology_edges
+WHERE last_heard < @before;
@@ -28,3 +28,24 @@ WHERE id = @id;
 SELECT * FROM users
 ORDER BY created_at DESC
 LIMIT @limit_val OFFSET @offset_val;
+
+-- name: SetUserDisabled :one
+-- Disable or re-enable a user, keeping when they were first disabled
+UPDATE users
+SET
+    disabled_at = CASE WHEN @disabled::boolean THEN COALESCE(disabled_at, NOW()) END,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: SetUserAdmin :one
+UPDATE users
+SET
+    is_admin = @is_admin,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: CountMeshesOwnedByUser :one
+SELECT COUNT(*) FROM meshes
+WHERE owner_id = @owner_id;
@@ -0,0 +1,185 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: secrets.sql
+
+package meshdb
+
+import (
+	"context"
+
+	"github.com/skandragon/meshmgr/internal/secrets"
+)
+
+const countStoredSecrets = `-- name: CountStoredSecrets :one
+SELECT (
+    (SELECT COUNT(*) FROM nodes WHERE private_key IS NOT NULL OR applied_private_key IS NOT NULL)
+    + (SELECT COUNT(*) FROM mesh_channels WHERE length(psk) > 0)
+)::bigint AS count
+`
+
+// Node private keys and channel PSKs already stored, which a server with
+// no master key could not write again
+func (q *Queries) CountStoredSecrets(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countStoredSecrets)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const listAdminKeyPrivateKeys = `-- name: ListAdminKeyPrivateKeys :many
+SELECT id, private_key_encrypted
+FROM admin_keys
+WHERE private_key_encrypted IS NOT NULL
+ORDER BY id
+`
+
+type ListAdminKeyPrivateKeysRow struct {
+	ID                  int64  `json:"id"`
+	PrivateKeyEncrypted []byte `json:"-"`
+}
+
+func (q *Queries) ListAdminKeyPrivateKeys(ctx context.Context) ([]ListAdminKeyPrivateKeysRow, error) {
+	rows, err := q.db.Query(ctx, listAdminKeyPrivateKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAdminKeyPrivateKeysRow
+	for rows.Next() {
+		var i ListAdminKeyPrivateKeysRow
+		if err := rows.Scan(&i.ID, &i.PrivateKeyEncrypted); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMeshChannelSecrets = `-- name: ListMeshChannelSecrets :many
+SELECT id, psk
+FROM mesh_channels
+ORDER BY id
+`
+
+type ListMeshChannelSecretsRow struct {
+	ID  int64         `json:"id"`
+	Psk secrets.Bytes `json:"psk"`
+}
+
+func (q *Queries) ListMeshChannelSecrets(ctx context.Context) ([]ListMeshChannelSecretsRow, error) {
+	rows, err := q.db.Query(ctx, listMeshChannelSecrets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMeshChannelSecretsRow
+	for rows.Next() {
+		var i ListMeshChannelSecretsRow
+		if err := rows.Scan(&i.ID, &i.Psk); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNodeSecrets = `-- name: ListNodeSecrets :many
+SELECT id, private_key, applied_private_key, raw_device_config
+FROM nodes
+ORDER BY id
+`
+
+type ListNodeSecretsRow struct {
+	ID                int64                `json:"id"`
+	PrivateKey        *secrets.Text        `json:"private_key"`
+	AppliedPrivateKey *secrets.Text        `json:"applied_private_key"`
+	RawDeviceConfig   secrets.DeviceConfig `json:"raw_device_config"`
+}
+
+// Secrets of every node, for re-encrypting them under a new master key
+func (q *Queries) ListNodeSecrets(ctx context.Context) ([]ListNodeSecretsRow, error) {
+	rows, err := q.db.Query(ctx, listNodeSecrets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNodeSecretsRow
+	for rows.Next() {
+		var i ListNodeSecretsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PrivateKey,
+			&i.AppliedPrivateKey,
+			&i.RawDeviceConfig,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAdminKeyPrivateKey = `-- name: UpdateAdminKeyPrivateKey :exec
+UPDATE admin_keys SET private_key_encrypted = $1
+WHERE id = $2
+`
+
+type UpdateAdminKeyPrivateKeyParams struct {
+	PrivateKeyEncrypted []byte `json:"-"`
+	ID                  int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAdminKeyPrivateKey(ctx context.Context, arg UpdateAdminKeyPrivateKeyParams) error {
+	_, err := q.db.Exec(ctx, updateAdminKeyPrivateKey, arg.PrivateKeyEncrypted, arg.ID)
+	return err
+}
+
+const updateMeshChannelSecret = `-- name: UpdateMeshChannelSecret :exec
+UPDATE mesh_channels SET psk = $1
+WHERE id = $2
+`
+
+type UpdateMeshChannelSecretParams struct {
+	Psk secrets.Bytes `json:"psk"`
+	ID  int64         `json:"id"`
+}
+
+func (q *Queries) UpdateMeshChannelSecret(ctx context.Context, arg UpdateMeshChannelSecretParams) error {
+	_, err := q.db.Exec(ctx, updateMeshChannelSecret, arg.Psk, arg.ID)
+	return err
+}
+
+const updateNodeSecrets = `-- name: UpdateNodeSecrets :exec
+UPDATE nodes SET
+    private_key = $1,
+    applied_private_key = $2,
+    raw_device_config = $3
+WHERE id = $4
+`
+
+type UpdateNodeSecretsParams struct {
+	PrivateKey        *secrets.Text        `json:"private_key"`
+	AppliedPrivateKey *secrets.Text        `json:"applied_private_key"`
+	RawDeviceConfig   secrets.DeviceConfig `json:"raw_device_config"`
+	ID                int64                `json:"id"`
+}
+
+func (q *Queries) UpdateNodeSecrets(ctx context.Context, arg UpdateNodeSecretsParams) error {
+	_, err := q.db.Exec(ctx, updateNodeSecrets,
+		arg.PrivateKey,
+		arg.AppliedPrivateKey,
+		arg.RawDeviceConfig,
+		arg.ID,
+	)
+	return err
+}
@@ -0,0 +1,91 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: topology.sql
+
+package meshdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteTopologyEdgesBefore = `-- name: DeleteTopologyEdgesBefore :execrows
+DELETE FROM topology_edges
+WHERE last_heard < $1
+`
+
+func (q *Queries) DeleteTopologyEdgesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTopologyEdgesBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listTopologyEdges = `-- name: ListTopologyEdges :many
+SELECT mesh_id, from_num, to_num, snr, source, last_heard FROM topology_edges
+WHERE mesh_id = $1
+ORDER BY from_num ASC, to_num ASC
+`
+
+func (q *Queries) ListTopologyEdges(ctx context.Context, meshID int64) ([]TopologyEdge, error) {
+	rows, err := q.db.Query(ctx, listTopologyEdges, meshID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TopologyEdge
+	for rows.Next() {
+		var i TopologyEdge
+		if err := rows.Scan(
+			&i.MeshID,
+			&i.FromNum,
+			&i.ToNum,
+			&i.Snr,
+			&i.Source,
+			&i.LastHeard,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertTopologyEdge = `-- name: UpsertTopologyEdge :exec
+INSERT INTO topology_edges (mesh_id, from_num, to_num, snr, source, last_heard)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (mesh_id, from_num, to_num) DO UPDATE
+SET snr = EXCLUDED.snr,
+    source = EXCLUDED.source,
+    last_heard = EXCLUDED.last_heard
+WHERE topology_edges.last_heard <= EXCLUDED.last_heard
+`
+
+type UpsertTopologyEdgeParams struct {
+	MeshID    int64         `json:"mesh_id"`
+	FromNum   int64         `json:"from_num"`
+	ToNum     int64         `json:"to_num"`
+	Snr       pgtype.Float4 `json:"snr"`
+	Source    string        `json:"source"`
+	LastHeard time.Time     `json:"last_heard"`
+}
+
+// Reports can arrive out of order; an older one doesn't replace a newer one
+func (q *Queries) UpsertTopologyEdge(ctx context.Context, arg UpsertTopologyEdgeParams) error {
+	_, err := q.db.Exec(ctx, upsertTopologyEdge,
+		arg.MeshID,
+		arg.FromNum,
+		arg.ToNum,
+		arg.Snr,
+		arg.Source,
+		arg.LastHeard,
+	)
+	return err
+}
@@ -9,15 +9,27 @@ import (
 	"context"
 )
 
+const countMeshesOwnedByUser = `-- name: CountMeshesOwnedByUser :one
+SELECT COUNT(*) FROM meshes
+WHERE owner_id = $1
+`
+
+func (q *Queries) CountMeshesOwnedByUser(ctx context.Context, ownerID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countMeshesOwnedByUser, ownerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash, display_name)
 VALUES ($1, $2, $3)
-RETURNING id, email, password_hash, display_name, created_at, updated_at
+RETURNING id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin
 `
 
 type CreateUserParams struct {
 	Email        string `json:"email"`
-	PasswordHash string `json:"password_hash"`
+	PasswordHash string `json:"-"`
 	DisplayName  string `json:"display_name"`
 }
 
@@ -31,6 +43,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.DisplayName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
@@ -46,7 +60,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, display_name, created_at, updated_at FROM users
+SELECT id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin FROM users
 WHERE email = $1
 `
 
@@ -60,12 +74,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.DisplayName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, display_name, created_at, updated_at FROM users
+SELECT id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin FROM users
 WHERE id = $1
 `
 
@@ -79,12 +95,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.DisplayName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, password_hash, display_name, created_at, updated_at FROM users
+SELECT id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin FROM users
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $1
 `
@@ -110,6 +128,8 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.DisplayName,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DisabledAt,
+			&i.IsAdmin,
 		); err != nil {
 			return nil, err
 		}
@@ -121,6 +141,67 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const setUserAdmin = `-- name: SetUserAdmin :one
+UPDATE users
+SET
+    is_admin = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin
+`
+
+type SetUserAdminParams struct {
+	IsAdmin bool  `json:"is_admin"`
+	ID      int64 `json:"id"`
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserAdmin, arg.IsAdmin, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.DisplayName,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
+	)
+	return i, err
+}
+
+const setUserDisabled = `-- name: SetUserDisabled :one
+UPDATE users
+SET
+    disabled_at = CASE WHEN $1::boolean THEN COALESCE(disabled_at, NOW()) END,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin
+`
+
+type SetUserDisabledParams struct {
+	Disabled bool  `json:"disabled"`
+	ID       int64 `json:"id"`
+}
+
+// Disable or re-enable a user, keeping when they were first disabled
+func (q *Queries) SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserDisabled, arg.Disabled, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.DisplayName,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -128,12 +209,12 @@ SET
     password_hash = COALESCE($2, password_hash),
     updated_at = NOW()
 WHERE id = $3
-RETURNING id, email, password_hash, display_name, created_at, updated_at
+RETURNING id, email, password_hash, display_name, created_at, updated_at, disabled_at, is_admin
 `
 
 type UpdateUserParams struct {
 	DisplayName  *string `json:"display_name"`
-	PasswordHash *string `json:"password_hash"`
+	PasswordHash *string `json:"-"`
 	ID           int64   `json:"id"`
 }
 
@@ -147,6 +228,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.DisplayName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
@@ -0,0 +1,113 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package admin
+
+import (
+	"fmt"
+
+	"github.com/skandragon/meshmgr/meshtastic-cli/meshcrypto"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// ChannelCipher encrypts packets with a channel PSK the way the firmware
+// does: AES-CTR keyed by the PSK, with a nonce made from the packet ID and
+// sender. It is used to reach nodes over a shared "admin" channel.
+type ChannelCipher struct {
+	// Key is the channel PSK, 16 bytes for AES128 or 32 for AES256
+	Key []byte
+
+	// Hash is the channel hash placed in the channel field of encrypted packets
+	Hash uint32
+}
+
+// NewChannelCipher creates a cipher for a channel from its name and PSK as
+// configured, expanding 1-byte default-key PSKs
+func NewChannelCipher(name string, psk []byte) (ChannelCipher, error) {
+	ch, err := meshcrypto.NewChannel(name, psk)
+	if err != nil {
+		return ChannelCipher{}, err
+	}
+	return ChannelCipher{Key: ch.Key, Hash: ch.Hash}, nil
+}
+
+// channel returns the channel the cipher encrypts for. Admin traffic is
+// never sent in the clear, so a key is required.
+func (c ChannelCipher) channel() (meshcrypto.Channel, error) {
+	switch len(c.Key) {
+	case 16, 32:
+	default:
+		return meshcrypto.Channel{}, fmt.Errorf("channel key must be 16 or 32 bytes, got %d", len(c.Key))
+	}
+	return meshcrypto.Channel{Key: c.Key, Hash: c.Hash}, nil
+}
+
+// Encrypt implements Cipher
+func (c ChannelCipher) Encrypt(packet *pb.MeshPacket) error {
+	ch, err := c.channel()
+	if err != nil {
+		return err
+	}
+	return ch.EncryptPacket(packet)
+}
+
+// Decrypt implements Cipher
+func (c ChannelCipher) Decrypt(packet *pb.MeshPacket) error {
+	ch, err := c.channel()
+	if err != nil {
+		return err
+	}
+	return ch.DecryptPacket(packet)
+}
+
+// PKICipher encrypts packets to each node's own public key, the way
+// firmware 2.5 and later expect remote admin traffic. A node accepts the
+// requests if the public key of PrivateKey is one of its admin keys.
+type PKICipher struct {
+	// PrivateKey is the X25519 private key requests are sent with
+	PrivateKey []byte
+
+	// NodeKeys holds the public key of each node by node number
+	NodeKeys map[uint32][]byte
+}
+
+// nodeKey returns the public key of a node
+func (c PKICipher) nodeKey(node uint32) ([]byte, error) {
+	key, ok := c.NodeKeys[node]
+	if !ok {
+		return nil, fmt.Errorf("no public key for node !%08x", node)
+	}
+	return key, nil
+}
+
+// Encrypt implements Cipher
+func (c PKICipher) Encrypt(packet *pb.MeshPacket) error {
+	key, err := c.nodeKey(packet.To)
+	if err != nil {
+		return err
+	}
+	return meshcrypto.EncryptPKIPacket(packet, c.PrivateKey, key)
+}
+
+// Decrypt implements Cipher
+func (c PKICipher) Decrypt(packet *pb.MeshPacket) error {
+	if !packet.PkiEncrypted {
+		return fmt.Errorf("packet is not PKI encrypted")
+	}
+	key, err := c.nodeKey(packet.From)
+	if err != nil {
+		return err
+	}
+	return meshcrypto.DecryptPKIPacket(packet, c.PrivateKey, key)
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package admin builds and sends Meshtastic AdminMessage requests, to the
+// node a client is attached to or to remote nodes through it, and matches
+// the replies to their requests by packet ID.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultTimeout is how long a request waits for its reply
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultHopLimit is the hop limit of requests sent to remote nodes
+	DefaultHopLimit = 3
+
+	// sessionLifetime is how long a session passkey is reused. Nodes expire
+	// them after 300 seconds, so refresh a little early.
+	sessionLifetime = 250 * time.Second
+)
+
+// ErrTimeout is returned when a node doesn't answer a request in time
+var ErrTimeout = errors.New("timeout waiting for reply")
+
+// NAKError is returned when a node or the mesh rejects a request
+type NAKError struct {
+	Reason pb.Routing_Error
+}
+
+func (e *NAKError) Error() string {
+	return fmt.Sprintf("request rejected: %s", e.Reason)
+}
+
+// Sender delivers ToRadio messages to the node the client is attached to
+type Sender interface {
+	SendToRadio(msg *pb.ToRadio) error
+}
+
+// Cipher encrypts outgoing packets and decrypts replies. Without one,
+// packets are sent decoded and the attached node encrypts them.
+type Cipher interface {
+	// Encrypt replaces the decoded payload of packet with its encrypted form
+	Encrypt(packet *pb.MeshPacket) error
+
+	// Decrypt replaces the encrypted payload of packet with its decoded form
+	Decrypt(packet *pb.MeshPacket) error
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithCipher encrypts requests and decrypts replies with cipher
+func WithCipher(cipher Cipher) Option {
+	return func(c *Client) {
+		c.cipher = cipher
+	}
+}
+
+// WithLocalNode sets the node number of the attached node. It is used as the
+// sender of encrypted packets and to tell local requests from remote ones.
+func WithLocalNode(nodeNum uint32) Option {
+	return func(c *Client) {
+		c.localNode = nodeNum
+	}
+}
+
+// WithTimeout sets how long requests wait for a reply
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHopLimit sets the hop limit of requests sent to remote nodes
+func WithHopLimit(hopLimit uint32) Option {
+	return func(c *Client) {
+		c.hopLimit = hopLimit
+	}
+}
+
+// session is a session passkey handed out by a node
+type session struct {
+	passkey []byte
+	expires time.Time
+}
+
+// Client sends admin requests through a Sender. Packets read from the
+// device must be passed to HandleFromRadio so replies reach their requests.
+type Client struct {
+	sender    Sender
+	cipher    Cipher
+	localNode uint32
+	timeout   time.Duration
+	hopLimit  uint32
+
+	mu       sync.Mutex
+	pending  map[uint32]chan *pb.MeshPacket
+	sessions map[uint32]session
+}
+
+// NewClient creates a new admin Client
+func NewClient(sender Sender, opts ...Option) *Client {
+	c := &Client{
+		sender:   sender,
+		timeout:  DefaultTimeout,
+		hopLimit: DefaultHopLimit,
+		pending:  make(map[uint32]chan *pb.MeshPacket),
+		sessions: make(map[uint32]session),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// HandleFromRadio passes a message read from the device to the client.
+// It reports whether the message was a reply to a pending request.
+func (c *Client) HandleFromRadio(msg *pb.FromRadio) bool {
+	packet := msg.GetPacket()
+	if packet == nil {
+		return false
+	}
+	return c.HandlePacket(packet)
+}
+
+// HandlePacket passes a received mesh packet to the client. It reports
+// whether the packet was a reply to a pending request.
+func (c *Client) HandlePacket(packet *pb.MeshPacket) bool {
+	if packet.GetEncrypted() != nil {
+		if c.cipher == nil {
+			return false
+		}
+		packet = protobuf.Clone(packet).(*pb.MeshPacket)
+		if err := c.cipher.Decrypt(packet); err != nil {
+			return false
+		}
+	}
+
+	decoded := packet.GetDecoded()
+	if decoded == nil || decoded.RequestId == 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	replies, ok := c.pending[decoded.RequestId]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	select {
+	case replies <- packet:
+	default:
+	}
+	return true
+}
+
+// isLocal reports whether requests to node go to the attached node
+func (c *Client) isLocal(node uint32) bool {
+	return node == 0 || c.localNode == 0 || node == c.localNode
+}
+
+// Send sends an admin message to node and waits for it to be acknowledged
+func (c *Client) Send(ctx context.Context, node uint32, msg *pb.AdminMessage) error {
+	if err := c.ensureSession(ctx, node); err != nil {
+		return err
+	}
+	_, err := c.roundTrip(ctx, node, msg, false)
+	return err
+}
+
+// Request sends an admin message to node and waits for the node's reply
+func (c *Client) Request(ctx context.Context, node uint32, msg *pb.AdminMessage) (*pb.AdminMessage, error) {
+	return c.roundTrip(ctx, node, msg, true)
+}
+
+// ensureSession makes sure there is a session passkey for a remote node,
+// which nodes require before accepting changes
+func (c *Client) ensureSession(ctx context.Context, node uint32) error {
+	if c.isLocal(node) {
+		return nil
+	}
+	c.mu.Lock()
+	s, ok := c.sessions[node]
+	c.mu.Unlock()
+	if ok && time.Now().Before(s.expires) {
+		return nil
+	}
+
+	_, err := c.GetDeviceMetadata(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to start admin session: %w", err)
+	}
+	return nil
+}
+
+// roundTrip sends msg and waits for the matching reply. If wantResponse is
+// set it waits for the node's admin reply, otherwise for the routing ACK.
+func (c *Client) roundTrip(ctx context.Context, node uint32, msg *pb.AdminMessage, wantResponse bool) (*pb.AdminMessage, error) {
+	msg = protobuf.Clone(msg).(*pb.AdminMessage)
+	if len(msg.SessionPasskey) == 0 {
+		c.mu.Lock()
+		if s, ok := c.sessions[node]; ok {
+			msg.SessionPasskey = s.passkey
+		}
+		c.mu.Unlock()
+	}
+
+	payload, err := protobuf.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal admin message: %w", err)
+	}
+
+	id, replies := c.register()
+	defer c.unregister(id)
+
+	packet := &pb.MeshPacket{
+		From:    c.localNode,
+		To:      node,
+		Id:      id,
+		WantAck: true,
+		PayloadVariant: &pb.MeshPacket_Decoded{
+			Decoded: &pb.Data{
+				Portnum:      pb.PortNum_ADMIN_APP,
+				Payload:      payload,
+				WantResponse: wantResponse,
+			},
+		},
+	}
+	if !c.isLocal(node) {
+		packet.HopLimit = c.hopLimit
+	}
+	if c.cipher != nil {
+		if err := c.cipher.Encrypt(packet); err != nil {
+			return nil, fmt.Errorf("failed to encrypt request: %w", err)
+		}
+	}
+
+	err = c.sender.SendToRadio(&pb.ToRadio{
+		PayloadVariant: &pb.ToRadio_Packet{Packet: packet},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	timeout := time.NewTimer(c.timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case reply := <-replies:
+			decoded := reply.GetDecoded()
+			switch decoded.Portnum {
+			case pb.PortNum_ROUTING_APP:
+				routing := &pb.Routing{}
+				if err := protobuf.Unmarshal(decoded.Payload, routing); err != nil {
+					return nil, fmt.Errorf("invalid routing reply: %w", err)
+				}
+				if reason := routing.GetErrorReason(); reason != pb.Routing_NONE {
+					if reason == pb.Routing_ADMIN_BAD_SESSION_KEY {
+						// The node restarted or expired the session early;
+						// the next request starts a new one
+						c.forgetSession(node)
+					}
+					return nil, &NAKError{Reason: reason}
+				}
+				if !wantResponse {
+					return nil, nil
+				}
+
+			case pb.PortNum_ADMIN_APP:
+				response := &pb.AdminMessage{}
+				if err := protobuf.Unmarshal(decoded.Payload, response); err != nil {
+					return nil, fmt.Errorf("invalid admin reply: %w", err)
+				}
+				c.saveSession(node, response.SessionPasskey)
+				return response, nil
+			}
+
+		case <-timeout.C:
+			return nil, ErrTimeout
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// register allocates a packet ID and a channel for its replies
+func (c *Client) register() (uint32, chan *pb.MeshPacket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		// Zero means "no ID" to the firmware
+		id := rand.Uint32()
+		if id == 0 {
+			continue
+		}
+		if _, ok := c.pending[id]; ok {
+			continue
+		}
+		replies := make(chan *pb.MeshPacket, 4)
+		c.pending[id] = replies
+		return id, replies
+	}
+}
+
+// unregister stops waiting for replies to a packet ID
+func (c *Client) unregister(id uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// saveSession remembers a session passkey sent by a node
+func (c *Client) saveSession(node uint32, passkey []byte) {
+	if len(passkey) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[node] = session{
+		passkey: passkey,
+		expires: time.Now().Add(sessionLifetime),
+	}
+}
+
+// forgetSession drops the session passkey of a node
+func (c *Client) forgetSession(node uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessions, node)
+}
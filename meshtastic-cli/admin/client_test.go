@@ -0,0 +1,328 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	testLocalNode  = 0x11111111
+	testRemoteNode = 0x22222222
+)
+
+// fakeNode answers admin requests the way the firmware does, feeding its
+// replies back into the client
+type fakeNode struct {
+	t       *testing.T
+	client  *Client
+	cipher  Cipher
+	passkey []byte
+	nak     pb.Routing_Error
+	silent  bool
+
+	mu       sync.Mutex
+	received []*pb.MeshPacket
+	requests []*pb.AdminMessage
+}
+
+func (f *fakeNode) SendToRadio(msg *pb.ToRadio) error {
+	packet := protobuf.Clone(msg.GetPacket()).(*pb.MeshPacket)
+	require.NotNil(f.t, packet)
+
+	f.mu.Lock()
+	f.received = append(f.received, protobuf.Clone(packet).(*pb.MeshPacket))
+	f.mu.Unlock()
+
+	if f.cipher != nil {
+		require.NoError(f.t, f.cipher.Decrypt(packet))
+	}
+	decoded := packet.GetDecoded()
+	require.Equal(f.t, pb.PortNum_ADMIN_APP, decoded.Portnum)
+
+	request := &pb.AdminMessage{}
+	require.NoError(f.t, protobuf.Unmarshal(decoded.Payload, request))
+	f.mu.Lock()
+	f.requests = append(f.requests, request)
+	f.mu.Unlock()
+
+	if f.silent {
+		return nil
+	}
+
+	var reply *pb.Data
+	switch {
+	case f.nak != pb.Routing_NONE:
+		reply = routingReply(packet.Id, f.nak)
+	case decoded.WantResponse:
+		response := &pb.AdminMessage{SessionPasskey: f.passkey}
+		switch request.PayloadVariant.(type) {
+		case *pb.AdminMessage_GetConfigRequest:
+			response.PayloadVariant = &pb.AdminMessage_GetConfigResponse{GetConfigResponse: &pb.Config{
+				PayloadVariant: &pb.Config_Lora{Lora: &pb.Config_LoRaConfig{HopLimit: 5}},
+			}}
+		case *pb.AdminMessage_GetOwnerRequest:
+			response.PayloadVariant = &pb.AdminMessage_GetOwnerResponse{GetOwnerResponse: &pb.User{LongName: "Remote"}}
+		case *pb.AdminMessage_GetDeviceMetadataRequest:
+			response.PayloadVariant = &pb.AdminMessage_GetDeviceMetadataResponse{GetDeviceMetadataResponse: &pb.DeviceMetadata{FirmwareVersion: "2.7.0"}}
+		}
+		payload, err := protobuf.Marshal(response)
+		require.NoError(f.t, err)
+		reply = &pb.Data{Portnum: pb.PortNum_ADMIN_APP, Payload: payload, RequestId: packet.Id}
+	default:
+		reply = routingReply(packet.Id, pb.Routing_NONE)
+	}
+
+	replyPacket := &pb.MeshPacket{
+		From:           packet.To,
+		To:             packet.From,
+		Id:             packet.Id + 1,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: reply},
+	}
+	if f.cipher != nil {
+		require.NoError(f.t, f.cipher.Encrypt(replyPacket))
+	}
+
+	// Replies arrive asynchronously from the read loop
+	go f.client.HandleFromRadio(&pb.FromRadio{
+		PayloadVariant: &pb.FromRadio_Packet{Packet: replyPacket},
+	})
+	return nil
+}
+
+func routingReply(requestID uint32, reason pb.Routing_Error) *pb.Data {
+	payload, _ := protobuf.Marshal(&pb.Routing{
+		Variant: &pb.Routing_ErrorReason{ErrorReason: reason},
+	})
+	return &pb.Data{Portnum: pb.PortNum_ROUTING_APP, Payload: payload, RequestId: requestID}
+}
+
+func newTestClient(t *testing.T, node *fakeNode, opts ...Option) *Client {
+	node.t = t
+	node.client = NewClient(node, append([]Option{WithLocalNode(testLocalNode), WithTimeout(time.Second)}, opts...)...)
+	return node.client
+}
+
+func TestGetConfig(t *testing.T) {
+	node := &fakeNode{}
+	client := newTestClient(t, node)
+
+	config, err := client.GetConfig(context.Background(), testLocalNode, pb.AdminMessage_LORA_CONFIG)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), config.GetLora().GetHopLimit())
+
+	require.Len(t, node.received, 1)
+	packet := node.received[0]
+	assert.Equal(t, uint32(testLocalNode), packet.To)
+	assert.True(t, packet.WantAck)
+	assert.True(t, packet.GetDecoded().WantResponse)
+	assert.NotZero(t, packet.Id)
+	assert.Zero(t, packet.HopLimit, "local requests are not relayed")
+	assert.Equal(t, pb.AdminMessage_LORA_CONFIG, node.requests[0].GetGetConfigRequest())
+}
+
+func TestSendWaitsForAck(t *testing.T) {
+	node := &fakeNode{}
+	client := newTestClient(t, node)
+
+	require.NoError(t, client.Reboot(context.Background(), testLocalNode, 5))
+	require.NoError(t, client.SetFavoriteNode(context.Background(), testLocalNode, testRemoteNode))
+	require.NoError(t, client.FactoryReset(context.Background(), testLocalNode, true))
+
+	require.Len(t, node.requests, 3)
+	assert.Equal(t, int32(5), node.requests[0].GetRebootSeconds())
+	assert.Equal(t, uint32(testRemoteNode), node.requests[1].GetSetFavoriteNode())
+	assert.Equal(t, int32(1), node.requests[2].GetFactoryResetDevice())
+	assert.NotEqual(t, node.received[0].Id, node.received[1].Id)
+	assert.Empty(t, client.pending, "replies are no longer awaited once matched")
+}
+
+func TestSendNAK(t *testing.T) {
+	node := &fakeNode{nak: pb.Routing_NOT_AUTHORIZED}
+	client := newTestClient(t, node)
+
+	err := client.SetOwner(context.Background(), testLocalNode, &pb.User{LongName: "New"})
+	var nak *NAKError
+	require.True(t, errors.As(err, &nak), "expected NAK, got %v", err)
+	assert.Equal(t, pb.Routing_NOT_AUTHORIZED, nak.Reason)
+}
+
+func TestTimeout(t *testing.T) {
+	node := &fakeNode{silent: true}
+	client := newTestClient(t, node, WithTimeout(50*time.Millisecond))
+
+	err := client.SetConfig(context.Background(), testLocalNode, &pb.Config{})
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.GetOwner(ctx, testLocalNode)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUnmatchedRepliesIgnored(t *testing.T) {
+	client := NewClient(&fakeNode{})
+
+	assert.False(t, client.HandleFromRadio(&pb.FromRadio{
+		PayloadVariant: &pb.FromRadio_Packet{Packet: &pb.MeshPacket{
+			PayloadVariant: &pb.MeshPacket_Decoded{Decoded: routingReply(1234, pb.Routing_NONE)},
+		}},
+	}))
+	assert.False(t, client.HandleFromRadio(&pb.FromRadio{
+		PayloadVariant: &pb.FromRadio_ConfigCompleteId{ConfigCompleteId: 1},
+	}))
+}
+
+func TestRemoteSessionPasskey(t *testing.T) {
+	passkey := []byte("session-passkey")
+	cipher := ChannelCipher{Key: bytes.Repeat([]byte{0x42}, 16), Hash: 8}
+	node := &fakeNode{passkey: passkey, cipher: cipher}
+	client := newTestClient(t, node, WithCipher(cipher))
+
+	// Changes to a remote node start a session first, then carry its passkey
+	require.NoError(t, client.SetOwner(context.Background(), testRemoteNode, &pb.User{LongName: "Remote"}))
+	require.Len(t, node.requests, 2)
+	assert.True(t, node.requests[0].GetGetDeviceMetadataRequest())
+	assert.Empty(t, node.requests[0].SessionPasskey)
+	assert.Equal(t, "Remote", node.requests[1].GetSetOwner().GetLongName())
+	assert.Equal(t, passkey, node.requests[1].SessionPasskey)
+
+	// The session is reused while it is fresh
+	require.NoError(t, client.Reboot(context.Background(), testRemoteNode, 10))
+	require.Len(t, node.requests, 3)
+	assert.Equal(t, passkey, node.requests[2].SessionPasskey)
+
+	// Remote packets are encrypted, relayed, and sent from the local node
+	for _, packet := range node.received {
+		assert.NotNil(t, packet.GetEncrypted())
+		assert.Equal(t, uint32(8), packet.Channel)
+		assert.Equal(t, uint32(testLocalNode), packet.From)
+		assert.Equal(t, uint32(DefaultHopLimit), packet.HopLimit)
+	}
+}
+
+func TestBadSessionKey(t *testing.T) {
+	cipher := ChannelCipher{Key: bytes.Repeat([]byte{0x42}, 16), Hash: 8}
+	node := &fakeNode{passkey: []byte("session-passkey"), cipher: cipher}
+	client := newTestClient(t, node, WithCipher(cipher))
+
+	require.NoError(t, client.Reboot(context.Background(), testRemoteNode, 10))
+	require.Len(t, node.requests, 2)
+
+	// A rejected passkey is dropped, so the next change starts a new session
+	node.nak = pb.Routing_ADMIN_BAD_SESSION_KEY
+	err := client.Reboot(context.Background(), testRemoteNode, 10)
+	var nak *NAKError
+	require.True(t, errors.As(err, &nak), "expected NAK, got %v", err)
+	require.Len(t, node.requests, 3)
+
+	node.nak = pb.Routing_NONE
+	require.NoError(t, client.Reboot(context.Background(), testRemoteNode, 10))
+	require.Len(t, node.requests, 5)
+	assert.True(t, node.requests[3].GetGetDeviceMetadataRequest())
+}
+
+func TestChannelCipher(t *testing.T) {
+	cipher := ChannelCipher{Key: bytes.Repeat([]byte{1}, 32), Hash: 3}
+	data := &pb.Data{Portnum: pb.PortNum_TEXT_MESSAGE_APP, Payload: []byte("hello")}
+	packet := &pb.MeshPacket{
+		From:           testLocalNode,
+		Id:             42,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: data},
+	}
+
+	require.NoError(t, cipher.Encrypt(packet))
+	plaintext, err := protobuf.Marshal(data)
+	require.NoError(t, err)
+	assert.Len(t, packet.GetEncrypted(), len(plaintext))
+	assert.NotEqual(t, plaintext, packet.GetEncrypted())
+
+	require.NoError(t, cipher.Decrypt(packet))
+	assert.True(t, protobuf.Equal(data, packet.GetDecoded()))
+
+	// The nonce is the packet ID and sender, so a different sender can't decrypt
+	require.NoError(t, cipher.Encrypt(packet))
+	packet.From++
+	if err := cipher.Decrypt(packet); err == nil {
+		assert.False(t, protobuf.Equal(data, packet.GetDecoded()))
+	}
+
+	assert.Error(t, ChannelCipher{Key: []byte{1}}.Encrypt(&pb.MeshPacket{
+		From:           testLocalNode,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: data},
+	}))
+}
+
+func TestPKICipher(t *testing.T) {
+	newKey := func() ([]byte, []byte) {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		return key.Bytes(), key.PublicKey().Bytes()
+	}
+	adminPrivate, adminPublic := newKey()
+	nodePrivate, nodePublic := newKey()
+
+	passkey := []byte("session-passkey")
+	node := &fakeNode{passkey: passkey, cipher: PKICipher{
+		PrivateKey: nodePrivate,
+		NodeKeys:   map[uint32][]byte{testLocalNode: adminPublic},
+	}}
+	client := newTestClient(t, node, WithCipher(PKICipher{
+		PrivateKey: adminPrivate,
+		NodeKeys:   map[uint32][]byte{testRemoteNode: nodePublic},
+	}))
+
+	owner, err := client.GetOwner(context.Background(), testRemoteNode)
+	require.NoError(t, err)
+	assert.Equal(t, "Remote", owner.LongName)
+
+	for _, packet := range node.received {
+		assert.True(t, packet.PkiEncrypted)
+		assert.Zero(t, packet.Channel)
+	}
+
+	// Nodes without a known public key can't be reached
+	_, err = client.GetOwner(context.Background(), testRemoteNode+1)
+	assert.Error(t, err)
+
+	// Packets from nodes with another key don't authenticate
+	other := &pb.MeshPacket{
+		From:           testRemoteNode,
+		To:             testLocalNode,
+		Id:             7,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: &pb.Data{Portnum: pb.PortNum_ADMIN_APP}},
+	}
+	otherPrivate, _ := newKey()
+	require.NoError(t, PKICipher{
+		PrivateKey: otherPrivate,
+		NodeKeys:   map[uint32][]byte{testLocalNode: adminPublic},
+	}.Encrypt(other))
+	assert.Error(t, PKICipher{
+		PrivateKey: adminPrivate,
+		NodeKeys:   map[uint32][]byte{testRemoteNode: nodePublic},
+	}.Decrypt(other))
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MergeJSONFields sets the fields present in a protobuf JSON object on msg,
+// leaving fields the object doesn't mention unchanged
+func MergeJSONFields(msg protoreflect.Message, data json.RawMessage) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	decoded := msg.New()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, decoded.Interface()); err != nil {
+		return err
+	}
+
+	fields := msg.Descriptor().Fields()
+	for key, value := range keys {
+		fd := fields.ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = fields.ByJSONName(key)
+		}
+		if fd == nil {
+			continue
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && msg.Has(fd) {
+			if err := MergeJSONFields(msg.Mutable(fd).Message(), value); err != nil {
+				return err
+			}
+			continue
+		}
+		if decoded.Has(fd) {
+			msg.Set(fd, decoded.Get(fd))
+		} else {
+			msg.Clear(fd)
+		}
+	}
+	return nil
+}
+
+// SetVariant sets the oneof field of a Config or ModuleConfig that holds section
+func SetVariant(msg protoreflect.Message, section protoreflect.Message) error {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() != nil && fd.Message().FullName() == section.Descriptor().FullName() {
+			msg.Set(fd, protoreflect.ValueOfMessage(section))
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has no field for %s", msg.Descriptor().Name(), section.Descriptor().Name())
+}
+
+// MessageName returns the name of the operation an admin message performs
+func MessageName(msg *pb.AdminMessage) string {
+	m := msg.ProtoReflect()
+	fd := m.WhichOneof(m.Descriptor().Oneofs().ByName("payload_variant"))
+	if fd == nil {
+		return "admin message"
+	}
+	name := string(fd.Name())
+	switch v := msg.PayloadVariant.(type) {
+	case *pb.AdminMessage_SetConfig:
+		name += " " + string(v.SetConfig.ProtoReflect().WhichOneof(v.SetConfig.ProtoReflect().Descriptor().Oneofs().Get(0)).Name())
+	case *pb.AdminMessage_SetModuleConfig:
+		name += " " + string(v.SetModuleConfig.ProtoReflect().WhichOneof(v.SetModuleConfig.ProtoReflect().Descriptor().Oneofs().Get(0)).Name())
+	case *pb.AdminMessage_SetChannel:
+		name += fmt.Sprintf(" %d", v.SetChannel.Index)
+	}
+	return name
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+)
+
+// unexpectedReply builds the error for a reply of the wrong type
+func unexpectedReply(want string, reply *pb.AdminMessage) error {
+	return fmt.Errorf("expected %s, got %T", want, reply.GetPayloadVariant())
+}
+
+// GetConfig fetches one section of a node's config
+func (c *Client) GetConfig(ctx context.Context, node uint32, configType pb.AdminMessage_ConfigType) (*pb.Config, error) {
+	reply, err := c.Request(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_GetConfigRequest{GetConfigRequest: configType},
+	})
+	if err != nil {
+		return nil, err
+	}
+	config := reply.GetGetConfigResponse()
+	if config == nil {
+		return nil, unexpectedReply("config", reply)
+	}
+	return config, nil
+}
+
+// SetConfig writes one section of a node's config
+func (c *Client) SetConfig(ctx context.Context, node uint32, config *pb.Config) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetConfig{SetConfig: config},
+	})
+}
+
+// GetModuleConfig fetches one module's config from a node
+func (c *Client) GetModuleConfig(ctx context.Context, node uint32, configType pb.AdminMessage_ModuleConfigType) (*pb.ModuleConfig, error) {
+	reply, err := c.Request(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_GetModuleConfigRequest{GetModuleConfigRequest: configType},
+	})
+	if err != nil {
+		return nil, err
+	}
+	config := reply.GetGetModuleConfigResponse()
+	if config == nil {
+		return nil, unexpectedReply("module config", reply)
+	}
+	return config, nil
+}
+
+// SetModuleConfig writes one module's config to a node
+func (c *Client) SetModuleConfig(ctx context.Context, node uint32, config *pb.ModuleConfig) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetModuleConfig{SetModuleConfig: config},
+	})
+}
+
+// GetChannel fetches the channel at index from a node
+func (c *Client) GetChannel(ctx context.Context, node uint32, index uint32) (*pb.Channel, error) {
+	reply, err := c.Request(ctx, node, &pb.AdminMessage{
+		// The request carries index+1, as zero would not be sent
+		PayloadVariant: &pb.AdminMessage_GetChannelRequest{GetChannelRequest: index + 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	channel := reply.GetGetChannelResponse()
+	if channel == nil {
+		return nil, unexpectedReply("channel", reply)
+	}
+	return channel, nil
+}
+
+// SetChannel writes a channel to a node
+func (c *Client) SetChannel(ctx context.Context, node uint32, channel *pb.Channel) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetChannel{SetChannel: channel},
+	})
+}
+
+// GetOwner fetches a node's owner
+func (c *Client) GetOwner(ctx context.Context, node uint32) (*pb.User, error) {
+	reply, err := c.Request(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_GetOwnerRequest{GetOwnerRequest: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	owner := reply.GetGetOwnerResponse()
+	if owner == nil {
+		return nil, unexpectedReply("owner", reply)
+	}
+	return owner, nil
+}
+
+// SetOwner sets a node's owner (names and unmessageable flag)
+func (c *Client) SetOwner(ctx context.Context, node uint32, owner *pb.User) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetOwner{SetOwner: owner},
+	})
+}
+
+// GetDeviceMetadata fetches a node's firmware and hardware details
+func (c *Client) GetDeviceMetadata(ctx context.Context, node uint32) (*pb.DeviceMetadata, error) {
+	reply, err := c.Request(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_GetDeviceMetadataRequest{GetDeviceMetadataRequest: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	metadata := reply.GetGetDeviceMetadataResponse()
+	if metadata == nil {
+		return nil, unexpectedReply("device metadata", reply)
+	}
+	return metadata, nil
+}
+
+// BeginEditSettings starts a settings transaction. The node holds off saving
+// and rebooting until CommitEditSettings.
+func (c *Client) BeginEditSettings(ctx context.Context, node uint32) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_BeginEditSettings{BeginEditSettings: true},
+	})
+}
+
+// CommitEditSettings saves the changes made since BeginEditSettings
+func (c *Client) CommitEditSettings(ctx context.Context, node uint32) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_CommitEditSettings{CommitEditSettings: true},
+	})
+}
+
+// Reboot reboots a node after the given number of seconds; a negative
+// delay cancels a pending reboot
+func (c *Client) Reboot(ctx context.Context, node uint32, seconds int32) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_RebootSeconds{RebootSeconds: seconds},
+	})
+}
+
+// FactoryReset returns a node's config to factory defaults. A full reset
+// also clears the node database and BLE bonds.
+func (c *Client) FactoryReset(ctx context.Context, node uint32, full bool) error {
+	msg := &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_FactoryResetConfig{FactoryResetConfig: 1},
+	}
+	if full {
+		msg.PayloadVariant = &pb.AdminMessage_FactoryResetDevice{FactoryResetDevice: 1}
+	}
+	return c.Send(ctx, node, msg)
+}
+
+// SetFavoriteNode marks favorite as a favorite in a node's node database
+func (c *Client) SetFavoriteNode(ctx context.Context, node uint32, favorite uint32) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_SetFavoriteNode{SetFavoriteNode: favorite},
+	})
+}
+
+// RemoveFavoriteNode clears the favorite flag of a node in a node's node database
+func (c *Client) RemoveFavoriteNode(ctx context.Context, node uint32, favorite uint32) error {
+	return c.Send(ctx, node, &pb.AdminMessage{
+		PayloadVariant: &pb.AdminMessage_RemoveFavoriteNode{RemoveFavoriteNode: favorite},
+	})
+}
@@ -0,0 +1,403 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/skandragon/meshmgr/meshtastic-cli/admin"
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ackTimeout is how long to wait for the device to acknowledge an admin message
+const ackTimeout = 10 * time.Second
+
+// applyOptions holds the server settings used by apply mode
+type applyOptions struct {
+	AdminURL   string
+	APIKey     string
+	MeshID     string
+	NodeID     string
+	JSONOutput bool
+}
+
+// effectiveConfig is the configuration the server wants a node to have.
+// The config documents use protobuf JSON names, and only contain the fields
+// the mesh manages; everything else is left as it is on the device.
+type effectiveConfig struct {
+	NodeID        int64                      `json:"node_id"`
+	ShortName     string                     `json:"short_name"`
+	LongName      string                     `json:"long_name"`
+	Unmessageable bool                       `json:"unmessageable"`
+	Config        map[string]json.RawMessage `json:"config"`
+	ModuleConfig  map[string]json.RawMessage `json:"module_config"`
+	Channels      []json.RawMessage          `json:"channels"`
+}
+
+// appliedState is reported to the server once the device has accepted the
+// config. AdminKeys is null if the admin keys weren't managed, and an empty
+// list if the device holds none.
+type appliedState struct {
+	ShortName     *string  `json:"short_name,omitempty"`
+	LongName      *string  `json:"long_name,omitempty"`
+	Role          *string  `json:"role,omitempty"`
+	PublicKey     *string  `json:"public_key,omitempty"`
+	PrivateKey    *string  `json:"private_key,omitempty"`
+	Unmessageable *bool    `json:"unmessageable,omitempty"`
+	AdminKeys     []string `json:"admin_keys"`
+}
+
+// applyConfig writes the server's configuration for this node to the device,
+// then reports the new device config and applied state back to the server
+func applyConfig(conn *transport.Conn, device *DeviceConfig, opts applyOptions) error {
+	nodeID := opts.NodeID
+	if nodeID == "" {
+		id, err := findNodeID(opts, device.HardwareID)
+		if err != nil {
+			return err
+		}
+		nodeID = id
+	}
+
+	var desired effectiveConfig
+	path := fmt.Sprintf("/api/meshes/%s/nodes/%s/effective-config", opts.MeshID, nodeID)
+	if err := apiRequest(opts, "GET", path, nil, &desired); err != nil {
+		return fmt.Errorf("failed to fetch effective config: %w", err)
+	}
+
+	messages, updated, err := buildAdminMessages(device, &desired)
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		if !opts.JSONOutput {
+			fmt.Println("\n✅ Device already matches the server configuration")
+		}
+	} else {
+		if !opts.JSONOutput {
+			fmt.Printf("\nApplying %d configuration changes...\n", len(messages))
+		}
+
+		messages = append([]*pb.AdminMessage{{
+			PayloadVariant: &pb.AdminMessage_BeginEditSettings{BeginEditSettings: true},
+		}}, messages...)
+		messages = append(messages, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_CommitEditSettings{CommitEditSettings: true},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client := admin.NewClient(conn, admin.WithLocalNode(device.NodeNum), admin.WithTimeout(ackTimeout))
+		go forwardReplies(ctx, conn, client)
+
+		for _, msg := range messages {
+			if err := client.Send(ctx, device.NodeNum, msg); err != nil {
+				return fmt.Errorf("%s: %w", admin.MessageName(msg), err)
+			}
+			if !opts.JSONOutput {
+				fmt.Printf("  ✓ %s\n", admin.MessageName(msg))
+			}
+		}
+	}
+
+	uploadConfig(updated, opts.AdminURL, opts.APIKey, opts.MeshID, opts.JSONOutput)
+
+	path = fmt.Sprintf("/api/meshes/%s/nodes/%s/applied", opts.MeshID, nodeID)
+	if err := apiRequest(opts, "POST", path, newAppliedState(&desired, updated), nil); err != nil {
+		return fmt.Errorf("failed to report applied config: %w", err)
+	}
+	if !opts.JSONOutput {
+		fmt.Println("✅ Applied configuration recorded")
+	}
+	return nil
+}
+
+// buildAdminMessages works out the admin messages needed to bring the device
+// to the desired config. It returns them with a copy of the device config as
+// it will be once they are applied.
+func buildAdminMessages(device *DeviceConfig, desired *effectiveConfig) ([]*pb.AdminMessage, *DeviceConfig, error) {
+	updated := *device
+	updated.LocalConfig = protobuf.Clone(device.LocalConfig).(*pb.LocalConfig)
+	updated.LocalModuleConfig = protobuf.Clone(device.LocalModuleConfig).(*pb.LocalModuleConfig)
+
+	var messages []*pb.AdminMessage
+
+	if device.LongName != desired.LongName || device.ShortName != desired.ShortName ||
+		device.Owner.GetIsUnmessagable() != desired.Unmessageable {
+		owner := &pb.User{
+			Id:             device.HardwareID,
+			LongName:       desired.LongName,
+			ShortName:      desired.ShortName,
+			IsUnmessagable: protobuf.Bool(desired.Unmessageable),
+		}
+		if device.Owner != nil {
+			owner.IsLicensed = device.Owner.IsLicensed
+		}
+		messages = append(messages, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetOwner{SetOwner: owner},
+		})
+		updated.LongName = desired.LongName
+		updated.ShortName = desired.ShortName
+		updated.Owner = owner
+	}
+
+	channels, err := channelChanges(device.Channels, desired.Channels)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(channels) > 0 {
+		updated.Channels = mergeChannels(device.Channels, channels)
+	}
+	for _, ch := range channels {
+		messages = append(messages, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetChannel{SetChannel: ch},
+		})
+	}
+
+	sections, err := sectionChanges(updated.LocalConfig.ProtoReflect(), desired.Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	for _, section := range sections {
+		config := &pb.Config{}
+		if err := admin.SetVariant(config.ProtoReflect(), section); err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetConfig{SetConfig: config},
+		})
+	}
+
+	sections, err = sectionChanges(updated.LocalModuleConfig.ProtoReflect(), desired.ModuleConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid module config: %w", err)
+	}
+	for _, section := range sections {
+		moduleConfig := &pb.ModuleConfig{}
+		if err := admin.SetVariant(moduleConfig.ProtoReflect(), section); err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, &pb.AdminMessage{
+			PayloadVariant: &pb.AdminMessage_SetModuleConfig{SetModuleConfig: moduleConfig},
+		})
+	}
+
+	return messages, &updated, nil
+}
+
+// sectionChanges applies the desired sections to local (a LocalConfig or
+// LocalModuleConfig) and returns the sections that changed
+func sectionChanges(local protoreflect.Message, desired map[string]json.RawMessage) ([]protoreflect.Message, error) {
+	var changed []protoreflect.Message
+	fields := local.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		data, ok := desired[string(fd.Name())]
+		if !ok || fd.Message() == nil {
+			continue
+		}
+
+		current := local.Get(fd).Message()
+		section := current.New()
+		protobuf.Merge(section.Interface(), current.Interface())
+		if err := admin.MergeJSONFields(section, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", fd.Name(), err)
+		}
+		if protobuf.Equal(section.Interface(), current.Interface()) {
+			continue
+		}
+		local.Set(fd, protoreflect.ValueOfMessage(section))
+		changed = append(changed, section)
+	}
+	return changed, nil
+}
+
+// channelChanges returns the channels that must be written so the device
+// matches the mesh channel set. Device channels the mesh doesn't define are
+// disabled. A mesh without channels doesn't manage them.
+func channelChanges(device []*pb.Channel, desired []json.RawMessage) ([]*pb.Channel, error) {
+	if len(desired) == 0 {
+		return nil, nil
+	}
+
+	wanted := make(map[int32]*pb.Channel, len(desired))
+	for _, data := range desired {
+		ch := &pb.Channel{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, ch); err != nil {
+			return nil, fmt.Errorf("invalid channel: %w", err)
+		}
+		wanted[ch.Index] = ch
+	}
+
+	current := make(map[int32]*pb.Channel, len(device))
+	for _, ch := range device {
+		current[ch.Index] = ch
+	}
+
+	var changes []*pb.Channel
+	for index := int32(0); index < 8; index++ {
+		want, ok := wanted[index]
+		if !ok {
+			if ch, exists := current[index]; !exists || ch.Role == pb.Channel_DISABLED {
+				continue
+			}
+			want = &pb.Channel{Index: index, Role: pb.Channel_DISABLED}
+		}
+		if protobuf.Equal(want, current[index]) {
+			continue
+		}
+		changes = append(changes, want)
+	}
+	return changes, nil
+}
+
+// mergeChannels returns the device channels with changes applied
+func mergeChannels(device []*pb.Channel, changes []*pb.Channel) []*pb.Channel {
+	byIndex := make(map[int32]*pb.Channel, len(device))
+	for _, ch := range device {
+		byIndex[ch.Index] = ch
+	}
+	for _, ch := range changes {
+		byIndex[ch.Index] = ch
+	}
+
+	merged := make([]*pb.Channel, 0, len(byIndex))
+	for index := int32(0); index < 8; index++ {
+		if ch, ok := byIndex[index]; ok {
+			merged = append(merged, ch)
+		}
+	}
+	return merged
+}
+
+// newAppliedState describes what the device now has for the values the
+// server tracks in its applied_* columns
+func newAppliedState(desired *effectiveConfig, updated *DeviceConfig) appliedState {
+	state := appliedState{
+		ShortName:     &updated.ShortName,
+		LongName:      &updated.LongName,
+		Unmessageable: protobuf.Bool(updated.Owner.GetIsUnmessagable()),
+	}
+
+	var device map[string]json.RawMessage
+	if data, ok := desired.Config["device"]; ok && json.Unmarshal(data, &device) == nil {
+		if _, ok := device["role"]; ok {
+			state.Role = protobuf.String(updated.LocalConfig.GetDevice().GetRole().String())
+		}
+	}
+
+	var security map[string]json.RawMessage
+	if data, ok := desired.Config["security"]; ok && json.Unmarshal(data, &security) == nil {
+		if _, ok := security["public_key"]; ok {
+			state.PublicKey = protobuf.String(base64.StdEncoding.EncodeToString(updated.LocalConfig.GetSecurity().GetPublicKey()))
+		}
+		if _, ok := security["private_key"]; ok {
+			state.PrivateKey = protobuf.String(base64.StdEncoding.EncodeToString(updated.LocalConfig.GetSecurity().GetPrivateKey()))
+		}
+		if _, ok := security["admin_key"]; ok {
+			state.AdminKeys = []string{}
+			for _, key := range updated.LocalConfig.GetSecurity().GetAdminKey() {
+				state.AdminKeys = append(state.AdminKeys, base64.StdEncoding.EncodeToString(key))
+			}
+		}
+	}
+	return state
+}
+
+// forwardReplies passes messages read from the device to the admin client
+// until ctx is done
+func forwardReplies(ctx context.Context, conn *transport.Conn, client *admin.Client) {
+	for {
+		select {
+		case msg, ok := <-conn.Packets():
+			if !ok {
+				return
+			}
+			client.HandleFromRadio(msg)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// findNodeID looks up the server's node ID for a device by its hardware ID
+func findNodeID(opts applyOptions, hardwareID string) (string, error) {
+	var nodes []struct {
+		ID         int64  `json:"id"`
+		HardwareID string `json:"hardware_id"`
+	}
+	if err := apiRequest(opts, "GET", fmt.Sprintf("/api/meshes/%s/nodes", opts.MeshID), nil, &nodes); err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes {
+		if node.HardwareID == hardwareID {
+			return fmt.Sprintf("%d", node.ID), nil
+		}
+	}
+	return "", fmt.Errorf("node %s is not in mesh %s; import it first or pass -node-id", hardwareID, opts.MeshID)
+}
+
+// apiRequest makes an authenticated request to the admin server, decoding
+// the JSON response into out if it is not nil
+func apiRequest(opts applyOptions, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, opts.AdminURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, apiErr.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -2,9 +2,15 @@ module github.com/skandragon/meshmgr/meshtastic-cli
 
 go 1.24.0
 
-require github.com/tarm/serial v0.0.0-20180830185346-98f6abe2eb07
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/tarm/serial v0.0.0-20180830185346-98f6abe2eb07
+	google.golang.org/protobuf v1.36.10
+)
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	google.golang.org/protobuf v1.36.10 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
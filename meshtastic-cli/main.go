@@ -16,7 +16,9 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -25,18 +27,11 @@ import (
 	"time"
 
 	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
 	"github.com/tarm/serial"
-	protobuf "google.golang.org/protobuf/proto"
 )
 
 const (
-	// Meshtastic serial protocol constants
-	MAGIC_START = 0x94
-	MAGIC_END   = 0xc3
-
-	// Max packet size
-	MAX_TO_FROM_RADIO_SIZE = 512
-
 	// Config request ID
 	WANT_CONFIG_ID = 64
 )
@@ -61,88 +56,167 @@ type DeviceConfig struct {
 	// Channels (up to 8)
 	Channels []*pb.Channel `json:"channels,omitempty"`
 
+	// Device metrics (battery, voltage, ...) from the device's own NodeInfo
+	DeviceMetrics *pb.DeviceMetrics `json:"device_metrics,omitempty"`
+
+	// Position from the device's own NodeInfo
+	Position *pb.Position `json:"position,omitempty"`
+
+	// Owner as reported in the device's own NodeInfo, used when applying config
+	Owner *pb.User `json:"-"`
+
 	// Status
 	ConfigComplete bool `json:"config_complete"`
 }
 
 func main() {
 	port := flag.String("port", "/dev/tty.usbmodem101", "Serial port device")
+	host := flag.String("host", "", "Connect to a network node at host[:port] instead of a serial port (default port 4403)")
 	baud := flag.Int("baud", 115200, "Baud rate")
 	jsonOutput := flag.Bool("json", false, "Output as JSON")
 	adminURL := flag.String("admin-url", "https://meshmanager.svc.rpi.flame.org", "Admin server URL")
 	apiKey := flag.String("api-key", os.Getenv("MESHMANAGER_API_KEY"), "API key for authentication")
 	meshID := flag.String("mesh-id", "", "Mesh ID to upload config to (required for upload)")
+	apply := flag.Bool("apply", false, "Write the node's configuration from the server to the device")
+	nodeID := flag.String("node-id", "", "Node ID to apply config for (default: look up by hardware ID)")
 	flag.Parse()
 
+	if *apply && (*apiKey == "" || *meshID == "") {
+		log.Fatalf("-apply requires -api-key and -mesh-id")
+	}
+	if *host != "" && flagSet("port") {
+		log.Fatalf("-host and -port cannot be used together")
+	}
+
 	if !*jsonOutput {
 		fmt.Printf("Meshtastic Device Config Reader\n")
 		fmt.Printf("================================\n")
-		fmt.Printf("Connecting to %s at %d baud...\n", *port, *baud)
-	}
-
-	config := &serial.Config{
-		Name:        *port,
-		Baud:        *baud,
-		ReadTimeout: time.Millisecond * 100,
+		if *host != "" {
+			fmt.Printf("Connecting to %s...\n", transport.TCPAddress(*host))
+		} else {
+			fmt.Printf("Connecting to %s at %d baud...\n", *port, *baud)
+		}
 	}
 
-	s, err := serial.OpenPort(config)
+	conn, err := connect(*host, *port, *baud)
 	if err != nil {
-		log.Fatalf("Failed to open serial port: %v", err)
+		log.Fatalf("%v", err)
 	}
 	defer func() {
-		_ = s.Close()
+		_ = conn.Close()
 	}()
 
 	if !*jsonOutput {
 		fmt.Println("Connected successfully!")
 	}
 
-	if err := wakeDevice(s); err != nil {
+	if err := conn.Wake(); err != nil {
 		log.Fatalf("Failed to wake device: %v", err)
 	}
 
-	packets := make(chan []byte, 100)
-	debugLines := make(chan string, 100)
-	go readPackets(s, packets, debugLines)
-
 	time.Sleep(time.Millisecond * 100)
 
 	if !*jsonOutput {
 		fmt.Println("\nRequesting device configuration...")
 	}
-	if err := requestConfig(s); err != nil {
+	if err := conn.RequestConfig(WANT_CONFIG_ID); err != nil {
 		log.Fatalf("Failed to request config: %v", err)
 	}
 
+	deviceConfig, err := readDeviceConfig(conn, 15*time.Second)
+	if errors.Is(err, errConfigTimeout) {
+		if !*jsonOutput {
+			fmt.Println("\n⚠️  Timeout waiting for device configuration")
+		}
+		// Never write config based on a partial read of the device
+		if *apply {
+			log.Fatalf("Not applying config without a complete device configuration")
+		}
+		// Output what we have
+		outputResult(deviceConfig, *jsonOutput)
+		uploadConfig(deviceConfig, *adminURL, *apiKey, *meshID, *jsonOutput)
+		return
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	outputResult(deviceConfig, *jsonOutput)
+	if *apply {
+		err := applyConfig(conn, deviceConfig, applyOptions{
+			AdminURL:   *adminURL,
+			APIKey:     *apiKey,
+			MeshID:     *meshID,
+			NodeID:     *nodeID,
+			JSONOutput: *jsonOutput,
+		})
+		if err != nil {
+			log.Fatalf("Failed to apply config: %v", err)
+		}
+		return
+	}
+	uploadConfig(deviceConfig, *adminURL, *apiKey, *meshID, *jsonOutput)
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// connect opens the device's API connection, over TCP if host is set and
+// the serial port otherwise
+func connect(host, port string, baud int) (*transport.Conn, error) {
+	if host != "" {
+		return transport.DialTCP(context.Background(), host)
+	}
+
+	s, err := serial.OpenPort(&serial.Config{
+		Name:        port,
+		Baud:        baud,
+		ReadTimeout: time.Millisecond * 100,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open serial port: %w", err)
+	}
+	// The connection closes the port when it ends
+	return transport.New(context.Background(), s), nil
+}
+
+// errConfigTimeout is returned with the partial configuration read so far
+// when the device does not finish sending its config in time
+var errConfigTimeout = errors.New("timeout waiting for device configuration")
+
+// readDeviceConfig collects the device's response to a config request
+func readDeviceConfig(conn *transport.Conn, timeout time.Duration) (*DeviceConfig, error) {
 	deviceConfig := &DeviceConfig{
 		Channels:          make([]*pb.Channel, 0, 8),
 		LocalConfig:       &pb.LocalConfig{},
 		LocalModuleConfig: &pb.LocalModuleConfig{},
 	}
-	timeout := time.After(15 * time.Second)
+	deadline := time.After(timeout)
 
 	for {
 		select {
-		case packet := <-packets:
-			if parseConfigPacket(packet, deviceConfig) {
+		case msg, ok := <-conn.Packets():
+			if !ok {
+				return nil, fmt.Errorf("lost connection to device: %w", conn.Err())
+			}
+			if parseConfigPacket(msg, deviceConfig) {
 				// Got config complete
-				outputResult(deviceConfig, *jsonOutput)
-				uploadConfig(deviceConfig, *adminURL, *apiKey, *meshID, *jsonOutput)
-				return
+				return deviceConfig, nil
 			}
 
-		case <-debugLines:
+		case <-conn.Logs():
 			// Discard debug output
 
-		case <-timeout:
-			if !*jsonOutput {
-				fmt.Println("\n⚠️  Timeout waiting for device configuration")
-			}
-			// Output what we have
-			outputResult(deviceConfig, *jsonOutput)
-			uploadConfig(deviceConfig, *adminURL, *apiKey, *meshID, *jsonOutput)
-			return
+		case <-deadline:
+			return deviceConfig, errConfigTimeout
 		}
 	}
 }
@@ -166,128 +240,7 @@ func outputResult(config *DeviceConfig, jsonOutput bool) {
 	}
 }
 
-func wakeDevice(s *serial.Port) error {
-	wakeBytes := make([]byte, 32)
-	for i := range wakeBytes {
-		wakeBytes[i] = MAGIC_END
-	}
-	if _, err := s.Write(wakeBytes); err != nil {
-		return fmt.Errorf("failed to wake device: %w", err)
-	}
-	return nil
-}
-
-func readPackets(s *serial.Port, packets chan []byte, debugLines chan string) {
-	var rxBuf []byte
-	var debugLine string
-
-	for {
-		b := make([]byte, 1)
-		n, err := s.Read(b)
-		if err != nil || n != 1 {
-			continue
-		}
-
-		c := b[0]
-		ptr := len(rxBuf)
-
-		if ptr == 0 {
-			if c == MAGIC_START {
-				rxBuf = append(rxBuf, c)
-			} else {
-				handleDebugByte(c, &debugLine, debugLines)
-			}
-		} else if ptr == 1 {
-			if c == MAGIC_END {
-				rxBuf = append(rxBuf, c)
-			} else {
-				handleDebugByte(rxBuf[0], &debugLine, debugLines)
-				handleDebugByte(c, &debugLine, debugLines)
-				rxBuf = nil
-			}
-		} else if ptr == 2 || ptr == 3 {
-			rxBuf = append(rxBuf, c)
-			if ptr == 3 {
-				msb := uint16(rxBuf[2])
-				lsb := uint16(rxBuf[3])
-				packetLen := (msb << 8) | lsb
-
-				if packetLen > MAX_TO_FROM_RADIO_SIZE {
-					for _, b := range rxBuf {
-						handleDebugByte(b, &debugLine, debugLines)
-					}
-					rxBuf = nil
-				}
-			}
-		} else {
-			rxBuf = append(rxBuf, c)
-
-			if len(rxBuf) >= 4 {
-				msb := uint16(rxBuf[2])
-				lsb := uint16(rxBuf[3])
-				packetLen := (msb << 8) | lsb
-				totalLen := 4 + int(packetLen)
-
-				if len(rxBuf) >= totalLen {
-					packetData := make([]byte, packetLen)
-					copy(packetData, rxBuf[4:totalLen])
-					packets <- packetData
-					rxBuf = nil
-				}
-			}
-		}
-	}
-}
-
-func handleDebugByte(c byte, debugLine *string, debugLines chan string) {
-	if c == '\r' {
-		return
-	} else if c == '\n' {
-		if len(*debugLine) > 0 {
-			select {
-			case debugLines <- *debugLine:
-			default:
-			}
-			*debugLine = ""
-		}
-	} else if c >= 32 && c <= 126 {
-		*debugLine += string(c)
-	}
-}
-
-func requestConfig(s *serial.Port) error {
-	toRadio := &pb.ToRadio{
-		PayloadVariant: &pb.ToRadio_WantConfigId{
-			WantConfigId: WANT_CONFIG_ID,
-		},
-	}
-
-	data, err := protobuf.Marshal(toRadio)
-	if err != nil {
-		return fmt.Errorf("failed to marshal ToRadio: %w", err)
-	}
-
-	packetLen := uint16(len(data))
-	packet := make([]byte, 4+len(data))
-	packet[0] = MAGIC_START
-	packet[1] = MAGIC_END
-	packet[2] = uint8(packetLen >> 8)
-	packet[3] = uint8(packetLen & 0xFF)
-	copy(packet[4:], data)
-
-	if _, err = s.Write(packet); err != nil {
-		return fmt.Errorf("failed to send config request: %w", err)
-	}
-	return nil
-}
-
-func parseConfigPacket(packet []byte, config *DeviceConfig) bool {
-	fromRadio := &pb.FromRadio{}
-	err := protobuf.Unmarshal(packet, fromRadio)
-	if err != nil {
-		return false
-	}
-
+func parseConfigPacket(fromRadio *pb.FromRadio, config *DeviceConfig) bool {
 	switch p := fromRadio.PayloadVariant.(type) {
 	case *pb.FromRadio_MyInfo:
 		if p.MyInfo != nil {
@@ -298,7 +251,14 @@ func parseConfigPacket(packet []byte, config *DeviceConfig) bool {
 	case *pb.FromRadio_NodeInfo:
 		// Only process our own node info
 		if p.NodeInfo != nil && p.NodeInfo.Num == config.NodeNum {
+			if p.NodeInfo.DeviceMetrics != nil {
+				config.DeviceMetrics = p.NodeInfo.DeviceMetrics
+			}
+			if p.NodeInfo.Position != nil {
+				config.Position = p.NodeInfo.Position
+			}
 			if p.NodeInfo.User != nil {
+				config.Owner = p.NodeInfo.User
 				config.HardwareID = p.NodeInfo.User.Id
 				config.LongName = p.NodeInfo.User.LongName
 				config.ShortName = p.NodeInfo.User.ShortName
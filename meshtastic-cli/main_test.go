@@ -0,0 +1,107 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDeviceConfig is what a node sends in reply to a config request
+func testDeviceConfig() []*pb.FromRadio {
+	return []*pb.FromRadio{
+		{PayloadVariant: &pb.FromRadio_MyInfo{MyInfo: &pb.MyNodeInfo{MyNodeNum: 0x0a1b2c3d}}},
+		{PayloadVariant: &pb.FromRadio_NodeInfo{NodeInfo: &pb.NodeInfo{
+			Num:  0x0a1b2c3d,
+			User: &pb.User{Id: "!0a1b2c3d", LongName: "Base Station", ShortName: "BASE"},
+		}}},
+		// Other nodes in the database are not the device's identity
+		{PayloadVariant: &pb.FromRadio_NodeInfo{NodeInfo: &pb.NodeInfo{
+			Num:  0x11111111,
+			User: &pb.User{Id: "!11111111", LongName: "Neighbor"},
+		}}},
+		{PayloadVariant: &pb.FromRadio_Metadata{Metadata: &pb.DeviceMetadata{FirmwareVersion: "2.7.11.ee68575"}}},
+		{PayloadVariant: &pb.FromRadio_Config{Config: &pb.Config{
+			PayloadVariant: &pb.Config_Lora{Lora: &pb.Config_LoRaConfig{Region: pb.Config_LoRaConfig_US}},
+		}}},
+		{PayloadVariant: &pb.FromRadio_ModuleConfig{ModuleConfig: &pb.ModuleConfig{
+			PayloadVariant: &pb.ModuleConfig_Mqtt{Mqtt: &pb.ModuleConfig_MQTTConfig{Address: "mqtt.meshtastic.org"}},
+		}}},
+		{PayloadVariant: &pb.FromRadio_Channel{Channel: &pb.Channel{Index: 0, Role: pb.Channel_PRIMARY}}},
+	}
+}
+
+func TestReadDeviceConfigOverTCP(t *testing.T) {
+	device, err := transporttest.NewDevice(testDeviceConfig())
+	require.NoError(t, err)
+	defer func() { _ = device.Close() }()
+
+	conn, err := connect(device.Addr(), "", 0)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	require.NoError(t, conn.RequestConfig(WANT_CONFIG_ID))
+	config, err := readDeviceConfig(conn, 5*time.Second)
+	require.NoError(t, err)
+
+	assert.True(t, config.ConfigComplete)
+	assert.Equal(t, uint32(0x0a1b2c3d), config.NodeNum)
+	assert.Equal(t, "!0a1b2c3d", config.HardwareID)
+	assert.Equal(t, "Base Station", config.LongName)
+	assert.Equal(t, "2.7.11.ee68575", config.Metadata.GetFirmwareVersion())
+	assert.Equal(t, pb.Config_LoRaConfig_US, config.LocalConfig.GetLora().GetRegion())
+	assert.Equal(t, "mqtt.meshtastic.org", config.LocalModuleConfig.GetMqtt().GetAddress())
+	assert.Len(t, config.Channels, 1)
+}
+
+func TestReadDeviceConfigTimeout(t *testing.T) {
+	// A device that never finishes its config download
+	device, err := transporttest.NewDevice(nil)
+	require.NoError(t, err)
+	defer func() { _ = device.Close() }()
+
+	conn, err := connect(device.Addr(), "", 0)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	require.NoError(t, device.Send(testDeviceConfig()[0]))
+	config, err := readDeviceConfig(conn, 200*time.Millisecond)
+	assert.ErrorIs(t, err, errConfigTimeout)
+	require.NotNil(t, config)
+	assert.False(t, config.ConfigComplete)
+}
+
+func TestReadDeviceConfigDisconnect(t *testing.T) {
+	device, err := transporttest.NewDevice(nil)
+	require.NoError(t, err)
+	defer func() { _ = device.Close() }()
+
+	conn, err := connect(device.Addr(), "", 0)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	// Wait for the device to see the client before dropping it
+	require.NoError(t, conn.Heartbeat())
+	require.Eventually(t, func() bool { return len(device.Received()) == 1 }, time.Second, 10*time.Millisecond)
+	device.Disconnect()
+
+	_, err = readDeviceConfig(conn, 5*time.Second)
+	assert.ErrorContains(t, err, "lost connection")
+}
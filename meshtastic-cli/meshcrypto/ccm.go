@@ -0,0 +1,148 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package meshcrypto
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ccmLengthSize is the size of CCM's message length field (L). The
+// firmware uses 2, which allows messages up to 64 KiB and 13-byte nonces.
+const ccmLengthSize = 2
+
+var errCCMOpen = errors.New("message authentication failed")
+
+// ccm is AES-CCM (RFC 3610) as a cipher.AEAD
+type ccm struct {
+	block   cipher.Block
+	tagSize int
+}
+
+// newCCM wraps a 128-bit block cipher in CCM mode with the given tag size
+func newCCM(block cipher.Block, tagSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != 16 {
+		return nil, fmt.Errorf("CCM requires a 128-bit block cipher")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, fmt.Errorf("invalid CCM tag size %d", tagSize)
+	}
+	return &ccm{block: block, tagSize: tagSize}, nil
+}
+
+// NonceSize implements cipher.AEAD
+func (c *ccm) NonceSize() int {
+	return 15 - ccmLengthSize
+}
+
+// Overhead implements cipher.AEAD
+func (c *ccm) Overhead() int {
+	return c.tagSize
+}
+
+// counterBlock returns the CTR block A_i for a nonce
+func (c *ccm) counterBlock(nonce []byte, i uint16) []byte {
+	a := make([]byte, 16)
+	a[0] = ccmLengthSize - 1
+	copy(a[1:], nonce)
+	binary.BigEndian.PutUint16(a[14:], i)
+	return a
+}
+
+// mac computes the unencrypted CBC-MAC tag of plaintext and additionalData
+func (c *ccm) mac(nonce, plaintext, additionalData []byte) []byte {
+	b := make([]byte, 16)
+	flags := byte((c.tagSize-2)/2<<3 | (ccmLengthSize - 1))
+	if len(additionalData) > 0 {
+		flags |= 0x40
+	}
+	b[0] = flags
+	copy(b[1:], nonce)
+	binary.BigEndian.PutUint16(b[14:], uint16(len(plaintext)))
+
+	x := make([]byte, 16)
+	c.block.Encrypt(x, b)
+	absorb := func(data []byte) {
+		for len(data) > 0 {
+			n := subtle.XORBytes(x, x, data)
+			data = data[n:]
+			c.block.Encrypt(x, x)
+		}
+	}
+
+	if len(additionalData) > 0 {
+		// Additional data is prefixed with its length and padded to a block
+		aad := make([]byte, 2+len(additionalData))
+		binary.BigEndian.PutUint16(aad, uint16(len(additionalData)))
+		copy(aad[2:], additionalData)
+		absorb(aad)
+	}
+	absorb(plaintext)
+	return x[:c.tagSize]
+}
+
+// ctr encrypts or decrypts data with counter blocks starting at A_1
+func (c *ccm) ctr(nonce, dst, src []byte) {
+	stream := cipher.NewCTR(c.block, c.counterBlock(nonce, 1))
+	stream.XORKeyStream(dst, src)
+}
+
+// check validates the sizes passed to Seal and Open
+func (c *ccm) check(nonce, text, additionalData []byte) {
+	if len(nonce) != c.NonceSize() {
+		panic("meshcrypto: incorrect nonce length given to CCM")
+	}
+	if len(text) > 0xffff || len(additionalData) >= 0xff00 {
+		panic("meshcrypto: message too large for CCM")
+	}
+}
+
+// Seal implements cipher.AEAD
+func (c *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	c.check(nonce, plaintext, additionalData)
+
+	tag := c.mac(nonce, plaintext, additionalData)
+	s0 := make([]byte, 16)
+	c.block.Encrypt(s0, c.counterBlock(nonce, 0))
+
+	out := make([]byte, len(plaintext)+c.tagSize)
+	c.ctr(nonce, out, plaintext)
+	subtle.XORBytes(out[len(plaintext):], tag, s0)
+	return append(dst, out...)
+}
+
+// Open implements cipher.AEAD
+func (c *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.tagSize {
+		return nil, errCCMOpen
+	}
+	c.check(nonce, ciphertext[:len(ciphertext)-c.tagSize], additionalData)
+
+	n := len(ciphertext) - c.tagSize
+	plaintext := make([]byte, n)
+	c.ctr(nonce, plaintext, ciphertext[:n])
+
+	s0 := make([]byte, 16)
+	c.block.Encrypt(s0, c.counterBlock(nonce, 0))
+	tag := make([]byte, c.tagSize)
+	subtle.XORBytes(tag, c.mac(nonce, plaintext, additionalData), s0)
+	if subtle.ConstantTimeCompare(tag, ciphertext[n:]) != 1 {
+		return nil, errCCMOpen
+	}
+	return append(dst, plaintext...), nil
+}
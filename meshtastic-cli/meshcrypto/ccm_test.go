@@ -0,0 +1,50 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package meshcrypto
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCCM(t *testing.T) {
+	// RFC 3610 packet vector #1
+	block, err := aes.NewCipher(mustHex(t, "c0c1c2c3c4c5c6c7c8c9cacbcccdcecf"))
+	require.NoError(t, err)
+	aead, err := newCCM(block, 8)
+	require.NoError(t, err)
+
+	nonce := mustHex(t, "00000003020100a0a1a2a3a4a5")
+	aad := mustHex(t, "0001020304050607")
+	plaintext := mustHex(t, "08090a0b0c0d0e0f101112131415161718191a1b1c1d1e")
+	want := mustHex(t, "588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0")
+
+	sealed := aead.Seal(nil, nonce, plaintext, aad)
+	assert.Equal(t, want, sealed)
+
+	opened, err := aead.Open(nil, nonce, sealed, aad)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	sealed[0] ^= 1
+	_, err = aead.Open(nil, nonce, sealed, aad)
+	assert.Error(t, err)
+
+	_, err = newCCM(block, 7)
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package meshcrypto implements Meshtastic packet encryption: AES-CTR with
+// a shared channel key, and the AES-CCM public key (PKI) encryption between
+// two nodes that firmware 2.5 added.
+package meshcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// DefaultKey is the well-known key a 1-byte PSK of 1 stands for. PSKs 2
+// through 255 stand for the same key with its last byte increased by one
+// less than the PSK.
+var DefaultKey = []byte{
+	0xd4, 0xf1, 0xbb, 0x3a, 0x20, 0x29, 0x07, 0x59,
+	0xf0, 0xbc, 0xff, 0xab, 0xcf, 0x4e, 0x69, 0x01,
+}
+
+// ErrNoChannel is returned when no channel can decrypt a packet
+var ErrNoChannel = errors.New("no channel can decrypt packet")
+
+// ExpandPSK turns a channel PSK into the AES key the firmware uses. An
+// empty PSK or a 1-byte PSK of 0 means no encryption and returns nil;
+// other 1-byte PSKs select a variant of DefaultKey; short keys are padded
+// with zeros to 16 or 32 bytes.
+func ExpandPSK(psk []byte) ([]byte, error) {
+	switch {
+	case len(psk) == 0:
+		return nil, nil
+	case len(psk) == 1:
+		if psk[0] == 0 {
+			return nil, nil
+		}
+		key := make([]byte, len(DefaultKey))
+		copy(key, DefaultKey)
+		key[len(key)-1] += psk[0] - 1
+		return key, nil
+	case len(psk) <= 16:
+		key := make([]byte, 16)
+		copy(key, psk)
+		return key, nil
+	case len(psk) <= 32:
+		key := make([]byte, 32)
+		copy(key, psk)
+		return key, nil
+	}
+	return nil, fmt.Errorf("PSK must be at most 32 bytes, got %d", len(psk))
+}
+
+// xorHash folds bytes into one by XOR
+func xorHash(data []byte) uint8 {
+	var h uint8
+	for _, b := range data {
+		h ^= b
+	}
+	return h
+}
+
+// ChannelHash is the 8-bit hash sent in MeshPacket.channel to say which
+// channel a packet was encrypted for. name is the channel name, or the
+// modem preset name ("LongFast") for an unnamed channel; key is the
+// expanded key.
+func ChannelHash(name string, key []byte) uint32 {
+	return uint32(xorHash([]byte(name)) ^ xorHash(key))
+}
+
+// Channel holds what is needed to encrypt and decrypt a channel's packets
+type Channel struct {
+	// Name is the channel name, or the modem preset name if unnamed
+	Name string
+
+	// Key is the expanded AES key, 16 or 32 bytes, or empty for no
+	// encryption
+	Key []byte
+
+	// Hash is the channel hash of Name and Key
+	Hash uint32
+}
+
+// NewChannel creates a channel from its name and PSK as configured
+func NewChannel(name string, psk []byte) (Channel, error) {
+	key, err := ExpandPSK(psk)
+	if err != nil {
+		return Channel{}, err
+	}
+	return Channel{Name: name, Key: key, Hash: ChannelHash(name, key)}, nil
+}
+
+// nonce builds the AES-CTR nonce for a packet: the packet ID as a 64-bit
+// and the sender as a 32-bit little-endian number
+func nonce(packetID, from uint32) []byte {
+	n := make([]byte, aes.BlockSize)
+	binary.LittleEndian.PutUint64(n[0:8], uint64(packetID))
+	binary.LittleEndian.PutUint32(n[8:12], from)
+	return n
+}
+
+// Crypt encrypts or decrypts a payload sent by from with the given packet
+// ID. With no key the payload is returned as is.
+func (c Channel) Crypt(packetID, from uint32, data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	if len(c.Key) == 0 {
+		copy(out, data)
+		return out, nil
+	}
+	switch len(c.Key) {
+	case 16, 32:
+	default:
+		return nil, fmt.Errorf("channel key must be 16 or 32 bytes, got %d", len(c.Key))
+	}
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCTR(block, nonce(packetID, from)).XORKeyStream(out, data)
+	return out, nil
+}
+
+// EncryptPacket replaces a packet's decoded payload with its encryption and
+// tags it with the channel hash
+func (c Channel) EncryptPacket(packet *pb.MeshPacket) error {
+	decoded := packet.GetDecoded()
+	if decoded == nil {
+		return fmt.Errorf("packet has no decoded payload")
+	}
+	if packet.From == 0 {
+		return fmt.Errorf("encrypted packets need a sender node number")
+	}
+
+	plaintext, err := protobuf.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := c.Crypt(packet.Id, packet.From, plaintext)
+	if err != nil {
+		return err
+	}
+
+	packet.Channel = c.Hash
+	packet.PayloadVariant = &pb.MeshPacket_Encrypted{Encrypted: ciphertext}
+	return nil
+}
+
+// DecryptPacket replaces a packet's encrypted payload with its decryption.
+// Like the firmware, it only accepts a result that decodes to a Data
+// message with a port number, since any key "decrypts" anything.
+func (c Channel) DecryptPacket(packet *pb.MeshPacket) error {
+	ciphertext := packet.GetEncrypted()
+	if ciphertext == nil {
+		return fmt.Errorf("packet is not encrypted")
+	}
+	if packet.Channel != c.Hash {
+		return fmt.Errorf("packet is for channel hash %d, not %d", packet.Channel, c.Hash)
+	}
+
+	plaintext, err := c.Crypt(packet.Id, packet.From, ciphertext)
+	if err != nil {
+		return err
+	}
+	decoded := &pb.Data{}
+	if err := protobuf.Unmarshal(plaintext, decoded); err != nil {
+		return fmt.Errorf("failed to decode decrypted payload: %w", err)
+	}
+	if decoded.Portnum == pb.PortNum_UNKNOWN_APP {
+		return fmt.Errorf("decrypted payload has no port")
+	}
+	packet.PayloadVariant = &pb.MeshPacket_Decoded{Decoded: decoded}
+	return nil
+}
+
+// DecryptPacket decrypts a packet with whichever of channels it was sent
+// on, trying each channel whose hash matches, and returns that channel.
+// Channel hashes are only 8 bits, so more than one may match.
+func DecryptPacket(packet *pb.MeshPacket, channels []Channel) (Channel, error) {
+	for _, c := range channels {
+		if c.Hash != packet.Channel {
+			continue
+		}
+		if err := c.DecryptPacket(packet); err == nil {
+			return c, nil
+		}
+	}
+	return Channel{}, ErrNoChannel
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package meshcrypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestExpandPSK(t *testing.T) {
+	key, err := ExpandPSK(nil)
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	key, err = ExpandPSK([]byte{0})
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	key, err = ExpandPSK([]byte{1})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultKey, key)
+
+	key, err = ExpandPSK([]byte{2})
+	require.NoError(t, err)
+	assert.Equal(t, mustHex(t, "d4f1bb3a20290759f0bcffabcf4e6902"), key)
+	assert.Equal(t, byte(0x01), DefaultKey[15], "DefaultKey must not be modified")
+
+	key, err = ExpandPSK([]byte{0xaa, 0xbb})
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0xaa, 0xbb}, make([]byte, 14)...), key)
+
+	key, err = ExpandPSK(bytes.Repeat([]byte{7}, 20))
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = ExpandPSK(make([]byte, 33))
+	assert.Error(t, err)
+}
+
+func TestChannelHash(t *testing.T) {
+	// The default LongFast channel is hash 8 on every public MQTT feed
+	ch, err := NewChannel("LongFast", []byte{1})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(8), ch.Hash)
+
+	ch, err = NewChannel("LongFast", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x0a), ch.Hash)
+}
+
+// Vectors cross-checked with openssl enc -aes-128-ctr / -aes-256-ctr
+var cryptVectors = []struct {
+	name       string
+	psk        string
+	packetID   uint32
+	from       uint32
+	plaintext  string
+	ciphertext string
+}{
+	{
+		name:       "default key",
+		psk:        "01",
+		packetID:   0x12345678,
+		from:       0xdeadbeef,
+		plaintext:  "08011205" + "68656c6c6f", // TEXT_MESSAGE_APP "hello"
+		ciphertext: "aba22994ee110552c6",
+	},
+	{
+		name:       "default key variant",
+		psk:        "02",
+		packetID:   0x12345678,
+		from:       0xdeadbeef,
+		plaintext:  "08011205" + "68656c6c6f",
+		ciphertext: "4267beec23fe513f4b",
+	},
+	{
+		name:       "AES256 across blocks",
+		psk:        "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		packetID:   1,
+		from:       0x0a0b0c0d,
+		plaintext:  "0801121974686520717569636b2062726f776e20666f78206a756d7073",
+		ciphertext: "2927d40799112c01bdb41e8e2e7ee484c03c5e66e515ae38ed5b06d1b5",
+	},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, v := range cryptVectors {
+		t.Run(v.name, func(t *testing.T) {
+			ch, err := NewChannel("test", mustHex(t, v.psk))
+			require.NoError(t, err)
+
+			ciphertext, err := ch.Crypt(v.packetID, v.from, mustHex(t, v.plaintext))
+			require.NoError(t, err)
+			assert.Equal(t, v.ciphertext, hex.EncodeToString(ciphertext))
+
+			plaintext, err := ch.Crypt(v.packetID, v.from, ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, v.plaintext, hex.EncodeToString(plaintext))
+		})
+	}
+
+	// No key, no encryption
+	ch, err := NewChannel("open", []byte{0})
+	require.NoError(t, err)
+	out, err := ch.Crypt(1, 2, []byte("clear"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("clear"), out)
+}
+
+func TestDecryptPacket(t *testing.T) {
+	longFast, err := NewChannel("LongFast", []byte{1})
+	require.NoError(t, err)
+	// A different key that folds to the same byte collides with LongFast
+	collision, err := NewChannel("LongFast", append(make([]byte, 15), xorHash(DefaultKey)))
+	require.NoError(t, err)
+	require.Equal(t, longFast.Hash, collision.Hash)
+	other, err := NewChannel("other", bytes.Repeat([]byte{9}, 32))
+	require.NoError(t, err)
+
+	packet := &pb.MeshPacket{
+		From:           0xdeadbeef,
+		Id:             0x12345678,
+		Channel:        8,
+		PayloadVariant: &pb.MeshPacket_Encrypted{Encrypted: mustHex(t, cryptVectors[0].ciphertext)},
+	}
+
+	ch, err := DecryptPacket(protobuf.Clone(packet).(*pb.MeshPacket), []Channel{other})
+	assert.ErrorIs(t, err, ErrNoChannel)
+	assert.Empty(t, ch.Name)
+
+	decrypted := protobuf.Clone(packet).(*pb.MeshPacket)
+	ch, err = DecryptPacket(decrypted, []Channel{other, collision, longFast})
+	require.NoError(t, err)
+	assert.Equal(t, longFast.Key, ch.Key)
+	assert.Equal(t, pb.PortNum_TEXT_MESSAGE_APP, decrypted.GetDecoded().GetPortnum())
+	assert.Equal(t, []byte("hello"), decrypted.GetDecoded().GetPayload())
+
+	// Round trip
+	require.NoError(t, other.EncryptPacket(decrypted))
+	assert.Equal(t, other.Hash, decrypted.Channel)
+	require.NoError(t, other.DecryptPacket(decrypted))
+	assert.Equal(t, []byte("hello"), decrypted.GetDecoded().GetPayload())
+}
+
+// TestDecryptCapturedEnvelope decrypts a ServiceEnvelope captured from MQTT
+// on the default LongFast channel, testdata/longfast_envelope.bin, and
+// checks it against the Data message the sending node reported,
+// testdata/longfast_data.bin. Capture both with a node whose logs show the
+// decoded packet; the test is skipped until they are present.
+func TestDecryptCapturedEnvelope(t *testing.T) {
+	raw, err := os.ReadFile("testdata/longfast_envelope.bin")
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("no captured LongFast envelope in testdata")
+	}
+	require.NoError(t, err)
+	want, err := os.ReadFile("testdata/longfast_data.bin")
+	require.NoError(t, err)
+
+	envelope := &pb.ServiceEnvelope{}
+	require.NoError(t, protobuf.Unmarshal(raw, envelope))
+	assert.Equal(t, "LongFast", envelope.ChannelId)
+
+	longFast, err := NewChannel("LongFast", []byte{1})
+	require.NoError(t, err)
+	packet := envelope.Packet
+	_, err = DecryptPacket(packet, []Channel{longFast})
+	require.NoError(t, err)
+
+	var data pb.Data
+	require.NoError(t, protobuf.Unmarshal(want, &data))
+	assert.True(t, protobuf.Equal(&data, packet.GetDecoded()), "decoded %v, want %v", packet.GetDecoded(), &data)
+}
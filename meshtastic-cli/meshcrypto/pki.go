@@ -0,0 +1,189 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package meshcrypto
+
+import (
+	"crypto/aes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	// pkiTagSize is the size of the CCM authentication tag
+	pkiTagSize = 8
+
+	// pkiExtraNonceSize is the size of the random nonce sent after the tag
+	pkiExtraNonceSize = 4
+
+	// PKIOverhead is how much longer PKI encryption makes a payload
+	PKIOverhead = pkiTagSize + pkiExtraNonceSize
+
+	// KeySize is the size of X25519 public and private keys
+	KeySize = 32
+
+	// broadcastNum is the destination of packets sent to every node
+	broadcastNum = 0xffffffff
+)
+
+// ErrPKIAuth is returned when a PKI payload fails authentication: it was
+// corrupted, or not sent between the two keys given
+var ErrPKIAuth = errors.New("PKI payload failed authentication")
+
+// PublicKey returns the X25519 public key of a private key
+func PublicKey(privateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return priv.PublicKey().Bytes(), nil
+}
+
+// SharedKey derives the AES-256 key two nodes share: the SHA-256 hash of
+// the X25519 shared secret of one's private key and the other's public key
+func SharedKey(privateKey, publicKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256(secret)
+	return key[:], nil
+}
+
+// pkiNonce builds the CCM nonce for a packet: the packet ID, extra nonce
+// and sender as 32-bit little-endian numbers
+func pkiNonce(packetID, from, extraNonce uint32) []byte {
+	n := make([]byte, 13)
+	binary.LittleEndian.PutUint32(n[0:4], packetID)
+	binary.LittleEndian.PutUint32(n[4:8], extraNonce)
+	binary.LittleEndian.PutUint32(n[8:12], from)
+	return n
+}
+
+// EncryptPKI encrypts a payload sent by from with the given packet ID under
+// a shared key. The result is the ciphertext followed by the tag and the
+// extra nonce, which should be random.
+func EncryptPKI(sharedKey []byte, packetID, from, extraNonce uint32, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newCCM(block, pkiTagSize)
+	if err != nil {
+		return nil, err
+	}
+	out := aead.Seal(nil, pkiNonce(packetID, from, extraNonce), plaintext, nil)
+	return binary.LittleEndian.AppendUint32(out, extraNonce), nil
+}
+
+// DecryptPKI decrypts a payload made by EncryptPKI
+func DecryptPKI(sharedKey []byte, packetID, from uint32, data []byte) ([]byte, error) {
+	if len(data) < PKIOverhead {
+		return nil, fmt.Errorf("PKI payload is too short")
+	}
+	block, err := aes.NewCipher(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newCCM(block, pkiTagSize)
+	if err != nil {
+		return nil, err
+	}
+	n := len(data) - pkiExtraNonceSize
+	extraNonce := binary.LittleEndian.Uint32(data[n:])
+	plaintext, err := aead.Open(nil, pkiNonce(packetID, from, extraNonce), data[:n], nil)
+	if err != nil {
+		return nil, ErrPKIAuth
+	}
+	return plaintext, nil
+}
+
+// EncryptPKIPacket replaces a packet's decoded payload with its PKI
+// encryption from the sender's private key to the recipient's public key
+func EncryptPKIPacket(packet *pb.MeshPacket, privateKey, remotePublicKey []byte) error {
+	decoded := packet.GetDecoded()
+	if decoded == nil {
+		return fmt.Errorf("packet has no decoded payload")
+	}
+	if packet.From == 0 {
+		return fmt.Errorf("encrypted packets need a sender node number")
+	}
+	if packet.To == broadcastNum {
+		return fmt.Errorf("broadcast packets can't be PKI encrypted")
+	}
+
+	key, err := SharedKey(privateKey, remotePublicKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := protobuf.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	var extra [pkiExtraNonceSize]byte
+	if _, err := rand.Read(extra[:]); err != nil {
+		return err
+	}
+	ciphertext, err := EncryptPKI(key, packet.Id, packet.From, binary.LittleEndian.Uint32(extra[:]), plaintext)
+	if err != nil {
+		return err
+	}
+
+	// PKI packets go out on channel 0 with no channel hash
+	packet.Channel = 0
+	packet.PkiEncrypted = true
+	packet.PayloadVariant = &pb.MeshPacket_Encrypted{Encrypted: ciphertext}
+	return nil
+}
+
+// DecryptPKIPacket replaces a PKI packet's encrypted payload with its
+// decryption, using the recipient's private key and the sender's public key
+func DecryptPKIPacket(packet *pb.MeshPacket, privateKey, remotePublicKey []byte) error {
+	ciphertext := packet.GetEncrypted()
+	if ciphertext == nil {
+		return fmt.Errorf("packet is not encrypted")
+	}
+
+	key, err := SharedKey(privateKey, remotePublicKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := DecryptPKI(key, packet.Id, packet.From, ciphertext)
+	if err != nil {
+		return err
+	}
+	decoded := &pb.Data{}
+	if err := protobuf.Unmarshal(plaintext, decoded); err != nil {
+		return fmt.Errorf("failed to decode decrypted payload: %w", err)
+	}
+	packet.PkiEncrypted = true
+	packet.PublicKey = remotePublicKey
+	packet.PayloadVariant = &pb.MeshPacket_Decoded{Decoded: decoded}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package meshcrypto
+
+import (
+	"testing"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// X25519 keys from RFC 7748 section 6.1
+const (
+	alicePrivate = "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"
+	alicePublic  = "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a"
+	bobPrivate   = "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb"
+	bobPublic    = "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f"
+)
+
+func TestPublicKey(t *testing.T) {
+	pub, err := PublicKey(mustHex(t, alicePrivate))
+	require.NoError(t, err)
+	assert.Equal(t, mustHex(t, alicePublic), pub)
+
+	_, err = PublicKey([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestSharedKey(t *testing.T) {
+	// SHA-256 of the RFC 7748 shared secret
+	want := mustHex(t, "dead45a1d43d6902aa9240b43c0d75a0b5fc750660590d6d45461cbfc4010684")
+
+	key, err := SharedKey(mustHex(t, alicePrivate), mustHex(t, bobPublic))
+	require.NoError(t, err)
+	assert.Equal(t, want, key)
+
+	key, err = SharedKey(mustHex(t, bobPrivate), mustHex(t, alicePublic))
+	require.NoError(t, err)
+	assert.Equal(t, want, key)
+
+	// A low-order point gives an all-zero secret, which is refused
+	_, err = SharedKey(mustHex(t, alicePrivate), make([]byte, KeySize))
+	assert.Error(t, err)
+}
+
+func TestPKI(t *testing.T) {
+	key, err := SharedKey(mustHex(t, alicePrivate), mustHex(t, bobPublic))
+	require.NoError(t, err)
+
+	// Data{portnum: ADMIN_APP, payload: "hi"}. This is a regression vector
+	// produced by this package; TestPKIFirmwareVector checks the firmware's.
+	plaintext := mustHex(t, "080612026869")
+	want := mustHex(t, "b2cea919f35f7d2b11019af759b7efbeadde")
+
+	ciphertext, err := EncryptPKI(key, 0x12345678, 0x0a0b0c0d, 0xdeadbeef, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, want, ciphertext)
+	assert.Len(t, ciphertext, len(plaintext)+PKIOverhead)
+
+	decrypted, err := DecryptPKI(key, 0x12345678, 0x0a0b0c0d, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// The nonce covers the packet ID and sender
+	_, err = DecryptPKI(key, 0x12345679, 0x0a0b0c0d, ciphertext)
+	assert.ErrorIs(t, err, ErrPKIAuth)
+	_, err = DecryptPKI(key, 0x12345678, 0x0a0b0c0e, ciphertext)
+	assert.ErrorIs(t, err, ErrPKIAuth)
+
+	_, err = DecryptPKI(key, 0x12345678, 0x0a0b0c0d, ciphertext[:PKIOverhead-1])
+	assert.Error(t, err)
+}
+
+// TestPKIFirmwareVector decrypts the PKI packet from the firmware's crypto
+// tests (test/test_crypto, test_PKC_Decrypt), and encrypts it back.
+func TestPKIFirmwareVector(t *testing.T) {
+	const (
+		from       = 0x0929
+		packetID   = 0x13b2d662
+		extraNonce = 0x2b796a03
+	)
+	privateKey := mustHex(t, "a00330633e63522f8a4d81ec6d9d1e6617f6c8ffd3a4c698229537d44e522277")
+	publicKey := mustHex(t, "db18fc50eea47f00251cb784819a3cf5fc361882597f589f0d7ff820e8064457")
+	// The 16 byte radio header, then the ciphertext, tag and extra nonce
+	radio := mustHex(t, "8c646d7a2909000062d6b2136b00000040df24abfcc30a17a3d9046726099e796a1c036a792b")
+	// Data{portnum: TEXT_MESSAGE_APP, payload: "test", bitfield: 0}
+	want := mustHex(t, "08011204746573744800")
+
+	key, err := SharedKey(privateKey, publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, mustHex(t, "777b1545c9d6f9a2"), key[:8])
+	assert.Equal(t, mustHex(t, "62d6b213036a792b2909000000"), pkiNonce(packetID, from, extraNonce))
+
+	decrypted, err := DecryptPKI(key, packetID, from, radio[16:])
+	require.NoError(t, err)
+	assert.Equal(t, want, decrypted)
+
+	encrypted, err := EncryptPKI(key, packetID, from, extraNonce, want)
+	require.NoError(t, err)
+	assert.Equal(t, radio[16:], encrypted)
+}
+
+func TestPKIPacket(t *testing.T) {
+	packet := &pb.MeshPacket{
+		From:    0x0a0b0c0d,
+		To:      0x01020304,
+		Id:      42,
+		Channel: 8,
+		PayloadVariant: &pb.MeshPacket_Decoded{Decoded: &pb.Data{
+			Portnum: pb.PortNum_ADMIN_APP,
+			Payload: []byte("hello"),
+		}},
+	}
+	original := protobuf.Clone(packet).(*pb.MeshPacket)
+
+	require.NoError(t, EncryptPKIPacket(packet, mustHex(t, alicePrivate), mustHex(t, bobPublic)))
+	assert.True(t, packet.PkiEncrypted)
+	assert.Zero(t, packet.Channel)
+	assert.Len(t, packet.GetEncrypted(), 9+PKIOverhead)
+
+	// The recipient decrypts with its own private key and the sender's
+	// public key
+	received := protobuf.Clone(packet).(*pb.MeshPacket)
+	assert.ErrorIs(t, DecryptPKIPacket(received, mustHex(t, bobPrivate), mustHex(t, bobPublic)), ErrPKIAuth)
+	require.NoError(t, DecryptPKIPacket(received, mustHex(t, bobPrivate), mustHex(t, alicePublic)))
+	assert.True(t, protobuf.Equal(original.GetDecoded(), received.GetDecoded()))
+	assert.Equal(t, mustHex(t, alicePublic), received.PublicKey)
+
+	broadcast := protobuf.Clone(original).(*pb.MeshPacket)
+	broadcast.To = broadcastNum
+	assert.Error(t, EncryptPKIPacket(broadcast, mustHex(t, alicePrivate), mustHex(t, bobPublic)))
+}
@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.32.1
+// source: meshtastic/clientonly.proto
+
+package generated
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This abstraction is used to contain any configuration for provisioning a node on any client.
+// It is useful for importing and exporting configurations.
+type DeviceProfile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Long name for the node
+	LongName *string `protobuf:"bytes,1,opt,name=long_name,json=longName,proto3,oneof" json:"long_name,omitempty"`
+	// Short name of the node
+	ShortName *string `protobuf:"bytes,2,opt,name=short_name,json=shortName,proto3,oneof" json:"short_name,omitempty"`
+	// The url of the channels from our node
+	ChannelUrl *string `protobuf:"bytes,3,opt,name=channel_url,json=channelUrl,proto3,oneof" json:"channel_url,omitempty"`
+	// The Config of the node
+	Config *LocalConfig `protobuf:"bytes,4,opt,name=config,proto3,oneof" json:"config,omitempty"`
+	// The ModuleConfig of the node
+	ModuleConfig *LocalModuleConfig `protobuf:"bytes,5,opt,name=module_config,json=moduleConfig,proto3,oneof" json:"module_config,omitempty"`
+	// Fixed position data
+	FixedPosition *Position `protobuf:"bytes,6,opt,name=fixed_position,json=fixedPosition,proto3,oneof" json:"fixed_position,omitempty"`
+	// Ringtone for ExternalNotification
+	Ringtone *string `protobuf:"bytes,7,opt,name=ringtone,proto3,oneof" json:"ringtone,omitempty"`
+	// Predefined messages for CannedMessage
+	CannedMessages *string `protobuf:"bytes,8,opt,name=canned_messages,json=cannedMessages,proto3,oneof" json:"canned_messages,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeviceProfile) Reset() {
+	*x = DeviceProfile{}
+	mi := &file_meshtastic_clientonly_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceProfile) ProtoMessage() {}
+
+func (x *DeviceProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_meshtastic_clientonly_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceProfile.ProtoReflect.Descriptor instead.
+func (*DeviceProfile) Descriptor() ([]byte, []int) {
+	return file_meshtastic_clientonly_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DeviceProfile) GetLongName() string {
+	if x != nil && x.LongName != nil {
+		return *x.LongName
+	}
+	return ""
+}
+
+func (x *DeviceProfile) GetShortName() string {
+	if x != nil && x.ShortName != nil {
+		return *x.ShortName
+	}
+	return ""
+}
+
+func (x *DeviceProfile) GetChannelUrl() string {
+	if x != nil && x.ChannelUrl != nil {
+		return *x.ChannelUrl
+	}
+	return ""
+}
+
+func (x *DeviceProfile) GetConfig() *LocalConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *DeviceProfile) GetModuleConfig() *LocalModuleConfig {
+	if x != nil {
+		return x.ModuleConfig
+	}
+	return nil
+}
+
+func (x *DeviceProfile) GetFixedPosition() *Position {
+	if x != nil {
+		return x.FixedPosition
+	}
+	return nil
+}
+
+func (x *DeviceProfile) GetRingtone() string {
+	if x != nil && x.Ringtone != nil {
+		return *x.Ringtone
+	}
+	return ""
+}
+
+func (x *DeviceProfile) GetCannedMessages() string {
+	if x != nil && x.CannedMessages != nil {
+		return *x.CannedMessages
+	}
+	return ""
+}
+
+var File_meshtastic_clientonly_proto protoreflect.FileDescriptor
+
+const file_meshtastic_clientonly_proto_rawDesc = "" +
+	"\n" +
+	"\x1bmeshtastic/clientonly.proto\x12\n" +
+	"meshtastic\x1a\x1ameshtastic/localonly.proto\x1a\x15meshtastic/mesh.proto\"\x89\x04\n" +
+	"\rDeviceProfile\x12 \n" +
+	"\tlong_name\x18\x01 \x01(\tH\x00R\blongName\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"short_name\x18\x02 \x01(\tH\x01R\tshortName\x88\x01\x01\x12$\n" +
+	"\vchannel_url\x18\x03 \x01(\tH\x02R\n" +
+	"channelUrl\x88\x01\x01\x124\n" +
+	"\x06config\x18\x04 \x01(\v2\x17.meshtastic.LocalConfigH\x03R\x06config\x88\x01\x01\x12G\n" +
+	"\rmodule_config\x18\x05 \x01(\v2\x1d.meshtastic.LocalModuleConfigH\x04R\fmoduleConfig\x88\x01\x01\x12@\n" +
+	"\x0efixed_position\x18\x06 \x01(\v2\x14.meshtastic.PositionH\x05R\rfixedPosition\x88\x01\x01\x12\x1f\n" +
+	"\bringtone\x18\a \x01(\tH\x06R\bringtone\x88\x01\x01\x12,\n" +
+	"\x0fcanned_messages\x18\b \x01(\tH\aR\x0ecannedMessages\x88\x01\x01B\f\n" +
+	"\n" +
+	"_long_nameB\r\n" +
+	"\v_short_nameB\x0e\n" +
+	"\f_channel_urlB\t\n" +
+	"\a_configB\x10\n" +
+	"\x0e_module_configB\x11\n" +
+	"\x0f_fixed_positionB\v\n" +
+	"\t_ringtoneB\x12\n" +
+	"\x10_canned_messagesBf\n" +
+	"\x14org.meshtastic.protoB\x10ClientOnlyProtosZ\"github.com/meshtastic/go/generated\xaa\x02\x14Meshtastic.Protobufs\xba\x02\x00b\x06proto3"
+
+var (
+	file_meshtastic_clientonly_proto_rawDescOnce sync.Once
+	file_meshtastic_clientonly_proto_rawDescData []byte
+)
+
+func file_meshtastic_clientonly_proto_rawDescGZIP() []byte {
+	file_meshtastic_clientonly_proto_rawDescOnce.Do(func() {
+		file_meshtastic_clientonly_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_meshtastic_clientonly_proto_rawDesc), len(file_meshtastic_clientonly_proto_rawDesc)))
+	})
+	return file_meshtastic_clientonly_proto_rawDescData
+}
+
+var file_meshtastic_clientonly_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_meshtastic_clientonly_proto_goTypes = []any{
+	(*DeviceProfile)(nil),     // 0: meshtastic.DeviceProfile
+	(*LocalConfig)(nil),       // 1: meshtastic.LocalConfig
+	(*LocalModuleConfig)(nil), // 2: meshtastic.LocalModuleConfig
+	(*Position)(nil),          // 3: meshtastic.Position
+}
+var file_meshtastic_clientonly_proto_depIdxs = []int32{
+	1, // 0: meshtastic.DeviceProfile.config:type_name -> meshtastic.LocalConfig
+	2, // 1: meshtastic.DeviceProfile.module_config:type_name -> meshtastic.LocalModuleConfig
+	3, // 2: meshtastic.DeviceProfile.fixed_position:type_name -> meshtastic.Position
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_meshtastic_clientonly_proto_init() }
+func file_meshtastic_clientonly_proto_init() {
+	if File_meshtastic_clientonly_proto != nil {
+		return
+	}
+	file_meshtastic_localonly_proto_init()
+	file_meshtastic_mesh_proto_init()
+	file_meshtastic_clientonly_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_meshtastic_clientonly_proto_rawDesc), len(file_meshtastic_clientonly_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_meshtastic_clientonly_proto_goTypes,
+		DependencyIndexes: file_meshtastic_clientonly_proto_depIdxs,
+		MessageInfos:      file_meshtastic_clientonly_proto_msgTypes,
+	}.Build()
+	File_meshtastic_clientonly_proto = out.File
+	file_meshtastic_clientonly_proto_goTypes = nil
+	file_meshtastic_clientonly_proto_depIdxs = nil
+}
@@ -0,0 +1,250 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultHeartbeatInterval is how often a heartbeat is sent to keep the
+	// device's API connection open. The firmware drops idle serial clients
+	// after 15 minutes.
+	DefaultHeartbeatInterval = 5 * time.Minute
+
+	// wakeLength is the number of Start2 bytes sent to wake a sleeping device
+	wakeLength = 32
+)
+
+// ErrClosed is returned when sending on a closed connection
+var ErrClosed = errors.New("connection closed")
+
+// Option configures a Conn
+type Option func(*Conn)
+
+// WithHeartbeat sets the heartbeat interval; zero disables heartbeats
+func WithHeartbeat(interval time.Duration) Option {
+	return func(c *Conn) {
+		c.heartbeat = interval
+	}
+}
+
+// WithLogBuffer sets how many debug log lines are buffered. Lines that
+// arrive while the buffer is full are dropped.
+func WithLogBuffer(size int) Option {
+	return func(c *Conn) {
+		c.logs = make(chan string, size)
+	}
+}
+
+// Conn is an API connection to a Meshtastic device over a stream such as a
+// serial port or TCP socket
+type Conn struct {
+	rwc       io.ReadWriteCloser
+	heartbeat time.Duration
+
+	packets chan *pb.FromRadio
+	logs    chan string
+	done    chan struct{}
+	cancel  context.CancelFunc
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	err       error
+}
+
+// New starts a connection over rwc. It reads from the device until ctx is
+// cancelled, Close is called or the stream fails; rwc is closed then.
+func New(ctx context.Context, rwc io.ReadWriteCloser, opts ...Option) *Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Conn{
+		rwc:       rwc,
+		heartbeat: DefaultHeartbeatInterval,
+		packets:   make(chan *pb.FromRadio, 100),
+		logs:      make(chan string, 100),
+		done:      make(chan struct{}),
+		cancel:    cancel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	go c.readLoop(ctx)
+	go func() {
+		<-ctx.Done()
+		c.close(ctx.Err())
+	}()
+	if c.heartbeat > 0 {
+		go c.heartbeatLoop(ctx)
+	}
+	return c
+}
+
+// Packets returns the messages received from the device. The channel is
+// closed when the connection ends.
+func (c *Conn) Packets() <-chan *pb.FromRadio {
+	return c.packets
+}
+
+// Logs returns the device's debug log lines
+func (c *Conn) Logs() <-chan string {
+	return c.logs
+}
+
+// Done is closed when the connection ends
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, or nil while it is open
+func (c *Conn) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the connection
+func (c *Conn) Close() error {
+	c.cancel()
+	<-c.done
+	if errors.Is(c.err, context.Canceled) {
+		return nil
+	}
+	return c.err
+}
+
+// close shuts the connection down once, recording the reason
+func (c *Conn) close(reason error) {
+	c.closeOnce.Do(func() {
+		c.err = reason
+		_ = c.rwc.Close()
+		close(c.done)
+		c.cancel()
+	})
+}
+
+// Wake sends the bytes that wake a device and resynchronize its parser
+func (c *Conn) Wake() error {
+	return c.write(bytes.Repeat([]byte{Start2}, wakeLength))
+}
+
+// SendToRadio sends a message to the device
+func (c *Conn) SendToRadio(msg *pb.ToRadio) error {
+	data, err := protobuf.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ToRadio: %w", err)
+	}
+	frame, err := EncodeFrame(data)
+	if err != nil {
+		return err
+	}
+	return c.write(frame)
+}
+
+// RequestConfig asks the device to send its config, channels and node
+// database, ending with a ConfigCompleteId of id
+func (c *Conn) RequestConfig(id uint32) error {
+	return c.SendToRadio(&pb.ToRadio{
+		PayloadVariant: &pb.ToRadio_WantConfigId{WantConfigId: id},
+	})
+}
+
+// Heartbeat sends a heartbeat to keep the API connection open
+func (c *Conn) Heartbeat() error {
+	return c.SendToRadio(&pb.ToRadio{
+		PayloadVariant: &pb.ToRadio_Heartbeat{Heartbeat: &pb.Heartbeat{Nonce: rand.Uint32()}},
+	})
+}
+
+// write writes raw bytes to the device
+func (c *Conn) write(data []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.rwc.Write(data); err != nil {
+		return fmt.Errorf("failed to write to device: %w", err)
+	}
+	return nil
+}
+
+// readLoop decodes the device stream until it fails or the connection closes
+func (c *Conn) readLoop(ctx context.Context) {
+	defer close(c.packets)
+
+	reader := NewReader(c.rwc)
+	for {
+		payload, line, err := reader.Next()
+		if err != nil {
+			// Reads fail once the stream is closed, so report why it was closed
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			c.close(err)
+			return
+		}
+
+		if payload == nil {
+			select {
+			case c.logs <- line:
+			default:
+			}
+			continue
+		}
+
+		msg := &pb.FromRadio{}
+		if err := protobuf.Unmarshal(payload, msg); err != nil {
+			continue
+		}
+		select {
+		case c.packets <- msg:
+		case <-ctx.Done():
+			c.close(ctx.Err())
+			return
+		}
+	}
+}
+
+// heartbeatLoop sends heartbeats until the connection closes
+func (c *Conn) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.Heartbeat()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// readToRadio reads the next framed ToRadio the connection wrote
+func readToRadio(t *testing.T, reader *Reader) *pb.ToRadio {
+	t.Helper()
+	payload, _, err := reader.Next()
+	require.NoError(t, err)
+	msg := &pb.ToRadio{}
+	require.NoError(t, protobuf.Unmarshal(payload, msg))
+	return msg
+}
+
+func TestConnConfigDownload(t *testing.T) {
+	stream, err := os.ReadFile("testdata/config_download.bin")
+	require.NoError(t, err)
+
+	device, host := net.Pipe()
+	conn := New(context.Background(), host, WithHeartbeat(0))
+	defer func() { _ = conn.Close() }()
+
+	// The device answers a config request with the recorded stream
+	go func() {
+		reader := NewReader(device)
+		payload, _, err := reader.Next()
+		if err != nil {
+			return
+		}
+		msg := &pb.ToRadio{}
+		if protobuf.Unmarshal(payload, msg) == nil && msg.GetWantConfigId() == 64 {
+			_, _ = device.Write(stream)
+		}
+	}()
+
+	require.NoError(t, conn.RequestConfig(64))
+
+	var received []*pb.FromRadio
+	for msg := range conn.Packets() {
+		received = append(received, msg)
+		if msg.GetConfigCompleteId() == 64 {
+			break
+		}
+	}
+	require.Len(t, received, 7)
+	assert.Equal(t, uint32(0x0a1b2c3d), received[0].GetMyInfo().GetMyNodeNum())
+
+	// Log lines arrive separately from packets
+	select {
+	case line := <-conn.Logs():
+		assert.Equal(t, "INFO  | 00:00:01 1 Booting Meshtastic 2.7.11", line)
+	case <-time.After(time.Second):
+		t.Fatal("no log line received")
+	}
+}
+
+func TestConnWakeAndHeartbeat(t *testing.T) {
+	device, host := net.Pipe()
+	conn := New(context.Background(), host, WithHeartbeat(20*time.Millisecond))
+	defer func() { _ = conn.Close() }()
+
+	go func() { _ = conn.Wake() }()
+	wake := make([]byte, wakeLength)
+	_, err := io.ReadFull(device, wake)
+	require.NoError(t, err)
+	for _, b := range wake {
+		assert.Equal(t, byte(Start2), b)
+	}
+
+	reader := NewReader(device)
+	first := readToRadio(t, reader).GetHeartbeat()
+	require.NotNil(t, first)
+	second := readToRadio(t, reader).GetHeartbeat()
+	require.NotNil(t, second)
+}
+
+func TestConnCancel(t *testing.T) {
+	_, host := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := New(ctx, host, WithHeartbeat(0))
+
+	cancel()
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection did not stop when cancelled")
+	}
+
+	// The packet channel is closed and nothing more can be sent
+	_, open := <-conn.Packets()
+	assert.False(t, open)
+	assert.ErrorIs(t, conn.Err(), context.Canceled)
+	assert.ErrorIs(t, conn.Heartbeat(), ErrClosed)
+	assert.NoError(t, conn.Close())
+}
+
+func TestConnDeviceDisconnect(t *testing.T) {
+	device, host := net.Pipe()
+	conn := New(context.Background(), host, WithHeartbeat(0))
+
+	require.NoError(t, device.Close())
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection did not stop when the device went away")
+	}
+	assert.ErrorIs(t, conn.Err(), io.EOF)
+	assert.ErrorIs(t, conn.Close(), io.EOF)
+}
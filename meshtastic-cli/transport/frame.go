@@ -0,0 +1,139 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package transport speaks the Meshtastic stream protocol used over serial
+// and TCP: protobufs framed by a 0x94 0xc3 header and a 2-byte length,
+// interleaved with the device's plain text debug log.
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	// Start1 and Start2 begin every frame
+	Start1 = 0x94
+	Start2 = 0xc3
+
+	// HeaderSize is the size of the frame header: two start bytes and a
+	// big-endian payload length
+	HeaderSize = 4
+
+	// MaxPayloadSize is the largest ToRadio or FromRadio the firmware sends or accepts
+	MaxPayloadSize = 512
+)
+
+// EncodeFrame frames a payload for sending to a device
+func EncodeFrame(payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("payload of %d bytes exceeds the %d byte limit", len(payload), MaxPayloadSize)
+	}
+	frame := make([]byte, HeaderSize+len(payload))
+	frame[0] = Start1
+	frame[1] = Start2
+	frame[2] = byte(len(payload) >> 8)
+	frame[3] = byte(len(payload))
+	copy(frame[HeaderSize:], payload)
+	return frame, nil
+}
+
+// Reader splits a device stream into frame payloads and debug log lines
+type Reader struct {
+	r      *bufio.Reader
+	line   []byte
+	escape bool
+}
+
+// NewReader creates a Reader. Reads that return no data and no error, as a
+// serial port with a read timeout does, are retried.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(&retryReader{r: r})}
+}
+
+// Next returns the next frame payload or complete debug log line from the
+// stream. Exactly one of payload and line is set when err is nil.
+func (r *Reader) Next() (payload []byte, line string, err error) {
+	for {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if c != Start1 {
+			if line, ok := r.logByte(c); ok {
+				return nil, line, nil
+			}
+			continue
+		}
+
+		// Look at the rest of the header without consuming it, so that if
+		// this isn't a frame the bytes are handled as log text or a new frame
+		header, err := r.r.Peek(HeaderSize - 1)
+		if err != nil {
+			return nil, "", err
+		}
+		length := int(header[1])<<8 | int(header[2])
+		if header[0] != Start2 || length > MaxPayloadSize {
+			if line, ok := r.logByte(Start1); ok {
+				return nil, line, nil
+			}
+			continue
+		}
+		_, _ = r.r.Discard(len(header))
+
+		payload = make([]byte, length)
+		if _, err := io.ReadFull(r.r, payload); err != nil {
+			return nil, "", err
+		}
+		return payload, "", nil
+	}
+}
+
+// logByte adds a byte to the current debug log line, returning the line
+// once it is complete. ANSI color codes and other non-printable bytes are dropped.
+func (r *Reader) logByte(c byte) (string, bool) {
+	switch {
+	case r.escape:
+		// Escape sequences end with a letter, as in "\x1b[34m"
+		r.escape = !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z')
+	case c == 0x1b:
+		r.escape = true
+	case c == '\n':
+		if len(r.line) == 0 {
+			return "", false
+		}
+		line := string(r.line)
+		r.line = r.line[:0]
+		return line, true
+	case c >= 32 && c <= 126:
+		r.line = append(r.line, c)
+	}
+	return "", false
+}
+
+// retryReader retries reads that return neither data nor an error
+type retryReader struct {
+	r io.Reader
+}
+
+func (rr *retryReader) Read(p []byte) (int, error) {
+	for {
+		n, err := rr.r.Read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// readAll collects every payload and log line from a stream
+func readAll(t *testing.T, r io.Reader) (payloads [][]byte, lines []string) {
+	t.Helper()
+	reader := NewReader(r)
+	for {
+		payload, line, err := reader.Next()
+		if err == io.EOF {
+			return payloads, lines
+		}
+		require.NoError(t, err)
+		if payload != nil {
+			payloads = append(payloads, payload)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestEncodeFrame(t *testing.T) {
+	frame, err := EncodeFrame([]byte{0x18, 0x40})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x94, 0xc3, 0x00, 0x02, 0x18, 0x40}, frame)
+
+	frame, err = EncodeFrame(bytes.Repeat([]byte{1}, 300))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x94, 0xc3, 0x01, 0x2c}, frame[:4])
+
+	_, err = EncodeFrame(make([]byte, MaxPayloadSize+1))
+	assert.Error(t, err)
+}
+
+func TestReaderConfigDownload(t *testing.T) {
+	// A config download as a device sends it: frames interleaved with its
+	// colored debug log, including a log line split around a frame
+	stream, err := os.ReadFile("testdata/config_download.bin")
+	require.NoError(t, err)
+
+	readers := map[string]io.Reader{
+		"whole":    bytes.NewReader(stream),
+		"one byte": iotest.OneByteReader(bytes.NewReader(stream)),
+		"half":     iotest.HalfReader(bytes.NewReader(stream)),
+	}
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			payloads, lines := readAll(t, r)
+
+			assert.Equal(t, []string{
+				"INFO  | 00:00:01 1 Booting Meshtastic 2.7.11",
+				"INFO  | 00:00:01 1 Power output set to 30",
+				"DEBUG | 00:00:02 2 [SerialConsole] Start API client",
+				"DEBUG | 00:00:02 3 [SerialConsole] Send config to phone",
+				"WARN  | 00:00:03 4 Temp  sensor not found",
+				"INFO  | 00:00:03 5 Config download complete",
+			}, lines)
+
+			require.Len(t, payloads, 7)
+			var msgs []*pb.FromRadio
+			for _, payload := range payloads {
+				msg := &pb.FromRadio{}
+				require.NoError(t, protobuf.Unmarshal(payload, msg))
+				msgs = append(msgs, msg)
+			}
+			assert.Equal(t, uint32(0x0a1b2c3d), msgs[0].GetMyInfo().GetMyNodeNum())
+			assert.Equal(t, "Base Station", msgs[1].GetNodeInfo().GetUser().GetLongName())
+			assert.Equal(t, "2.7.11.ee68575", msgs[2].GetMetadata().GetFirmwareVersion())
+			assert.Equal(t, pb.Config_LoRaConfig_US, msgs[3].GetConfig().GetLora().GetRegion())
+			assert.Equal(t, "mqtt.meshtastic.org", msgs[4].GetModuleConfig().GetMqtt().GetAddress())
+			assert.Equal(t, pb.Channel_PRIMARY, msgs[5].GetChannel().GetRole())
+			assert.Equal(t, uint32(64), msgs[6].GetConfigCompleteId())
+		})
+	}
+}
+
+func TestReaderResync(t *testing.T) {
+	frame, err := EncodeFrame([]byte{0x18, 0x40})
+	require.NoError(t, err)
+
+	var stream []byte
+	// A start byte followed by another start byte: the second begins the frame
+	stream = append(stream, Start1)
+	stream = append(stream, frame...)
+	// A header with an impossible length is not a frame
+	stream = append(stream, Start1, Start2, 0x7f, 0xff)
+	stream = append(stream, "ok\n"...)
+	stream = append(stream, frame...)
+
+	payloads, lines := readAll(t, bytes.NewReader(stream))
+	assert.Equal(t, [][]byte{{0x18, 0x40}, {0x18, 0x40}}, payloads)
+	assert.Equal(t, []string{"ok"}, lines)
+}
+
+func TestReaderTruncated(t *testing.T) {
+	frame, err := EncodeFrame([]byte{1, 2, 3, 4})
+	require.NoError(t, err)
+
+	reader := NewReader(bytes.NewReader(frame[:6]))
+	_, _, err = reader.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestReaderRetriesEmptyReads(t *testing.T) {
+	frame, err := EncodeFrame([]byte{7})
+	require.NoError(t, err)
+
+	// Serial ports with a read timeout return no data and no error
+	r := &timeoutReader{data: frame, empty: 150}
+	payload, _, err := NewReader(r).Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{7}, payload)
+}
+
+// timeoutReader returns empty reads before its data, like a serial port
+// that times out waiting for input
+type timeoutReader struct {
+	data  []byte
+	empty int
+}
+
+func (r *timeoutReader) Read(p []byte) (int, error) {
+	if r.empty > 0 {
+		r.empty--
+		return 0, nil
+	}
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// DefaultTCPPort is the port network-attached nodes serve the stream API on
+const DefaultTCPPort = 4403
+
+// TCPAddress returns host with the default API port added if it has none
+func TCPAddress(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(DefaultTCPPort))
+}
+
+// DialTCP connects to a network-attached node. host may include a port;
+// otherwise DefaultTCPPort is used.
+func DialTCP(ctx context.Context, host string, opts ...Option) (*Conn, error) {
+	var dialer net.Dialer
+	nc, err := dialer.DialContext(ctx, "tcp", TCPAddress(host))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", host, err)
+	}
+	return New(ctx, nc, opts...), nil
+}
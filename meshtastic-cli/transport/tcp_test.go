@@ -0,0 +1,89 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPAddress(t *testing.T) {
+	assert.Equal(t, "meshtastic.local:4403", transport.TCPAddress("meshtastic.local"))
+	assert.Equal(t, "192.168.1.20:4403", transport.TCPAddress("192.168.1.20"))
+	assert.Equal(t, "192.168.1.20:9000", transport.TCPAddress("192.168.1.20:9000"))
+	assert.Equal(t, "[::1]:4403", transport.TCPAddress("::1"))
+	assert.Equal(t, "[::1]:9000", transport.TCPAddress("[::1]:9000"))
+}
+
+func TestDialTCP(t *testing.T) {
+	device, err := transporttest.NewDevice([]*pb.FromRadio{
+		{PayloadVariant: &pb.FromRadio_MyInfo{MyInfo: &pb.MyNodeInfo{MyNodeNum: 0x0a1b2c3d}}},
+		{PayloadVariant: &pb.FromRadio_Config{Config: &pb.Config{
+			PayloadVariant: &pb.Config_Lora{Lora: &pb.Config_LoRaConfig{Region: pb.Config_LoRaConfig_US}},
+		}}},
+	})
+	require.NoError(t, err)
+	defer func() { _ = device.Close() }()
+	device.Logs = []string{"INFO  | 00:00:01 1 Start API client"}
+
+	conn, err := transport.DialTCP(context.Background(), device.Addr(), transport.WithHeartbeat(0))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	require.NoError(t, conn.RequestConfig(64))
+
+	var received []*pb.FromRadio
+	for msg := range conn.Packets() {
+		received = append(received, msg)
+		if msg.GetConfigCompleteId() == 64 {
+			break
+		}
+	}
+	require.Len(t, received, 3)
+	assert.Equal(t, uint32(0x0a1b2c3d), received[0].GetMyInfo().GetMyNodeNum())
+	assert.Equal(t, pb.Config_LoRaConfig_US, received[1].GetConfig().GetLora().GetRegion())
+
+	select {
+	case line := <-conn.Logs():
+		assert.Equal(t, "INFO  | 00:00:01 1 Start API client", line)
+	case <-time.After(time.Second):
+		t.Fatal("no log line received")
+	}
+
+	// The device going away ends the connection
+	device.Disconnect()
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection did not stop when the device disconnected")
+	}
+}
+
+func TestDialTCPRefused(t *testing.T) {
+	device, err := transporttest.NewDevice(nil)
+	require.NoError(t, err)
+	addr := device.Addr()
+	require.NoError(t, device.Close())
+
+	_, err = transport.DialTCP(context.Background(), addr)
+	assert.Error(t, err)
+}
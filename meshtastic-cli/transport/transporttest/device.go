@@ -0,0 +1,196 @@
+// Copyright (C) 2025 Michael Graff
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, version 3.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package transporttest provides a fake Meshtastic device that serves the
+// stream API over TCP, for testing code that talks to devices.
+package transporttest
+
+import (
+	"net"
+	"sync"
+
+	pb "github.com/skandragon/meshmgr/meshtastic-cli/proto/meshtastic"
+	"github.com/skandragon/meshmgr/meshtastic-cli/transport"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Device is a fake node listening on a local TCP port. It answers config
+// requests with its Config messages and records everything it receives.
+type Device struct {
+	// Config is sent in reply to a want_config_id request, followed by the
+	// matching config_complete_id
+	Config []*pb.FromRadio
+
+	// Logs are debug lines written before each config reply
+	Logs []string
+
+	// Handle, if set, is called for every message received. Messages it
+	// returns are sent back to the client.
+	Handle func(msg *pb.ToRadio) []*pb.FromRadio
+
+	ln net.Listener
+
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	received []*pb.ToRadio
+	wg       sync.WaitGroup
+}
+
+// NewDevice starts a fake device on a free local port
+func NewDevice(config []*pb.FromRadio) (*Device, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		Config: config,
+		ln:     ln,
+		conns:  make(map[net.Conn]bool),
+	}
+	d.wg.Add(1)
+	go d.accept()
+	return d, nil
+}
+
+// Addr returns the host:port the device listens on
+func (d *Device) Addr() string {
+	return d.ln.Addr().String()
+}
+
+// Received returns the messages the device has received so far
+func (d *Device) Received() []*pb.ToRadio {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*pb.ToRadio(nil), d.received...)
+}
+
+// Send sends a message to every connected client
+func (d *Device) Send(msg *pb.FromRadio) error {
+	frame, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for conn := range d.conns {
+		if _, err := conn.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Disconnect drops all connected clients, as a rebooting device would
+func (d *Device) Disconnect() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for conn := range d.conns {
+		_ = conn.Close()
+	}
+}
+
+// Close stops the device and drops its clients
+func (d *Device) Close() error {
+	err := d.ln.Close()
+	d.Disconnect()
+	d.wg.Wait()
+	return err
+}
+
+// accept serves clients until the listener closes
+func (d *Device) accept() {
+	defer d.wg.Done()
+	for {
+		conn, err := d.ln.Accept()
+		if err != nil {
+			return
+		}
+		d.mu.Lock()
+		d.conns[conn] = true
+		d.mu.Unlock()
+
+		d.wg.Add(1)
+		go d.serve(conn)
+	}
+}
+
+// serve handles one client connection
+func (d *Device) serve(conn net.Conn) {
+	defer d.wg.Done()
+	defer func() {
+		d.mu.Lock()
+		delete(d.conns, conn)
+		d.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	reader := transport.NewReader(conn)
+	for {
+		payload, _, err := reader.Next()
+		if err != nil {
+			return
+		}
+		msg := &pb.ToRadio{}
+		if err := protobuf.Unmarshal(payload, msg); err != nil {
+			continue
+		}
+
+		d.mu.Lock()
+		d.received = append(d.received, msg)
+		d.mu.Unlock()
+
+		var replies []*pb.FromRadio
+		if id := msg.GetWantConfigId(); id != 0 {
+			for _, line := range d.Logs {
+				if err := d.write(conn, []byte(line+"\r\n")); err != nil {
+					return
+				}
+			}
+			replies = append(replies, d.Config...)
+			replies = append(replies, &pb.FromRadio{
+				PayloadVariant: &pb.FromRadio_ConfigCompleteId{ConfigCompleteId: id},
+			})
+		}
+		if d.Handle != nil {
+			replies = append(replies, d.Handle(msg)...)
+		}
+
+		for _, reply := range replies {
+			frame, err := encode(reply)
+			if err != nil {
+				continue
+			}
+			if err := d.write(conn, frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// write sends data to one client without interleaving it with other writes
+func (d *Device) write(conn net.Conn, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := conn.Write(data)
+	return err
+}
+
+// encode frames a FromRadio message
+func encode(msg *pb.FromRadio) ([]byte, error) {
+	data, err := protobuf.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return transport.EncodeFrame(data)
+}